@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/app"
+	"github.com/tabortao/gocron/internal/modules/setting"
+	"github.com/tabortao/gocron/internal/service"
+	"github.com/urfave/cli/v2"
+)
+
+// backfillCommand 按cron表达式补跑历史时间范围内的任务
+func backfillCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "backfill",
+		Usage:  "run a task for every scheduled time in a historical range",
+		Action: runBackfill,
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:     "task-id",
+				Aliases:  []string{"t"},
+				Usage:    "task id",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "start",
+				Usage:    "range start, format: 2006-01-02 15:04:05",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "end",
+				Usage:    "range end, format: 2006-01-02 15:04:05",
+				Required: true,
+			},
+			&cli.IntFlag{
+				Name:    "concurrency",
+				Aliases: []string{"c"},
+				Value:   1,
+				Usage:   fmt.Sprintf("max runs in parallel, 1-%d", service.BackfillMaxConcurrency),
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "only print the scheduled times",
+			},
+		},
+	}
+}
+
+func runBackfill(ctx *cli.Context) error {
+	app.InitEnv(AppVersion)
+	if !app.Installed {
+		return errors.New("gocron is not installed")
+	}
+	config, err := setting.Read(app.AppConfig)
+	if err != nil {
+		return fmt.Errorf("read config failed: %w", err)
+	}
+	app.Setting = config
+	app.InitTimeZone()
+
+	start, err := time.ParseInLocation(models.DefaultTimeFormat, ctx.String("start"), time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid start time: %w", err)
+	}
+	end, err := time.ParseInLocation(models.DefaultTimeFormat, ctx.String("end"), time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid end time: %w", err)
+	}
+
+	models.Db = models.CreateDb()
+	taskModel := new(models.Task)
+	task, err := taskModel.Detail(ctx.Int("task-id"))
+	if err != nil || task.Id <= 0 {
+		return fmt.Errorf("task #%d not found", ctx.Int("task-id"))
+	}
+	if task.Level != models.TaskLevelParent {
+		return errors.New("child tasks can not be backfilled, backfill the parent task instead")
+	}
+
+	times, err := service.BackfillTimes(task.Spec, start, end)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Task #%d %s: %d run(s)\n", task.Id, task.Name, len(times))
+	for _, t := range times {
+		fmt.Println(t.Format(models.DefaultTimeFormat))
+	}
+	if ctx.Bool("dry-run") || len(times) == 0 {
+		return nil
+	}
+
+	service.ServiceTask.InitRunner()
+	service.ServiceTask.Backfill(task, times, ctx.Int("concurrency"))
+	fmt.Println("Backfill completed, see task logs for results")
+
+	return nil
+}
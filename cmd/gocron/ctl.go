@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/tabortao/gocron/pkg/client"
+	"github.com/urfave/cli/v2"
+)
+
+// 输出格式
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// ctlCommand 使用API令牌远程操作gocron
+func ctlCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "ctl",
+		Usage: "operate a remote gocron server with a personal API token",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "server",
+				Aliases: []string{"s"},
+				Value:   fmt.Sprintf("http://127.0.0.1:%d", DefaultPort),
+				Usage:   "gocron server address",
+				EnvVars: []string{"GOCRON_SERVER"},
+			},
+			&cli.StringFlag{
+				Name:    "token",
+				Usage:   "personal API token, gct_...",
+				EnvVars: []string{"GOCRON_TOKEN"},
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Value:   outputTable,
+				Usage:   "output format, table|json|yaml",
+			},
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Value: 30 * time.Second,
+				Usage: "timeout of each request",
+			},
+		},
+		Before: func(ctx *cli.Context) error {
+			switch ctx.String("output") {
+			case outputTable, outputJSON, outputYAML:
+			default:
+				return fmt.Errorf("invalid output format %q, expect table|json|yaml", ctx.String("output"))
+			}
+			if ctx.String("token") == "" {
+				return errors.New("API token is required, set --token or GOCRON_TOKEN")
+			}
+			return nil
+		},
+		Subcommands: []*cli.Command{
+			ctlTaskCommand(),
+			ctlLogCommand(),
+			ctlHostCommand(),
+			ctlBackupCommand(),
+		},
+	}
+}
+
+func ctlHostCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "host",
+		Usage: "manage hosts",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "list hosts",
+				Flags: append([]cli.Flag{
+					&cli.StringFlag{Name: "name", Usage: "filter by host name"},
+					&cli.StringFlag{Name: "group", Usage: "filter by host group"},
+					&cli.IntFlag{Name: "project-id", Usage: "filter by project"},
+				}, pageFlags()...),
+				Action: func(ctx *cli.Context) error {
+					page, err := newClient(ctx).ListHosts(ctx.Context, client.HostListOptions{
+						ListOptions: listOptions(ctx),
+						Name:        ctx.String("name"),
+						Group:       ctx.String("group"),
+						ProjectId:   ctx.Int("project-id"),
+					})
+					if err != nil {
+						return err
+					}
+					return writePage(ctx, page, writeHosts)
+				},
+			},
+			{
+				Name:      "ping",
+				Usage:     "test the connection to a host",
+				ArgsUsage: "<host-id>",
+				Action: func(ctx *cli.Context) error {
+					id, err := idArg(ctx)
+					if err != nil {
+						return err
+					}
+					message, err := newClient(ctx).PingHost(ctx.Context, id)
+					if err != nil {
+						return err
+					}
+					return writeOutput(ctx, client.PingResult{Message: message}, func(w io.Writer) {
+						fmt.Fprintln(w, message)
+					})
+				},
+			},
+		},
+	}
+}
+
+func writeHosts(w io.Writer, hosts []client.Host) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tALIAS\tPORT\tGROUP\tSTATUS")
+	for _, host := range hosts {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%s\t%s\n", host.Id, host.Name, host.Alias, host.Port, host.Group, hostStatus(host.Status))
+	}
+	tw.Flush()
+}
+
+func newClient(ctx *cli.Context) *client.Client {
+	c := client.New(ctx.String("server"), ctx.String("token"))
+	c.HTTPClient = &http.Client{Timeout: ctx.Duration("timeout")}
+
+	return c
+}
+
+// 解析第一个参数为资源ID
+func idArg(ctx *cli.Context) (int, error) {
+	id, err := strconv.Atoi(ctx.Args().First())
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid id %q, usage: %s %s", ctx.Args().First(), ctx.Command.HelpName, ctx.Command.ArgsUsage)
+	}
+
+	return id, nil
+}
+
+func pageFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.IntFlag{Name: "limit", Aliases: []string{"l"}, Usage: "items per page, 1-100 (default 20)"},
+		&cli.StringFlag{Name: "cursor", Usage: "next cursor returned by the previous page"},
+	}
+}
+
+func listOptions(ctx *cli.Context) client.ListOptions {
+	return client.ListOptions{Cursor: ctx.String("cursor"), Limit: ctx.Int("limit")}
+}
+
+// 按输出格式输出结果, table格式调用table输出
+func writeOutput(ctx *cli.Context, value interface{}, table func(w io.Writer)) error {
+	w := ctx.App.Writer
+	format := ctx.String("output")
+	if format == outputTable {
+		table(w)
+		return nil
+	}
+	data, err := marshal(value, format)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+
+	return err
+}
+
+// 输出分页列表, json、yaml格式包含next_cursor, table格式在标准错误中提示下一页
+func writePage[T any](ctx *cli.Context, page *client.Page[T], table func(w io.Writer, items []T)) error {
+	return writeOutput(ctx, page, func(w io.Writer) {
+		table(w, page.Items)
+		if page.NextCursor != "" {
+			fmt.Fprintf(ctx.App.ErrWriter, "more items available, use --cursor %s\n", page.NextCursor)
+		}
+	})
+}
+
+// 按json或yaml格式序列化, yaml由json转换以使用相同的字段名
+func marshal(value interface{}, format string) ([]byte, error) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if format == outputYAML {
+		return yaml.JSONToYAML(data)
+	}
+
+	return append(data, '\n'), nil
+}
+
+// 读取json或yaml文件, 文件名为-时读取标准输入
+func readManifest(ctx *cli.Context, file string) ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if file == "-" {
+		data, err = io.ReadAll(ctx.App.Reader)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil, fmt.Errorf("%s is empty", file)
+	}
+
+	// json是yaml的子集, 统一转换为json解析
+	return yaml.YAMLToJSON(data)
+}
+
+func taskStatus(status client.Status) string {
+	if status == client.TaskEnabled {
+		return "enabled"
+	}
+
+	return "disabled"
+}
+
+func logStatus(status client.Status) string {
+	switch status {
+	case client.LogFailure:
+		return "failure"
+	case client.LogRunning:
+		return "running"
+	case client.LogFinish:
+		return "finish"
+	case client.LogCancel:
+		return "cancel"
+	default:
+		return strconv.Itoa(int(status))
+	}
+}
+
+func hostStatus(status int) string {
+	switch status {
+	case 1:
+		return "online"
+	case 2:
+		return "offline"
+	default:
+		return "unknown"
+	}
+}
+
+func protocolName(protocol int) string {
+	if protocol == client.ProtocolHTTP {
+		return "http"
+	}
+
+	return "shell"
+}
+
+func formatTime(t client.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+
+	return t.Format("2006-01-02 15:04:05")
+}
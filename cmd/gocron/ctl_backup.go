@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tabortao/gocron/pkg/client"
+	"github.com/urfave/cli/v2"
+)
+
+// backupDocument ctl backup导出的配置, 其中的任务可通过task apply导入
+type backupDocument struct {
+	Server               string                 `json:"server"`
+	CreatedAt            time.Time              `json:"created_at"`
+	Tasks                []client.Task          `json:"tasks"`
+	Hosts                []client.Host          `json:"hosts"`
+	NotificationChannels []client.NotifyChannel `json:"notification_channels"`
+}
+
+func ctlBackupCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "backup",
+		Usage: "export tasks, hosts and notification channels, restore tasks with task apply",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "file",
+				Aliases: []string{"f"},
+				Usage:   "write to the file instead of stdout",
+			},
+		},
+		Action: runBackup,
+	}
+}
+
+func runBackup(ctx *cli.Context) error {
+	c := newClient(ctx)
+	doc := backupDocument{Server: c.BaseURL, CreatedAt: time.Now()}
+	var err error
+	doc.Tasks, err = listAll(func(opts client.ListOptions) (*client.Page[client.Task], error) {
+		return c.ListTasks(ctx.Context, client.TaskListOptions{ListOptions: opts})
+	})
+	if err != nil {
+		return fmt.Errorf("export tasks failed: %w", err)
+	}
+	doc.Hosts, err = listAll(func(opts client.ListOptions) (*client.Page[client.Host], error) {
+		return c.ListHosts(ctx.Context, client.HostListOptions{ListOptions: opts})
+	})
+	if err != nil {
+		return fmt.Errorf("export hosts failed: %w", err)
+	}
+	// 通知渠道需要令牌有notify:read权限, 没有权限时跳过
+	doc.NotificationChannels, err = listAll(func(opts client.ListOptions) (*client.Page[client.NotifyChannel], error) {
+		return c.ListChannels(ctx.Context, client.ChannelListOptions{ListOptions: opts})
+	})
+	if errors.Is(err, client.ErrForbidden) {
+		fmt.Fprintln(ctx.App.ErrWriter, "skip notification channels: token has no notify:read scope")
+	} else if err != nil {
+		return fmt.Errorf("export notification channels failed: %w", err)
+	}
+
+	// 备份没有表格格式, 默认使用yaml
+	format := ctx.String("output")
+	if format == outputTable {
+		format = outputYAML
+	}
+	data, err := marshal(doc, format)
+	if err != nil {
+		return err
+	}
+	file := ctx.String("file")
+	if file == "" {
+		_, err = ctx.App.Writer.Write(data)
+		return err
+	}
+	if err = os.WriteFile(file, data, 0o600); err != nil {
+		return err
+	}
+	fmt.Fprintf(ctx.App.ErrWriter, "exported %d task(s), %d host(s), %d notification channel(s) to %s\n",
+		len(doc.Tasks), len(doc.Hosts), len(doc.NotificationChannels), file)
+
+	return nil
+}
+
+// 按游标遍历所有分页
+func listAll[T any](list func(opts client.ListOptions) (*client.Page[T], error)) ([]T, error) {
+	items := []T{}
+	opts := client.ListOptions{Limit: 100}
+	for {
+		page, err := list(opts)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page.Items...)
+		if page.NextCursor == "" {
+			return items, nil
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
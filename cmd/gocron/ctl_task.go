@@ -0,0 +1,428 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/tabortao/gocron/pkg/client"
+	"github.com/urfave/cli/v2"
+)
+
+// taskManifest task apply、task create读取的任务定义, 与task get的输出兼容
+type taskManifest struct {
+	// 为0或任务不存在时按名称查找任务
+	Id int `json:"id"`
+	client.TaskRequest
+	// host_id为空时使用其中的主机ID
+	Hosts  []client.TaskHost `json:"hosts"`
+	Status *client.Status    `json:"status"`
+}
+
+func (m taskManifest) request() client.TaskRequest {
+	req := m.TaskRequest
+	if req.HostId == "" && len(m.Hosts) > 0 {
+		req.HostId = client.Task{Hosts: m.Hosts}.Request().HostId
+	}
+
+	return req
+}
+
+// applyResult task apply的执行结果
+type applyResult struct {
+	Action string      `json:"action"`
+	Task   client.Task `json:"task"`
+}
+
+func ctlTaskCommand() *cli.Command {
+	fileFlag := &cli.StringFlag{
+		Name:     "file",
+		Aliases:  []string{"f"},
+		Usage:    "task definition in json or yaml, - for stdin",
+		Required: true,
+	}
+
+	return &cli.Command{
+		Name:  "task",
+		Usage: "manage tasks",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "list tasks",
+				Flags: append([]cli.Flag{
+					&cli.StringFlag{Name: "name", Usage: "filter by task name"},
+					&cli.StringFlag{Name: "tag", Usage: "filter by tag"},
+					&cli.IntFlag{Name: "project-id", Usage: "filter by project"},
+					&cli.IntFlag{Name: "host-id", Usage: "filter by host"},
+					&cli.StringFlag{Name: "status", Usage: "filter by status, enabled|disabled"},
+				}, pageFlags()...),
+				Action: listTasks,
+			},
+			{
+				Name:      "get",
+				Usage:     "show a task",
+				ArgsUsage: "<task-id>",
+				Action: func(ctx *cli.Context) error {
+					id, err := idArg(ctx)
+					if err != nil {
+						return err
+					}
+					task, err := newClient(ctx).GetTask(ctx.Context, id)
+					if err != nil {
+						return err
+					}
+					return writeOutput(ctx, task, func(w io.Writer) {
+						writeTasks(w, []client.Task{*task})
+					})
+				},
+			},
+			{
+				Name:   "create",
+				Usage:  "create a task from a file",
+				Flags:  []cli.Flag{fileFlag},
+				Action: createTask,
+			},
+			{
+				Name:   "apply",
+				Usage:  "create or update tasks from a file, matched by id and then by name",
+				Flags:  []cli.Flag{fileFlag},
+				Action: applyTasks,
+			},
+			{
+				Name:      "run",
+				Usage:     "run a task now",
+				ArgsUsage: "<task-id>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "wait", Aliases: []string{"w"}, Usage: "wait until the task finishes"},
+					&cli.BoolFlag{Name: "follow", Aliases: []string{"F"}, Usage: "print the output until the task finishes"},
+				},
+				Action: runTask,
+			},
+			{
+				Name:      "enable",
+				Usage:     "enable a task",
+				ArgsUsage: "<task-id>",
+				Action: func(ctx *cli.Context) error {
+					return setTaskStatus(ctx, client.TaskEnabled)
+				},
+			},
+			{
+				Name:      "disable",
+				Usage:     "disable a task",
+				ArgsUsage: "<task-id>",
+				Action: func(ctx *cli.Context) error {
+					return setTaskStatus(ctx, client.TaskDisabled)
+				},
+			},
+		},
+	}
+}
+
+func ctlLogCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "log",
+		Usage: "inspect task logs",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "get",
+				Usage:     "show a task log and its result",
+				ArgsUsage: "<log-id>",
+				Action: func(ctx *cli.Context) error {
+					id, err := idArg(ctx)
+					if err != nil {
+						return err
+					}
+					taskLog, err := newClient(ctx).GetLog(ctx.Context, int64(id))
+					if err != nil {
+						return err
+					}
+					return writeLog(ctx, taskLog)
+				},
+			},
+			{
+				Name:      "tail",
+				Usage:     "print the output of a task log until the task finishes",
+				ArgsUsage: "<log-id>",
+				Action: func(ctx *cli.Context) error {
+					id, err := idArg(ctx)
+					if err != nil {
+						return err
+					}
+					return tailLog(ctx, int64(id))
+				},
+			},
+		},
+	}
+}
+
+func listTasks(ctx *cli.Context) error {
+	opts := client.TaskListOptions{
+		ListOptions: listOptions(ctx),
+		Name:        ctx.String("name"),
+		Tag:         ctx.String("tag"),
+		ProjectId:   ctx.Int("project-id"),
+		HostId:      ctx.Int("host-id"),
+	}
+	switch ctx.String("status") {
+	case "":
+	case "enabled":
+		opts.Status = client.Ptr(client.TaskEnabled)
+	case "disabled":
+		opts.Status = client.Ptr(client.TaskDisabled)
+	default:
+		return fmt.Errorf("invalid status %q, expect enabled|disabled", ctx.String("status"))
+	}
+	page, err := newClient(ctx).ListTasks(ctx.Context, opts)
+	if err != nil {
+		return err
+	}
+
+	return writePage(ctx, page, writeTasks)
+}
+
+func createTask(ctx *cli.Context) error {
+	manifests, err := readTaskManifests(ctx)
+	if err != nil {
+		return err
+	}
+	if len(manifests) != 1 {
+		return fmt.Errorf("%s should contain exactly one task, use task apply for multiple tasks", ctx.String("file"))
+	}
+	c := newClient(ctx)
+	task, err := c.CreateTask(ctx.Context, manifests[0].request())
+	if err == nil {
+		task, err = syncTaskStatus(ctx, c, task, manifests[0].Status)
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(ctx, task, func(w io.Writer) {
+		writeTasks(w, []client.Task{*task})
+	})
+}
+
+// 按ID或名称查找已存在的任务并修改, 未找到时创建
+func applyTasks(ctx *cli.Context) error {
+	manifests, err := readTaskManifests(ctx)
+	if err != nil {
+		return err
+	}
+	c := newClient(ctx)
+	results := make([]applyResult, 0, len(manifests))
+	for _, manifest := range manifests {
+		id, err := findTask(ctx, c, manifest)
+		if err != nil {
+			return err
+		}
+		result := applyResult{Action: "created"}
+		var task *client.Task
+		if id > 0 {
+			result.Action = "updated"
+			task, err = c.UpdateTask(ctx.Context, id, manifest.request())
+		} else {
+			task, err = c.CreateTask(ctx.Context, manifest.request())
+		}
+		if err == nil {
+			task, err = syncTaskStatus(ctx, c, task, manifest.Status)
+		}
+		if err != nil {
+			return fmt.Errorf("apply task %q failed: %w", manifest.Name, err)
+		}
+		result.Task = *task
+		results = append(results, result)
+	}
+
+	return writeOutput(ctx, results, func(w io.Writer) {
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ACTION\tID\tNAME\tSTATUS")
+		for _, result := range results {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", result.Action, result.Task.Id, result.Task.Name, taskStatus(result.Task.Status))
+		}
+		tw.Flush()
+	})
+}
+
+// 返回任务定义对应的已存在任务ID, 不存在时返回0
+func findTask(ctx *cli.Context, c *client.Client, manifest taskManifest) (int, error) {
+	if manifest.Id > 0 {
+		task, err := c.GetTask(ctx.Context, manifest.Id)
+		if err == nil {
+			return task.Id, nil
+		}
+		if !errors.Is(err, client.ErrNotFound) {
+			return 0, err
+		}
+	}
+	if manifest.Name == "" {
+		return 0, nil
+	}
+
+	// 名称筛选为模糊匹配, 从所有结果中查找同名任务
+	tasks, err := listAll(func(opts client.ListOptions) (*client.Page[client.Task], error) {
+		return c.ListTasks(ctx.Context, client.TaskListOptions{ListOptions: opts, Name: manifest.Name})
+	})
+	if err != nil {
+		return 0, err
+	}
+	ids := []int{}
+	for _, task := range tasks {
+		if task.Name == manifest.Name {
+			ids = append(ids, task.Id)
+		}
+	}
+	if len(ids) > 1 {
+		return 0, fmt.Errorf("found %d tasks named %q, set id to choose one", len(ids), manifest.Name)
+	}
+	if len(ids) == 1 {
+		return ids[0], nil
+	}
+
+	return 0, nil
+}
+
+// 任务定义设置了状态时启用或停用任务
+func syncTaskStatus(ctx *cli.Context, c *client.Client, task *client.Task, status *client.Status) (*client.Task, error) {
+	if status == nil || *status == task.Status {
+		return task, nil
+	}
+	if *status == client.TaskEnabled {
+		return c.EnableTask(ctx.Context, task.Id)
+	}
+
+	return c.DisableTask(ctx.Context, task.Id)
+}
+
+// 读取任务定义, 文件可以是单个任务、任务数组或backup导出的文件
+func readTaskManifests(ctx *cli.Context) ([]taskManifest, error) {
+	data, err := readManifest(ctx, ctx.String("file"))
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("{")) {
+		var doc struct {
+			Tasks json.RawMessage `json:"tasks"`
+		}
+		if err = json.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		if len(doc.Tasks) == 0 {
+			data = append(append([]byte("["), data...), ']')
+		} else {
+			data = doc.Tasks
+		}
+	}
+	var manifests []taskManifest
+	if err = json.Unmarshal(data, &manifests); err != nil {
+		return nil, fmt.Errorf("invalid task definition: %w", err)
+	}
+
+	return manifests, nil
+}
+
+func runTask(ctx *cli.Context) error {
+	id, err := idArg(ctx)
+	if err != nil {
+		return err
+	}
+	c := newClient(ctx)
+	logId, err := c.RunTask(ctx.Context, id)
+	if err != nil {
+		return err
+	}
+	if ctx.Bool("follow") {
+		fmt.Fprintf(ctx.App.ErrWriter, "task #%d started, log id %d\n", id, logId)
+		return tailLog(ctx, logId)
+	}
+	if !ctx.Bool("wait") {
+		return writeOutput(ctx, client.RunResult{LogId: logId}, func(w io.Writer) {
+			fmt.Fprintf(w, "task #%d started, log id %d\n", id, logId)
+		})
+	}
+	taskLog, err := c.WaitForLog(ctx.Context, logId)
+	if err != nil {
+		return err
+	}
+	if err = writeLog(ctx, taskLog); err != nil {
+		return err
+	}
+
+	return logExitError(taskLog)
+}
+
+func setTaskStatus(ctx *cli.Context, status client.Status) error {
+	id, err := idArg(ctx)
+	if err != nil {
+		return err
+	}
+	c := newClient(ctx)
+	var task *client.Task
+	if status == client.TaskEnabled {
+		task, err = c.EnableTask(ctx.Context, id)
+	} else {
+		task, err = c.DisableTask(ctx.Context, id)
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(ctx, task, func(w io.Writer) {
+		writeTasks(w, []client.Task{*task})
+	})
+}
+
+// 输出任务日志的实时输出, 任务执行失败或被取消时以非0状态退出
+func tailLog(ctx *cli.Context, logId int64) error {
+	taskLog, err := newClient(ctx).TailLog(ctx.Context, logId, ctx.App.Writer)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(ctx.App.ErrWriter, "\nlog #%d %s, total time %ds\n", taskLog.Id, logStatus(taskLog.Status), taskLog.TotalTime)
+
+	return logExitError(taskLog)
+}
+
+func logExitError(taskLog *client.TaskLog) error {
+	if taskLog.Status == client.LogFailure || taskLog.Status == client.LogCancel {
+		return cli.Exit(fmt.Sprintf("task #%d %s", taskLog.TaskId, logStatus(taskLog.Status)), 1)
+	}
+
+	return nil
+}
+
+func writeLog(ctx *cli.Context, taskLog *client.TaskLog) error {
+	return writeOutput(ctx, taskLog, func(w io.Writer) {
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tTASK\tNAME\tSTATUS\tSTART\tEND\tTOTAL")
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%s\t%s\t%ds\n", taskLog.Id, taskLog.TaskId, taskLog.Name,
+			logStatus(taskLog.Status), formatTime(taskLog.StartTime), formatTime(taskLog.EndTime), taskLog.TotalTime)
+		tw.Flush()
+		if taskLog.Result != "" {
+			fmt.Fprintf(w, "\n%s\n", strings.TrimRight(taskLog.Result, "\n"))
+		}
+	})
+}
+
+func writeTasks(w io.Writer, tasks []client.Task) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tSPEC\tPROTOCOL\tHOSTS\tSTATUS\tNEXT RUN")
+	for _, task := range tasks {
+		hosts := make([]string, 0, len(task.Hosts))
+		for _, host := range task.Hosts {
+			hosts = append(hosts, host.Name+":"+strconv.Itoa(host.Port))
+		}
+		hostNames := strings.Join(hosts, ",")
+		if hostNames == "" {
+			hostNames = "-"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", task.Id, task.Name, task.Spec, protocolName(task.Protocol),
+			hostNames, taskStatus(task.Status), formatTime(task.NextRunTime))
+	}
+	tw.Flush()
+}
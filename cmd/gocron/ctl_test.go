@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/tabortao/gocron/pkg/client"
+	"github.com/urfave/cli/v2"
+)
+
+// 内存中保存任务的v2接口
+type fakeServer struct {
+	mu     sync.Mutex
+	tasks  []client.Task
+	nextId int
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer gct_test" {
+		respond(w, http.StatusUnauthorized, client.CodeAuth, nil)
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, client.APIPrefix+"/"), "/")
+	switch {
+	case parts[0] == "hosts":
+		respond(w, http.StatusOK, 0, client.Page[client.Host]{Items: []client.Host{{Id: 1, Name: "127.0.0.1", Port: 5921}}})
+	case parts[0] == "notification-channels":
+		respond(w, http.StatusForbidden, client.CodeUnauthorized, nil)
+	case parts[0] == "tasks" && len(parts) == 1 && r.Method == http.MethodGet:
+		page := client.Page[client.Task]{Items: []client.Task{}}
+		for _, task := range s.tasks {
+			if strings.Contains(task.Name, r.URL.Query().Get("name")) {
+				page.Items = append(page.Items, task)
+			}
+		}
+		respond(w, http.StatusOK, 0, page)
+	case parts[0] == "tasks" && len(parts) == 1 && r.Method == http.MethodPost:
+		var req client.TaskRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		s.nextId++
+		task := client.Task{Id: s.nextId, Name: req.Name, Spec: req.Spec, Command: req.Command, Protocol: req.Protocol, Status: client.TaskEnabled}
+		s.tasks = append(s.tasks, task)
+		respond(w, http.StatusCreated, 0, task)
+	case parts[0] == "tasks":
+		id, _ := strconv.Atoi(parts[1])
+		task := s.find(id)
+		if task == nil {
+			respond(w, http.StatusNotFound, client.CodeNotFound, nil)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPut:
+			var req client.TaskRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			task.Name, task.Spec, task.Command = req.Name, req.Spec, req.Command
+		case len(parts) == 3 && parts[2] == "enable":
+			task.Status = client.TaskEnabled
+		case len(parts) == 3 && parts[2] == "disable":
+			task.Status = client.TaskDisabled
+		}
+		respond(w, http.StatusOK, 0, task)
+	default:
+		respond(w, http.StatusNotFound, client.CodeNotFound, nil)
+	}
+}
+
+func (s *fakeServer) find(id int) *client.Task {
+	for i := range s.tasks {
+		if s.tasks[i].Id == id {
+			return &s.tasks[i]
+		}
+	}
+
+	return nil
+}
+
+func respond(w http.ResponseWriter, status, code int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"code": code, "message": http.StatusText(status), "data": data})
+}
+
+// 执行ctl命令, 返回标准输出
+func runCtl(t *testing.T, server string, args ...string) (string, error) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	cliApp := &cli.App{
+		Name:           "gocron",
+		Commands:       []*cli.Command{ctlCommand()},
+		Writer:         &stdout,
+		ErrWriter:      &stderr,
+		ExitErrHandler: func(*cli.Context, error) {},
+	}
+	err := cliApp.Run(append([]string{"gocron", "ctl", "--server", server, "--token", "gct_test"}, args...))
+
+	return stdout.String(), err
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+func TestCtlApply(t *testing.T) {
+	fake := &fakeServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	manifest := writeFile(t, "tasks.yaml", `
+- name: backup
+  spec: "0 0 3 * * *"
+  protocol: 2
+  command: ./backup.sh
+  host_id: "1"
+- name: cleanup
+  spec: "@daily"
+  protocol: 2
+  command: ./cleanup.sh
+  hosts:
+    - host_id: 1
+  status: 0
+`)
+	output, err := runCtl(t, server.URL, "-o", "json", "task", "apply", "-f", manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []applyResult
+	if err = json.Unmarshal([]byte(output), &results); err != nil {
+		t.Fatal(err, output)
+	}
+	if len(results) != 2 || results[0].Action != "created" || results[1].Task.Status != client.TaskDisabled {
+		t.Fatalf("unexpected results %+v", results)
+	}
+
+	// 同名任务被修改, 单个任务可以使用json
+	manifest = writeFile(t, "task.json", `{"name": "backup", "spec": "0 0 4 * * *", "protocol": 2, "command": "./backup.sh --full"}`)
+	output, err = runCtl(t, server.URL, "task", "apply", "-f", manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output, "updated") || len(fake.tasks) != 2 || fake.tasks[0].Command != "./backup.sh --full" {
+		t.Fatalf("unexpected output %q, tasks %+v", output, fake.tasks)
+	}
+
+	output, err = runCtl(t, server.URL, "-o", "yaml", "task", "list", "--name", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output, "name: backup") || !strings.Contains(output, "next_cursor:") {
+		t.Errorf("unexpected yaml output %q", output)
+	}
+	output, err = runCtl(t, server.URL, "task", "list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(output, "ID") || !strings.Contains(output, "cleanup") || !strings.Contains(output, "disabled") {
+		t.Errorf("unexpected table output %q", output)
+	}
+
+	if _, err = runCtl(t, server.URL, "task", "get", "9"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expect not found, got %v", err)
+	}
+	if _, err = runCtl(t, server.URL, "-o", "xml", "task", "list"); err == nil {
+		t.Error("expect invalid output format")
+	}
+}
+
+func TestCtlBackup(t *testing.T) {
+	fake := &fakeServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	manifest := writeFile(t, "task.yaml", "name: backup\nspec: '@daily'\nprotocol: 2\ncommand: ./backup.sh\n")
+	if _, err := runCtl(t, server.URL, "task", "create", "-f", manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(t.TempDir(), "backup.yaml")
+	if _, err := runCtl(t, server.URL, "backup", "-f", file); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "tasks:") || !strings.Contains(string(data), "127.0.0.1") {
+		t.Fatalf("unexpected backup %s", data)
+	}
+
+	// 备份文件可以通过apply恢复, 按ID匹配已存在的任务
+	fake.tasks[0].Command = "changed"
+	if _, err = runCtl(t, server.URL, "task", "apply", "-f", file); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.tasks) != 1 || fake.tasks[0].Command != "./backup.sh" {
+		t.Errorf("unexpected tasks %+v", fake.tasks)
+	}
+}
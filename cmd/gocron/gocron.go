@@ -13,6 +13,8 @@ import (
 	"github.com/tabortao/gocron/internal/models"
 	"github.com/tabortao/gocron/internal/modules/app"
 	"github.com/tabortao/gocron/internal/modules/logger"
+	"github.com/tabortao/gocron/internal/modules/rpc/grpcpool"
+	"github.com/tabortao/gocron/internal/modules/rpc/reverse"
 	"github.com/tabortao/gocron/internal/modules/setting"
 	"github.com/tabortao/gocron/internal/modules/utils"
 	"github.com/tabortao/gocron/internal/routers"
@@ -21,7 +23,7 @@ import (
 )
 
 var (
-	AppVersion           = "1.5.8"
+	AppVersion           = "1.7.0"
 	BuildDate, GitCommit string
 )
 
@@ -74,7 +76,7 @@ func getCommands() []*cli.Command {
 		},
 	}
 
-	return []*cli.Command{command}
+	return []*cli.Command{command, backfillCommand(), ctlCommand()}
 }
 
 func runWeb(ctx *cli.Context) error {
@@ -132,8 +134,8 @@ func initModule() {
 		logger.Error("修复配置记录失败", err)
 	}
 
-	// 初始化定时任务
-	service.ServiceTask.Initialize()
+	// 初始化定时任务及后台服务
+	service.StartBackground()
 }
 
 // 解析端口
@@ -201,9 +203,14 @@ func shutdown() {
 		return
 	}
 	logger.Info("Application preparing to exit")
+	service.ServiceHostHealth.Stop()
+	grpcpool.Pool.Stop()
+	service.StopBuiltinCA()
 	// 停止所有任务调度
 	logger.Info("Stopping scheduled task scheduler")
 	service.ServiceTask.WaitAndExit()
+	// 任务结束后再断开反向连接的节点
+	reverse.Stop()
 }
 
 // 判断应用是否需要升级, 当存在版本号文件且版本小于app.VersionId时升级
@@ -228,12 +235,31 @@ func upgradeIfNeed() {
 
 // 确保所有表都存在
 func ensureTables() {
-	if !models.Db.Migrator().HasTable(&models.AgentToken{}) {
-		logger.Info("检测到agent_token表不存在，开始创建...")
-		if err := models.Db.AutoMigrate(&models.AgentToken{}); err != nil {
-			logger.Error("创建agent_token表失败", err)
+	tables := []struct {
+		name  string
+		model interface{}
+	}{
+		{"agent_token", &models.AgentToken{}},
+		{"task_log_attempt", &models.TaskLogAttempt{}},
+		{"task_log_host", &models.TaskLogHost{}},
+		{"project", &models.Project{}},
+		{"project_member", &models.ProjectMember{}},
+		{"audit_log", &models.AuditLog{}},
+		{"task_revision", &models.TaskRevision{}},
+		{"api_token", &models.ApiToken{}},
+	}
+	for _, table := range tables {
+		if models.Db.Migrator().HasTable(table.model) {
+			continue
+		}
+		logger.Infof("检测到%s表不存在，开始创建...", table.name)
+		if err := models.Db.AutoMigrate(table.model); err != nil {
+			logger.Errorf("创建%s表失败: %v", table.name, err)
 		} else {
-			logger.Info("agent_token表创建成功")
+			logger.Infof("%s表创建成功", table.name)
 		}
 	}
+	if err := models.EnsureDefaultProject(models.Db); err != nil {
+		logger.Error("创建默认项目失败", err)
+	}
 }
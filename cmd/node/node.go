@@ -2,9 +2,13 @@
 package main
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"os"
+	"os/user"
 	"runtime"
+	"slices"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
@@ -17,15 +21,32 @@ var (
 	AppVersion, BuildDate, GitCommit string
 )
 
+var (
+	configFile    string
+	serverAddr    string
+	allowRoot     bool
+	version       bool
+	CAFile        string
+	certFile      string
+	keyFile       string
+	enableTLS     bool
+	logLevel      string
+	reverseServer string
+	hostname      string
+	tokenFile     string
+	serverName    string
+	renewURL      string
+	clientName    string
+	policyFile    string
+	genSignKey    string
+	signKey       string
+	signCommand   string
+	signTaskId    int64
+	stateDir      string
+)
+
 func main() {
-	var serverAddr string
-	var allowRoot bool
-	var version bool
-	var CAFile string
-	var certFile string
-	var keyFile string
-	var enableTLS bool
-	var logLevel string
+	flag.StringVar(&configFile, "c", "", "./gocron-node -c /etc/gocron-node/node.ini")
 	flag.BoolVar(&allowRoot, "allow-root", false, "./gocron-node -allow-root")
 	flag.StringVar(&serverAddr, "s", "0.0.0.0:5921", "./gocron-node -s ip:port")
 	flag.BoolVar(&version, "v", false, "./gocron-node -v")
@@ -34,42 +55,150 @@ func main() {
 	flag.StringVar(&certFile, "cert-file", "", "./gocron-node -cert-file path")
 	flag.StringVar(&keyFile, "key-file", "", "./gocron-node -key-file path")
 	flag.StringVar(&logLevel, "log-level", "info", "-log-level error")
+	flag.StringVar(&reverseServer, "reverse-server", "", "./gocron-node -reverse-server ip:port")
+	flag.StringVar(&hostname, "hostname", "", "./gocron-node -reverse-server ip:port -hostname name")
+	flag.StringVar(&tokenFile, "token-file", "", "./gocron-node -token-file path")
+	flag.StringVar(&serverName, "server-name", "", "./gocron-node -reverse-server ip:port -server-name gocron-server")
+	flag.StringVar(&renewURL, "cert-renew-url", "", "./gocron-node -cert-renew-url http://gocron-web:5920")
+	flag.StringVar(&clientName, "client-name", "", "./gocron-node -enable-tls -client-name gocron-server")
+	flag.StringVar(&policyFile, "policy-file", "", "./gocron-node -policy-file path")
+	flag.StringVar(&stateDir, "state-dir", "", "./gocron-node -state-dir path")
+	flag.StringVar(&genSignKey, "gen-sign-key", "", "./gocron-node -gen-sign-key path")
+	flag.StringVar(&signKey, "sign-key", "", "./gocron-node -sign-key path -sign-task-id id -sign 'command'")
+	flag.StringVar(&signCommand, "sign", "", "./gocron-node -sign-key path -sign-task-id id -sign 'command'")
+	flag.Int64Var(&signTaskId, "sign-task-id", 0, "./gocron-node -sign-key path -sign-task-id id -sign 'command'")
 	flag.Parse()
-	level, err := log.ParseLevel(logLevel)
-	if err != nil {
-		log.Fatal(err)
-	}
-	log.SetLevel(level)
 
 	if version {
 		utils.PrintAppVersion(AppVersion, GitCommit, BuildDate)
 		return
 	}
 
-	if enableTLS {
-		if !utils.FileExist(CAFile) {
-			log.Fatalf("failed to read ca cert file: %s", CAFile)
+	// 生成命令签名密钥, 公钥配置到节点的 trusted_keys
+	if genSignKey != "" {
+		if err := auth.GenerateSigningKey(genSignKey); err != nil {
+			log.Fatal(err)
 		}
-		if !utils.FileExist(certFile) {
-			log.Fatalf("failed to read server cert file: %s", certFile)
-			return
+		fmt.Printf("signing key: %s\npublic key: %s.pub\n", genSignKey, genSignKey)
+		return
+	}
+	// 对任务命令签名, 输出的签名填写到任务的命令签名中
+	if signKey != "" {
+		if signTaskId <= 0 {
+			log.Fatal("-sign-task-id is required")
 		}
-		if !utils.FileExist(keyFile) {
-			log.Fatalf("failed to read server key file: %s", keyFile)
-			return
+		privateKey, err := auth.LoadSigningKey(signKey)
+		if err != nil {
+			log.Fatal(err)
 		}
+		fmt.Println(auth.SignCommand(privateKey, signTaskId, strings.TrimSpace(signCommand)))
+		return
 	}
 
-	certificate := auth.Certificate{
-		CAFile:   strings.TrimSpace(CAFile),
-		CertFile: strings.TrimSpace(certFile),
-		KeyFile:  strings.TrimSpace(keyFile),
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := checkUser(cfg); err != nil {
+		log.Fatal(err)
 	}
 
-	if runtime.GOOS != "windows" && os.Getuid() == 0 && !allowRoot {
-		log.Fatal("Do not run gocron-node as root user")
+	server.AppVersion = AppVersion
+	// 反向连接模式: 节点主动连接服务端, 不再监听端口
+	if cfg.ReverseServer != "" {
+		server.StartReverse(cfg, loadConfig)
 		return
 	}
+	server.Start(cfg, loadConfig)
+}
+
+// 读取配置文件, 命令行中指定的参数优先
+func loadConfig() (*server.Config, error) {
+	cfg := server.DefaultConfig()
+	if configFile != "" {
+		var err error
+		cfg, err = server.LoadConfig(strings.TrimSpace(configFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config file: %w", err)
+		}
+	}
+
+	var err error
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "s":
+			cfg.Listen = strings.TrimSpace(serverAddr)
+		case "log-level":
+			cfg.LogLevel = logLevel
+		case "enable-tls":
+			cfg.EnableTLS = enableTLS
+		case "ca-file":
+			cfg.CAFile = strings.TrimSpace(CAFile)
+		case "cert-file":
+			cfg.CertFile = strings.TrimSpace(certFile)
+		case "key-file":
+			cfg.KeyFile = strings.TrimSpace(keyFile)
+		case "server-name":
+			cfg.ServerName = strings.TrimSpace(serverName)
+		case "cert-renew-url":
+			cfg.RenewURL = strings.TrimSpace(renewURL)
+		case "client-name":
+			cfg.ClientName = strings.TrimSpace(clientName)
+		case "reverse-server":
+			cfg.ReverseServer = strings.TrimSpace(reverseServer)
+		case "hostname":
+			cfg.Hostname = strings.TrimSpace(hostname)
+		case "token-file":
+			cfg.TokenFile = strings.TrimSpace(tokenFile)
+		case "state-dir":
+			cfg.StateDir = strings.TrimSpace(stateDir)
+		case "policy-file":
+			cfg.Policy, err = server.LoadPolicy(strings.TrimSpace(policyFile))
+			if err != nil {
+				err = fmt.Errorf("failed to load policy file: %w", err)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.EnableTLS {
+		if !utils.FileExist(cfg.CAFile) {
+			return nil, fmt.Errorf("failed to read ca cert file: %s", cfg.CAFile)
+		}
+		if !utils.FileExist(cfg.CertFile) {
+			return nil, fmt.Errorf("failed to read server cert file: %s", cfg.CertFile)
+		}
+		if !utils.FileExist(cfg.KeyFile) {
+			return nil, fmt.Errorf("failed to read server key file: %s", cfg.KeyFile)
+		}
+	}
+	if cfg.ReverseServer != "" && cfg.Hostname == "" {
+		cfg.Hostname, err = os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// 配置了 allow_users 时只允许以其中的用户运行, 否则不允许以root运行
+func checkUser(cfg *server.Config) error {
+	if len(cfg.AllowUsers) > 0 {
+		current, err := user.Current()
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(cfg.AllowUsers, current.Username) {
+			return fmt.Errorf("user %s is not in node.allow_users", current.Username)
+		}
+		return nil
+	}
+	if runtime.GOOS != "windows" && os.Getuid() == 0 && !allowRoot {
+		return errors.New("do not run gocron-node as root user, or start with -allow-root")
+	}
 
-	server.Start(serverAddr, enableTLS, certificate)
+	return nil
 }
@@ -11,6 +11,8 @@ type AgentToken struct {
 	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
 	Used      bool       `json:"used" gorm:"default:false"`
 	UsedAt    *time.Time `json:"used_at" gorm:"default:null"`
+	HostGroup string     `json:"host_group" gorm:"type:varchar(64);not null;default:''"` // 注册主机的分组
+	Labels    string     `json:"labels" gorm:"type:varchar(512);not null;default:''"`    // 注册主机的标签
 	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
 }
 
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/tabortao/gocron/internal/modules/utils"
+)
+
+// API令牌前缀, 便于识别及密钥扫描
+const ApiTokenPrefix = "gct_"
+
+// API令牌的权限范围
+const (
+	ScopeTaskRead  = "task:read"
+	ScopeTaskWrite = "task:write"
+	ScopeTaskRun   = "task:run"
+	ScopeLogRead   = "log:read"
+	ScopeLogWrite  = "log:write"
+	ScopeHostRead  = "host:read"
+	ScopeHostWrite = "host:write"
+	// 以下权限范围的接口只允许系统管理员访问
+	ScopeNotifyRead  = "notify:read"
+	ScopeNotifyWrite = "notify:write"
+	ScopeUserRead    = "user:read"
+	ScopeUserWrite   = "user:write"
+)
+
+var ApiScopes = []string{
+	ScopeTaskRead, ScopeTaskWrite, ScopeTaskRun,
+	ScopeLogRead, ScopeLogWrite,
+	ScopeHostRead, ScopeHostWrite,
+	ScopeNotifyRead, ScopeNotifyWrite,
+	ScopeUserRead, ScopeUserWrite,
+}
+
+// 最近使用时间的更新间隔, 避免每次请求都写数据库
+const apiTokenTouchInterval = time.Minute
+
+// 用户的个人API令牌, 只保存令牌的哈希, 令牌明文仅在创建时返回
+// 令牌以所属用户的身份及项目角色访问接口, 同时受权限范围限制
+type ApiToken struct {
+	Id         int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserId     int        `json:"user_id" gorm:"not null;index"`
+	Name       string     `json:"name" gorm:"type:varchar(64);not null"`
+	TokenHash  string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	Prefix     string     `json:"prefix" gorm:"type:varchar(16);not null;default:''"` // 令牌开头几位, 用于区分令牌
+	Scopes     string     `json:"scopes" gorm:"type:varchar(255);not null;default:''"`
+	ExpiresAt  *time.Time `json:"expires_at" gorm:"default:null"` // 为空时不过期
+	LastUsedAt *time.Time `json:"last_used_at" gorm:"default:null"`
+	LastUsedIp string     `json:"last_used_ip" gorm:"type:varchar(64);not null;default:''"`
+	CreatedAt  time.Time  `json:"created" gorm:"column:created;autoCreateTime"`
+}
+
+// Create 生成令牌并保存哈希, 返回令牌明文
+func (token *ApiToken) Create() (string, error) {
+	plain := ApiTokenPrefix + utils.RandAuthToken()
+	token.TokenHash = utils.Sha256(plain)
+	token.Prefix = plain[:len(ApiTokenPrefix)+6]
+	if err := Db.Create(token).Error; err != nil {
+		return "", err
+	}
+
+	return plain, nil
+}
+
+// FindByToken 按令牌明文查找, 不存在时Id为0
+func (token *ApiToken) FindByToken(plain string) error {
+	return Db.Where("token_hash = ?", utils.Sha256(plain)).Limit(1).Find(token).Error
+}
+
+// List 用户的令牌, userId为0时返回全部
+func (token *ApiToken) List(userId int) ([]ApiToken, error) {
+	list := make([]ApiToken, 0)
+	query := Db.Order("id DESC")
+	if userId > 0 {
+		query = query.Where("user_id = ?", userId)
+	}
+	err := query.Find(&list).Error
+
+	return list, err
+}
+
+// Find 查找用户的令牌, 不存在时Id为0
+func (token *ApiToken) Find(id, userId int) error {
+	return Db.Where("id = ? AND user_id = ?", id, userId).Limit(1).Find(token).Error
+}
+
+func (token *ApiToken) Delete(id int) error {
+	return Db.Delete(&ApiToken{}, id).Error
+}
+
+// DeleteByUser 删除用户时吊销其全部令牌
+func (token *ApiToken) DeleteByUser(userId int) error {
+	return Db.Where("user_id = ?", userId).Delete(&ApiToken{}).Error
+}
+
+// Expired 令牌是否已过期
+func (token *ApiToken) Expired() bool {
+	return token.ExpiresAt != nil && !time.Now().Before(*token.ExpiresAt)
+}
+
+// HasScope 令牌是否拥有权限范围
+func (token *ApiToken) HasScope(scope string) bool {
+	return scope != "" && utils.InStringSlice(strings.Split(token.Scopes, ","), scope)
+}
+
+// Touch 记录最近使用时间及IP
+func (token *ApiToken) Touch(ip string) error {
+	now := time.Now()
+	if token.LastUsedAt != nil && now.Sub(*token.LastUsedAt) < apiTokenTouchInterval && token.LastUsedIp == ip {
+		return nil
+	}
+	token.LastUsedAt = &now
+	token.LastUsedIp = ip
+
+	return Db.Model(&ApiToken{}).Where("id = ?", token.Id).UpdateColumns(map[string]interface{}{
+		"last_used_at": now,
+		"last_used_ip": ip,
+	}).Error
+}
+
+// ValidScopes 过滤无效的权限范围并去重
+func ValidScopes(scopes []string) []string {
+	valid := make([]string, 0, len(scopes))
+	for _, scope := range ApiScopes {
+		if utils.InStringSlice(scopes, scope) {
+			valid = append(valid, scope)
+		}
+	}
+
+	return valid
+}
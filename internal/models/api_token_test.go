@@ -0,0 +1,74 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func TestApiToken(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{SingularTable: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&ApiToken{}); err != nil {
+		t.Fatal(err)
+	}
+	oldDb := Db
+	Db = db
+	defer func() { Db = oldDb }()
+
+	token := &ApiToken{UserId: 1, Name: "ci", Scopes: strings.Join(ValidScopes([]string{ScopeTaskRun, "task:admin", ScopeTaskRead}), ",")}
+	plain, err := token.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(plain, ApiTokenPrefix) || !strings.HasPrefix(plain, token.Prefix) || token.TokenHash == plain {
+		t.Errorf("token %q, prefix %q, hash %q", plain, token.Prefix, token.TokenHash)
+	}
+	if token.Scopes != "task:read,task:run" {
+		t.Errorf("scopes = %q", token.Scopes)
+	}
+
+	found := new(ApiToken)
+	if err = found.FindByToken(plain); err != nil || found.Id != token.Id {
+		t.Fatalf("find by token = %d, %v", found.Id, err)
+	}
+	if !found.HasScope(ScopeTaskRun) || found.HasScope(ScopeTaskWrite) || found.HasScope("") {
+		t.Errorf("unexpected scopes %q", found.Scopes)
+	}
+	missing := new(ApiToken)
+	if _ = missing.FindByToken(plain + "x"); missing.Id != 0 {
+		t.Error("wrong token should not be found")
+	}
+
+	if found.Expired() {
+		t.Error("token without expiry should not expire")
+	}
+	past := time.Now().Add(-time.Second)
+	found.ExpiresAt = &past
+	if !found.Expired() {
+		t.Error("token should be expired")
+	}
+
+	_ = found.Touch("10.0.0.1")
+	used := new(ApiToken)
+	_ = used.Find(token.Id, 1)
+	if used.LastUsedAt == nil || used.LastUsedIp != "10.0.0.1" {
+		t.Errorf("last used = %v %q", used.LastUsedAt, used.LastUsedIp)
+	}
+	if other := new(ApiToken); other.Find(token.Id, 2) != nil || other.Id != 0 {
+		t.Error("other user should not find the token")
+	}
+
+	_ = token.DeleteByUser(1)
+	if list, _ := token.List(1); len(list) != 0 {
+		t.Errorf("tokens should be deleted, got %d", len(list))
+	}
+}
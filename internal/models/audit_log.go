@@ -0,0 +1,190 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 审计日志的资源类型
+const (
+	AuditResourceTask     = "task"
+	AuditResourceTaskLog  = "task_log"
+	AuditResourceHost     = "host"
+	AuditResourceSetting  = "setting"
+	AuditResourceProject  = "project"
+	AuditResourceApiToken = "api_token"
+)
+
+// 审计日志的操作
+const (
+	AuditActionCreate   = "create"
+	AuditActionUpdate   = "update"
+	AuditActionDelete   = "delete"
+	AuditActionEnable   = "enable"
+	AuditActionDisable  = "disable"
+	AuditActionRun      = "run"
+	AuditActionStop     = "stop"
+	AuditActionClear    = "clear"
+	AuditActionRelease  = "release"
+	AuditActionRollback = "rollback"
+)
+
+// 差异中不记录的字段, 包括节点健康检查上报的运行信息
+var auditIgnoreFields = map[string]bool{
+	"created":       true,
+	"deleted":       true,
+	"next_run_time": true,
+	"owner":         true,
+	"last_seen":     true,
+	"load1":         true,
+	"mem_total":     true,
+	"mem_free":      true,
+	"disk_total":    true,
+	"disk_free":     true,
+	"running_jobs":  true,
+}
+
+// 差异中隐藏值的敏感字段
+var auditSecretFields = map[string]bool{
+	"password":   true,
+	"salt":       true,
+	"token_hash": true,
+}
+
+// 操作审计日志, 记录配置修改及手动操作
+type AuditLog struct {
+	Id           int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserId       int       `json:"user_id" gorm:"not null;default:0;index"`
+	Username     string    `json:"username" gorm:"type:varchar(32);not null;default:''"`
+	Ip           string    `json:"ip" gorm:"type:varchar(64);not null;default:''"`
+	Action       string    `json:"action" gorm:"type:varchar(32);not null;index"`
+	Resource     string    `json:"resource" gorm:"type:varchar(32);not null;index:idx_audit_resource"`
+	ResourceId   int       `json:"resource_id" gorm:"not null;default:0;index:idx_audit_resource"`
+	ResourceName string    `json:"resource_name" gorm:"type:varchar(128);not null;default:''"`
+	Diff         string    `json:"diff" gorm:"type:text"` // 修改前后变化的字段, JSON格式 {"字段": [修改前, 修改后]}
+	CreatedAt    time.Time `json:"created" gorm:"column:created;autoCreateTime;index"`
+	BaseModel    `json:"-" gorm:"-"`
+}
+
+func (log *AuditLog) Create() (int64, error) {
+	result := Db.Create(log)
+	return log.Id, result.Error
+}
+
+func (log *AuditLog) List(params CommonMap) ([]AuditLog, error) {
+	log.parsePageAndPageSize(params)
+	list := make([]AuditLog, 0)
+	query := Db.Model(&AuditLog{})
+	log.parseWhere(query, params)
+	err := query.Order("id DESC").Limit(log.PageSize).Offset(log.pageLimitOffset()).Find(&list).Error
+
+	return list, err
+}
+
+func (log *AuditLog) Total(params CommonMap) (int64, error) {
+	var count int64
+	query := Db.Model(&AuditLog{})
+	log.parseWhere(query, params)
+	err := query.Count(&count).Error
+
+	return count, err
+}
+
+// RemoveByDays 删除N天前的审计日志
+func (log *AuditLog) RemoveByDays(days int) (int64, error) {
+	result := Db.Where("created < ?", time.Now().AddDate(0, 0, -days)).Delete(&AuditLog{})
+	return result.RowsAffected, result.Error
+}
+
+func (log *AuditLog) parseWhere(query *gorm.DB, params CommonMap) {
+	if username, ok := params["Username"].(string); ok && username != "" {
+		query.Where("username = ?", username)
+	}
+	if action, ok := params["Action"].(string); ok && action != "" {
+		query.Where("action = ?", action)
+	}
+	if resource, ok := params["Resource"].(string); ok && resource != "" {
+		query.Where("resource = ?", resource)
+	}
+	if resourceId, ok := params["ResourceId"].(int); ok && resourceId > 0 {
+		query.Where("resource_id = ?", resourceId)
+	}
+	if keyword, ok := params["Keyword"].(string); ok && keyword != "" {
+		query.Where("(resource_name LIKE ? OR diff LIKE ?)", "%"+keyword+"%", "%"+keyword+"%")
+	}
+	if start, ok := params["StartTime"].(time.Time); ok && !start.IsZero() {
+		query.Where("created >= ?", start)
+	}
+	if end, ok := params["EndTime"].(time.Time); ok && !end.IsZero() {
+		query.Where("created < ?", end)
+	}
+}
+
+// AuditDiff 比较资源修改前后序列化为JSON的字段, 返回变化的字段
+// 新增时before为nil, 删除时after为nil, 没有变化时返回空字符串
+func AuditDiff(before, after interface{}) string {
+	diff := DiffFields(before, after)
+	if len(diff) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(diff)
+	if err != nil {
+		return ""
+	}
+
+	return string(data)
+}
+
+// DiffFields 比较序列化为JSON后的字段, 返回 {"字段": [修改前, 修改后]}, 敏感字段不返回原值
+func DiffFields(before, after interface{}) map[string][2]interface{} {
+	beforeFields := auditFields(before)
+	afterFields := auditFields(after)
+	keys := make(map[string]bool, len(beforeFields)+len(afterFields))
+	for key := range beforeFields {
+		keys[key] = true
+	}
+	for key := range afterFields {
+		keys[key] = true
+	}
+
+	diff := make(map[string][2]interface{})
+	for key := range keys {
+		if auditIgnoreFields[key] {
+			continue
+		}
+		oldValue, newValue := beforeFields[key], afterFields[key]
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		if auditSecretFields[key] {
+			oldValue, newValue = maskAuditValue(oldValue), maskAuditValue(newValue)
+		}
+		diff[key] = [2]interface{}{oldValue, newValue}
+	}
+
+	return diff
+}
+
+func auditFields(value interface{}) map[string]interface{} {
+	fields := make(map[string]interface{})
+	if value == nil || (reflect.ValueOf(value).Kind() == reflect.Ptr && reflect.ValueOf(value).IsNil()) {
+		return fields
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fields
+	}
+	_ = json.Unmarshal(data, &fields)
+
+	return fields
+}
+
+func maskAuditValue(value interface{}) interface{} {
+	if value == nil || value == "" {
+		return value
+	}
+	return "******"
+}
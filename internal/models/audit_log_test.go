@@ -0,0 +1,94 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestAuditDiff(t *testing.T) {
+	before := Host{Id: 1, Name: "node1", Port: 5921, Load1: 0.5}
+	after := Host{Id: 1, Name: "node2", Port: 5921, Load1: 2}
+
+	var diff map[string][2]interface{}
+	if err := json.Unmarshal([]byte(AuditDiff(before, after)), &diff); err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 1 || diff["name"] != [2]interface{}{"node1", "node2"} {
+		t.Errorf("diff = %v, want only name changed", diff)
+	}
+	if got := AuditDiff(before, before); got != "" {
+		t.Errorf("diff of same value = %q, want empty", got)
+	}
+
+	// 新增时所有字段都记录, 敏感字段不记录原值
+	created := AuditDiff(nil, map[string]interface{}{"name": "smtp", "password": "secret"})
+	if strings.Contains(created, "secret") || !strings.Contains(created, "smtp") {
+		t.Errorf("created diff = %s", created)
+	}
+	var nilHost *Host
+	if got := AuditDiff(nilHost, nilHost); got != "" {
+		t.Errorf("diff of nil pointers = %q, want empty", got)
+	}
+}
+
+func TestAuditLogList(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&AuditLog{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	oldDb := Db
+	Db = db
+	defer func() { Db = oldDb }()
+
+	logs := []AuditLog{
+		{Username: "alice", Action: AuditActionUpdate, Resource: AuditResourceTask, ResourceId: 1, ResourceName: "backup", Diff: `{"spec":["0 * * * * *","0 0 * * * *"]}`},
+		{Username: "bob", Action: AuditActionDelete, Resource: AuditResourceHost, ResourceId: 2, ResourceName: "node1"},
+		{Username: "alice", Action: AuditActionRun, Resource: AuditResourceTask, ResourceId: 3, ResourceName: "report"},
+	}
+	for i := range logs {
+		if _, err := logs[i].Create(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 更早的日志, 用于时间范围和清理
+	db.Model(&AuditLog{}).Where("id = ?", logs[1].Id).Update("created", time.Now().AddDate(0, 0, -40))
+
+	cases := []struct {
+		name   string
+		params CommonMap
+		want   int64
+	}{
+		{"all", CommonMap{}, 3},
+		{"username", CommonMap{"Username": "alice"}, 2},
+		{"resource", CommonMap{"Resource": AuditResourceTask, "ResourceId": 3}, 1},
+		{"keyword in diff", CommonMap{"Keyword": "0 0 *"}, 1},
+		{"keyword in name", CommonMap{"Keyword": "node"}, 1},
+		{"start time", CommonMap{"StartTime": time.Now().AddDate(0, 0, -1)}, 2},
+	}
+	auditLogModel := new(AuditLog)
+	for _, tc := range cases {
+		total, err := auditLogModel.Total(tc.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != tc.want {
+			t.Errorf("%s: total = %d, want %d", tc.name, total, tc.want)
+		}
+	}
+
+	list, _ := auditLogModel.List(CommonMap{"Page": 1, "PageSize": 2})
+	if len(list) != 2 || list[0].ResourceName != "report" {
+		t.Errorf("list should be ordered by id desc and paged, got %v", list)
+	}
+	if count, _ := auditLogModel.RemoveByDays(30); count != 1 {
+		t.Errorf("RemoveByDays = %d, want 1", count)
+	}
+}
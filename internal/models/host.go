@@ -1,18 +1,53 @@
 package models
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
+// 节点健康状态
+type HostStatus int8
+
+const (
+	HostStatusUnknown  HostStatus = iota // 未检查
+	HostStatusOnline                     // 在线
+	HostStatusOffline                    // 离线
+	HostStatusDraining                   // 排空中, 不再接收新任务
+)
+
 // 主机
 type Host struct {
-	Id        int    `json:"id" gorm:"primaryKey;autoIncrement"`
-	Name      string `json:"name" gorm:"type:varchar(64);not null"`
+	Id   int    `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name string `json:"name" gorm:"type:varchar(64);not null"`
+	// 所属项目
+	ProjectId int    `json:"project_id" gorm:"not null;index;default:1"`
 	Alias     string `json:"alias" gorm:"type:varchar(32);not null;default:''"`
 	Port      int    `json:"port" gorm:"not null;default:5921"`
 	Remark    string `json:"remark" gorm:"type:varchar(100);not null;default:''"`
-	BaseModel `json:"-" gorm:"-"`
-	Selected  bool `json:"-" gorm:"-"`
+	Group     string `json:"group" gorm:"column:host_group;type:varchar(64);not null;default:'';index"`
+	Labels    string `json:"labels" gorm:"type:varchar(512);not null;default:''"` // key=value, 多个以逗号分隔
+	// 反向连接: 节点主动连接服务端, 服务端不直接访问 Name:Port
+	Reverse bool `json:"reverse" gorm:"not null;default:false"`
+	// 节点使用内置CA签发的证书, 服务端通过TLS连接
+	TLS bool `json:"tls" gorm:"column:tls;not null;default:false"`
+	// 节点令牌, 只保存生成令牌的nonce和令牌哈希
+	TokenNonce    string     `json:"-" gorm:"type:varchar(64);not null;default:''"`
+	TokenHash     string     `json:"-" gorm:"type:varchar(64);not null;default:''"`
+	TokenIssuedAt *time.Time `json:"token_issued_at" gorm:"column:token_issued_at"`
+	// 健康检查上报的节点状态及资源信息
+	Status      HostStatus `json:"status" gorm:"type:tinyint;not null;default:0"`
+	LastSeen    *time.Time `json:"last_seen" gorm:"column:last_seen"`
+	Version     string     `json:"version" gorm:"type:varchar(32);not null;default:''"`
+	Platform    string     `json:"platform" gorm:"type:varchar(32);not null;default:''"` // os/arch
+	Load1       float64    `json:"load1" gorm:"not null;default:0"`
+	MemTotal    int64      `json:"mem_total" gorm:"type:bigint;not null;default:0"`
+	MemFree     int64      `json:"mem_free" gorm:"type:bigint;not null;default:0"`
+	DiskTotal   int64      `json:"disk_total" gorm:"type:bigint;not null;default:0"`
+	DiskFree    int64      `json:"disk_free" gorm:"type:bigint;not null;default:0"`
+	RunningJobs int        `json:"running_jobs" gorm:"not null;default:0"`
+	BaseModel   `json:"-" gorm:"-"`
+	Selected    bool `json:"-" gorm:"-"`
 }
 
 // 新增
@@ -27,11 +62,42 @@ func (host *Host) Create() (insertId int, err error) {
 
 func (host *Host) UpdateBean(id int) (int64, error) {
 	result := Db.Model(&Host{}).Where("id = ?", id).
-		Select("name", "alias", "port", "remark").
+		Select("name", "project_id", "alias", "port", "remark", "host_group", "labels", "reverse").
 		Updates(host)
 	return result.RowsAffected, result.Error
 }
 
+// UpdateTarget 按主机名更新分组和标签
+func (host *Host) UpdateTarget(name string) error {
+	return Db.Model(&Host{}).Where("name = ?", name).
+		UpdateColumns(map[string]interface{}{"host_group": host.Group, "labels": host.Labels}).Error
+}
+
+// SetReverse 按主机名设置是否为反向连接
+func (host *Host) SetReverse(name string, reverse bool) error {
+	return Db.Model(&Host{}).Where("name = ?", name).UpdateColumn("reverse", reverse).Error
+}
+
+// SetToken 保存节点令牌, 旧令牌随之失效
+func (host *Host) SetToken(id int, nonce string, hash string) error {
+	now := time.Now()
+	return Db.Model(&Host{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"token_nonce": nonce, "token_hash": hash, "token_issued_at": &now,
+	}).Error
+}
+
+// RevokeToken 吊销节点令牌
+func (host *Host) RevokeToken(id int) error {
+	return Db.Model(&Host{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"token_nonce": "", "token_hash": "", "token_issued_at": nil,
+	}).Error
+}
+
+// FindByName 按主机名查找
+func (host *Host) FindByName(name string) error {
+	return Db.Where("name = ?", name).First(host).Error
+}
+
 // 更新
 func (host *Host) Update(id int, data CommonMap) (int64, error) {
 	updateData := make(map[string]interface{})
@@ -74,7 +140,7 @@ func (host *Host) List(params CommonMap) ([]Host, error) {
 
 func (host *Host) AllList() ([]Host, error) {
 	list := make([]Host, 0)
-	err := Db.Select("name", "port").Order("id DESC").Find(&list).Error
+	err := Db.Order("id DESC").Find(&list).Error
 
 	return list, err
 }
@@ -100,4 +166,72 @@ func (host *Host) parseWhere(query *gorm.DB, params CommonMap) {
 	if ok && name.(string) != "" {
 		query.Where("name = ?", name)
 	}
+	group, ok := params["Group"]
+	if ok && group.(string) != "" {
+		query.Where("host_group = ?", group)
+	}
+	projectId, ok := params["ProjectId"]
+	if ok && projectId.(int) > 0 {
+		query.Where("project_id = ?", projectId)
+	}
+	// 游标分页, 返回id小于游标的记录
+	beforeId, ok := params["BeforeId"]
+	if ok && beforeId.(int) > 0 {
+		query.Where("id < ?", beforeId)
+	}
+	// 非管理员只能查看所在项目的主机
+	if projectIds, ok := params["ProjectIds"].([]int); ok {
+		query.Where("project_id IN ?", projectIds)
+	}
+}
+
+// LabelMap 解析主机标签
+func (host *Host) LabelMap() map[string]string {
+	labels, err := ParseLabels(host.Labels)
+	if err != nil {
+		return map[string]string{}
+	}
+
+	return labels
+}
+
+// Groups 所有主机分组
+func (host *Host) Groups(params CommonMap) ([]string, error) {
+	groups := make([]string, 0)
+	query := Db.Model(&Host{}).Where("host_group != ?", "")
+	host.parseWhere(query, params)
+	err := query.Distinct().Order("host_group").Pluck("host_group", &groups).Error
+
+	return groups, err
+}
+
+// MatchTarget 按主机分组和标签选择器筛选主机, 两者都设置时需同时满足
+func (host *Host) MatchTarget(group string, selector string, params CommonMap) ([]Host, error) {
+	list := make([]Host, 0)
+	query := Db.Order("id ASC")
+	host.parseWhere(query, params)
+	if err := query.Find(&list).Error; err != nil {
+		return nil, err
+	}
+
+	return filterHosts(list, group, selector)
+}
+
+func filterHosts(hosts []Host, group string, selector string) ([]Host, error) {
+	labelSelector, err := ParseLabelSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	matched := make([]Host, 0)
+	for _, item := range hosts {
+		if group != "" && item.Group != group {
+			continue
+		}
+		if len(labelSelector) > 0 && !labelSelector.Matches(item.LabelMap()) {
+			continue
+		}
+		matched = append(matched, item)
+	}
+
+	return matched, nil
 }
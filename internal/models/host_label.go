@@ -0,0 +1,138 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 标签key/value及主机分组允许的字符
+var labelPattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9_./-]{0,62}[A-Za-z0-9])?$`)
+
+// ValidHostGroup 校验主机分组名称, 空表示不分组
+func ValidHostGroup(group string) bool {
+	return group == "" || labelPattern.MatchString(group)
+}
+
+// ParseLabels 解析 key=value 形式的标签, 多个以逗号分隔, 如 env=prod,role=worker
+func ParseLabels(s string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(item, "=")
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if !ok || !labelPattern.MatchString(key) || !labelPattern.MatchString(value) {
+			return nil, fmt.Errorf("invalid label: %s", item)
+		}
+		labels[key] = value
+	}
+
+	return labels, nil
+}
+
+// FormatLabels 按key排序后格式化为 key=value,key=value
+func FormatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	items := make([]string, len(keys))
+	for i, key := range keys {
+		items[i] = key + "=" + labels[key]
+	}
+
+	return strings.Join(items, ",")
+}
+
+// NormalizeLabels 校验并规范化标签字符串
+func NormalizeLabels(s string) (string, error) {
+	labels, err := ParseLabels(s)
+	if err != nil {
+		return "", err
+	}
+
+	return FormatLabels(labels), nil
+}
+
+type labelRequirement struct {
+	key      string
+	value    string
+	operator string // =, != 或空(只要求存在key)
+}
+
+// LabelSelector 标签选择器, 多个条件以逗号分隔且需全部满足
+// 支持 key=value、key!=value 和 key(存在该标签)
+type LabelSelector []labelRequirement
+
+func ParseLabelSelector(s string) (LabelSelector, error) {
+	selector := make(LabelSelector, 0)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		requirement := labelRequirement{key: item}
+		if key, value, ok := strings.Cut(item, "!="); ok {
+			requirement = labelRequirement{key: key, value: value, operator: "!="}
+		} else if key, value, ok := strings.Cut(item, "="); ok {
+			requirement = labelRequirement{key: key, value: value, operator: "="}
+		}
+		requirement.key = strings.TrimSpace(requirement.key)
+		requirement.value = strings.TrimSpace(requirement.value)
+		if !labelPattern.MatchString(requirement.key) ||
+			(requirement.operator != "" && !labelPattern.MatchString(requirement.value)) {
+			return nil, fmt.Errorf("invalid label selector: %s", item)
+		}
+		selector = append(selector, requirement)
+	}
+
+	return selector, nil
+}
+
+// NormalizeLabelSelector 校验并规范化标签选择器
+func NormalizeLabelSelector(s string) (string, error) {
+	selector, err := ParseLabelSelector(s)
+	if err != nil {
+		return "", err
+	}
+
+	return selector.String(), nil
+}
+
+func (selector LabelSelector) String() string {
+	items := make([]string, len(selector))
+	for i, r := range selector {
+		items[i] = r.key + r.operator + r.value
+	}
+
+	return strings.Join(items, ",")
+}
+
+// Matches 标签是否满足选择器的所有条件
+func (selector LabelSelector) Matches(labels map[string]string) bool {
+	for _, r := range selector {
+		value, ok := labels[r.key]
+		switch r.operator {
+		case "=":
+			if !ok || value != r.value {
+				return false
+			}
+		case "!=":
+			if ok && value == r.value {
+				return false
+			}
+		default:
+			if !ok {
+				return false
+			}
+		}
+	}
+
+	return true
+}
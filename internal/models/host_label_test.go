@@ -0,0 +1,89 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestNormalizeLabels(t *testing.T) {
+	got, err := NormalizeLabels(" role=worker, env=prod ,,")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "env=prod,role=worker" {
+		t.Errorf("NormalizeLabels = %q", got)
+	}
+
+	for _, s := range []string{"env", "env=", "=prod", "env=pro d", "-env=prod"} {
+		if _, err := NormalizeLabels(s); err == nil {
+			t.Errorf("NormalizeLabels(%q) should fail", s)
+		}
+	}
+}
+
+func TestLabelSelectorMatches(t *testing.T) {
+	labels := map[string]string{"env": "prod", "role": "worker"}
+	tests := []struct {
+		selector string
+		want     bool
+	}{
+		{"", true},
+		{"env=prod", true},
+		{"env=test", false},
+		{"env!=test", true},
+		{"env!=prod", false},
+		{"zone!=cn", true},
+		{"role", true},
+		{"zone", false},
+		{"env=prod, role=worker", true},
+		{"env=prod,role=db", false},
+	}
+	for _, tt := range tests {
+		selector, err := ParseLabelSelector(tt.selector)
+		if err != nil {
+			t.Fatalf("ParseLabelSelector(%q) error: %v", tt.selector, err)
+		}
+		if got := selector.Matches(labels); got != tt.want {
+			t.Errorf("%q.Matches = %v, want %v", tt.selector, got, tt.want)
+		}
+	}
+
+	if _, err := ParseLabelSelector("env=="); err == nil {
+		t.Error("invalid selector should fail")
+	}
+	if got, _ := NormalizeLabelSelector(" env = prod , role "); got != "env=prod,role" {
+		t.Errorf("NormalizeLabelSelector = %q", got)
+	}
+}
+
+func TestFilterHosts(t *testing.T) {
+	hosts := []Host{
+		{Id: 1, Group: "web", Labels: "env=prod"},
+		{Id: 2, Group: "web", Labels: "env=test"},
+		{Id: 3, Group: "db", Labels: "env=prod"},
+		{Id: 4, Labels: "invalid"},
+	}
+	tests := []struct {
+		group    string
+		selector string
+		want     []int
+	}{
+		{"web", "", []int{1, 2}},
+		{"", "env=prod", []int{1, 3}},
+		{"web", "env=prod", []int{1}},
+		{"cache", "", []int{}},
+	}
+	for _, tt := range tests {
+		matched, err := filterHosts(hosts, tt.group, tt.selector)
+		if err != nil {
+			t.Fatalf("filterHosts error: %v", err)
+		}
+		if len(matched) != len(tt.want) {
+			t.Fatalf("filterHosts(%q, %q) = %d hosts, want %d", tt.group, tt.selector, len(matched), len(tt.want))
+		}
+		for i, host := range matched {
+			if host.Id != tt.want[i] {
+				t.Errorf("filterHosts(%q, %q)[%d] = %d, want %d", tt.group, tt.selector, i, host.Id, tt.want[i])
+			}
+		}
+	}
+}
@@ -14,6 +14,7 @@ func (migration *Migration) Install(dbName string) error {
 	setting := new(Setting)
 	tables := []interface{}{
 		&User{}, &Task{}, &TaskLog{}, &Host{}, setting, &LoginLog{}, &TaskHost{}, &AgentToken{},
+		&TaskLogAttempt{}, &TaskLogHost{}, &Project{}, &ProjectMember{}, &AuditLog{}, &TaskRevision{}, &ApiToken{},
 	}
 
 	for _, table := range tables {
@@ -31,6 +32,11 @@ func (migration *Migration) Install(dbName string) error {
 		migration.fixSQLiteAutoIncrement()
 	}
 
+	// 创建默认项目
+	if err := EnsureDefaultProject(Db); err != nil {
+		return err
+	}
+
 	// 初始化配置
 	if err := RepairSettings(); err != nil {
 		return err
@@ -46,7 +52,7 @@ func (migration *Migration) Upgrade(oldVersionId int) {
 		return
 	}
 
-	versionIds := []int{110, 122, 130, 140, 150, 151, 152, 153, 154, 155, 156, 157, 158}
+	versionIds := []int{110, 122, 130, 140, 150, 151, 152, 153, 154, 155, 156, 157, 158, 170}
 	upgradeFuncs := []func(*gorm.DB) error{
 		migration.upgradeFor110,
 		migration.upgradeFor122,
@@ -61,6 +67,7 @@ func (migration *Migration) Upgrade(oldVersionId int) {
 		migration.upgradeFor156,
 		migration.upgradeFor157,
 		migration.upgradeFor158,
+		migration.upgradeFor170,
 	}
 
 	startIndex := -1
@@ -567,6 +574,54 @@ func (m *Migration) upgradeFor158(tx *gorm.DB) error {
 	return nil
 }
 
+// 升级到v1.7.0版本, 各功能的表结构变更分别执行, 见 migration_170.go
+func (m *Migration) upgradeFor170(tx *gorm.DB) error {
+	logger.Info("开始升级到v1.7.0")
+
+	steps := []func(*gorm.DB) error{
+		upgradeTaskRetry,
+		upgradeHostSelection,
+		upgradeHostTarget,
+		upgradeHostHealth,
+		upgradeReverseHost,
+		upgradeNodeToken,
+		upgradeHostTLS,
+		upgradeCommandSignature,
+		upgradeDetachedTask,
+		upgradeRollingTask,
+		upgradeTaskLogHost,
+		upgradeProject,
+		upgradeAuditLog,
+		upgradeProjectScope,
+		upgradeTaskRevision,
+		upgradeExternalUser,
+		upgradeApiToken,
+	}
+	for _, step := range steps {
+		if err := step(tx); err != nil {
+			return err
+		}
+	}
+
+	logger.Info("已升级到v1.7.0\n")
+
+	return nil
+}
+
+// 新增表字段, 已存在的字段跳过
+func addColumnsIfNotExist(tx *gorm.DB, model interface{}, fields ...string) error {
+	for _, field := range fields {
+		if tx.Migrator().HasColumn(model, field) {
+			continue
+		}
+		if err := tx.Migrator().AddColumn(model, field); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // contains 检查字符串是否包含子串
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) && (s[:len(substr)] == substr || s[len(s)-len(substr):] == substr || containsMiddle(s, substr)))
@@ -621,9 +676,27 @@ func (m *Migration) fixSQLiteAutoIncrement() {
 			CREATE TABLE host_new (
 				id INTEGER PRIMARY KEY AUTOINCREMENT,
 				name varchar(64) NOT NULL,
+				project_id integer NOT NULL DEFAULT 1,
 				alias varchar(32) NOT NULL DEFAULT '',
 				port integer NOT NULL DEFAULT 5921,
-				remark varchar(100) NOT NULL DEFAULT ''
+				remark varchar(100) NOT NULL DEFAULT '',
+				host_group varchar(64) NOT NULL DEFAULT '',
+				labels varchar(512) NOT NULL DEFAULT '',
+				status tinyint NOT NULL DEFAULT 0,
+				last_seen datetime,
+				version varchar(32) NOT NULL DEFAULT '',
+				platform varchar(32) NOT NULL DEFAULT '',
+				load1 real NOT NULL DEFAULT 0,
+				mem_total bigint NOT NULL DEFAULT 0,
+				mem_free bigint NOT NULL DEFAULT 0,
+				disk_total bigint NOT NULL DEFAULT 0,
+				disk_free bigint NOT NULL DEFAULT 0,
+				running_jobs integer NOT NULL DEFAULT 0,
+				reverse tinyint NOT NULL DEFAULT 0,
+				token_nonce varchar(64) NOT NULL DEFAULT '',
+				token_hash varchar(64) NOT NULL DEFAULT '',
+				token_issued_at datetime,
+				tls tinyint NOT NULL DEFAULT 0
 			);
 		`)
 		Db.Exec(`DROP TABLE host;`)
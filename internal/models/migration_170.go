@@ -0,0 +1,146 @@
+package models
+
+import "gorm.io/gorm"
+
+// v1.7.0 各功能的表结构变更, 均可重复执行
+
+// 任务重试策略及每次执行尝试的记录
+func upgradeTaskRetry(tx *gorm.DB) error {
+	if err := addColumnsIfNotExist(tx, &Task{},
+		"RetryStrategy", "RetryMaxInterval", "RetryJitter", "RetryOn", "RetryExitCodes"); err != nil {
+		return err
+	}
+
+	return tx.AutoMigrate(&TaskLogAttempt{})
+}
+
+// 多主机任务的主机选择方式
+func upgradeHostSelection(tx *gorm.DB) error {
+	return addColumnsIfNotExist(tx, &Task{}, "HostSelection")
+}
+
+// 主机分组、标签及任务的目标选择
+func upgradeHostTarget(tx *gorm.DB) error {
+	if err := addColumnsIfNotExist(tx, &Host{}, "Group", "Labels"); err != nil {
+		return err
+	}
+	if err := addColumnsIfNotExist(tx, &Task{}, "HostGroup", "HostSelector"); err != nil {
+		return err
+	}
+
+	return addColumnsIfNotExist(tx, &AgentToken{}, "HostGroup", "Labels")
+}
+
+// 节点健康状态及资源信息
+func upgradeHostHealth(tx *gorm.DB) error {
+	return addColumnsIfNotExist(tx, &Host{}, "Status", "LastSeen", "Version", "Platform",
+		"Load1", "MemTotal", "MemFree", "DiskTotal", "DiskFree", "RunningJobs")
+}
+
+// 反向连接的节点
+func upgradeReverseHost(tx *gorm.DB) error {
+	return addColumnsIfNotExist(tx, &Host{}, "Reverse")
+}
+
+// 节点令牌
+func upgradeNodeToken(tx *gorm.DB) error {
+	return addColumnsIfNotExist(tx, &Host{}, "TokenNonce", "TokenHash", "TokenIssuedAt")
+}
+
+// 内置CA签发证书的节点
+func upgradeHostTLS(tx *gorm.DB) error {
+	return addColumnsIfNotExist(tx, &Host{}, "TLS")
+}
+
+// 节点校验的命令签名
+func upgradeCommandSignature(tx *gorm.DB) error {
+	return addColumnsIfNotExist(tx, &Task{}, "CommandSignature")
+}
+
+// 分离执行
+func upgradeDetachedTask(tx *gorm.DB) error {
+	return addColumnsIfNotExist(tx, &Task{}, "Detached")
+}
+
+// 多主机滚动执行
+func upgradeRollingTask(tx *gorm.DB) error {
+	return addColumnsIfNotExist(tx, &Task{}, "BatchSize", "BatchUnit", "BatchInterval", "MaxFailures")
+}
+
+// 多主机任务每台主机的执行记录
+func upgradeTaskLogHost(tx *gorm.DB) error {
+	return tx.AutoMigrate(&TaskLogHost{})
+}
+
+// 项目及成员角色, 已有任务和主机归属默认项目
+// 普通用户原来可查看全部任务和主机, 升级后作为默认项目的查看者
+func upgradeProject(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&Project{}, &ProjectMember{}); err != nil {
+		return err
+	}
+	if err := EnsureDefaultProject(tx); err != nil {
+		return err
+	}
+	if err := addColumnsIfNotExist(tx, &Task{}, "ProjectId"); err != nil {
+		return err
+	}
+	if err := addColumnsIfNotExist(tx, &Host{}, "ProjectId"); err != nil {
+		return err
+	}
+	var userIds []int
+	if err := tx.Model(&User{}).Where("is_admin = ?", 0).Pluck("id", &userIds).Error; err != nil {
+		return err
+	}
+	for _, userId := range userIds {
+		var count int64
+		tx.Model(&ProjectMember{}).Where("project_id = ? AND user_id = ?", DefaultProjectId, userId).Count(&count)
+		if count > 0 {
+			continue
+		}
+		member := ProjectMember{ProjectId: DefaultProjectId, UserId: userId, Role: RoleViewer}
+		if err := tx.Create(&member).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 操作审计日志
+func upgradeAuditLog(tx *gorm.DB) error {
+	return tx.AutoMigrate(&AuditLog{})
+}
+
+// 任务创建人、通知接收者所属项目及跨项目依赖开关
+func upgradeProjectScope(tx *gorm.DB) error {
+	if err := addColumnsIfNotExist(tx, &Task{}, "CreatedBy"); err != nil {
+		return err
+	}
+	if err := addColumnsIfNotExist(tx, &Setting{}, "ProjectId"); err != nil {
+		return err
+	}
+
+	return addColumnsIfNotExist(tx, &Project{}, "AllowCrossDependency")
+}
+
+// 任务版本历史
+func upgradeTaskRevision(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&TaskRevision{}); err != nil {
+		return err
+	}
+	if err := addColumnsIfNotExist(tx, &Task{}, "Revision"); err != nil {
+		return err
+	}
+
+	return addColumnsIfNotExist(tx, &TaskLog{}, "Revision")
+}
+
+// 单点登录用户
+func upgradeExternalUser(tx *gorm.DB) error {
+	return addColumnsIfNotExist(tx, &User{}, "Source", "ExternalId")
+}
+
+// 个人API令牌
+func upgradeApiToken(tx *gorm.DB) error {
+	return tx.AutoMigrate(&ApiToken{})
+}
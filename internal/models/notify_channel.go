@@ -0,0 +1,146 @@
+package models
+
+import (
+	"encoding/json"
+
+	"gorm.io/gorm"
+)
+
+// NotifyChannelTypes 通知渠道类型, 与系统配置中通知接收者的code一致
+var NotifyChannelTypes = []string{MailCode, SlackCode, WebhookCode, ServerChan3Code, BarkCode}
+
+// NotifyChannel 通知渠道, 统一邮件接收用户、Slack频道及Webhook、Server酱、Bark地址
+type NotifyChannel struct {
+	Id   int    `json:"id"`
+	Type string `json:"type"`
+	// 邮件用户名、Slack频道名或地址名称
+	Name string `json:"name"`
+	// 邮箱或通知地址, Slack频道为空
+	Target string `json:"target"`
+	// 所属项目, 为0时所有项目共享
+	ProjectId int `json:"project_id"`
+}
+
+// 通知渠道的内容, 与页面配置中保存的格式一致
+type notifyChannelValue struct {
+	Username string `json:"username,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Url      string `json:"url,omitempty"`
+}
+
+// 通知渠道在系统配置中的key
+func notifyChannelKey(channelType string) string {
+	switch channelType {
+	case MailCode:
+		return MailUserKey
+	case SlackCode:
+		return SlackChannelKey
+	default:
+		// webhook、server酱、bark的地址使用相同的key
+		return WebhookUrlKey
+	}
+}
+
+func notifyChannelQuery() *gorm.DB {
+	return Db.Model(&Setting{}).
+		Where("(code = ? AND `key` = ?) OR (code = ? AND `key` = ?) OR (code IN ? AND `key` = ?)",
+			MailCode, MailUserKey, SlackCode, SlackChannelKey,
+			[]string{WebhookCode, ServerChan3Code, BarkCode}, WebhookUrlKey)
+}
+
+// List 按类型及项目查询通知渠道, 按id倒序
+func (channel *NotifyChannel) List(params CommonMap) ([]NotifyChannel, error) {
+	list := make([]Setting, 0)
+	query := notifyChannelQuery().Order("id DESC")
+	if channelType, ok := params["Type"]; ok && channelType.(string) != "" {
+		query.Where("code = ?", channelType)
+	}
+	if projectId, ok := params["ProjectId"]; ok && projectId.(int) > 0 {
+		query.Where("project_id = ?", projectId)
+	}
+	// 游标分页, 返回id小于游标的记录
+	if beforeId, ok := params["BeforeId"]; ok && beforeId.(int) > 0 {
+		query.Where("id < ?", beforeId)
+	}
+	if pageSize, ok := params["PageSize"]; ok && pageSize.(int) > 0 {
+		query.Limit(pageSize.(int))
+	}
+	if err := query.Find(&list).Error; err != nil {
+		return nil, err
+	}
+	channels := make([]NotifyChannel, 0, len(list))
+	for _, item := range list {
+		channels = append(channels, newNotifyChannel(item))
+	}
+
+	return channels, nil
+}
+
+// Find 按id查找通知渠道, 不存在时Id为0
+func (channel *NotifyChannel) Find(id int) error {
+	setting := Setting{}
+	if err := notifyChannelQuery().Where("id = ?", id).Limit(1).Find(&setting).Error; err != nil {
+		return err
+	}
+	*channel = NotifyChannel{}
+	if setting.Id > 0 {
+		*channel = newNotifyChannel(setting)
+	}
+
+	return nil
+}
+
+// Save 新增或修改通知渠道, 修改时不能变更类型
+func (channel *NotifyChannel) Save() error {
+	value := channel.Name
+	if channel.Type != SlackCode {
+		item := notifyChannelValue{Name: channel.Name, Url: channel.Target}
+		if channel.Type == MailCode {
+			item = notifyChannelValue{Username: channel.Name, Email: channel.Target}
+		}
+		jsonByte, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		value = string(jsonByte)
+	}
+	if channel.Id > 0 {
+		return Db.Model(&Setting{}).Where("id = ? AND code = ?", channel.Id, channel.Type).
+			UpdateColumns(map[string]interface{}{"value": value, "project_id": channel.ProjectId}).Error
+	}
+	setting := Setting{
+		Code:      channel.Type,
+		Key:       notifyChannelKey(channel.Type),
+		Value:     value,
+		ProjectId: channel.ProjectId,
+	}
+	if err := Db.Create(&setting).Error; err != nil {
+		return err
+	}
+	channel.Id = setting.Id
+
+	return nil
+}
+
+// Delete 删除通知渠道
+func (channel *NotifyChannel) Delete(id int) (int64, error) {
+	result := notifyChannelQuery().Where("id = ?", id).Delete(&Setting{})
+	return result.RowsAffected, result.Error
+}
+
+func newNotifyChannel(setting Setting) NotifyChannel {
+	channel := NotifyChannel{Id: setting.Id, Type: setting.Code, ProjectId: setting.ProjectId}
+	if setting.Code == SlackCode {
+		channel.Name = setting.Value
+		return channel
+	}
+	item := notifyChannelValue{}
+	_ = json.Unmarshal([]byte(setting.Value), &item)
+	channel.Name, channel.Target = item.Name, item.Url
+	if setting.Code == MailCode {
+		channel.Name, channel.Target = item.Username, item.Email
+	}
+
+	return channel
+}
@@ -0,0 +1,350 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 默认项目, 升级前的任务和主机都属于该项目
+const DefaultProjectId = 1
+
+// Role 项目成员角色, 高级别角色拥有低级别角色的全部权限
+type Role int8
+
+const (
+	RoleViewer   Role = 1 // 查看任务、主机及日志
+	RoleOperator Role = 2 // 执行、停止、启用、禁用任务
+	RoleEditor   Role = 3 // 新增、修改、删除任务和主机
+	RoleAdmin    Role = 4 // 管理项目成员及节点令牌
+)
+
+// Action 对项目中资源的操作, 需要的最低角色与其级别相同
+type Action int8
+
+const (
+	ActionView   Action = 1
+	ActionRun    Action = 2
+	ActionEdit   Action = 3
+	ActionManage Action = 4
+)
+
+// Can 角色是否允许执行操作
+func (role Role) Can(action Action) bool {
+	return action > 0 && int8(role) >= int8(action)
+}
+
+func (role Role) Valid() bool {
+	return role >= RoleViewer && role <= RoleAdmin
+}
+
+// ParseRole 按名称解析角色, 名称无效时返回0
+func ParseRole(name string) Role {
+	switch name {
+	case "viewer":
+		return RoleViewer
+	case "operator":
+		return RoleOperator
+	case "editor":
+		return RoleEditor
+	case "admin":
+		return RoleAdmin
+	}
+
+	return 0
+}
+
+// 项目, 任务和主机归属于项目, 用户按在项目中的角色获得权限
+type Project struct {
+	Id     int    `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name   string `json:"name" gorm:"type:varchar(32);not null;uniqueIndex"`
+	Remark string `json:"remark" gorm:"type:varchar(100);not null;default:''"`
+	// 是否允许与其他项目的任务建立依赖, 父子任务所在项目都允许时才能跨项目依赖
+	AllowCrossDependency int8      `json:"allow_cross_dependency" gorm:"type:tinyint;not null;default:0"`
+	CreatedAt            time.Time `json:"created" gorm:"column:created;autoCreateTime"`
+	Role                 Role      `json:"role" gorm:"-"` // 当前用户在项目中的角色
+}
+
+// 项目成员
+type ProjectMember struct {
+	Id        int    `json:"id" gorm:"primaryKey;autoIncrement"`
+	ProjectId int    `json:"project_id" gorm:"not null;uniqueIndex:idx_project_member"`
+	UserId    int    `json:"user_id" gorm:"not null;uniqueIndex:idx_project_member;index"`
+	Role      Role   `json:"role" gorm:"type:tinyint;not null;default:1"`
+	Username  string `json:"username" gorm:"->;-:migration"`
+}
+
+func (project *Project) Create() (int, error) {
+	result := Db.Create(project)
+	return project.Id, result.Error
+}
+
+func (project *Project) Update(id int) (int64, error) {
+	result := Db.Model(&Project{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"name":                   project.Name,
+			"remark":                 project.Remark,
+			"allow_cross_dependency": project.AllowCrossDependency,
+		})
+	return result.RowsAffected, result.Error
+}
+
+func (project *Project) Find(id int) error {
+	return Db.Where("id = ?", id).Limit(1).Find(project).Error
+}
+
+func (project *Project) NameExists(name string, id int) (bool, error) {
+	var count int64
+	err := Db.Model(&Project{}).Where("name = ? AND id != ?", name, id).Count(&count).Error
+	return count > 0, err
+}
+
+// InUse 项目下是否还有任务或主机
+func (project *Project) InUse(id int) (bool, error) {
+	var count int64
+	if err := Db.Model(&Task{}).Where("project_id = ?", id).Count(&count).Error; err != nil || count > 0 {
+		return count > 0, err
+	}
+	err := Db.Model(&Host{}).Where("project_id = ?", id).Count(&count).Error
+
+	return count > 0, err
+}
+
+// Delete 删除项目及其成员
+func (project *Project) Delete(id int) error {
+	return Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("project_id = ?", id).Delete(&ProjectMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Project{}, id).Error
+	})
+}
+
+// List 所有项目, 管理员使用
+func (project *Project) List() ([]Project, error) {
+	list := make([]Project, 0)
+	err := Db.Order("id ASC").Find(&list).Error
+	for i := range list {
+		list[i].Role = RoleAdmin
+	}
+
+	return list, err
+}
+
+// ListByUser 用户所在的项目及其角色
+func (project *Project) ListByUser(userId int) ([]Project, error) {
+	members := make([]ProjectMember, 0)
+	if err := Db.Where("user_id = ?", userId).Find(&members).Error; err != nil {
+		return nil, err
+	}
+	roles := make(map[int]Role, len(members))
+	ids := make([]int, 0, len(members))
+	for _, member := range members {
+		roles[member.ProjectId] = member.Role
+		ids = append(ids, member.ProjectId)
+	}
+	list := make([]Project, 0)
+	if len(ids) == 0 {
+		return list, nil
+	}
+	if err := Db.Where("id IN ?", ids).Order("id ASC").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	for i := range list {
+		list[i].Role = roles[list[i].Id]
+	}
+
+	return list, nil
+}
+
+// 可添加为项目成员的用户
+type ProjectUser struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// Candidates 可添加为项目成员的用户, 系统管理员拥有全部权限无需添加
+func (member *ProjectMember) Candidates() ([]ProjectUser, error) {
+	list := make([]ProjectUser, 0)
+	err := Db.Model(&User{}).Select("id", "name").Where("is_admin = ?", 0).
+		Order("id ASC").Find(&list).Error
+
+	return list, err
+}
+
+// FindRole 用户在项目中的角色, 不是成员时返回0
+func (member *ProjectMember) FindRole(projectId, userId int) (Role, error) {
+	found := new(ProjectMember)
+	err := Db.Where("project_id = ? AND user_id = ?", projectId, userId).Limit(1).Find(found).Error
+
+	return found.Role, err
+}
+
+// ProjectIds 用户拥有指定操作权限的项目
+func (member *ProjectMember) ProjectIds(userId int, action Action) ([]int, error) {
+	ids := make([]int, 0)
+	err := Db.Model(&ProjectMember{}).Where("user_id = ? AND role >= ?", userId, int8(action)).
+		Pluck("project_id", &ids).Error
+
+	return ids, err
+}
+
+// List 项目成员
+func (member *ProjectMember) List(projectId int) ([]ProjectMember, error) {
+	list := make([]ProjectMember, 0)
+	err := Db.Table(TablePrefix+"project_member as m").
+		Select("m.*, u.name as username").
+		Joins("LEFT JOIN "+TablePrefix+"user as u ON m.user_id = u.id").
+		Where("m.project_id = ?", projectId).
+		Order("m.id ASC").
+		Scan(&list).Error
+
+	return list, err
+}
+
+// Replace 替换项目成员
+func (member *ProjectMember) Replace(projectId int, members []ProjectMember) error {
+	return Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("project_id = ?", projectId).Delete(&ProjectMember{}).Error; err != nil {
+			return err
+		}
+		if len(members) == 0 {
+			return nil
+		}
+		for i := range members {
+			members[i].Id = 0
+			members[i].ProjectId = projectId
+		}
+		return tx.Create(&members).Error
+	})
+}
+
+// SyncRoles 按roles设置用户在projectIds中各项目的角色, 没有角色的项目移除成员, 其他项目不变
+func (member *ProjectMember) SyncRoles(userId int, projectIds []int, roles map[int]Role) error {
+	return Db.Transaction(func(tx *gorm.DB) error {
+		for _, projectId := range projectIds {
+			query := tx.Where("project_id = ? AND user_id = ?", projectId, userId)
+			role := roles[projectId]
+			if !role.Valid() {
+				if err := query.Delete(&ProjectMember{}).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			found := new(ProjectMember)
+			if err := query.Limit(1).Find(found).Error; err != nil {
+				return err
+			}
+			if found.Id > 0 {
+				if found.Role != role {
+					if err := tx.Model(found).UpdateColumn("role", role).Error; err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if err := tx.Create(&ProjectMember{ProjectId: projectId, UserId: userId, Role: role}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteByUser 删除用户时移除其所在项目的成员记录
+func (member *ProjectMember) DeleteByUser(userId int) error {
+	return Db.Where("user_id = ?", userId).Delete(&ProjectMember{}).Error
+}
+
+// TaskProjectId 任务所属项目, 任务不存在时返回0
+func TaskProjectId(id int) (int, error) {
+	task := new(Task)
+	err := Db.Select("project_id").Where("id = ?", id).Limit(1).Find(task).Error
+
+	return task.ProjectId, err
+}
+
+// HostProjectId 主机所属项目, 主机不存在时返回0
+func HostProjectId(id int) (int, error) {
+	host := new(Host)
+	err := Db.Select("project_id").Where("id = ?", id).Limit(1).Find(host).Error
+
+	return host.ProjectId, err
+}
+
+// TaskLogProjectId 任务日志所属项目, 日志或任务不存在时返回0
+func TaskLogProjectId(id int64) (int, error) {
+	taskLog := new(TaskLog)
+	if err := Db.Select("task_id").Where("id = ?", id).Limit(1).Find(taskLog).Error; err != nil {
+		return 0, err
+	}
+	if taskLog.TaskId == 0 {
+		return 0, nil
+	}
+
+	return TaskProjectId(taskLog.TaskId)
+}
+
+// TaskProjectIds 任务ID对应的项目, 不存在的任务不在结果中
+func TaskProjectIds(ids []int) (map[int]int, error) {
+	result := make(map[int]int, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+	list := make([]Task, 0)
+	if err := Db.Select("id", "project_id").Where("id IN ?", ids).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	for _, item := range list {
+		result[item.Id] = item.ProjectId
+	}
+
+	return result, nil
+}
+
+// CrossDependencyAllowed 父任务能否依赖子任务, 不同项目时需两个项目都允许跨项目依赖
+func CrossDependencyAllowed(parentProjectId, childProjectId int) (bool, error) {
+	if parentProjectId == childProjectId {
+		return true, nil
+	}
+	var count int64
+	err := Db.Model(&Project{}).
+		Where("id IN ? AND allow_cross_dependency = ?", []int{parentProjectId, childProjectId}, 1).
+		Count(&count).Error
+
+	return count == 2, err
+}
+
+// HostsInProject 主机是否都属于项目
+func HostsInProject(hostIds []int, projectId int) (bool, error) {
+	if len(hostIds) == 0 {
+		return true, nil
+	}
+	var count int64
+	err := Db.Model(&Host{}).Where("id IN ? AND project_id = ?", hostIds, projectId).Count(&count).Error
+
+	return count == int64(len(hostIds)), err
+}
+
+// HostUsedOutsideProject 主机是否被其他项目的任务直接关联
+func HostUsedOutsideProject(hostId, projectId int) (bool, error) {
+	var count int64
+	err := Db.Model(&TaskHost{}).
+		Where("host_id = ? AND task_id IN (?)", hostId, Db.Model(&Task{}).Select("id").Where("project_id != ?", projectId)).
+		Count(&count).Error
+
+	return count > 0, err
+}
+
+// EnsureDefaultProject 项目表为空时创建默认项目, 自增ID即为 DefaultProjectId
+func EnsureDefaultProject(tx *gorm.DB) error {
+	var count int64
+	if err := tx.Model(&Project{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	return tx.Create(&Project{Name: "default"}).Error
+}
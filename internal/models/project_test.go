@@ -0,0 +1,211 @@
+package models
+
+import (
+	"os"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/tabortao/gocron/internal/modules/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func TestMain(m *testing.M) {
+	_ = os.MkdirAll("log", 0o755)
+	logger.InitLogger()
+	os.Exit(m.Run())
+}
+
+func TestRoleCan(t *testing.T) {
+	cases := []struct {
+		role   Role
+		action Action
+		want   bool
+	}{
+		{RoleViewer, ActionView, true},
+		{RoleViewer, ActionRun, false},
+		{RoleOperator, ActionRun, true},
+		{RoleOperator, ActionEdit, false},
+		{RoleEditor, ActionEdit, true},
+		{RoleEditor, ActionManage, false},
+		{RoleAdmin, ActionManage, true},
+		{0, ActionView, false},
+		{RoleAdmin, 0, false},
+	}
+	for _, tc := range cases {
+		if got := tc.role.Can(tc.action); got != tc.want {
+			t.Errorf("Role(%d).Can(%d) = %v, want %v", tc.role, tc.action, got, tc.want)
+		}
+	}
+}
+
+func TestProjectMembers(t *testing.T) {
+	// 成员列表使用原生表名, 与正式环境一样使用单数表名
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{SingularTable: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&User{}, &Task{}, &TaskLog{}, &Project{}, &ProjectMember{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	oldDb := Db
+	Db = db
+	defer func() { Db = oldDb }()
+
+	if err := EnsureDefaultProject(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := EnsureDefaultProject(db); err != nil {
+		t.Fatal(err)
+	}
+	projectId, _ := (&Project{Name: "ops"}).Create()
+	if projectId == DefaultProjectId {
+		t.Fatalf("default project should be created first, got %d", projectId)
+	}
+	db.Create(&User{Name: "alice", Email: "alice@example.com"})
+	db.Create(&User{Name: "bob", Email: "bob@example.com"})
+
+	memberModel := new(ProjectMember)
+	err = memberModel.Replace(projectId, []ProjectMember{{UserId: 1, Role: RoleOperator}, {UserId: 2, Role: RoleViewer}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = memberModel.Replace(DefaultProjectId, []ProjectMember{{UserId: 1, Role: RoleViewer}})
+
+	if role, _ := memberModel.FindRole(projectId, 1); role != RoleOperator {
+		t.Errorf("FindRole = %d, want operator", role)
+	}
+	if role, _ := memberModel.FindRole(DefaultProjectId, 2); role != 0 {
+		t.Errorf("non member role = %d", role)
+	}
+	if ids, _ := memberModel.ProjectIds(1, ActionRun); len(ids) != 1 || ids[0] != projectId {
+		t.Errorf("ProjectIds(run) = %v", ids)
+	}
+	if ids, _ := memberModel.ProjectIds(1, ActionView); len(ids) != 2 {
+		t.Errorf("ProjectIds(view) = %v", ids)
+	}
+	members, err := memberModel.List(projectId)
+	if err != nil || len(members) != 2 || members[0].Username != "alice" {
+		t.Fatalf("List = %+v, %v", members, err)
+	}
+	projects, _ := new(Project).ListByUser(2)
+	if len(projects) != 1 || projects[0].Name != "ops" || projects[0].Role != RoleViewer {
+		t.Fatalf("ListByUser = %+v", projects)
+	}
+
+	// 日志按任务所属项目过滤
+	db.Create(&Task{Name: "a", ProjectId: projectId, Command: "echo"})
+	db.Create(&Task{Name: "b", Command: "echo"})
+	db.Create(&TaskLog{Id: 1, TaskId: 1, Name: "a", Command: "echo"})
+	db.Create(&TaskLog{Id: 2, TaskId: 2, Name: "b", Command: "echo"})
+	if total, _ := new(TaskLog).Total(CommonMap{"ProjectIds": []int{projectId}}); total != 1 {
+		t.Errorf("filtered log total = %d, want 1", total)
+	}
+	if id, _ := TaskLogProjectId(2); id != DefaultProjectId {
+		t.Errorf("TaskLogProjectId = %d, want default project", id)
+	}
+
+	// 删除项目同时删除成员
+	if err := new(Project).Delete(projectId); err != nil {
+		t.Fatal(err)
+	}
+	if role, _ := memberModel.FindRole(projectId, 1); role != 0 {
+		t.Error("members should be removed with project")
+	}
+}
+
+func TestProjectScope(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{SingularTable: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&User{}, &Task{}, &TaskHost{}, &Host{}, &Setting{}, &Project{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	oldDb := Db
+	Db = db
+	defer func() { Db = oldDb }()
+
+	_ = EnsureDefaultProject(db)
+	opsId, _ := (&Project{Name: "ops"}).Create()
+	devId, _ := (&Project{Name: "dev"}).Create()
+
+	// 跨项目依赖需两个项目都允许
+	if ok, _ := CrossDependencyAllowed(opsId, opsId); !ok {
+		t.Error("same project dependency should be allowed")
+	}
+	if ok, _ := CrossDependencyAllowed(opsId, devId); ok {
+		t.Error("cross project dependency should be forbidden by default")
+	}
+	db.Model(&Project{}).Where("id = ?", opsId).Update("allow_cross_dependency", 1)
+	if ok, _ := CrossDependencyAllowed(opsId, devId); ok {
+		t.Error("both projects must allow cross project dependency")
+	}
+	db.Model(&Project{}).Where("id = ?", devId).Update("allow_cross_dependency", 1)
+	if ok, _ := CrossDependencyAllowed(opsId, devId); !ok {
+		t.Error("cross project dependency should be allowed")
+	}
+
+	// 主机只能被同项目的任务使用
+	db.Create(&Host{Id: 1, Name: "web1", ProjectId: opsId, Group: "web"})
+	db.Create(&Host{Id: 2, Name: "web2", ProjectId: devId, Group: "web"})
+	if ok, _ := HostsInProject([]int{1}, opsId); !ok {
+		t.Error("host 1 belongs to ops")
+	}
+	if ok, _ := HostsInProject([]int{1, 2}, opsId); ok {
+		t.Error("host 2 does not belong to ops")
+	}
+	db.Create(&User{Name: "alice", Email: "alice@example.com"})
+	task := Task{Name: "deploy", ProjectId: opsId, CreatedBy: 1, Protocol: TaskRPC, HostGroup: "web", Command: "echo"}
+	if _, err := task.Create(); err != nil {
+		t.Fatal(err)
+	}
+	_ = new(TaskHost).Add(task.Id, []int{1})
+	if used, _ := HostUsedOutsideProject(1, devId); !used {
+		t.Error("host 1 is used by ops task")
+	}
+	if used, _ := HostUsedOutsideProject(1, opsId); used {
+		t.Error("host 1 is only used by ops tasks")
+	}
+	tasks, err := new(Task).List(CommonMap{})
+	if err != nil || len(tasks) != 1 {
+		t.Fatalf("List = %+v, %v", tasks, err)
+	}
+	if tasks[0].Owner != "alice" {
+		t.Errorf("Owner = %q, want alice", tasks[0].Owner)
+	}
+	if len(tasks[0].Hosts) != 1 || tasks[0].Hosts[0].HostId != 1 {
+		t.Errorf("group should only match hosts of the task project, got %+v", tasks[0].Hosts)
+	}
+
+	// 通知接收者: 项目自己的及共享的
+	shared := &Setting{}
+	_, _ = shared.CreateWebhookUrl("shared", "https://example.com/shared")
+	own := &Setting{ProjectId: opsId}
+	_, _ = own.CreateWebhookUrl("ops", "https://example.com/ops")
+	other := &Setting{ProjectId: devId}
+	_, _ = other.CreateBarkUrl("dev", "https://example.com/dev")
+	_, _ = (&Setting{ProjectId: devId}).CreateMailUser("bob", "bob@example.com")
+	receivers, err := new(Setting).NotifyReceivers(opsId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(receivers.WebhookUrls) != 2 || len(receivers.BarkUrls) != 0 || len(receivers.MailUsers) != 0 {
+		t.Errorf("NotifyReceivers(ops) = %+v", receivers)
+	}
+	receivers, _ = new(Setting).NotifyReceivers(devId)
+	if len(receivers.WebhookUrls) != 1 || len(receivers.BarkUrls) != 1 || len(receivers.MailUsers) != 1 {
+		t.Errorf("NotifyReceivers(dev) = %+v", receivers)
+	}
+	devBarkId := receivers.BarkUrls[0].Id
+	if ok, _ := new(Setting).ReceiversInProject([]int{shared.Id, devBarkId}, devId); !ok {
+		t.Error("shared and own receivers should be allowed")
+	}
+	if ok, _ := new(Setting).ReceiversInProject([]int{devBarkId}, opsId); ok {
+		t.Error("receiver of other project should be rejected")
+	}
+}
@@ -10,6 +10,8 @@ type Setting struct {
 	Code  string `gorm:"type:varchar(32);not null"`
 	Key   string `gorm:"type:varchar(64);not null"`
 	Value string `gorm:"type:varchar(4096);not null;default:''"`
+	// 通知接收者所属项目, 为0时所有项目共享
+	ProjectId int `gorm:"not null;default:0;index"`
 }
 
 const slackTemplate = `Task ID: {{.TaskId}}
@@ -92,6 +94,11 @@ const (
 	LogRetentionDaysKey = "log_retention_days"
 	LogCleanupTimeKey   = "log_cleanup_time"
 	LogFileSizeLimitKey = "log_file_size_limit"
+	// 审计日志保留天数, 为0时不清理
+	AuditLogRetentionDaysKey = "audit_log_retention_days"
+	// 禁用本地密码登录, 只保留应急管理员使用密码登录
+	PasswordLoginDisabledKey = "password_login_disabled"
+	BreakGlassUserKey        = "break_glass_user"
 )
 
 // region slack配置
@@ -103,8 +110,9 @@ type Slack struct {
 }
 
 type Channel struct {
-	Id   int    `json:"id"`
-	Name string `json:"name"`
+	Id        int    `json:"id"`
+	Name      string `json:"name"`
+	ProjectId int    `json:"project_id"`
 }
 
 func (setting *Setting) Slack() (Slack, error) {
@@ -129,7 +137,7 @@ func (setting *Setting) formatSlack(list []Setting, slack *Slack) {
 			slack.Template = v.Value
 		default:
 			slack.Channels = append(slack.Channels, Channel{
-				v.Id, v.Value,
+				v.Id, v.Value, v.ProjectId,
 			})
 		}
 	}
@@ -179,9 +187,10 @@ type Mail struct {
 }
 
 type MailUser struct {
-	Id       int    `json:"id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
+	Id        int    `json:"id"`
+	Username  string `json:"username"`
+	Email     string `json:"email"`
+	ProjectId int    `json:"project_id"`
 }
 
 // region 邮件配置
@@ -210,6 +219,7 @@ func (setting *Setting) formatMail(list []Setting, mail *Mail) {
 			if v.Value != "" {
 				_ = json.Unmarshal([]byte(v.Value), &mailUser)
 				mailUser.Id = v.Id
+				mailUser.ProjectId = v.ProjectId
 				mail.MailUsers = append(mail.MailUsers, mailUser)
 			}
 		case MailTemplateKey:
@@ -229,7 +239,7 @@ func (setting *Setting) UpdateMail(config, template string) error {
 func (setting *Setting) CreateMailUser(username, email string) (int64, error) {
 	setting.Code = MailCode
 	setting.Key = MailUserKey
-	mailUser := MailUser{0, username, email}
+	mailUser := MailUser{Username: username, Email: email}
 	jsonByte, err := json.Marshal(mailUser)
 	if err != nil {
 		return 0, err
@@ -251,9 +261,10 @@ type WebHook struct {
 }
 
 type WebhookUrl struct {
-	Id   int    `json:"id"`
-	Name string `json:"name"`
-	Url  string `json:"url"`
+	Id        int    `json:"id"`
+	Name      string `json:"name"`
+	Url       string `json:"url"`
+	ProjectId int    `json:"project_id"`
 }
 
 func (setting *Setting) Webhook() (WebHook, error) {
@@ -277,6 +288,7 @@ func (setting *Setting) formatWebhook(list []Setting, webHook *WebHook) {
 			if v.Value != "" {
 				_ = json.Unmarshal([]byte(v.Value), &webhookUrl)
 				webhookUrl.Id = v.Id
+				webhookUrl.ProjectId = v.ProjectId
 				webHook.WebhookUrls = append(webHook.WebhookUrls, webhookUrl)
 			}
 		case WebhookTemplateKey:
@@ -291,16 +303,17 @@ func (setting *Setting) UpdateWebHook(template string) error {
 }
 
 func (setting *Setting) CreateWebhookUrl(name, url string) (int64, error) {
-	webhookUrl := WebhookUrl{0, name, url}
+	webhookUrl := WebhookUrl{Name: name, Url: url}
 	jsonByte, err := json.Marshal(webhookUrl)
 	if err != nil {
 		return 0, err
 	}
 
 	newSetting := Setting{
-		Code:  WebhookCode,
-		Key:   WebhookUrlKey,
-		Value: string(jsonByte),
+		Code:      WebhookCode,
+		Key:       WebhookUrlKey,
+		Value:     string(jsonByte),
+		ProjectId: setting.ProjectId,
 	}
 
 	result := Db.Create(&newSetting)
@@ -321,9 +334,10 @@ type ServerChan3 struct {
 }
 
 type ServerChan3Url struct {
-	Id   int    `json:"id"`
-	Name string `json:"name"`
-	Url  string `json:"url"`
+	Id        int    `json:"id"`
+	Name      string `json:"name"`
+	Url       string `json:"url"`
+	ProjectId int    `json:"project_id"`
 }
 
 func (setting *Setting) ServerChan3() (ServerChan3, error) {
@@ -346,6 +360,7 @@ func (setting *Setting) formatServerChan3(list []Setting, serverChan3 *ServerCha
 			if v.Value != "" {
 				_ = json.Unmarshal([]byte(v.Value), &urlItem)
 				urlItem.Id = v.Id
+				urlItem.ProjectId = v.ProjectId
 				serverChan3.Urls = append(serverChan3.Urls, urlItem)
 			}
 		case ServerChan3TitleTemplateKey:
@@ -367,16 +382,17 @@ func (setting *Setting) UpdateServerChan3(titleTemplate, despTemplate string) er
 }
 
 func (setting *Setting) CreateServerChan3Url(name, url string) (int64, error) {
-	urlItem := ServerChan3Url{0, name, url}
+	urlItem := ServerChan3Url{Name: name, Url: url}
 	jsonByte, err := json.Marshal(urlItem)
 	if err != nil {
 		return 0, err
 	}
 
 	newSetting := Setting{
-		Code:  ServerChan3Code,
-		Key:   ServerChan3UrlKey,
-		Value: string(jsonByte),
+		Code:      ServerChan3Code,
+		Key:       ServerChan3UrlKey,
+		Value:     string(jsonByte),
+		ProjectId: setting.ProjectId,
 	}
 
 	result := Db.Create(&newSetting)
@@ -395,9 +411,10 @@ type Bark struct {
 }
 
 type BarkUrl struct {
-	Id   int    `json:"id"`
-	Name string `json:"name"`
-	Url  string `json:"url"`
+	Id        int    `json:"id"`
+	Name      string `json:"name"`
+	Url       string `json:"url"`
+	ProjectId int    `json:"project_id"`
 }
 
 func (setting *Setting) Bark() (Bark, error) {
@@ -420,6 +437,7 @@ func (setting *Setting) formatBark(list []Setting, bark *Bark) {
 			if v.Value != "" {
 				_ = json.Unmarshal([]byte(v.Value), &urlItem)
 				urlItem.Id = v.Id
+				urlItem.ProjectId = v.ProjectId
 				bark.Urls = append(bark.Urls, urlItem)
 			}
 		case BarkTitleTemplateKey:
@@ -441,16 +459,17 @@ func (setting *Setting) UpdateBark(titleTemplate, bodyTemplate string) error {
 }
 
 func (setting *Setting) CreateBarkUrl(name, url string) (int64, error) {
-	urlItem := BarkUrl{0, name, url}
+	urlItem := BarkUrl{Name: name, Url: url}
 	jsonByte, err := json.Marshal(urlItem)
 	if err != nil {
 		return 0, err
 	}
 
 	newSetting := Setting{
-		Code:  BarkCode,
-		Key:   BarkUrlKey,
-		Value: string(jsonByte),
+		Code:      BarkCode,
+		Key:       BarkUrlKey,
+		Value:     string(jsonByte),
+		ProjectId: setting.ProjectId,
 	}
 
 	result := Db.Create(&newSetting)
@@ -462,8 +481,71 @@ func (setting *Setting) RemoveBarkUrl(id int) (int64, error) {
 	return result.RowsAffected, result.Error
 }
 
+// NotifyReceivers 项目可选的通知接收者
+type NotifyReceivers struct {
+	MailUsers       []MailUser       `json:"mail_users"`
+	Channels        []Channel        `json:"channels"`
+	WebhookUrls     []WebhookUrl     `json:"webhook_urls"`
+	ServerChan3Urls []ServerChan3Url `json:"serverchan3_urls"`
+	BarkUrls        []BarkUrl        `json:"bark_urls"`
+}
+
+// NotifyReceivers 项目自己的及所有项目共享的通知接收者
+func (setting *Setting) NotifyReceivers(projectId int) (NotifyReceivers, error) {
+	receivers := NotifyReceivers{}
+	list := make([]Setting, 0)
+	err := Db.Where("project_id IN ?", []int{0, projectId}).
+		// webhook、server酱、bark的地址使用相同的key
+		Where("(code = ? AND `key` = ?) OR (code = ? AND `key` = ?) OR (code IN ? AND `key` = ?)",
+			MailCode, MailUserKey, SlackCode, SlackChannelKey,
+			[]string{WebhookCode, ServerChan3Code, BarkCode}, WebhookUrlKey).
+		Order("id ASC").Find(&list).Error
+	if err != nil {
+		return receivers, err
+	}
+	groups := make(map[string][]Setting)
+	for _, v := range list {
+		groups[v.Code] = append(groups[v.Code], v)
+	}
+
+	mail := Mail{MailUsers: make([]MailUser, 0)}
+	setting.formatMail(groups[MailCode], &mail)
+	receivers.MailUsers = mail.MailUsers
+	slack := Slack{Channels: make([]Channel, 0)}
+	setting.formatSlack(groups[SlackCode], &slack)
+	receivers.Channels = slack.Channels
+	webHook := WebHook{WebhookUrls: make([]WebhookUrl, 0)}
+	setting.formatWebhook(groups[WebhookCode], &webHook)
+	receivers.WebhookUrls = webHook.WebhookUrls
+	serverChan3 := ServerChan3{Urls: make([]ServerChan3Url, 0)}
+	setting.formatServerChan3(groups[ServerChan3Code], &serverChan3)
+	receivers.ServerChan3Urls = serverChan3.Urls
+	bark := Bark{Urls: make([]BarkUrl, 0)}
+	setting.formatBark(groups[BarkCode], &bark)
+	receivers.BarkUrls = bark.Urls
+
+	return receivers, nil
+}
+
+// ReceiversInProject 通知接收者是否都属于项目或为共享接收者
+func (setting *Setting) ReceiversInProject(ids []int, projectId int) (bool, error) {
+	if len(ids) == 0 {
+		return true, nil
+	}
+	var count int64
+	err := Db.Model(&Setting{}).Where("id IN ? AND project_id NOT IN ?", ids, []int{0, projectId}).
+		Count(&count).Error
+
+	return count == 0, err
+}
+
 // region 通用配置辅助方法
 
+// Find 按id查找配置
+func (setting *Setting) Find(id int) error {
+	return Db.Where("id = ?", id).Limit(1).Find(setting).Error
+}
+
 // getSettingValue 获取配置值的通用方法
 func (setting *Setting) getSettingValue(code, key string) (string, error) {
 	var s Setting
@@ -538,4 +620,40 @@ func (setting *Setting) UpdateLogFileSizeLimit(size int) error {
 	return setting.updateOrCreateSetting(SystemCode, LogFileSizeLimitKey, strconv.Itoa(size))
 }
 
+func (setting *Setting) GetAuditLogRetentionDays() int {
+	value, err := setting.getSettingValue(SystemCode, AuditLogRetentionDaysKey)
+	if err != nil || value == "" {
+		return 0
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil {
+		return 0
+	}
+	return days
+}
+
+func (setting *Setting) UpdateAuditLogRetentionDays(days int) error {
+	return setting.updateOrCreateSetting(SystemCode, AuditLogRetentionDaysKey, strconv.Itoa(days))
+}
+
+// PasswordLogin 本地密码登录配置, 禁用后只有应急管理员可使用密码登录
+func (setting *Setting) PasswordLogin() (disabled bool, breakGlassUser string) {
+	value, _ := setting.getSettingValue(SystemCode, PasswordLoginDisabledKey)
+	breakGlassUser, _ = setting.getSettingValue(SystemCode, BreakGlassUserKey)
+
+	return value == "1", breakGlassUser
+}
+
+func (setting *Setting) UpdatePasswordLogin(disabled bool, breakGlassUser string) error {
+	value := "0"
+	if disabled {
+		value = "1"
+	}
+	if err := setting.updateOrCreateSetting(SystemCode, PasswordLoginDisabledKey, value); err != nil {
+		return err
+	}
+
+	return setting.updateOrCreateSetting(SystemCode, BreakGlassUserKey, breakGlassUser)
+}
+
 // endregion
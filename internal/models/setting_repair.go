@@ -39,6 +39,9 @@ func RepairSettings() error {
 		{SystemCode, LogRetentionDaysKey, "0"},
 		{SystemCode, LogCleanupTimeKey, "03:00"},
 		{SystemCode, LogFileSizeLimitKey, "0"},
+		{SystemCode, AuditLogRetentionDaysKey, "0"},
+		{SystemCode, PasswordLoginDisabledKey, "0"},
+		{SystemCode, BreakGlassUserKey, ""},
 	}
 
 	// 检查并创建缺失的配置
@@ -38,6 +38,43 @@ const (
 	TaskHttpMethodPost TaskHTTPMethod = 2
 )
 
+// 重试间隔策略
+type TaskRetryStrategy int8
+
+const (
+	RetryStrategyDefault     TaskRetryStrategy = iota // 默认: 设置了重试间隔时固定间隔, 否则每次递增1分钟
+	RetryStrategyFixed                                // 固定间隔
+	RetryStrategyLinear                               // 线性递增
+	RetryStrategyExponential                          // 指数退避
+)
+
+// 重试条件, 未设置时任何错误都重试
+const (
+	RetryOnTimeout     = "timeout"     // 执行超时
+	RetryOnUnavailable = "unavailable" // 节点不可用
+	RetryOnExitCode    = "exit_code"   // 退出码在 RetryExitCodes 中
+	RetryOnHTTP5xx     = "http_5xx"    // HTTP状态码5xx
+)
+
+// 多主机任务的主机选择方式
+type TaskHostSelection int8
+
+const (
+	HostSelectionAll         TaskHostSelection = iota // 所有主机执行
+	HostSelectionRoundRobin                           // 任选一台: 轮询
+	HostSelectionRandom                               // 任选一台: 随机
+	HostSelectionLeastLoaded                          // 任选一台: 运行中任务最少
+	HostSelectionFailover                             // 按顺序选择第一台可用主机
+)
+
+// 滚动执行每批主机数量的单位
+type TaskBatchUnit int8
+
+const (
+	BatchUnitHost    TaskBatchUnit = iota // 主机数
+	BatchUnitPercent                      // 占全部主机的百分比
+)
+
 // NextRunTime 自定义时间类型，零值时序列化为空字符串
 type NextRunTime time.Time
 
@@ -70,17 +107,33 @@ func (t *NextRunTime) UnmarshalJSON(data []byte) error {
 type Task struct {
 	Id               int                  `json:"id" gorm:"primaryKey;autoIncrement"`
 	Name             string               `json:"name" gorm:"type:varchar(32);not null"`
+	ProjectId        int                  `json:"project_id" gorm:"not null;index;default:1"`
+	CreatedBy        int                  `json:"created_by" gorm:"not null;index;default:0"` // 创建人, 升级前的任务为0
 	Level            TaskLevel            `json:"level" gorm:"type:tinyint;not null;index;default:1"`
 	DependencyTaskId string               `json:"dependency_task_id" gorm:"type:varchar(64);not null;default:''"`
 	DependencyStatus TaskDependencyStatus `json:"dependency_status" gorm:"type:tinyint;not null;default:1"`
 	Spec             string               `json:"spec" gorm:"type:varchar(64);not null"`
 	Protocol         TaskProtocol         `json:"protocol" gorm:"type:tinyint;not null;index"`
 	Command          string               `json:"command" gorm:"type:text;not null"`
+	CommandSignature string               `json:"command_signature" gorm:"type:varchar(128);not null;default:''"`
 	HttpMethod       TaskHTTPMethod       `json:"http_method" gorm:"type:tinyint;not null;default:1"`
 	Timeout          int                  `json:"timeout" gorm:"type:mediumint;not null;default:0"`
 	Multi            int8                 `json:"multi" gorm:"type:tinyint;not null;default:1"`
+	Detached         int8                 `json:"detached" gorm:"type:tinyint;not null;default:0"`
+	HostSelection    TaskHostSelection    `json:"host_selection" gorm:"type:tinyint;not null;default:0"`
+	HostGroup        string               `json:"host_group" gorm:"type:varchar(64);not null;default:''"`
+	HostSelector     string               `json:"host_selector" gorm:"type:varchar(256);not null;default:''"`
+	BatchSize        int                  `json:"batch_size" gorm:"type:mediumint;not null;default:0"`
+	BatchUnit        TaskBatchUnit        `json:"batch_unit" gorm:"type:tinyint;not null;default:0"`
+	BatchInterval    int                  `json:"batch_interval" gorm:"type:mediumint;not null;default:0"`
+	MaxFailures      int                  `json:"max_failures" gorm:"type:mediumint;not null;default:0"`
 	RetryTimes       int8                 `json:"retry_times" gorm:"type:tinyint;not null;default:0"`
 	RetryInterval    int16                `json:"retry_interval" gorm:"type:smallint;not null;default:0"`
+	RetryStrategy    TaskRetryStrategy    `json:"retry_strategy" gorm:"type:tinyint;not null;default:0"`
+	RetryMaxInterval int                  `json:"retry_max_interval" gorm:"type:mediumint;not null;default:0"`
+	RetryJitter      int8                 `json:"retry_jitter" gorm:"type:tinyint;not null;default:0"`
+	RetryOn          string               `json:"retry_on" gorm:"type:varchar(64);not null;default:''"`
+	RetryExitCodes   string               `json:"retry_exit_codes" gorm:"type:varchar(64);not null;default:''"`
 	NotifyStatus     int8                 `json:"notify_status" gorm:"type:tinyint;not null;default:1"`
 	NotifyType       int8                 `json:"notify_type" gorm:"type:tinyint;not null;default:0"`
 	NotifyReceiverId string               `json:"notify_receiver_id" gorm:"type:varchar(256);not null;default:''"`
@@ -88,11 +141,14 @@ type Task struct {
 	Tag              string               `json:"tag" gorm:"type:varchar(32);not null;default:''"`
 	Remark           string               `json:"remark" gorm:"type:varchar(100);not null;default:''"`
 	Status           Status               `json:"status" gorm:"type:tinyint;not null;index;default:0"`
+	Revision         int                  `json:"revision" gorm:"not null;default:0"` // 当前配置的版本号, 升级前未修改过的任务为0
 	CreatedAt        time.Time            `json:"created" gorm:"column:created;autoCreateTime"`
 	DeletedAt        *time.Time           `json:"deleted" gorm:"column:deleted;index"`
 	BaseModel        `json:"-" gorm:"-"`
 	Hosts            []TaskHostDetail `json:"hosts" gorm:"-"`
 	NextRunTime      NextRunTime      `json:"next_run_time" gorm:"-"`
+	Owner            string           `json:"owner" gorm:"-"` // 创建人用户名
+	ScheduleTime     time.Time        `json:"-" gorm:"-"`     // 本次执行的逻辑调度时间, 补跑时为历史时间
 }
 
 // 新增
@@ -102,17 +158,33 @@ func (task *Task) Create() (insertId int, err error) {
 	// 但这里我们使用 map 来明确指定所有字段值
 	data := map[string]interface{}{
 		"name":               task.Name,
+		"project_id":         task.ProjectId,
+		"created_by":         task.CreatedBy,
 		"level":              task.Level,
 		"dependency_task_id": task.DependencyTaskId,
 		"dependency_status":  task.DependencyStatus,
 		"spec":               task.Spec,
 		"protocol":           task.Protocol,
 		"command":            task.Command,
+		"command_signature":  task.CommandSignature,
 		"http_method":        task.HttpMethod,
 		"timeout":            task.Timeout,
 		"multi":              task.Multi,
+		"detached":           task.Detached,
+		"host_selection":     task.HostSelection,
+		"host_group":         task.HostGroup,
+		"host_selector":      task.HostSelector,
+		"batch_size":         task.BatchSize,
+		"batch_unit":         task.BatchUnit,
+		"batch_interval":     task.BatchInterval,
+		"max_failures":       task.MaxFailures,
 		"retry_times":        task.RetryTimes,
 		"retry_interval":     task.RetryInterval,
+		"retry_strategy":     task.RetryStrategy,
+		"retry_max_interval": task.RetryMaxInterval,
+		"retry_jitter":       task.RetryJitter,
+		"retry_on":           task.RetryOn,
+		"retry_exit_codes":   task.RetryExitCodes,
 		"notify_status":      task.NotifyStatus,
 		"notify_type":        task.NotifyType,
 		"notify_receiver_id": task.NotifyReceiverId,
@@ -136,19 +208,36 @@ func (task *Task) Create() (insertId int, err error) {
 
 func (task *Task) UpdateBean(id int) (int64, error) {
 	result := Db.Model(&Task{}).Where("id = ?", id).
-		Select("name", "spec", "protocol", "command", "timeout", "multi",
-			"retry_times", "retry_interval", "remark", "notify_status",
+		Select("name", "project_id", "spec", "protocol", "command", "command_signature", "timeout", "multi", "detached", "host_selection",
+			"host_group", "host_selector", "batch_size", "batch_unit", "batch_interval", "max_failures",
+			"retry_times", "retry_interval", "retry_strategy", "retry_max_interval",
+			"retry_jitter", "retry_on", "retry_exit_codes", "remark", "notify_status",
 			"notify_type", "notify_receiver_id", "dependency_task_id",
 			"dependency_status", "tag", "http_method", "notify_keyword").
 		UpdateColumns(map[string]interface{}{
 			"name":               task.Name,
+			"project_id":         task.ProjectId,
 			"spec":               task.Spec,
 			"protocol":           task.Protocol,
 			"command":            task.Command,
+			"command_signature":  task.CommandSignature,
 			"timeout":            task.Timeout,
 			"multi":              task.Multi,
+			"detached":           task.Detached,
+			"host_selection":     task.HostSelection,
+			"host_group":         task.HostGroup,
+			"host_selector":      task.HostSelector,
+			"batch_size":         task.BatchSize,
+			"batch_unit":         task.BatchUnit,
+			"batch_interval":     task.BatchInterval,
+			"max_failures":       task.MaxFailures,
 			"retry_times":        task.RetryTimes,
 			"retry_interval":     task.RetryInterval,
+			"retry_strategy":     task.RetryStrategy,
+			"retry_max_interval": task.RetryMaxInterval,
+			"retry_jitter":       task.RetryJitter,
+			"retry_on":           task.RetryOn,
+			"retry_exit_codes":   task.RetryExitCodes,
 			"remark":             task.Remark,
 			"notify_status":      task.NotifyStatus,
 			"notify_type":        task.NotifyType,
@@ -225,6 +314,42 @@ func (task *Task) ActiveListByHostId(hostId int) ([]Task, error) {
 	return task.setHostsForTasks(list)
 }
 
+// 获取在该主机上执行且开启了通知的激活任务, 包括通过主机分组或标签选择器匹配的任务
+func (task *Task) NotifyListByHost(host Host) ([]Task, error) {
+	taskIds, err := new(TaskHost).GetTaskIdsByHostId(host.Id)
+	if err != nil {
+		return nil, err
+	}
+	bound := make(map[int]bool, len(taskIds))
+	for _, id := range taskIds {
+		bound[id.(int)] = true
+	}
+	list := make([]Task, 0)
+	err = Db.Where("status = ? AND protocol = ? AND notify_status > 0", Enabled, TaskRPC).Find(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	tasks := make([]Task, 0)
+	for _, item := range list {
+		if !bound[item.Id] && !item.matchHost(host) {
+			continue
+		}
+		tasks = append(tasks, item)
+	}
+
+	return tasks, nil
+}
+
+// 主机是否满足任务的主机分组和标签选择器
+func (task *Task) matchHost(host Host) bool {
+	if !task.HasHostTarget() || host.ProjectId != task.ProjectId {
+		return false
+	}
+	hosts, err := filterHosts([]Host{host}, task.HostGroup, task.HostSelector)
+
+	return err == nil && len(hosts) > 0
+}
+
 // 优化：批量查询任务主机信息，避免N+1查询问题
 func (task *Task) setHostsForTasks(tasks []Task) ([]Task, error) {
 	if len(tasks) == 0 {
@@ -244,6 +369,18 @@ func (task *Task) setHostsForTasks(tasks []Task) ([]Task, error) {
 		return nil, err
 	}
 
+	// 通过主机分组或标签选择器指定目标的任务, 在运行时解析主机
+	var allHosts []Host
+	for i := range tasks {
+		if tasks[i].Protocol == TaskRPC && tasks[i].HasHostTarget() {
+			err = Db.Order("id ASC").Find(&allHosts).Error
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
 	// 分配主机信息到对应任务
 	for i := range tasks {
 		if hosts, ok := hostsMap[tasks[i].Id]; ok {
@@ -251,12 +388,66 @@ func (task *Task) setHostsForTasks(tasks []Task) ([]Task, error) {
 		} else {
 			tasks[i].Hosts = []TaskHostDetail{}
 		}
+		if err = tasks[i].resolveTargetHosts(allHosts); err != nil {
+			logger.Errorf("Task ID-%d Failed to resolve target hosts-%s", tasks[i].Id, err.Error())
+		}
 		logger.Debugf("Task ID-%d Associated host count-%d", tasks[i].Id, len(tasks[i].Hosts))
 	}
 
 	return tasks, nil
 }
 
+// HasHostTarget 是否通过主机分组或标签选择器指定目标主机
+func (task *Task) HasHostTarget() bool {
+	return task.HostGroup != "" || task.HostSelector != ""
+}
+
+// RefreshHosts 重新读取任务关联的主机, 并解析主机分组和标签选择器
+func (task *Task) RefreshHosts() error {
+	tasks, err := task.setHostsForTasks([]Task{*task})
+	if err != nil {
+		return err
+	}
+	task.Hosts = tasks[0].Hosts
+
+	return nil
+}
+
+// 按主机分组和标签选择器解析目标主机, 追加到直接关联的主机之后
+func (task *Task) resolveTargetHosts(allHosts []Host) error {
+	if task.Protocol != TaskRPC || !task.HasHostTarget() {
+		return nil
+	}
+	// 只在任务所属项目的主机中选择
+	projectHosts := make([]Host, 0, len(allHosts))
+	for _, host := range allHosts {
+		if host.ProjectId == task.ProjectId {
+			projectHosts = append(projectHosts, host)
+		}
+	}
+	hosts, err := filterHosts(projectHosts, task.HostGroup, task.HostSelector)
+	if err != nil {
+		return err
+	}
+	exists := make(map[int]bool, len(task.Hosts))
+	for _, host := range task.Hosts {
+		exists[host.HostId] = true
+	}
+	for _, host := range hosts {
+		if exists[host.Id] {
+			continue
+		}
+		task.Hosts = append(task.Hosts, TaskHostDetail{
+			TaskHost: TaskHost{TaskId: task.Id, HostId: host.Id},
+			Name:     host.Name,
+			Port:     host.Port,
+			Alias:    host.Alias,
+		})
+	}
+
+	return nil
+}
+
 // 判断任务名称是否存在
 func (task *Task) NameExist(name string, id int) (bool, error) {
 	var count int64
@@ -312,10 +503,39 @@ func (task *Task) List(params CommonMap) ([]Task, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err = task.setOwners(list); err != nil {
+		return nil, err
+	}
 
 	return task.setHostsForTasks(list)
 }
 
+// 填充任务创建人用户名
+func (task *Task) setOwners(tasks []Task) error {
+	userIds := make([]int, 0, len(tasks))
+	for _, item := range tasks {
+		if item.CreatedBy > 0 {
+			userIds = append(userIds, item.CreatedBy)
+		}
+	}
+	if len(userIds) == 0 {
+		return nil
+	}
+	users := make([]User, 0)
+	if err := Db.Select("id", "name").Where("id IN ?", userIds).Find(&users).Error; err != nil {
+		return err
+	}
+	names := make(map[int]string, len(users))
+	for _, item := range users {
+		names[item.Id] = item.Name
+	}
+	for i := range tasks {
+		tasks[i].Owner = names[tasks[i].CreatedBy]
+	}
+
+	return nil
+}
+
 // 获取依赖任务列表
 func (task *Task) GetDependencyTaskList(ids string) ([]Task, error) {
 	list := make([]Task, 0)
@@ -381,4 +601,21 @@ func (task *Task) parseWhere(query *gorm.DB, params CommonMap) {
 	if ok && tag.(string) != "" {
 		query.Where("t.tag LIKE ?", "%"+tag.(string)+"%")
 	}
+	projectId, ok := params["ProjectId"]
+	if ok && projectId.(int) > 0 {
+		query.Where("t.project_id = ?", projectId)
+	}
+	createdBy, ok := params["CreatedBy"]
+	if ok && createdBy.(int) > 0 {
+		query.Where("t.created_by = ?", createdBy)
+	}
+	// 游标分页, 返回id小于游标的记录
+	beforeId, ok := params["BeforeId"]
+	if ok && beforeId.(int) > 0 {
+		query.Where("t.id < ?", beforeId)
+	}
+	// 非管理员只能查看所在项目的任务
+	if projectIds, ok := params["ProjectIds"].([]int); ok {
+		query.Where("t.project_id IN ?", projectIds)
+	}
 }
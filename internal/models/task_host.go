@@ -27,6 +27,10 @@ func (th *TaskHost) Add(taskId int, hostIds []int) error {
 		return err
 	}
 
+	if len(hostIds) == 0 {
+		return nil
+	}
+
 	taskHosts := make([]TaskHost, len(hostIds))
 	for i, value := range hostIds {
 		taskHosts[i].TaskId = taskId
@@ -48,6 +48,7 @@ type TaskType int8
 type TaskLog struct {
 	Id         int64        `json:"id" gorm:"primaryKey;autoIncrement;type:bigint"`
 	TaskId     int          `json:"task_id" gorm:"not null;index;default:0"`
+	Revision   int          `json:"revision" gorm:"not null;default:0"` // 执行时任务配置的版本号
 	Name       string       `json:"name" gorm:"type:varchar(32);not null"`
 	Spec       string       `json:"spec" gorm:"type:varchar(64);not null"`
 	Protocol   TaskProtocol `json:"protocol" gorm:"type:tinyint;not null;index"`
@@ -60,6 +61,8 @@ type TaskLog struct {
 	Status     Status       `json:"status" gorm:"type:tinyint;not null;index;default:1"`
 	Result     string       `json:"result" gorm:"type:mediumtext;not null"`
 	TotalTime  int          `json:"total_time" gorm:"-"`
+	HostTotal  int          `json:"host_total" gorm:"-"`  // 有执行记录的主机数
+	HostFailed int          `json:"host_failed" gorm:"-"` // 执行失败的主机数
 	BaseModel  `json:"-" gorm:"-"`
 }
 
@@ -110,22 +113,56 @@ func (taskLog *TaskLog) List(params CommonMap) ([]TaskLog, error) {
 			execSeconds := endTime.Sub(time.Time(item.StartTime)).Seconds()
 			list[i].TotalTime = int(execSeconds)
 		}
+		taskLog.setHostSummaries(list)
 	}
 
 	return list, err
 }
 
+// 设置多主机任务的主机执行汇总
+func (taskLog *TaskLog) setHostSummaries(list []TaskLog) {
+	ids := make([]int64, 0, len(list))
+	for _, item := range list {
+		if item.Protocol == TaskRPC {
+			ids = append(ids, item.Id)
+		}
+	}
+	summaries, err := new(TaskLogHost).Summaries(ids)
+	if err != nil {
+		return
+	}
+	for i, item := range list {
+		if summary, ok := summaries[item.Id]; ok {
+			list[i].HostTotal = summary.Total
+			list[i].HostFailed = summary.Failed
+		}
+	}
+}
+
 // 清空表
 func (taskLog *TaskLog) Clear() (int64, error) {
 	result := Db.Where("1=1").Delete(&TaskLog{})
+	if result.Error == nil {
+		_, _ = new(TaskLogAttempt).Clear()
+		_, _ = new(TaskLogHost).Clear()
+	}
+	return result.RowsAffected, result.Error
+}
+
+// 删除单条日志
+func (taskLog *TaskLog) Delete(id int64) (int64, error) {
+	result := Db.Where("id = ?", id).Delete(&TaskLog{})
+	if result.Error == nil {
+		_, _ = new(TaskLogAttempt).RemoveByLog(id)
+		_, _ = new(TaskLogHost).RemoveByLog(id)
+	}
 	return result.RowsAffected, result.Error
 }
 
 // 删除N个月前的日志
 func (taskLog *TaskLog) Remove(id int) (int64, error) {
 	t := time.Now().AddDate(0, -id, 0)
-	result := Db.Where("start_time <= ?", t.Format(DefaultTimeFormat)).Delete(&TaskLog{})
-	return result.RowsAffected, result.Error
+	return taskLog.removeWhere("start_time <= ?", t.Format(DefaultTimeFormat))
 }
 
 // 删除N天前的日志
@@ -134,7 +171,20 @@ func (taskLog *TaskLog) RemoveByDays(days int) (int64, error) {
 		return 0, nil
 	}
 	t := time.Now().AddDate(0, 0, -days)
-	result := Db.Where("start_time < ?", t).Delete(&TaskLog{})
+	return taskLog.removeWhere("start_time < ?", t)
+}
+
+// 按条件删除日志, 先按相同条件删除日志的执行尝试和主机记录, 避免残留
+func (taskLog *TaskLog) removeWhere(query string, args ...interface{}) (int64, error) {
+	logIds := Db.Model(&TaskLog{}).Select("id").Where(query, args...)
+	if _, err := new(TaskLogAttempt).RemoveByLogs(logIds); err != nil {
+		return 0, err
+	}
+	if _, err := new(TaskLogHost).RemoveByLogs(logIds); err != nil {
+		return 0, err
+	}
+	result := Db.Where(query, args...).Delete(&TaskLog{})
+
 	return result.RowsAffected, result.Error
 }
 
@@ -146,6 +196,14 @@ func (taskLog *TaskLog) Total(params CommonMap) (int64, error) {
 	return count, err
 }
 
+// 指定协议运行中的任务日志
+func (taskLog *TaskLog) RunningList(protocol TaskProtocol) ([]TaskLog, error) {
+	list := make([]TaskLog, 0)
+	err := Db.Where("status = ? AND protocol = ?", Running, protocol).Order("id ASC").Find(&list).Error
+
+	return list, err
+}
+
 // 解析where
 func (taskLog *TaskLog) parseWhere(query *gorm.DB, params CommonMap) {
 	if len(params) == 0 {
@@ -163,6 +221,15 @@ func (taskLog *TaskLog) parseWhere(query *gorm.DB, params CommonMap) {
 	if ok && status.(int) > -1 {
 		query.Where("status = ?", status)
 	}
+	// 游标分页, 返回id小于游标的记录
+	beforeId, ok := params["BeforeId"]
+	if ok && beforeId.(int64) > 0 {
+		query.Where("id < ?", beforeId)
+	}
+	// 非管理员只能查看所在项目任务的日志
+	if projectIds, ok := params["ProjectIds"].([]int); ok {
+		query.Where("task_id IN (?)", Db.Model(&Task{}).Select("id").Where("project_id IN ?", projectIds))
+	}
 }
 
 // 统计相关方法
@@ -175,55 +242,51 @@ type DailyStats struct {
 	Failed  int    `json:"failed"`
 }
 
-// GetLast7DaysTrend 获取最近7天的执行趋势
-func (taskLog *TaskLog) GetLast7DaysTrend() ([]DailyStats, error) {
+// GetLast7DaysTrend 获取最近7天的执行趋势, params 中的 ProjectIds 限制统计的项目
+func (taskLog *TaskLog) GetLast7DaysTrend(params CommonMap) ([]DailyStats, error) {
 	var stats []DailyStats
 
 	// 使用 Go 计算7天前的日期，兼容所有数据库
 	sevenDaysAgo := time.Now().AddDate(0, 0, -7).Format("2006-01-02")
 	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
 
-	err := Db.Raw(`
-		SELECT 
-			DATE(start_time) as date,
+	query := Db.Model(&TaskLog{}).
+		Select(`DATE(start_time) as date,
 			COUNT(*) as total,
 			SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) as success,
-			SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) as failed
-		FROM task_log
-		WHERE start_time >= ? AND start_time < ?
-		GROUP BY DATE(start_time)
-		ORDER BY date DESC
-	`, Finish, Failure, sevenDaysAgo, tomorrow).Scan(&stats).Error
+			SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) as failed`, Finish, Failure).
+		Where("start_time >= ? AND start_time < ?", sevenDaysAgo, tomorrow)
+	taskLog.parseWhere(query, params)
+	err := query.Group("DATE(start_time)").Order("date DESC").Scan(&stats).Error
 
 	return stats, err
 }
 
-// GetTodayStats 获取今日统计数据
-func (taskLog *TaskLog) GetTodayStats() (total, success, failed int64, err error) {
+// GetTodayStats 获取今日统计数据, params 中的 ProjectIds 限制统计的项目
+func (taskLog *TaskLog) GetTodayStats(params CommonMap) (total, success, failed int64, err error) {
 	// 使用 Go 计算今天的日期范围
 	today := time.Now().Format("2006-01-02")
 	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	todayQuery := func() *gorm.DB {
+		query := Db.Model(&TaskLog{}).Where("start_time >= ? AND start_time < ?", today, tomorrow)
+		taskLog.parseWhere(query, params)
+		return query
+	}
 
 	// 今日总执行次数
-	err = Db.Model(&TaskLog{}).
-		Where("start_time >= ? AND start_time < ?", today, tomorrow).
-		Count(&total).Error
+	err = todayQuery().Count(&total).Error
 	if err != nil {
 		return
 	}
 
 	// 今日成功次数
-	err = Db.Model(&TaskLog{}).
-		Where("start_time >= ? AND start_time < ? AND status = ?", today, tomorrow, Finish).
-		Count(&success).Error
+	err = todayQuery().Where("status = ?", Finish).Count(&success).Error
 	if err != nil {
 		return
 	}
 
 	// 今日失败次数
-	err = Db.Model(&TaskLog{}).
-		Where("start_time >= ? AND start_time < ? AND status = ?", today, tomorrow, Failure).
-		Count(&failed).Error
+	err = todayQuery().Where("status = ?", Failure).Count(&failed).Error
 
 	return
 }
@@ -0,0 +1,49 @@
+package models
+
+import "gorm.io/gorm"
+
+// 任务执行尝试记录, 配置了重试时保留每次执行的输出
+type TaskLogAttempt struct {
+	Id        int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	TaskLogId int64     `json:"task_log_id" gorm:"not null;index;default:0"`
+	Attempt   int8      `json:"attempt" gorm:"type:tinyint;not null;default:1"`
+	Status    Status    `json:"status" gorm:"type:tinyint;not null;default:0"`
+	Error     string    `json:"error" gorm:"type:varchar(512);not null;default:''"`
+	Result    string    `json:"result" gorm:"type:mediumtext;not null"`
+	StartTime LocalTime `json:"start_time" gorm:"column:start_time;index"`
+	EndTime   LocalTime `json:"end_time" gorm:"column:end_time"`
+}
+
+// 批量新增
+func (attempt *TaskLogAttempt) BatchCreate(attempts []TaskLogAttempt) error {
+	if len(attempts) == 0 {
+		return nil
+	}
+
+	return Db.Create(&attempts).Error
+}
+
+func (attempt *TaskLogAttempt) List(taskLogId int64) ([]TaskLogAttempt, error) {
+	list := make([]TaskLogAttempt, 0)
+	err := Db.Where("task_log_id = ?", taskLogId).Order("attempt ASC").Find(&list).Error
+
+	return list, err
+}
+
+// 删除多条任务日志的记录, logIds为任务日志id的子查询
+func (attempt *TaskLogAttempt) RemoveByLogs(logIds *gorm.DB) (int64, error) {
+	result := Db.Where("task_log_id IN (?)", logIds).Delete(&TaskLogAttempt{})
+	return result.RowsAffected, result.Error
+}
+
+// 删除任务日志的记录
+func (attempt *TaskLogAttempt) RemoveByLog(taskLogId int64) (int64, error) {
+	result := Db.Where("task_log_id = ?", taskLogId).Delete(&TaskLogAttempt{})
+	return result.RowsAffected, result.Error
+}
+
+// 清空表
+func (attempt *TaskLogAttempt) Clear() (int64, error) {
+	result := Db.Where("1=1").Delete(&TaskLogAttempt{})
+	return result.RowsAffected, result.Error
+}
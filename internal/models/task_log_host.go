@@ -0,0 +1,82 @@
+package models
+
+import "gorm.io/gorm"
+
+// 多主机任务每台主机的执行记录
+type TaskLogHost struct {
+	Id        int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	TaskLogId int64     `json:"task_log_id" gorm:"not null;index;default:0"`
+	HostId    int       `json:"host_id" gorm:"not null;default:0"`
+	Alias     string    `json:"alias" gorm:"type:varchar(32);not null;default:''"`
+	Name      string    `json:"name" gorm:"type:varchar(64);not null;default:''"`
+	Port      int       `json:"port" gorm:"not null;default:0"`
+	Status    Status    `json:"status" gorm:"type:tinyint;not null;default:0"`
+	ExitCode  int       `json:"exit_code" gorm:"not null;default:0"` // 无法获取时为-1
+	Error     string    `json:"error" gorm:"type:varchar(512);not null;default:''"`
+	Output    string    `json:"output" gorm:"type:mediumtext;not null"`
+	StartTime LocalTime `json:"start_time" gorm:"column:start_time;index"`
+	EndTime   LocalTime `json:"end_time" gorm:"column:end_time"`
+}
+
+// 任务日志的主机执行汇总
+type TaskLogHostSummary struct {
+	TaskLogId int64
+	Total     int
+	Failed    int
+}
+
+// 批量新增
+func (logHost *TaskLogHost) BatchCreate(hosts []TaskLogHost) error {
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	return Db.Create(&hosts).Error
+}
+
+func (logHost *TaskLogHost) List(taskLogId int64) ([]TaskLogHost, error) {
+	list := make([]TaskLogHost, 0)
+	err := Db.Where("task_log_id = ?", taskLogId).Order("id ASC").Find(&list).Error
+
+	return list, err
+}
+
+// 按任务日志汇总主机总数及失败数
+func (logHost *TaskLogHost) Summaries(taskLogIds []int64) (map[int64]TaskLogHostSummary, error) {
+	summaries := make(map[int64]TaskLogHostSummary)
+	if len(taskLogIds) == 0 {
+		return summaries, nil
+	}
+	list := make([]TaskLogHostSummary, 0)
+	err := Db.Model(&TaskLogHost{}).
+		Select("task_log_id, COUNT(*) AS total, SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS failed", Failure).
+		Where("task_log_id IN ?", taskLogIds).
+		Group("task_log_id").
+		Scan(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range list {
+		summaries[item.TaskLogId] = item
+	}
+
+	return summaries, nil
+}
+
+// 删除多条任务日志的记录, logIds为任务日志id的子查询
+func (logHost *TaskLogHost) RemoveByLogs(logIds *gorm.DB) (int64, error) {
+	result := Db.Where("task_log_id IN (?)", logIds).Delete(&TaskLogHost{})
+	return result.RowsAffected, result.Error
+}
+
+// 删除任务日志的记录
+func (logHost *TaskLogHost) RemoveByLog(taskLogId int64) (int64, error) {
+	result := Db.Where("task_log_id = ?", taskLogId).Delete(&TaskLogHost{})
+	return result.RowsAffected, result.Error
+}
+
+// 清空表
+func (logHost *TaskLogHost) Clear() (int64, error) {
+	result := Db.Where("1=1").Delete(&TaskLogHost{})
+	return result.RowsAffected, result.Error
+}
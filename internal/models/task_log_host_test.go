@@ -0,0 +1,86 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTaskLogHostSummaries(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&TaskLogHost{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	oldDb := Db
+	Db = db
+	defer func() { Db = oldDb }()
+
+	now := LocalTime(time.Now())
+	logHostModel := new(TaskLogHost)
+	err := logHostModel.BatchCreate([]TaskLogHost{
+		{TaskLogId: 1, HostId: 1, Status: Finish, StartTime: now, EndTime: now},
+		{TaskLogId: 1, HostId: 2, Status: Failure, ExitCode: 1, StartTime: now, EndTime: now},
+		{TaskLogId: 1, HostId: 3, Status: Failure, ExitCode: -1, StartTime: now, EndTime: now},
+		{TaskLogId: 2, HostId: 1, Status: Finish, StartTime: now, EndTime: now},
+		{TaskLogId: 3, HostId: 1, Status: Failure, StartTime: now, EndTime: now},
+	})
+	if err != nil {
+		t.Fatalf("BatchCreate error: %v", err)
+	}
+
+	summaries, err := logHostModel.Summaries([]int64{1, 2, 4})
+	if err != nil {
+		t.Fatalf("Summaries error: %v", err)
+	}
+	if len(summaries) != 2 {
+		t.Fatalf("expected 2 summaries, got %v", summaries)
+	}
+	if s := summaries[1]; s.Total != 3 || s.Failed != 2 {
+		t.Errorf("task log 1 summary = %+v", s)
+	}
+	if s := summaries[2]; s.Total != 1 || s.Failed != 0 {
+		t.Errorf("task log 2 summary = %+v", s)
+	}
+
+	hosts, err := logHostModel.List(1)
+	if err != nil || len(hosts) != 3 || hosts[1].ExitCode != 1 {
+		t.Fatalf("List = %+v, %v", hosts, err)
+	}
+}
+
+// 清理日志时按日志删除主机和执行尝试记录, 不受记录自身开始时间影响
+func TestTaskLogRemoveByDaysRemovesChildren(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&TaskLog{}, &TaskLogHost{}, &TaskLogAttempt{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	oldDb := Db
+	Db = db
+	defer func() { Db = oldDb }()
+
+	old := time.Now().AddDate(0, 0, -3)
+	for i, start := range []time.Time{old, time.Now()} {
+		taskLog := &TaskLog{Id: int64(i + 1), Name: "backup", StartTime: LocalTime(start)}
+		if _, err := taskLog.Create(); err != nil {
+			t.Fatal(err)
+		}
+		// 主机记录在日志创建之后开始
+		later := LocalTime(start.Add(2 * 24 * time.Hour))
+		_ = new(TaskLogHost).BatchCreate([]TaskLogHost{{TaskLogId: taskLog.Id, HostId: 1, StartTime: later, EndTime: later}})
+		_ = new(TaskLogAttempt).BatchCreate([]TaskLogAttempt{{TaskLogId: taskLog.Id, Attempt: 1, StartTime: later, EndTime: later}})
+	}
+
+	if removed, err := new(TaskLog).RemoveByDays(1); err != nil || removed != 1 {
+		t.Fatalf("RemoveByDays = %d, %v", removed, err)
+	}
+	var hosts, attempts int64
+	db.Model(&TaskLogHost{}).Where("task_log_id = ?", 1).Count(&hosts)
+	db.Model(&TaskLogAttempt{}).Where("task_log_id = ?", 1).Count(&attempts)
+	if hosts != 0 || attempts != 0 {
+		t.Errorf("records of removed log remain: hosts %d, attempts %d", hosts, attempts)
+	}
+	db.Model(&TaskLogHost{}).Count(&hosts)
+	db.Model(&TaskLogAttempt{}).Count(&attempts)
+	if hosts != 1 || attempts != 1 {
+		t.Errorf("records of kept log should remain: hosts %d, attempts %d", hosts, attempts)
+	}
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 版本快照中不保存的任务字段, 状态等运行信息不属于任务配置
+var revisionIgnoreFields = []string{
+	"id", "status", "created", "deleted", "next_run_time", "owner", "created_by", "revision", "hosts",
+}
+
+// 任务版本, 每次保存任务配置生成一个不可修改的版本
+type TaskRevision struct {
+	Id        int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	TaskId    int       `json:"task_id" gorm:"not null;uniqueIndex:idx_task_revision"`
+	Version   int       `json:"version" gorm:"not null;uniqueIndex:idx_task_revision"`
+	Content   string    `json:"content,omitempty" gorm:"type:text;not null"` // 任务配置快照, JSON格式
+	Source    int       `json:"source" gorm:"not null;default:0"`            // 回滚生成的版本为恢复的版本号
+	UserId    int       `json:"user_id" gorm:"not null;default:0"`
+	Username  string    `json:"username" gorm:"type:varchar(32);not null;default:''"`
+	CreatedAt time.Time `json:"created" gorm:"column:created;autoCreateTime"`
+}
+
+// Create 保存任务配置为新版本并更新任务的当前版本号, 配置与最新版本相同时不生成新版本
+func (rev *TaskRevision) Create(task Task) (int, error) {
+	content, err := revisionContent(task)
+	if err != nil {
+		return 0, err
+	}
+
+	err = Db.Transaction(func(tx *gorm.DB) error {
+		latest := new(TaskRevision)
+		if err := tx.Where("task_id = ?", task.Id).Order("version DESC").Limit(1).Find(latest).Error; err != nil {
+			return err
+		}
+		if latest.Id > 0 && latest.Content == content {
+			rev.Version = latest.Version
+			return nil
+		}
+		rev.Id = 0
+		rev.TaskId = task.Id
+		rev.Version = latest.Version + 1
+		rev.Content = content
+		if err := tx.Create(rev).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&Task{}).Where("id = ?", task.Id).UpdateColumn("revision", rev.Version).Error
+	})
+
+	return rev.Version, err
+}
+
+// List 任务的所有版本, 不返回配置快照
+func (rev *TaskRevision) List(taskId int) ([]TaskRevision, error) {
+	list := make([]TaskRevision, 0)
+	err := Db.Omit("content").Where("task_id = ?", taskId).Order("version DESC").Find(&list).Error
+
+	return list, err
+}
+
+// Find 查找任务的指定版本, 不存在时Id为0
+func (rev *TaskRevision) Find(taskId, version int) error {
+	return Db.Where("task_id = ? AND version = ?", taskId, version).Limit(1).Find(rev).Error
+}
+
+// Remove 删除任务的所有版本
+func (rev *TaskRevision) Remove(taskId int) error {
+	return Db.Where("task_id = ?", taskId).Delete(&TaskRevision{}).Error
+}
+
+// Snapshot 版本保存的任务配置及关联的主机
+func (rev *TaskRevision) Snapshot() (Task, []int, error) {
+	var task Task
+	var hosts struct {
+		HostIds []int `json:"host_ids"`
+	}
+	if rev.Content == "" {
+		return task, nil, errors.New("empty task revision")
+	}
+	if err := json.Unmarshal([]byte(rev.Content), &task); err != nil {
+		return task, nil, err
+	}
+	if err := json.Unmarshal([]byte(rev.Content), &hosts); err != nil {
+		return task, nil, err
+	}
+
+	return task, hosts.HostIds, nil
+}
+
+// Fields 版本快照的字段, 用于比较两个版本
+func (rev *TaskRevision) Fields() map[string]interface{} {
+	fields := make(map[string]interface{})
+	_ = json.Unmarshal([]byte(rev.Content), &fields)
+
+	return fields
+}
+
+// 任务配置快照, 关联的主机只保存id
+func revisionContent(task Task) (string, error) {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return "", err
+	}
+	fields := make(map[string]interface{})
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return "", err
+	}
+	for _, field := range revisionIgnoreFields {
+		delete(fields, field)
+	}
+	hostIds := make([]int, 0, len(task.Hosts))
+	for _, host := range task.Hosts {
+		hostIds = append(hostIds, host.HostId)
+	}
+	sort.Ints(hostIds)
+	fields["host_ids"] = hostIds
+
+	data, err = json.Marshal(fields)
+
+	return string(data), err
+}
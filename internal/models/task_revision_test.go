@@ -0,0 +1,74 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestTaskRevision(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&Task{}, &TaskRevision{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	oldDb := Db
+	Db = db
+	defer func() { Db = oldDb }()
+
+	task := Task{Name: "backup", Spec: "0 0 * * * *", Protocol: TaskRPC, Command: "echo v1", Status: Enabled}
+	task.Hosts = []TaskHostDetail{{TaskHost: TaskHost{HostId: 3}}, {TaskHost: TaskHost{HostId: 1}}}
+	task.Id, _ = task.Create()
+
+	revisionModel := new(TaskRevision)
+	if version, err := revisionModel.Create(task); err != nil || version != 1 {
+		t.Fatalf("first revision = %d, %v", version, err)
+	}
+	// 配置没有变化时不生成新版本, 状态不属于配置
+	task.Status = Disabled
+	if version, _ := (&TaskRevision{}).Create(task); version != 1 {
+		t.Errorf("unchanged config should keep revision 1, got %d", version)
+	}
+	task.Command = "echo v2"
+	if version, _ := (&TaskRevision{UserId: 2, Username: "alice"}).Create(task); version != 2 {
+		t.Errorf("changed config should create revision 2, got %d", version)
+	}
+
+	var current Task
+	db.First(&current, task.Id)
+	if current.Revision != 2 {
+		t.Errorf("task revision = %d, want 2", current.Revision)
+	}
+	list, _ := revisionModel.List(task.Id)
+	if len(list) != 2 || list[0].Version != 2 || list[0].Username != "alice" || list[0].Content != "" {
+		t.Errorf("list should be newest first without content, got %+v", list)
+	}
+
+	first, second := new(TaskRevision), new(TaskRevision)
+	_ = first.Find(task.Id, 1)
+	_ = second.Find(task.Id, 2)
+	diff := DiffFields(first.Fields(), second.Fields())
+	if len(diff) != 1 || diff["command"] != [2]interface{}{"echo v1", "echo v2"} {
+		t.Errorf("diff = %v, want only command changed", diff)
+	}
+
+	restored, hostIds, err := first.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Command != "echo v1" || restored.Spec != task.Spec || len(hostIds) != 2 || hostIds[0] != 1 {
+		t.Errorf("snapshot = %+v, hosts %v", restored, hostIds)
+	}
+
+	missing := new(TaskRevision)
+	if err := missing.Find(task.Id, 9); err != nil || missing.Id != 0 {
+		t.Errorf("missing revision should have zero id, got %d, %v", missing.Id, err)
+	}
+	_ = revisionModel.Remove(task.Id)
+	if list, _ := revisionModel.List(task.Id); len(list) != 0 {
+		t.Errorf("revisions should be removed, got %d", len(list))
+	}
+}
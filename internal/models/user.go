@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/tabortao/gocron/internal/modules/utils"
+	"gorm.io/gorm"
 )
 
 const PasswordSaltLength = 6
@@ -21,7 +22,10 @@ type User struct {
 	UpdatedAt    time.Time `json:"updated" gorm:"column:updated;autoUpdateTime"`
 	IsAdmin      int8      `json:"is_admin" gorm:"type:tinyint;not null;default:0"`
 	Status       Status    `json:"status" gorm:"type:tinyint;not null;default:1"`
-	BaseModel    `json:"-" gorm:"-"`
+	// 单点登录创建的用户来源及在身份提供方的用户标识, 本地用户为空
+	Source     string `json:"source" gorm:"type:varchar(16);not null;default:''"`
+	ExternalId string `json:"-" gorm:"type:varchar(128);not null;default:'';index"`
+	BaseModel  `json:"-" gorm:"-"`
 }
 
 // 新增
@@ -89,6 +93,21 @@ func (user *User) Find(id int) error {
 	return Db.First(user, id).Error
 }
 
+// FindByExternalId 按单点登录的用户标识查找, 不存在时Id为0
+func (user *User) FindByExternalId(source, externalId string) error {
+	return Db.Where("source = ? AND external_id = ?", source, externalId).Limit(1).Find(user).Error
+}
+
+// FindByEmail 按邮箱查找, 不存在时Id为0
+func (user *User) FindByEmail(email string) error {
+	return Db.Where("email = ?", email).Limit(1).Find(user).Error
+}
+
+// FindByName 按用户名查找, 不存在时Id为0
+func (user *User) FindByName(name string) error {
+	return Db.Where("name = ?", name).Limit(1).Find(user).Error
+}
+
 // 用户名是否存在
 func (user *User) UsernameExists(username string, uid int) (int64, error) {
 	var count int64
@@ -114,11 +133,30 @@ func (user *User) EmailExists(email string, uid int) (int64, error) {
 func (user *User) List(params CommonMap) ([]User, error) {
 	user.parsePageAndPageSize(params)
 	list := make([]User, 0)
-	err := Db.Order("id DESC").Limit(user.PageSize).Offset(user.pageLimitOffset()).Find(&list).Error
+	query := Db.Order("id DESC")
+	user.parseWhere(query, params)
+	err := query.Limit(user.PageSize).Offset(user.pageLimitOffset()).Find(&list).Error
 
 	return list, err
 }
 
+// 解析where
+func (user *User) parseWhere(query *gorm.DB, params CommonMap) {
+	name, ok := params["Name"]
+	if ok && name.(string) != "" {
+		query.Where("name LIKE ?", "%"+name.(string)+"%")
+	}
+	status, ok := params["Status"]
+	if ok && status.(int) > -1 {
+		query.Where("status = ?", status)
+	}
+	// 游标分页, 返回id小于游标的记录
+	beforeId, ok := params["BeforeId"]
+	if ok && beforeId.(int) > 0 {
+		query.Where("id < ?", beforeId)
+	}
+}
+
 func (user *User) Total() (int64, error) {
 	var count int64
 	err := Db.Model(&User{}).Count(&count).Error
@@ -15,6 +15,7 @@ type ResponseWrapper struct {
 	StatusCode int
 	Body       string
 	Header     http.Header
+	Err        error // 请求未完成时的错误, 如连接失败、超时
 }
 
 type httpDoer interface {
@@ -85,12 +86,14 @@ func request(req *http.Request, timeout int) ResponseWrapper {
 	resp, err := client.Do(req)
 	if err != nil {
 		wrapper.Body = fmt.Sprintf("执行HTTP请求错误-%s", err.Error())
+		wrapper.Err = err
 		return wrapper
 	}
 	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		wrapper.Body = fmt.Sprintf("读取HTTP请求返回值失败-%s", err.Error())
+		wrapper.Err = err
 		return wrapper
 	}
 	wrapper.StatusCode = resp.StatusCode
@@ -106,5 +109,5 @@ func setRequestHeader(req *http.Request) {
 
 func createRequestError(err error) ResponseWrapper {
 	errorMessage := fmt.Sprintf("创建HTTP请求错误-%s", err.Error())
-	return ResponseWrapper{0, errorMessage, make(http.Header)}
+	return ResponseWrapper{0, errorMessage, make(http.Header), err}
 }
@@ -81,4 +81,48 @@ var enUS = map[string]string{
 	"rpc_unavailable":                        "Unable to connect to remote server",
 	"rpc_timeout":                            "Execution timeout, forcibly terminated",
 	"rpc_manual_stop":                        "Manually stopped",
+	"backfill_time_invalid":                  "Invalid time format, expected YYYY-MM-DD HH:MM:SS",
+	"backfill_range_invalid":                 "End time must be after start time",
+	"backfill_too_many_runs":                 "Too many runs in the time range, at most 1000 allowed",
+	"backfill_child_task_unsupported":        "Child tasks cannot be backfilled",
+	"backfill_no_runs":                       "No scheduled runs in the time range",
+	"backfill_started":                       "Backfill started, please check task log for results",
+	"retry_on_invalid":                       "Invalid retry condition, allowed: timeout, unavailable, exit_code, http_5xx",
+	"retry_exit_codes_invalid":               "Retry exit codes must be comma separated numbers between 0 and 255",
+	"retry_exit_codes_required":              "Please enter the exit codes to retry on",
+	"host_group_invalid":                     "Invalid host group, use letters, digits, \"_\", \".\", \"-\" or \"/\"",
+	"host_labels_invalid":                    "Invalid host labels, use key=value separated by commas, e.g. env=prod,role=worker",
+	"host_selector_invalid":                  "Invalid label selector, use key=value, key!=value or key separated by commas",
+	"agent_labels_invalid":                   "Invalid group or labels",
+	"batch_percent_range_0_100":              "Batch percentage must be between 0 and 100",
+	"project_not_exist":                      "Project does not exist",
+	"project_name_exists":                    "Project name already exists",
+	"project_in_use_cannot_delete":           "Project still has tasks or hosts and cannot be deleted",
+	"default_project_cannot_delete":          "The default project cannot be deleted",
+	"project_member_invalid":                 "Invalid project member or role",
+	"host_not_in_project":                    "Selected hosts must belong to the task project",
+	"notify_receiver_not_in_project":         "Notification receivers must belong to the task project or be shared",
+	"cross_project_dependency_forbidden":     "Cross-project dependency is not allowed",
+	"host_used_by_other_project":             "Host is still used by tasks of its current project",
+	"task_revision_not_found":                "Task revision not found",
+	"task_rollback_success":                  "Task rolled back, a new revision has been saved",
+	"sso_not_enabled":                        "Single sign-on is not enabled",
+	"sso_login_failed":                       "Single sign-on failed, please try again or contact the administrator",
+	"sso_user_disabled":                      "User is disabled",
+	"password_login_disabled":                "Password login is disabled, please use single sign-on",
+	"break_glass_user_invalid":               "Break-glass account must be an enabled administrator",
+	"api_token_scope_invalid":                "Invalid API token scope",
+	"api_token_created":                      "API token created, copy it now, it will not be shown again",
+	"api_token_not_found":                    "API token not found",
+	"user_not_exist":                         "User does not exist",
+	"notify_channel_not_exist":               "Notification channel does not exist",
+	"notify_channel_type_invalid":            "Invalid notification channel type",
+	"notify_channel_target_required":         "Notification address is required",
+	"invalid_cursor":                         "Invalid pagination cursor",
+	"invalid_request_body":                   "Invalid request body",
+	"backfill_reboot_unsupported":            "@reboot tasks cannot be backfilled",
+	"backfill_running":                       "A backfill of this task is already running",
+	"node_token_push_failed":                 "Failed to send the new token to the node, the current token is still in use",
+	"node_token_revoke_not_pushed":           "Token revoked on the server, but the node could not be notified and still accepts the old token. Replace the node token file or re-register the node",
+	"command_signature_schedule_var":         "Signed commands can not contain schedule time variables",
 }
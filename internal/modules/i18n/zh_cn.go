@@ -81,4 +81,48 @@ var zhCN = map[string]string{
 	"rpc_unavailable":                        "无法连接远程服务器",
 	"rpc_timeout":                            "执行超时, 强制结束",
 	"rpc_manual_stop":                        "手动停止",
+	"backfill_time_invalid":                  "时间格式错误, 格式为 YYYY-MM-DD HH:MM:SS",
+	"backfill_range_invalid":                 "结束时间必须晚于开始时间",
+	"backfill_too_many_runs":                 "时间范围内的执行次数过多, 最多1000次",
+	"backfill_child_task_unsupported":        "子任务不支持补跑",
+	"backfill_no_runs":                       "时间范围内没有调度时间点",
+	"backfill_started":                       "补跑已开始, 请到任务日志中查看结果",
+	"retry_on_invalid":                       "重试条件无效, 可选: timeout, unavailable, exit_code, http_5xx",
+	"retry_exit_codes_invalid":               "重试退出码必须是0-255之间的数字, 多个用逗号分隔",
+	"retry_exit_codes_required":              "请填写需要重试的退出码",
+	"host_group_invalid":                     "主机分组无效, 只能包含字母、数字、\"_\"、\".\"、\"-\"和\"/\"",
+	"host_labels_invalid":                    "主机标签无效, 格式为 key=value, 多个用逗号分隔, 如 env=prod,role=worker",
+	"host_selector_invalid":                  "标签选择器无效, 支持 key=value、key!=value 或 key, 多个用逗号分隔",
+	"agent_labels_invalid":                   "分组或标签无效",
+	"batch_percent_range_0_100":              "每批主机百分比范围0-100",
+	"project_not_exist":                      "项目不存在",
+	"project_name_exists":                    "项目名称已存在",
+	"project_in_use_cannot_delete":           "项目下仍有任务或主机，不能删除",
+	"default_project_cannot_delete":          "默认项目不能删除",
+	"project_member_invalid":                 "项目成员或角色无效",
+	"host_not_in_project":                    "所选主机必须属于任务所在项目",
+	"notify_receiver_not_in_project":         "通知接收者必须属于任务所在项目或为共享接收者",
+	"cross_project_dependency_forbidden":     "不允许依赖其他项目的任务",
+	"host_used_by_other_project":             "主机仍被原项目的任务使用, 不能移动到其他项目",
+	"task_revision_not_found":                "任务版本不存在",
+	"task_rollback_success":                  "任务已回滚, 已保存为新版本",
+	"sso_not_enabled":                        "未启用单点登录",
+	"sso_login_failed":                       "单点登录失败, 请重试或联系管理员",
+	"sso_user_disabled":                      "用户已被禁用",
+	"password_login_disabled":                "已禁用密码登录, 请使用单点登录",
+	"break_glass_user_invalid":               "应急账户必须是已启用的管理员",
+	"api_token_scope_invalid":                "API令牌权限范围无效",
+	"api_token_created":                      "API令牌已创建, 请立即复制, 关闭后无法再次查看",
+	"api_token_not_found":                    "API令牌不存在",
+	"user_not_exist":                         "用户不存在",
+	"notify_channel_not_exist":               "通知渠道不存在",
+	"notify_channel_type_invalid":            "通知渠道类型无效",
+	"notify_channel_target_required":         "通知地址不能为空",
+	"invalid_cursor":                         "分页游标无效",
+	"invalid_request_body":                   "请求内容无效",
+	"backfill_reboot_unsupported":            "@reboot任务不支持补跑",
+	"backfill_running":                       "该任务正在补跑中, 请等待完成后再试",
+	"node_token_push_failed":                 "新令牌下发到节点失败, 当前令牌继续有效",
+	"node_token_revoke_not_pushed":           "令牌已在服务端吊销, 但未能通知节点, 节点仍接受原令牌, 请替换节点的令牌文件或重新注册节点",
+	"command_signature_schedule_var":         "签名的命令不能包含调度时间变量",
 }
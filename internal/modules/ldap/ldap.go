@@ -0,0 +1,170 @@
+// Package ldap 通过LDAP/Active Directory验证用户名和密码
+package ldap
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	ldapv3 "github.com/go-ldap/ldap/v3"
+)
+
+const timeout = 10 * time.Second
+
+var ErrInvalidCredentials = errors.New("ldap: invalid credentials")
+
+// Config LDAP服务器及查询配置
+type Config struct {
+	Url                string
+	StartTLS           bool
+	InsecureSkipVerify bool
+	CAFile             string
+	BindDN             string // 查询用户的账户, 为空时匿名查询
+	BindPassword       string
+	BaseDN             string
+	UserFilter         string // %s替换为转义后的用户名, 如 (uid=%s)
+	UsernameAttribute  string
+	EmailAttribute     string
+	GroupBaseDN        string // 为空时使用BaseDN
+	GroupFilter        string // %s替换为转义后的用户DN, 如 (member=%s), 为空时不查询用户组
+	GroupAttribute     string
+}
+
+// Entry 验证通过的用户
+type Entry struct {
+	DN       string
+	Username string
+	Email    string
+	Groups   []string
+}
+
+// Authenticate 查找用户后以用户DN和密码绑定, 用户不存在或密码错误时返回 ErrInvalidCredentials
+func Authenticate(config Config, username, password string) (*Entry, error) {
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+	conn, err := dial(config)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err = serviceBind(conn, config); err != nil {
+		return nil, err
+	}
+	filter := fmt.Sprintf(config.UserFilter, ldapv3.EscapeFilter(username))
+	result, err := conn.Search(ldapv3.NewSearchRequest(
+		config.BaseDN, ldapv3.ScopeWholeSubtree, ldapv3.NeverDerefAliases, 2, int(timeout.Seconds()), false,
+		filter, []string{config.UsernameAttribute, config.EmailAttribute}, nil,
+	))
+	if err != nil && !ldapv3.IsErrorWithCode(err, ldapv3.LDAPResultSizeLimitExceeded) {
+		return nil, err
+	}
+	// 用户名须唯一对应一个用户
+	if result == nil || len(result.Entries) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+	user := result.Entries[0]
+	if err = conn.Bind(user.DN, password); err != nil {
+		if ldapv3.IsErrorWithCode(err, ldapv3.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	entry := &Entry{
+		DN:       user.DN,
+		Username: user.GetAttributeValue(config.UsernameAttribute),
+		Email:    user.GetAttributeValue(config.EmailAttribute),
+		Groups:   make([]string, 0),
+	}
+	if entry.Username == "" {
+		entry.Username = username
+	}
+	if config.GroupFilter == "" {
+		return entry, nil
+	}
+	// 以查询账户身份查询用户组, 普通用户可能没有读取组的权限
+	if err = serviceBind(conn, config); err != nil {
+		return nil, err
+	}
+	groupBaseDN := config.GroupBaseDN
+	if groupBaseDN == "" {
+		groupBaseDN = config.BaseDN
+	}
+	groups, err := conn.Search(ldapv3.NewSearchRequest(
+		groupBaseDN, ldapv3.ScopeWholeSubtree, ldapv3.NeverDerefAliases, 0, int(timeout.Seconds()), false,
+		fmt.Sprintf(config.GroupFilter, ldapv3.EscapeFilter(user.DN)), []string{config.GroupAttribute}, nil,
+	))
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups.Entries {
+		if name := group.GetAttributeValue(config.GroupAttribute); name != "" {
+			entry.Groups = append(entry.Groups, name)
+		}
+	}
+
+	return entry, nil
+}
+
+func dial(config Config) (*ldapv3.Conn, error) {
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := ldapv3.DialURL(config.Url,
+		ldapv3.DialWithDialer(&net.Dialer{Timeout: timeout}),
+		ldapv3.DialWithTLSConfig(tlsConfig),
+	)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(timeout)
+	if config.StartTLS && !strings.HasPrefix(config.Url, "ldaps://") {
+		if err = conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return conn, nil
+}
+
+func newTLSConfig(config Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+	if u, err := url.Parse(config.Url); err == nil {
+		tlsConfig.ServerName = u.Hostname()
+	}
+	if config.CAFile != "" {
+		data, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("ldap: no certificate found in %s", config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+// 以查询账户绑定, 未配置时匿名绑定
+func serviceBind(conn *ldapv3.Conn, config Config) error {
+	if config.BindDN == "" {
+		return conn.UnauthenticatedBind("")
+	}
+
+	return conn.Bind(config.BindDN, config.BindPassword)
+}
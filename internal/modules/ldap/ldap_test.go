@@ -0,0 +1,243 @@
+package ldap
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	ldapv3 "github.com/go-ldap/ldap/v3"
+)
+
+const (
+	serviceDN       = "cn=admin,dc=example,dc=org"
+	servicePassword = "adminpw"
+)
+
+type testEntry struct {
+	dn       string
+	password string
+	attrs    map[string][]string
+}
+
+// 进程内的LDAP测试服务器, 支持简单绑定及单个等值条件的查询
+// 用户组只有查询账户可以读取, 用于验证查询用户组前重新绑定查询账户
+type testServer struct {
+	listener net.Listener
+	entries  []testEntry
+}
+
+func newTestServer(t *testing.T) *testServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testServer{listener: listener, entries: []testEntry{
+		{dn: serviceDN, password: servicePassword},
+		{dn: "uid=alice,ou=people,dc=example,dc=org", password: "alicepw", attrs: map[string][]string{
+			"objectClass": {"person"}, "uid": {"alice"}, "mail": {"alice@example.com"},
+		}},
+		{dn: "uid=bob,ou=people,dc=example,dc=org", password: "bobpw", attrs: map[string][]string{
+			"objectClass": {"person"}, "uid": {"bob"},
+		}},
+		{dn: "uid=dup,ou=people,dc=example,dc=org", password: "duppw", attrs: map[string][]string{
+			"objectClass": {"person"}, "uid": {"dup"},
+		}},
+		{dn: "cn=dup,ou=other,dc=example,dc=org", password: "duppw", attrs: map[string][]string{
+			"objectClass": {"person"}, "uid": {"dup"},
+		}},
+		{dn: "cn=ops,ou=groups,dc=example,dc=org", attrs: map[string][]string{
+			"objectClass": {"groupOfNames"}, "cn": {"ops"},
+			"member": {"uid=alice,ou=people,dc=example,dc=org"},
+		}},
+		{dn: "cn=dev,ou=groups,dc=example,dc=org", attrs: map[string][]string{
+			"objectClass": {"groupOfNames"}, "cn": {"dev"},
+			"member": {"uid=alice,ou=people,dc=example,dc=org", "uid=bob,ou=people,dc=example,dc=org"},
+		}},
+	}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { _ = listener.Close() })
+
+	return s
+}
+
+func (s *testServer) url() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+func (s *testServer) serve(conn net.Conn) {
+	defer conn.Close()
+	boundDN := ""
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		messageId := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+		switch op.Tag {
+		case ldapv3.ApplicationBindRequest:
+			name, _ := op.Children[1].Value.(string)
+			password := op.Children[2].Data.String()
+			code := uint16(ldapv3.LDAPResultInvalidCredentials)
+			if name == "" && password == "" {
+				code = ldapv3.LDAPResultSuccess
+			}
+			for _, entry := range s.entries {
+				if entry.dn == name && entry.password != "" && entry.password == password {
+					code = ldapv3.LDAPResultSuccess
+				}
+			}
+			if code == ldapv3.LDAPResultSuccess {
+				boundDN = name
+			}
+			s.respond(conn, messageId, ldapv3.ApplicationBindResponse, code)
+		case ldapv3.ApplicationSearchRequest:
+			s.search(conn, messageId, op, boundDN)
+		case ldapv3.ApplicationUnbindRequest:
+			return
+		default:
+			s.respond(conn, messageId, ldapv3.ApplicationExtendedResponse, ldapv3.LDAPResultUnwillingToPerform)
+		}
+	}
+}
+
+func (s *testServer) search(conn net.Conn, messageId int64, op *ber.Packet, boundDN string) {
+	baseDN, _ := op.Children[0].Value.(string)
+	sizeLimit, _ := op.Children[3].Value.(int64)
+	filter, err := ldapv3.DecompileFilter(op.Children[6])
+	attr, value, found := strings.Cut(strings.Trim(filter, "()"), "=")
+	if err != nil || !found {
+		s.respond(conn, messageId, ldapv3.ApplicationSearchResultDone, ldapv3.LDAPResultUnwillingToPerform)
+		return
+	}
+	if attr == "member" && boundDN != serviceDN {
+		s.respond(conn, messageId, ldapv3.ApplicationSearchResultDone, ldapv3.LDAPResultInsufficientAccessRights)
+		return
+	}
+	sent := int64(0)
+	for _, entry := range s.entries {
+		if !strings.HasSuffix(entry.dn, baseDN) || !contains(entry.attrs[attr], value) {
+			continue
+		}
+		if sizeLimit > 0 && sent >= sizeLimit {
+			s.respond(conn, messageId, ldapv3.ApplicationSearchResultDone, ldapv3.LDAPResultSizeLimitExceeded)
+			return
+		}
+		result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldapv3.ApplicationSearchResultEntry, nil, "")
+		result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.dn, ""))
+		attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+		names := make([]string, 0, len(entry.attrs))
+		for name := range entry.attrs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+			attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, ""))
+			values := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+			for _, item := range entry.attrs[name] {
+				values.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, item, ""))
+			}
+			attribute.AppendChild(values)
+			attributes.AppendChild(attribute)
+		}
+		result.AppendChild(attributes)
+		s.write(conn, messageId, result)
+		sent++
+	}
+	s.respond(conn, messageId, ldapv3.ApplicationSearchResultDone, ldapv3.LDAPResultSuccess)
+}
+
+func (s *testServer) respond(conn net.Conn, messageId int64, tag ber.Tag, code uint16) {
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+	result.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), ""))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	s.write(conn, messageId, result)
+}
+
+func (s *testServer) write(conn net.Conn, messageId int64, op *ber.Packet) {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageId, ""))
+	packet.AppendChild(op)
+	_, _ = conn.Write(packet.Bytes())
+}
+
+func contains(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAuthenticate(t *testing.T) {
+	server := newTestServer(t)
+	config := Config{
+		Url:               server.url(),
+		BindDN:            serviceDN,
+		BindPassword:      servicePassword,
+		BaseDN:            "ou=people,dc=example,dc=org",
+		UserFilter:        "(uid=%s)",
+		UsernameAttribute: "uid",
+		EmailAttribute:    "mail",
+		GroupBaseDN:       "ou=groups,dc=example,dc=org",
+		GroupFilter:       "(member=%s)",
+		GroupAttribute:    "cn",
+	}
+
+	entry, err := Authenticate(config, "alice", "alicepw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.DN != "uid=alice,ou=people,dc=example,dc=org" || entry.Username != "alice" || entry.Email != "alice@example.com" {
+		t.Errorf("entry = %+v", entry)
+	}
+	if len(entry.Groups) != 2 || entry.Groups[0] != "ops" || entry.Groups[1] != "dev" {
+		t.Errorf("groups = %v", entry.Groups)
+	}
+
+	cases := map[string][2]string{
+		"wrong password": {"alice", "wrong"},
+		"empty password": {"alice", ""},
+		"unknown user":   {"carol", "carolpw"},
+		"filter inject":  {"*", "alicepw"},
+	}
+	for name, credentials := range cases {
+		if _, err = Authenticate(config, credentials[0], credentials[1]); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("%s: err = %v, want invalid credentials", name, err)
+		}
+	}
+	// 用户名对应多个用户时拒绝登录
+	config.BaseDN = "dc=example,dc=org"
+	if _, err = Authenticate(config, "dup", "duppw"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("duplicate user: err = %v, want invalid credentials", err)
+	}
+
+	// 未配置用户组查询时不读取用户组
+	config.GroupFilter = ""
+	if entry, err = Authenticate(config, "bob", "bobpw"); err != nil || len(entry.Groups) != 0 || entry.Email != "" {
+		t.Errorf("bob = %+v, %v", entry, err)
+	}
+
+	config.BindPassword = "wrong"
+	if _, err = Authenticate(config, "alice", "alicepw"); err == nil || errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("service bind failure should not be reported as invalid credentials, got %v", err)
+	}
+	config.Url = "ldap://127.0.0.1:1"
+	if _, err = Authenticate(config, "alice", "alicepw"); err == nil {
+		t.Error("unreachable server should fail")
+	}
+}
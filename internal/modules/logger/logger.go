@@ -136,19 +136,23 @@ func write(level Level, v ...interface{}) {
 		return
 	}
 
-	// 降级到同步写入
+	// 降级到同步写入, 未初始化时(如命令行参数错误)使用默认日志输出到标准错误
+	l := logger
+	if l == nil {
+		l = slog.Default()
+	}
 	switch level {
 	case DEBUG:
-		logger.Debug(msg, args...)
+		l.Debug(msg, args...)
 	case INFO:
-		logger.Info(msg, args...)
+		l.Info(msg, args...)
 	case WARN:
-		logger.Warn(msg, args...)
+		l.Warn(msg, args...)
 	case FATAL:
-		logger.Error(msg, args...)
+		l.Error(msg, args...)
 		exitFunc(1)
 	case ERROR:
-		logger.Error(msg, args...)
+		l.Error(msg, args...)
 	}
 }
 
@@ -182,18 +186,22 @@ func writef(level Level, format string, v ...interface{}) {
 		return
 	}
 
-	// 降级到同步写入
+	// 降级到同步写入, 未初始化时(如命令行参数错误)使用默认日志输出到标准错误
+	l := logger
+	if l == nil {
+		l = slog.Default()
+	}
 	switch level {
 	case DEBUG:
-		logger.Debug(msg, args...)
+		l.Debug(msg, args...)
 	case INFO:
-		logger.Info(msg, args...)
+		l.Info(msg, args...)
 	case WARN:
-		logger.Warn(msg, args...)
+		l.Warn(msg, args...)
 	case FATAL:
-		logger.Error(msg, args...)
+		l.Error(msg, args...)
 		exitFunc(1)
 	case ERROR:
-		logger.Error(msg, args...)
+		l.Error(msg, args...)
 	}
 }
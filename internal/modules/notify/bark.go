@@ -48,6 +48,7 @@ func (bark *Bark) Send(msg Message) {
 }
 
 func (bark *Bark) getActiveUrls(setting models.Bark, msg Message) []models.BarkUrl {
+	setting.Urls = filterProject(setting.Urls, func(v models.BarkUrl) int { return v.ProjectId }, msg)
 	raw, _ := msg["task_receiver_id"].(string)
 	raw = strings.TrimSpace(raw)
 	if raw == "" {
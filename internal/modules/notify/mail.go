@@ -75,6 +75,7 @@ func (mail *Mail) send(mailSetting models.Mail, toUsers []string, msg Message) {
 }
 
 func (mail *Mail) getActiveMailUsers(mailSetting models.Mail, msg Message) []string {
+	mailSetting.MailUsers = filterProject(mailSetting.MailUsers, func(v models.MailUser) int { return v.ProjectId }, msg)
 	raw, _ := msg["task_receiver_id"].(string)
 	typed, legacy := parseReceiverTokens(raw)
 	rawIds := legacy
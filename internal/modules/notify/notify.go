@@ -42,6 +42,9 @@ func run() {
 		}
 		enhanceMessage(msg)
 		msg["content"] = fmt.Sprintf("============\n============\n============\n任务名称: %s\n状态: %s\n输出:\n %s\n", msg["name"], msg["status"], msg["output"])
+		if summary, ok := msg["host_summary"].(string); ok && summary != "" {
+			msg["content"] = fmt.Sprintf("%s主机: %s\n", msg["content"], summary)
+		}
 		logger.Debugf("%+v", msg)
 		switch taskType.(int8) {
 		case 0:
@@ -95,6 +98,9 @@ func parseNotifyTemplate(notifyTemplate string, msg Message) string {
 		"ResultBody":        msg["result_body"],
 		"ResultSummary":     resultSummary,
 		"Host":              msg["host"],
+		"HostTotal":         msg["host_total"],
+		"HostFailed":        msg["host_failed"],
+		"HostSummary":       msg["host_summary"],
 		"ResultJsonCode":    msg["result_json_code"],
 		"ResultJsonErrno":   msg["result_json_errno"],
 		"ResultJsonMessage": msg["result_json_message"],
@@ -118,6 +124,10 @@ func enhanceMessage(msg Message) {
 
 	rawOutput, _ := msg["output"].(string)
 	host, body, summary, jsonCode, jsonErrno, jsonMessage := extractOutputInfo(rawOutput)
+	// 多主机任务的输出包含多个 Host 行, 节点信息使用主机执行汇总
+	if total, ok := msg["host_total"].(int); ok && total > 1 {
+		host, _ = msg["host_summary"].(string)
+	}
 	msg["host"] = host
 	msg["result_body"] = body
 	msg["result_summary"] = summary
@@ -177,6 +177,20 @@ func TestParseNotifyTemplate(t *testing.T) {
 			},
 			contains: []string{"123", "定时任务", "失败", "错误信息", "重要任务"},
 		},
+		{
+			name:     "多主机汇总",
+			template: "节点: {{.Host}}, 失败: {{.HostFailed}}/{{.HostTotal}}",
+			msg: Message{
+				"task_id":      1,
+				"name":         "部署任务",
+				"status":       "Failed",
+				"output":       "Host: [web1-10.0.0.1:5921]\nok\nHost: [web2-10.0.0.2:5921]\nexit status 1",
+				"host_total":   5,
+				"host_failed":  3,
+				"host_summary": "3/5 hosts failed",
+			},
+			contains: []string{"节点: 3/5 hosts failed", "失败: 3/5"},
+		},
 		{
 			name:     "空模板",
 			template: "",
@@ -58,3 +58,20 @@ func toIntSet(values []string) map[int]struct{} {
 	}
 	return set
 }
+
+// 只保留共享的及任务所属项目的接收者, 消息中没有项目时不过滤
+// 选择全部接收者(*)时也只发送到这些接收者
+func filterProject[T any](items []T, projectId func(T) int, msg Message) []T {
+	taskProjectId, ok := msg["task_project_id"].(int)
+	if !ok {
+		return items
+	}
+	result := make([]T, 0, len(items))
+	for _, item := range items {
+		if id := projectId(item); id == 0 || id == taskProjectId {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
@@ -48,6 +48,7 @@ func (serverChan3 *ServerChan3) Send(msg Message) {
 }
 
 func (serverChan3 *ServerChan3) getActiveUrls(setting models.ServerChan3, msg Message) []models.ServerChan3Url {
+	setting.Urls = filterProject(setting.Urls, func(v models.ServerChan3Url) int { return v.ProjectId }, msg)
 	raw, _ := msg["task_receiver_id"].(string)
 	raw = strings.TrimSpace(raw)
 	if raw == "" {
@@ -61,6 +61,7 @@ func (slack *Slack) send(msg Message, slackUrl string, channel string) {
 }
 
 func (slack *Slack) getActiveSlackChannels(slackSetting models.Slack, msg Message) []string {
+	slackSetting.Channels = filterProject(slackSetting.Channels, func(v models.Channel) int { return v.ProjectId }, msg)
 	raw, _ := msg["task_receiver_id"].(string)
 	typed, legacy := parseReceiverTokens(raw)
 	rawIds := legacy
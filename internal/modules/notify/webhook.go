@@ -61,6 +61,7 @@ func (webHook *WebHook) Send(msg Message) {
 }
 
 func (webHook *WebHook) getActiveWebhookUrls(webHookSetting models.WebHook, msg Message) []models.WebhookUrl {
+	webHookSetting.WebhookUrls = filterProject(webHookSetting.WebhookUrls, func(v models.WebhookUrl) int { return v.ProjectId }, msg)
 	raw, _ := msg["task_receiver_id"].(string)
 	raw = strings.TrimSpace(raw)
 	if raw == "" {
@@ -168,6 +168,28 @@ func TestWebHook_getActiveWebhookUrls_EdgeCases(t *testing.T) {
 			t.Errorf("expected 1 active url (no duplicates in result), got %d", len(activeUrls))
 		}
 	})
+
+	t.Run("receivers of other projects", func(t *testing.T) {
+		webHookSetting := models.WebHook{
+			WebhookUrls: []models.WebhookUrl{
+				{Id: 1, Name: "Shared", Url: "https://shared.example.com"},
+				{Id: 2, Name: "Ops", Url: "https://ops.example.com", ProjectId: 2},
+				{Id: 3, Name: "Dev", Url: "https://dev.example.com", ProjectId: 3},
+			},
+		}
+
+		msg := Message{
+			"task_receiver_id": "w:*",
+			"task_project_id":  2,
+		}
+
+		activeUrls := webHook.getActiveWebhookUrls(webHookSetting, msg)
+
+		// 选择全部接收者时只发送到共享的及任务所属项目的地址
+		if len(activeUrls) != 2 || activeUrls[0].Id != 1 || activeUrls[1].Id != 2 {
+			t.Errorf("expected shared and project urls, got %+v", activeUrls)
+		}
+	})
 }
 
 // TestWebHook_getActiveWebhookUrls_LargeDataset 测试大数据集
@@ -0,0 +1,168 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 模拟的身份提供方, 授权码对应签发的ID Token声明
+type mockIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	codes  map[string]jwt.MapClaims
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &mockIdP{key: key, codes: make(map[string]jwt.MapClaims)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "k1",
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		clientId, secret, _ := r.BasicAuth()
+		claims, ok := idp.codes[r.PostFormValue("code")]
+		if clientId != "gocron" || secret != "secret" || !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": idp.sign(t, claims)})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+
+	return idp
+}
+
+func (idp *mockIdP) sign(t *testing.T, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "k1"
+	signed, err := token.SignedString(idp.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func (idp *mockIdP) claims(nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":    idp.server.URL,
+		"aud":    "gocron",
+		"sub":    "u-1",
+		"exp":    time.Now().Add(time.Minute).Unix(),
+		"nonce":  nonce,
+		"groups": []string{"ops", "dev"},
+	}
+}
+
+func TestProvider(t *testing.T) {
+	idp := newMockIdP(t)
+	ctx := context.Background()
+	provider, err := NewProvider(ctx, Config{
+		Issuer:       idp.server.URL,
+		ClientId:     "gocron",
+		ClientSecret: "secret",
+		RedirectUrl:  "http://gocron/api/user/oidc/callback",
+		Scopes:       []string{"openid", "profile"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authURL, _ := url.Parse(provider.AuthCodeURL("state1", "nonce1"))
+	query := authURL.Query()
+	if authURL.Path != "/authorize" || query.Get("state") != "state1" || query.Get("nonce") != "nonce1" ||
+		query.Get("scope") != "openid profile" || query.Get("response_type") != "code" {
+		t.Errorf("unexpected auth url %s", authURL)
+	}
+
+	idp.codes["code1"] = idp.claims("nonce1")
+	rawIdToken, err := provider.Exchange(ctx, "code1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := provider.Verify(ctx, rawIdToken, "nonce1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims["sub"] != "u-1" {
+		t.Errorf("sub = %v", claims["sub"])
+	}
+	if groups := Groups(claims, "groups"); len(groups) != 2 || groups[0] != "ops" {
+		t.Errorf("groups = %v", groups)
+	}
+
+	if _, err = provider.Exchange(ctx, "unknown"); err == nil {
+		t.Error("unknown code should fail")
+	}
+	if _, err = provider.Verify(ctx, rawIdToken, "other"); !errors.Is(err, ErrNonceInvalid) {
+		t.Errorf("nonce mismatch err = %v", err)
+	}
+
+	cases := map[string]func(jwt.MapClaims){
+		"expired":      func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"other client": func(c jwt.MapClaims) { c["aud"] = "other" },
+		"other issuer": func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+	}
+	for name, modify := range cases {
+		claims := idp.claims("nonce1")
+		modify(claims)
+		if _, err = provider.Verify(ctx, idp.sign(t, claims), "nonce1"); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: err = %v, want invalid token", name, err)
+		}
+	}
+
+	// 其他密钥签名的令牌
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	forged := jwt.NewWithClaims(jwt.SigningMethodRS256, idp.claims("nonce1"))
+	forged.Header["kid"] = "k1"
+	signed, _ := forged.SignedString(otherKey)
+	if _, err = provider.Verify(ctx, signed, "nonce1"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("forged token err = %v", err)
+	}
+}
+
+func TestGroups(t *testing.T) {
+	claims := jwt.MapClaims{"groups": "ops, dev,", "roles": []interface{}{"a", 1, ""}}
+	if groups := Groups(claims, "groups"); len(groups) != 2 || groups[1] != "dev" {
+		t.Errorf("string groups = %v", groups)
+	}
+	if groups := Groups(claims, "roles"); len(groups) != 1 || groups[0] != "a" {
+		t.Errorf("array groups = %v", groups)
+	}
+	if groups := Groups(claims, "missing"); len(groups) != 0 {
+		t.Errorf("missing groups = %v", groups)
+	}
+}
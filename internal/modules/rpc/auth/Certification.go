@@ -15,15 +15,22 @@ type Certificate struct {
 	CertFile   string
 	KeyFile    string
 	ServerName string
+	// 设置后从 Reloader 获取证书, 证书续期后无需重启
+	Reloader *KeyPairReloader
+	// 不强制要求客户端证书, 由节点令牌等方式认证
+	ClientCertOptional bool
+	// 设置后只接受该名称(CN或SAN)的客户端证书, 如节点只接受服务端的证书
+	ClientName string
 }
 
 func (c Certificate) GetTLSConfigForServer() (*tls.Config, error) {
-	certificate, err := tls.LoadX509KeyPair(
-		c.CertFile,
-		c.KeyFile,
-	)
-	if err != nil {
-		return nil, err
+	reloader := c.Reloader
+	if reloader == nil {
+		var err error
+		reloader, err = NewKeyPairReloader(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	certPool := x509.NewCertPool()
@@ -38,21 +45,46 @@ func (c Certificate) GetTLSConfigForServer() (*tls.Config, error) {
 	}
 
 	tlsConfig := &tls.Config{
-		ClientAuth:   tls.RequireAndVerifyClientCert,
-		Certificates: []tls.Certificate{certificate},
-		ClientCAs:    certPool,
+		ClientAuth:     tls.RequireAndVerifyClientCert,
+		GetCertificate: reloader.GetCertificate,
+		ClientCAs:      certPool,
+	}
+	if c.ClientCertOptional {
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	if c.ClientName != "" {
+		tlsConfig.VerifyConnection = verifyClientName(c.ClientName)
 	}
 
 	return tlsConfig, nil
 }
 
+// 校验客户端证书的名称, 同一CA签发的其他证书不能冒充该客户端
+func verifyClientName(name string) func(tls.ConnectionState) error {
+	return func(state tls.ConnectionState) error {
+		if len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+			if len(state.PeerCertificates) == 0 {
+				return nil
+			}
+			return errors.New("client certificate is not verified")
+		}
+		leaf := state.VerifiedChains[0][0]
+		if leaf.Subject.CommonName == name || leaf.VerifyHostname(name) == nil {
+			return nil
+		}
+
+		return fmt.Errorf("client certificate %q is not allowed, expected %q", leaf.Subject.CommonName, name)
+	}
+}
+
 func (c Certificate) GetTransportCredsForClient() (credentials.TransportCredentials, error) {
-	certificate, err := tls.LoadX509KeyPair(
-		c.CertFile,
-		c.KeyFile,
-	)
-	if err != nil {
-		return nil, err
+	reloader := c.Reloader
+	if reloader == nil {
+		var err error
+		reloader, err = NewKeyPairReloader(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	certPool := x509.NewCertPool()
@@ -67,9 +99,9 @@ func (c Certificate) GetTransportCredsForClient() (credentials.TransportCredenti
 	}
 
 	transportCreds := credentials.NewTLS(&tls.Config{
-		ServerName:   c.ServerName,
-		Certificates: []tls.Certificate{certificate},
-		RootCAs:      certPool,
+		ServerName:           c.ServerName,
+		GetClientCertificate: reloader.GetClientCertificate,
+		RootCAs:              certPool,
 	})
 
 	return transportCreds, nil
@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	caValidity = 10 * 365 * 24 * time.Hour
+	// 节点及服务端证书有效期, 到期前 CertRenewBefore 内自动续期
+	CertValidity    = 90 * 24 * time.Hour
+	CertRenewBefore = 30 * 24 * time.Hour
+	// 内置CA签发的服务端证书使用的名称, 反向连接的节点以此校验服务端
+	BuiltinServerName = "gocron-server"
+)
+
+// CA 内置证书颁发机构, 用于签发节点证书及服务端证书
+type CA struct {
+	dir     string
+	cert    *x509.Certificate
+	key     crypto.Signer
+	certPEM []byte
+}
+
+// LoadOrCreateCA 从目录加载CA, 不存在时生成新的CA
+func LoadOrCreateCA(dir string) (*CA, error) {
+	certFile, keyFile := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	if _, err := os.Stat(certFile); os.IsNotExist(err) {
+		if err := createCA(dir, certFile, keyFile); err != nil {
+			return nil, err
+		}
+	}
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported ca private key")
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CA{dir: dir, cert: cert, key: key, certPEM: certPEM}, nil
+}
+
+func createCA(dir, certFile, keyFile string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          randomSerial(),
+		Subject:               pkix.Name{CommonName: "gocron internal CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+
+	return writeKeyPair(certFile, keyFile, der, key)
+}
+
+// CertPEM CA证书
+func (ca *CA) CertPEM() []byte {
+	return ca.certPEM
+}
+
+// CertFile CA证书文件路径
+func (ca *CA) CertFile() string {
+	return filepath.Join(ca.dir, "ca.crt")
+}
+
+// SignCSR 校验节点的CSR并签发证书, 证书的主机名使用注册的主机名而不是CSR中的名称
+func (ca *CA) SignCSR(csrPEM []byte, hostname string) ([]byte, error) {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, errors.New("invalid certificate request")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("invalid certificate request signature: %w", err)
+	}
+	der, err := ca.sign(csr.PublicKey, hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+}
+
+// 签发同时可用于服务端和客户端认证的证书
+// 节点证书在反向连接时作为客户端证书, 节点通过 Certificate.ClientName 只接受服务端证书, 防止节点证书冒充服务端
+func (ca *CA) sign(publicKey interface{}, names ...string) ([]byte, error) {
+	template := &x509.Certificate{
+		SerialNumber: randomSerial(),
+		Subject:      pkix.Name{CommonName: names[0]},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(CertValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	for _, name := range names {
+		if ip := net.ParseIP(name); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, name)
+		}
+	}
+
+	return x509.CreateCertificate(rand.Reader, template, ca.cert, publicKey, ca.key)
+}
+
+// ServerCertificate 服务端证书, 连接节点时作为客户端证书, 反向连接监听时作为服务端证书
+// 证书不存在或即将过期时重新签发
+func (ca *CA) ServerCertificate() (Certificate, error) {
+	certificate := Certificate{
+		CAFile:   ca.CertFile(),
+		CertFile: filepath.Join(ca.dir, "server.crt"),
+		KeyFile:  filepath.Join(ca.dir, "server.key"),
+	}
+	if cert, err := LoadCertFile(certificate.CertFile); err == nil && time.Until(cert.NotAfter) > CertRenewBefore {
+		return certificate, nil
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return certificate, err
+	}
+	der, err := ca.sign(&key.PublicKey, BuiltinServerName)
+	if err != nil {
+		return certificate, err
+	}
+
+	return certificate, writeKeyPair(certificate.CertFile, certificate.KeyFile, der, key)
+}
+
+// LoadCertFile 读取PEM格式的证书文件
+func LoadCertFile(certFile string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid certificate file")
+	}
+
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// NewCSR 生成私钥及证书签名请求, 返回PEM格式
+func NewCSR(hostname string) (csrPEM []byte, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: hostname},
+	}, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), nil
+}
+
+// WriteFileAtomic 先写临时文件再重命名, 避免读取到写了一半的证书
+func WriteFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	tmp := filename + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, filename)
+}
+
+func writeKeyPair(certFile, keyFile string, der []byte, key *ecdsa.PrivateKey) error {
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	if err := WriteFileAtomic(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return err
+	}
+
+	return WriteFileAtomic(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+}
+
+func randomSerial() *big.Int {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+	if err != nil {
+		return big.NewInt(time.Now().UnixNano())
+	}
+
+	return serial
+}
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"testing"
+)
+
+func TestCASignCSR(t *testing.T) {
+	dir := t.TempDir()
+	ca, err := LoadOrCreateCA(dir)
+	if err != nil {
+		t.Fatalf("LoadOrCreateCA error: %v", err)
+	}
+	// 再次加载使用同一个CA
+	reloaded, err := LoadOrCreateCA(dir)
+	if err != nil || string(reloaded.CertPEM()) != string(ca.CertPEM()) {
+		t.Fatalf("expected the existing CA to be loaded, err=%v", err)
+	}
+
+	csrPEM, _, err := NewCSR("spoofed.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, err := ca.SignCSR(csrPEM, "10.0.0.5")
+	if err != nil {
+		t.Fatalf("SignCSR error: %v", err)
+	}
+	block, _ := pem.Decode(certPEM)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.Subject.CommonName != "10.0.0.5" || len(cert.DNSNames) != 0 || len(cert.IPAddresses) != 1 {
+		t.Fatalf("certificate should use the registered host name, got CN=%s DNS=%v IP=%v",
+			cert.Subject.CommonName, cert.DNSNames, cert.IPAddresses)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(ca.CertPEM())
+	for _, usage := range []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth} {
+		_, err := cert.Verify(x509.VerifyOptions{Roots: pool, DNSName: "10.0.0.5", KeyUsages: []x509.ExtKeyUsage{usage}})
+		if err != nil {
+			t.Errorf("verify usage %v: %v", usage, err)
+		}
+	}
+
+	if _, err := ca.SignCSR([]byte("not a csr"), "node"); err == nil {
+		t.Error("expected error for invalid CSR")
+	}
+}
+
+func TestCAServerCertificate(t *testing.T) {
+	ca, err := LoadOrCreateCA(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := ca.ServerCertificate()
+	if err != nil {
+		t.Fatalf("ServerCertificate error: %v", err)
+	}
+	first, _ := os.ReadFile(certificate.CertFile)
+	// 证书未到续期时间时不重新签发
+	if _, err := ca.ServerCertificate(); err != nil {
+		t.Fatal(err)
+	}
+	second, _ := os.ReadFile(certificate.CertFile)
+	if string(first) != string(second) {
+		t.Error("valid server certificate should not be reissued")
+	}
+	cert, err := LoadCertFile(certificate.CertFile)
+	if err != nil || cert.DNSNames[0] != BuiltinServerName {
+		t.Fatalf("unexpected server certificate: %v, %v", cert, err)
+	}
+	if _, err := NewKeyPairReloader(certificate.CertFile, certificate.KeyFile); err != nil {
+		t.Fatalf("server key pair should load: %v", err)
+	}
+}
+
+func TestVerifyClientName(t *testing.T) {
+	ca, err := LoadOrCreateCA(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := ca.ServerCertificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverCert, err := LoadCertFile(certificate.CertFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csrPEM, _, err := NewCSR("node-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, err := ca.SignCSR(csrPEM, "node-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(certPEM)
+	nodeCert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verify := verifyClientName(BuiltinServerName)
+	if err := verify(tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{serverCert}}}); err != nil {
+		t.Errorf("server certificate should be accepted: %v", err)
+	}
+	// 同一CA签发的节点证书不能冒充服务端
+	if err := verify(tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{nodeCert}}}); err == nil {
+		t.Error("node certificate should be rejected")
+	}
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"crypto/tls"
+	"sync"
+)
+
+// KeyPairReloader 证书文件更新后可重新加载, 新建立的连接使用新证书
+type KeyPairReloader struct {
+	certFile string
+	keyFile  string
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+}
+
+func NewKeyPairReloader(certFile, keyFile string) (*KeyPairReloader, error) {
+	r := &KeyPairReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Reload 重新读取证书文件
+func (r *KeyPairReloader) Reload() error {
+	r.mu.RLock()
+	certFile, keyFile := r.certFile, r.keyFile
+	r.mu.RUnlock()
+
+	return r.SetFiles(certFile, keyFile)
+}
+
+// SetFiles 更换证书文件并加载, 加载失败时继续使用原证书
+func (r *KeyPairReloader) SetFiles(certFile, keyFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.certFile, r.keyFile = certFile, keyFile
+	r.cert = &cert
+	r.mu.Unlock()
+
+	return nil
+}
+
+func (r *KeyPairReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+func (r *KeyPairReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"os"
+	"strconv"
+)
+
+// 签名内容前缀, 避免签名被用于其他用途
+const commandSignPrefix = "gocron-command:v2\n"
+
+// GenerateSigningKey 生成ed25519命令签名密钥, 私钥写入 keyFile, 公钥写入 keyFile.pub
+func GenerateSigningKey(keyFile string) error {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return err
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0600); err != nil {
+		return err
+	}
+
+	return os.WriteFile(keyFile+".pub", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0644)
+}
+
+// LoadSigningKey 读取PEM格式的ed25519私钥
+func LoadSigningKey(keyFile string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid signing key file")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("signing key is not an ed25519 key")
+	}
+
+	return privateKey, nil
+}
+
+// LoadTrustedKeys 读取受信任的公钥, 文件中可包含多个PEM格式的ed25519公钥
+func LoadTrustedKeys(keyFile string) ([]ed25519.PublicKey, error) {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]ed25519.PublicKey, 0)
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		publicKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, errors.New("trusted key is not an ed25519 key")
+		}
+		keys = append(keys, publicKey)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no trusted key found")
+	}
+
+	return keys, nil
+}
+
+// 签名内容包含任务ID, 签名不能用于其他任务
+func commandPayload(taskId int64, command string) []byte {
+	return []byte(commandSignPrefix + strconv.FormatInt(taskId, 10) + "\n" + command)
+}
+
+// SignCommand 对任务命令签名, 返回base64编码的签名
+func SignCommand(privateKey ed25519.PrivateKey, taskId int64, command string) string {
+	signature := ed25519.Sign(privateKey, commandPayload(taskId, command))
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+// VerifyCommand 校验任务命令签名是否由任一受信任的公钥签发
+func VerifyCommand(keys []ed25519.PublicKey, taskId int64, command, signature string) bool {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+	for _, key := range keys {
+		if ed25519.Verify(key, commandPayload(taskId, command), sig) {
+			return true
+		}
+	}
+
+	return false
+}
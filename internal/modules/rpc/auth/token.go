@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// 节点令牌在gRPC metadata中的key
+const TokenMetadataKey = "x-gocron-node-token"
+
+// DeriveNodeToken 由服务端密钥和主机的随机nonce生成节点令牌
+// 数据库只保存nonce和令牌哈希, 泄露数据库不会泄露令牌
+func DeriveNodeToken(secret, nonce string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("gocron-node:" + nonce))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// HashToken 令牌哈希
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyToken 比较令牌与哈希是否匹配
+func VerifyToken(token, hash string) bool {
+	if token == "" || hash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(HashToken(token)), []byte(hash)) == 1
+}
+
+// WithToken 在请求 context 中附加节点令牌, token为空时不附加
+func WithToken(ctx context.Context, token string) context.Context {
+	if token == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, TokenMetadataKey, token)
+}
+
+// TokenInterceptors 节点端校验请求中的令牌, tokenHash 返回当前令牌哈希, 为空时不校验
+func TokenInterceptors(tokenHash func() string) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	unary := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := checkToken(ctx, tokenHash); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+	stream := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := checkToken(ss.Context(), tokenHash); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+
+	return unary, stream
+}
+
+func checkToken(ctx context.Context, tokenHash func() string) error {
+	hash := tokenHash()
+	if hash == "" {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(TokenMetadataKey)
+	if len(values) == 0 || !VerifyToken(values[0], hash) {
+		return status.Error(codes.Unauthenticated, "invalid node token")
+	}
+	return nil
+}
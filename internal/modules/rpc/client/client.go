@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -11,26 +13,99 @@ import (
 
 	"github.com/tabortao/gocron/internal/modules/i18n"
 	"github.com/tabortao/gocron/internal/modules/logger"
+	"github.com/tabortao/gocron/internal/modules/rpc/auth"
 	"github.com/tabortao/gocron/internal/modules/rpc/grpcpool"
 	pb "github.com/tabortao/gocron/internal/modules/rpc/proto"
+	"github.com/tabortao/gocron/internal/modules/rpc/reverse"
 	"google.golang.org/grpc/codes"
 )
 
 var (
 	taskCtxMap     sync.Map // 存储任务执行的 context.CancelFunc
-	errUnavailable = errors.New(i18n.Translate("rpc_unavailable"))
+	ErrUnavailable = errors.New(i18n.Translate("rpc_unavailable"))
+	ErrTimeout     = errors.New(i18n.Translate("rpc_timeout"))
 	ErrManualStop  = errors.New("rpc_manual_stop") // 特殊错误标识，用于判断是否手动停止
+	// 节点版本过旧, 不支持该RPC
+	ErrUnimplemented = errors.New("rpc_unimplemented")
+	// 节点正在排空, 不再接收新任务
+	ErrDraining = errors.New("node is draining")
 )
 
+// NodeToken 返回请求节点时附带的令牌, 为空表示不附带
+var NodeToken func(ip string, port int) string
+
+// 节点执行超时被强制结束时返回的错误信息
+const nodeTimeoutMessage = "timeout killed"
+
+// 节点排空时拒绝新任务返回的错误信息
+const nodeDrainingMessage = "node is draining"
+
+const (
+	// 分离执行时查询任务状态的间隔
+	detachedPollInterval = 2 * time.Second
+	// 节点重启或网络中断时, 在任务超时后继续等待节点恢复的时间
+	detachedRecoverTimeout = 5 * time.Minute
+)
+
+// 节点上找不到分离执行的任务, 如状态目录被清理
+var errDetachedJobLost = errors.New("detached job not found on node")
+
+// ErrDetachedNotFound 节点上没有该任务, 任务未在该节点执行或结果已取回
+var ErrDetachedNotFound = errors.New("detached job does not exist on node")
+
+// ExecError 节点返回的命令执行错误
+type ExecError struct {
+	Message  string
+	ExitCode int // 命令退出码, 无法解析时为-1
+}
+
+func (e *ExecError) Error() string {
+	return e.Message
+}
+
+// Is 节点上执行超时视为 ErrTimeout
+func (e *ExecError) Is(target error) bool {
+	return target == ErrTimeout && e.Message == nodeTimeoutMessage
+}
+
+func newExecError(message string) *ExecError {
+	execErr := &ExecError{Message: message, ExitCode: -1}
+	if code, ok := strings.CutPrefix(message, "exit status "); ok {
+		if n, err := strconv.Atoi(strings.TrimSpace(code)); err == nil {
+			execErr.ExitCode = n
+		}
+	}
+
+	return execErr
+}
+
 func generateTaskUniqueKey(ip string, port int, id int64) string {
 	return fmt.Sprintf("%s:%d:%d", ip, port, id)
 }
 
+// 节点已反向连接时通过其连接通信, 否则从连接池获取直连客户端
+func getClient(ip string, port int) (pb.TaskClient, error) {
+	if conn, ok := reverse.Lookup(ip); ok {
+		return conn, nil
+	}
+
+	return grpcpool.Pool.Get(fmt.Sprintf("%s:%d", ip, port))
+}
+
+// 在请求 context 中附加节点令牌
+func withNodeToken(ctx context.Context, ip string, port int) context.Context {
+	if NodeToken == nil {
+		return ctx
+	}
+
+	return auth.WithToken(ctx, NodeToken(ip, port))
+}
+
 func Stop(ip string, port int, id int64) {
 	// 异步发送停止信号，不阻塞调用者
 	go func() {
 		addr := fmt.Sprintf("%s:%d", ip, port)
-		c, err := grpcpool.Pool.Get(addr)
+		c, err := getClient(ip, port)
 		if err != nil {
 			logger.Errorf("连接服务器失败#%s#%v", addr, err)
 			return
@@ -38,6 +113,7 @@ func Stop(ip string, port int, id int64) {
 
 		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 		defer cancel()
+		ctx = withNodeToken(ctx, ip, port)
 
 		_, err = c.Run(ctx, &pb.TaskRequest{
 			Command: "__STOP__",
@@ -60,16 +136,78 @@ func Tail(ip string, port int, id int64) (string, error) {
 	})
 }
 
+// Health 获取节点健康状态及资源信息
+func Health(ip string, port int, timeout time.Duration) (*pb.HealthResponse, error) {
+	addr := fmt.Sprintf("%s:%d", ip, port)
+	c, err := getClient(ip, port)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnavailable, err.Error())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ctx = withNodeToken(ctx, ip, port)
+
+	resp, err := c.Health(ctx, &pb.HealthRequest{})
+	if err != nil {
+		switch status.Code(err) {
+		case codes.Unimplemented:
+			return nil, ErrUnimplemented
+		case codes.Unavailable:
+			grpcpool.Pool.Release(addr)
+		}
+		_, err = parseGRPCError(err)
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// SetToken 将新的节点令牌下发到节点, 请求附带节点当前的令牌, token为空时吊销
+func SetToken(ip string, port int, token string, timeout time.Duration) error {
+	addr := fmt.Sprintf("%s:%d", ip, port)
+	c, err := getClient(ip, port)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrUnavailable, err.Error())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ctx = withNodeToken(ctx, ip, port)
+
+	_, err = c.SetToken(ctx, &pb.TokenRequest{Token: token})
+	if err != nil {
+		switch status.Code(err) {
+		case codes.Unimplemented:
+			return ErrUnimplemented
+		case codes.Unavailable:
+			grpcpool.Pool.Release(addr)
+		}
+		_, err = parseGRPCError(err)
+		return err
+	}
+
+	return nil
+}
+
+// Exec 在节点上执行任务并等待结果, 分离执行时启动后轮询任务状态
 func Exec(ip string, port int, taskReq *pb.TaskRequest) (string, error) {
+	if taskReq.Detached {
+		return execDetached(ip, port, taskReq)
+	}
+	output, _, err := run(ip, port, taskReq)
+
+	return output, err
+}
+
+func run(ip string, port int, taskReq *pb.TaskRequest) (output string, detached bool, err error) {
 	defer func() {
 		if err := recover(); err != nil {
 			logger.Error("panic#rpc/client.go:Exec#", err)
 		}
 	}()
 	addr := fmt.Sprintf("%s:%d", ip, port)
-	c, err := grpcpool.Pool.Get(addr)
+	c, err := getClient(ip, port)
 	if err != nil {
-		return "", err
+		return "", false, err
 	}
 	if taskReq.Timeout <= 0 || taskReq.Timeout > 86400 {
 		taskReq.Timeout = 86400
@@ -83,7 +221,7 @@ func Exec(ip string, port int, taskReq *pb.TaskRequest) (string, error) {
 	taskCtxMap.Store(taskUniqueKey, cancel)
 	defer taskCtxMap.Delete(taskUniqueKey)
 
-	resp, err := c.Run(ctx, taskReq)
+	resp, err := c.Run(withNodeToken(ctx, ip, port), taskReq)
 	if err != nil {
 		if status.Code(err) == codes.Unavailable {
 			grpcpool.Pool.Release(addr)
@@ -93,29 +231,116 @@ func Exec(ip string, port int, taskReq *pb.TaskRequest) (string, error) {
 	// 处理响应：即使有错误，也要返回已产生的输出
 	if err != nil {
 		if resp != nil && resp.Output != "" {
-			return resp.Output, parseGRPCErrorOnly(err)
+			return resp.Output, false, parseGRPCErrorOnly(err)
 		}
-		return parseGRPCError(err)
+		output, err := parseGRPCError(err)
+		return output, false, err
+	}
+
+	return resp.Output, resp.Detached, responseError(resp.Error)
+}
+
+// 节点返回的错误信息转换为错误
+func responseError(message string) error {
+	switch message {
+	case "":
+		return nil
+	// 手动停止
+	case "manual stop":
+		return ErrManualStop
+	// 节点排空中视为不可用, 可转移到其他节点执行
+	case nodeDrainingMessage:
+		return fmt.Errorf("%w: %w", ErrUnavailable, ErrDraining)
 	}
 
-	if resp.Error == "" {
-		return resp.Output, nil
+	return newExecError(message)
+}
+
+// 分离执行: 节点启动任务后立即返回, 之后轮询任务状态直到结束
+// 轮询期间节点重启或网络中断时继续重试, 节点恢复后由其接管的任务继续返回结果
+func execDetached(ip string, port int, taskReq *pb.TaskRequest) (string, error) {
+	output, detached, err := run(ip, port, taskReq)
+	// 旧版本节点不支持分离执行, 已同步执行完成
+	if err != nil || !detached {
+		return output, err
 	}
 
-	// 检查是否是手动停止
-	if resp.Error == "manual stop" {
-		return resp.Output, ErrManualStop
+	deadline := time.Now().Add(time.Duration(taskReq.Timeout)*time.Second + detachedRecoverTimeout)
+	output, err = pollDetached(ip, port, taskReq.Id, deadline)
+	if errors.Is(err, ErrDetachedNotFound) {
+		err = errDetachedJobLost
 	}
 
-	return resp.Output, errors.New(resp.Error)
+	return output, err
+}
+
+// ResumeDetached 服务端重启后继续查询节点上分离执行的任务, 节点不可用时按任务开始时间计算等待期限
+func ResumeDetached(ip string, port int, id int64, startTime time.Time, timeout int) (string, error) {
+	deadline := startTime.Add(time.Duration(timeout)*time.Second + detachedRecoverTimeout)
+
+	return pollDetached(ip, port, id, deadline)
+}
+
+// 轮询任务状态直到结束, 节点不可用时重试至 deadline
+func pollDetached(ip string, port int, id int64, deadline time.Time) (string, error) {
+	addr := fmt.Sprintf("%s:%d", ip, port)
+	var buf strings.Builder
+	var offset int64
+	var lastErr error
+	for {
+		time.Sleep(detachedPollInterval)
+		resp, err := jobStatus(ip, port, &pb.JobStatusRequest{Id: id, Offset: offset})
+		if err != nil {
+			switch status.Code(err) {
+			case codes.Unavailable:
+				grpcpool.Pool.Release(addr)
+			// 旧版本节点不支持分离执行
+			case codes.Unimplemented:
+				return buf.String(), ErrDetachedNotFound
+			}
+			if time.Now().After(deadline) {
+				_, err = parseGRPCError(err)
+				return buf.String(), fmt.Errorf("%w: %w", ErrTimeout, err)
+			}
+			if lastErr == nil {
+				logger.Warnf("查询分离执行任务状态失败, 等待节点恢复#%s#%d#%v", addr, id, err)
+			}
+			lastErr = err
+			continue
+		}
+		lastErr = nil
+		if !resp.Found {
+			return buf.String(), ErrDetachedNotFound
+		}
+		buf.WriteString(resp.Output)
+		offset = resp.Offset
+		if resp.Done {
+			// 结果已取回, 通知节点删除任务状态
+			if _, err := jobStatus(ip, port, &pb.JobStatusRequest{Id: id, Offset: offset, Release: true}); err != nil {
+				logger.Warnf("释放分离执行任务失败#%s#%d#%v", addr, id, err)
+			}
+			return buf.String(), responseError(resp.Error)
+		}
+	}
+}
+
+func jobStatus(ip string, port int, req *pb.JobStatusRequest) (*pb.JobStatusResponse, error) {
+	c, err := getClient(ip, port)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return c.JobStatus(withNodeToken(ctx, ip, port), req)
 }
 
 func parseGRPCError(err error) (string, error) {
 	switch status.Code(err) {
 	case codes.Unavailable:
-		return "", errors.New(errUnavailable.Error() + ": " + err.Error())
+		return "", fmt.Errorf("%w: %s", ErrUnavailable, err.Error())
 	case codes.DeadlineExceeded:
-		return "", errors.New(i18n.Translate("rpc_timeout"))
+		return "", ErrTimeout
 	case codes.Canceled:
 		return "", ErrManualStop
 	}
@@ -126,9 +351,9 @@ func parseGRPCError(err error) (string, error) {
 func parseGRPCErrorOnly(err error) error {
 	switch status.Code(err) {
 	case codes.Unavailable:
-		return errors.New(errUnavailable.Error() + ": " + err.Error())
+		return fmt.Errorf("%w: %s", ErrUnavailable, err.Error())
 	case codes.DeadlineExceeded:
-		return errors.New(i18n.Translate("rpc_timeout"))
+		return ErrTimeout
 	case codes.Canceled:
 		return ErrManualStop
 	}
@@ -2,22 +2,33 @@ package grpcpool
 
 import (
 	"context"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/tabortao/gocron/internal/modules/app"
+	"github.com/tabortao/gocron/internal/modules/logger"
 	"github.com/tabortao/gocron/internal/modules/rpc/auth"
 	pb "github.com/tabortao/gocron/internal/modules/rpc/proto"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
 )
 
 const (
 	backOffMaxDelay = 3 * time.Second
 	dialTimeout     = 5 * time.Second
+	// 移出连接池的连接, 最近使用后等待该时间再关闭, 避免关闭刚取出还未发起请求的连接
+	retireGrace = 30 * time.Second
+	// 检查连接时健康检查请求的超时时间
+	healthCheckTimeout = 3 * time.Second
 )
 
 var (
@@ -25,6 +36,9 @@ var (
 		conns: make(map[string]*Client),
 	}
 
+	// TransportCredentials 未配置 enable_tls 时按节点返回TLS凭证, 返回nil表示不加密
+	TransportCredentials func(addr string) (credentials.TransportCredentials, error)
+
 	keepAliveParams = keepalive.ClientParameters{
 		Time:                20 * time.Second,
 		Timeout:             3 * time.Second,
@@ -32,15 +46,81 @@ var (
 	}
 )
 
+// Client 连接池中的连接, 记录进行中的请求数, 有请求时不会被关闭
 type Client struct {
+	addr      string
 	conn      *grpc.ClientConn
 	rpcClient pb.TaskClient
+	createdAt time.Time
+	lastUsed  atomic.Int64 // 最近使用时间, UnixNano
+	active    atomic.Int32 // 进行中的请求数
+}
+
+var _ pb.TaskClient = (*Client)(nil)
+
+func (c *Client) Run(ctx context.Context, req *pb.TaskRequest, opts ...grpc.CallOption) (*pb.TaskResponse, error) {
+	defer c.track()()
+	return c.rpcClient.Run(ctx, req, opts...)
+}
+
+func (c *Client) Health(ctx context.Context, req *pb.HealthRequest, opts ...grpc.CallOption) (*pb.HealthResponse, error) {
+	defer c.track()()
+	return c.rpcClient.Health(ctx, req, opts...)
+}
+
+func (c *Client) JobStatus(ctx context.Context, req *pb.JobStatusRequest, opts ...grpc.CallOption) (*pb.JobStatusResponse, error) {
+	defer c.track()()
+	return c.rpcClient.JobStatus(ctx, req, opts...)
+}
+
+func (c *Client) SetToken(ctx context.Context, req *pb.TokenRequest, opts ...grpc.CallOption) (*pb.TokenResponse, error) {
+	defer c.track()()
+	return c.rpcClient.SetToken(ctx, req, opts...)
+}
+
+// 记录请求开始, 返回请求结束时调用的函数
+func (c *Client) track() func() {
+	c.active.Add(1)
+	c.touch()
+	return func() {
+		c.touch()
+		c.active.Add(-1)
+	}
+}
+
+func (c *Client) touch() {
+	c.lastUsed.Store(time.Now().UnixNano())
+}
+
+func (c *Client) idle() time.Duration {
+	return time.Since(time.Unix(0, c.lastUsed.Load()))
+}
+
+// Options 连接池维护配置, 各项为0时不启用
+type Options struct {
+	CheckInterval time.Duration // 检查连接状态的间隔
+	IdleTimeout   time.Duration // 空闲超过该时间的连接关闭
+	MaxAge        time.Duration // 连接最长使用时间, 到期后重新建立以使用更新后的证书
 }
 
 type GRPCPool struct {
 	// map key格式 ip:port
 	conns map[string]*Client
-	mu    sync.RWMutex
+	// 已移出连接池, 等待进行中的请求结束后关闭
+	retired []*Client
+	mu      sync.RWMutex
+	options Options
+	stop    chan struct{}
+}
+
+// ConnStats 连接状态, 用于管理接口展示
+type ConnStats struct {
+	Addr        string    `json:"addr"`
+	State       string    `json:"state"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastUsed    time.Time `json:"last_used"`
+	ActiveCalls int32     `json:"active_calls"`
+	Retired     bool      `json:"retired"` // 已移出连接池, 进行中的请求结束后关闭
 }
 
 func (p *GRPCPool) Size() int {
@@ -54,18 +134,172 @@ func (p *GRPCPool) Get(addr string) (pb.TaskClient, error) {
 	client, ok := p.conns[addr]
 	p.mu.RUnlock()
 	if ok {
-		return client.rpcClient, nil
+		client.touch()
+		return client, nil
 	}
 
 	client, err := p.factory(addr)
 	if err != nil {
 		return nil, err
 	}
+	client.touch()
+
+	return client, nil
+}
+
+// Start 按间隔检查连接, 关闭异常、空闲及超过最长使用时间的连接
+func (p *GRPCPool) Start(options Options) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.options = options
+	if options.CheckInterval <= 0 || p.stop != nil {
+		return
+	}
+	p.stop = make(chan struct{})
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(options.CheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.Check()
+			case <-stop:
+				return
+			}
+		}
+	}(p.stop)
+}
 
-	return client.rpcClient, nil
+func (p *GRPCPool) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+	}
 }
 
-// 释放连接
+// Check 检查连接, 需要淘汰的连接移出连接池, 之后的请求重新建立连接
+// 有进行中请求的连接等请求结束后再关闭
+func (p *GRPCPool) Check() {
+	p.mu.RLock()
+	options := p.options
+	clients := make([]*Client, 0, len(p.conns))
+	for _, client := range p.conns {
+		clients = append(clients, client)
+	}
+	p.mu.RUnlock()
+
+	// 健康检查需要发起请求, 不持有锁
+	reasons := make(map[*Client]string)
+	for _, client := range clients {
+		if reason := evictReason(client, options); reason != "" {
+			reasons[client] = reason
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for client, reason := range reasons {
+		// 检查期间连接可能已被释放并重新建立
+		if p.conns[client.addr] != client {
+			continue
+		}
+		logger.Infof("RPC连接池#移出连接#%s#%s", client.addr, reason)
+		delete(p.conns, client.addr)
+		p.retired = append(p.retired, client)
+	}
+	p.closeRetired()
+}
+
+// 关闭请求已结束且超过宽限时间的已移出连接, 调用方持有锁
+func (p *GRPCPool) closeRetired() {
+	retired := p.retired[:0]
+	for _, client := range p.retired {
+		if client.active.Load() > 0 || client.idle() < retireGrace {
+			retired = append(retired, client)
+			continue
+		}
+		client.conn.Close()
+	}
+	p.retired = retired
+}
+
+// 未启用定期检查时, 按宽限时间关闭已移出的连接
+func (p *GRPCPool) scheduleCloseRetired() {
+	time.AfterFunc(retireGrace, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.closeRetired()
+		if len(p.retired) > 0 && p.stop == nil {
+			p.scheduleCloseRetired()
+		}
+	})
+}
+
+// 连接需要淘汰的原因, 不需要时返回空
+func evictReason(client *Client, options Options) string {
+	if options.MaxAge > 0 && time.Since(client.createdAt) > options.MaxAge {
+		return "max age"
+	}
+	if options.IdleTimeout > 0 && client.active.Load() == 0 && client.idle() > options.IdleTimeout {
+		return "idle"
+	}
+	if !client.healthy() {
+		return "unhealthy"
+	}
+
+	return ""
+}
+
+// 发起健康检查请求, 连接不可用或节点无响应时返回false
+// 节点返回其他错误(如未认证、不支持)说明连接正常
+func (c *Client) healthy() bool {
+	switch c.conn.GetState() {
+	case connectivity.TransientFailure, connectivity.Shutdown:
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+	_, err := c.rpcClient.Health(ctx, &pb.HealthRequest{})
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return false
+	}
+
+	return true
+}
+
+// Stats 连接池中的连接及等待关闭的连接
+func (p *GRPCPool) Stats() []ConnStats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	stats := make([]ConnStats, 0, len(p.conns)+len(p.retired))
+	for _, client := range p.conns {
+		stats = append(stats, client.stats(false))
+	}
+	for _, client := range p.retired {
+		stats = append(stats, client.stats(true))
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Addr < stats[j].Addr
+	})
+
+	return stats
+}
+
+func (c *Client) stats(retired bool) ConnStats {
+	return ConnStats{
+		Addr:        c.addr,
+		State:       c.conn.GetState().String(),
+		CreatedAt:   c.createdAt,
+		LastUsed:    time.Unix(0, c.lastUsed.Load()),
+		ActiveCalls: c.active.Load(),
+		Retired:     retired,
+	}
+}
+
+// 释放连接, 移出连接池后等进行中的请求结束再关闭
 func (p *GRPCPool) Release(addr string) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -74,7 +308,10 @@ func (p *GRPCPool) Release(addr string) {
 		return
 	}
 	delete(p.conns, addr)
-	client.conn.Close()
+	p.retired = append(p.retired, client)
+	if p.stop == nil && len(p.retired) == 1 {
+		p.scheduleCloseRetired()
+	}
 }
 
 // 创建连接
@@ -95,7 +332,18 @@ func (p *GRPCPool) factory(addr string) (*Client, error) {
 	}
 
 	if !app.Setting.EnableTLS {
-		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		var transportCreds credentials.TransportCredentials
+		if TransportCredentials != nil {
+			var err error
+			transportCreds, err = TransportCredentials(addr)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if transportCreds == nil {
+			transportCreds = insecure.NewCredentials()
+		}
+		opts = append(opts, grpc.WithTransportCredentials(transportCreds))
 	} else {
 		server := strings.Split(addr, ":")
 		certificate := auth.Certificate{
@@ -121,8 +369,10 @@ func (p *GRPCPool) factory(addr string) (*Client, error) {
 	}
 
 	client = &Client{
+		addr:      addr,
 		conn:      conn,
 		rpcClient: pb.NewTaskClient(conn),
+		createdAt: time.Now(),
 	}
 
 	p.conns[addr] = client
@@ -0,0 +1,103 @@
+package grpcpool
+
+import (
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/tabortao/gocron/internal/modules/logger"
+	pb "github.com/tabortao/gocron/internal/modules/rpc/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestMain(m *testing.M) {
+	_ = os.MkdirAll("log", 0o755)
+	logger.InitLogger()
+	os.Exit(m.Run())
+}
+
+func newTestClient(t *testing.T, addr string, created, lastUsed time.Time) *Client {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	client := &Client{addr: addr, conn: conn, rpcClient: pb.NewTaskClient(conn), createdAt: created}
+	client.lastUsed.Store(lastUsed.UnixNano())
+	return client
+}
+
+// 启动未实现任何方法的节点, 健康检查返回 Unimplemented
+func startTestServer(t *testing.T) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	pb.RegisterTaskServer(server, pb.UnimplementedTaskServer{})
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
+
+func TestPoolCheck(t *testing.T) {
+	now := time.Now()
+	fresh := newTestClient(t, startTestServer(t), now, now)
+	dead := newTestClient(t, "127.0.0.1:1", now, now)
+	idle := newTestClient(t, "127.0.0.1:2", now, now.Add(-time.Hour))
+	old := newTestClient(t, "127.0.0.1:3", now.Add(-2*time.Hour), now.Add(-time.Hour))
+	old.active.Store(1)
+	p := &GRPCPool{
+		conns:   map[string]*Client{fresh.addr: fresh, dead.addr: dead, idle.addr: idle, old.addr: old},
+		options: Options{IdleTimeout: 10 * time.Minute, MaxAge: time.Hour},
+	}
+
+	p.Check()
+	if p.Size() != 1 {
+		t.Fatalf("unhealthy, idle and expired connections should be evicted, got %d", p.Size())
+	}
+	stats := p.Stats()
+	if len(stats) != 3 || stats[0].Addr != dead.addr || !stats[0].Retired ||
+		stats[1].Addr != old.addr || !stats[1].Retired || stats[1].ActiveCalls != 1 ||
+		stats[2].Addr != fresh.addr || stats[2].Retired {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if idle.conn.GetState().String() != "SHUTDOWN" {
+		t.Fatal("idle connection should be closed")
+	}
+
+	// 请求结束后关闭已移出连接池的连接
+	old.active.Store(0)
+	dead.lastUsed.Store(now.Add(-time.Hour).UnixNano())
+	p.Check()
+	if len(p.Stats()) != 1 || old.conn.GetState().String() != "SHUTDOWN" {
+		t.Fatal("retired connection should be closed after calls finish")
+	}
+
+	// 从连接池取出后更新最近使用时间
+	if client, err := p.Get(fresh.addr); err != nil || client != fresh || fresh.idle() > time.Second {
+		t.Fatalf("Get = %v, %v", client, err)
+	}
+}
+
+func TestPoolReleaseWaitsForCalls(t *testing.T) {
+	now := time.Now()
+	client := newTestClient(t, "127.0.0.1:1", now, now.Add(-time.Hour))
+	client.active.Store(1)
+	p := &GRPCPool{conns: map[string]*Client{client.addr: client}, stop: make(chan struct{})}
+
+	p.Release(client.addr)
+	if p.Size() != 0 {
+		t.Fatal("released connection should be removed from the pool")
+	}
+	if client.conn.GetState().String() == "SHUTDOWN" {
+		t.Fatal("released connection with active calls should not be closed")
+	}
+	client.active.Store(0)
+	p.Check()
+	if len(p.Stats()) != 0 || client.conn.GetState().String() != "SHUTDOWN" {
+		t.Fatal("released connection should be closed after calls finish")
+	}
+}
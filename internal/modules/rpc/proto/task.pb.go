@@ -23,9 +23,12 @@ const (
 
 type TaskRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Command       string                 `protobuf:"bytes,2,opt,name=command,proto3" json:"command,omitempty"`  // 命令
-	Timeout       int32                  `protobuf:"varint,3,opt,name=timeout,proto3" json:"timeout,omitempty"` // 任务执行超时时间
-	Id            int64                  `protobuf:"varint,4,opt,name=id,proto3" json:"id,omitempty"`           // 执行任务唯一ID
+	Command       string                 `protobuf:"bytes,2,opt,name=command,proto3" json:"command,omitempty"`              // 命令
+	Timeout       int32                  `protobuf:"varint,3,opt,name=timeout,proto3" json:"timeout,omitempty"`             // 任务执行超时时间
+	Id            int64                  `protobuf:"varint,4,opt,name=id,proto3" json:"id,omitempty"`                       // 执行任务唯一ID
+	Signature     string                 `protobuf:"bytes,5,opt,name=signature,proto3" json:"signature,omitempty"`          // 命令签名, base64编码的ed25519签名
+	Detached      bool                   `protobuf:"varint,6,opt,name=detached,proto3" json:"detached,omitempty"`           // 分离执行: 节点启动任务后立即返回, 通过 JobStatus 查询结果
+	TaskId        int64                  `protobuf:"varint,7,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"` // 任务ID, 参与命令签名校验
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -81,10 +84,32 @@ func (x *TaskRequest) GetId() int64 {
 	return 0
 }
 
+func (x *TaskRequest) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *TaskRequest) GetDetached() bool {
+	if x != nil {
+		return x.Detached
+	}
+	return false
+}
+
+func (x *TaskRequest) GetTaskId() int64 {
+	if x != nil {
+		return x.TaskId
+	}
+	return 0
+}
+
 type TaskResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Output        string                 `protobuf:"bytes,1,opt,name=output,proto3" json:"output,omitempty"` // 命令标准输出
-	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`   // 命令错误
+	Output        string                 `protobuf:"bytes,1,opt,name=output,proto3" json:"output,omitempty"`      // 命令标准输出
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`        // 命令错误
+	Detached      bool                   `protobuf:"varint,3,opt,name=detached,proto3" json:"detached,omitempty"` // 任务已在节点分离执行, 旧版本节点不支持时为false
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -133,21 +158,634 @@ func (x *TaskResponse) GetError() string {
 	return ""
 }
 
+func (x *TaskResponse) GetDetached() bool {
+	if x != nil {
+		return x.Detached
+	}
+	return false
+}
+
+type HealthRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthRequest) Reset() {
+	*x = HealthRequest{}
+	mi := &file_task_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthRequest) ProtoMessage() {}
+
+func (x *HealthRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_task_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthRequest.ProtoReflect.Descriptor instead.
+func (*HealthRequest) Descriptor() ([]byte, []int) {
+	return file_task_proto_rawDescGZIP(), []int{2}
+}
+
+type HealthResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`                             // 节点版本
+	Os            string                 `protobuf:"bytes,2,opt,name=os,proto3" json:"os,omitempty"`                                       // 操作系统
+	Arch          string                 `protobuf:"bytes,3,opt,name=arch,proto3" json:"arch,omitempty"`                                   // CPU架构
+	Load1         float64                `protobuf:"fixed64,4,opt,name=load1,proto3" json:"load1,omitempty"`                               // 1分钟平均负载
+	MemTotal      uint64                 `protobuf:"varint,5,opt,name=mem_total,json=memTotal,proto3" json:"mem_total,omitempty"`          // 内存总量(字节)
+	MemFree       uint64                 `protobuf:"varint,6,opt,name=mem_free,json=memFree,proto3" json:"mem_free,omitempty"`             // 可用内存(字节)
+	DiskTotal     uint64                 `protobuf:"varint,7,opt,name=disk_total,json=diskTotal,proto3" json:"disk_total,omitempty"`       // 工作目录所在磁盘总量(字节)
+	DiskFree      uint64                 `protobuf:"varint,8,opt,name=disk_free,json=diskFree,proto3" json:"disk_free,omitempty"`          // 工作目录所在磁盘可用空间(字节)
+	RunningJobs   int32                  `protobuf:"varint,9,opt,name=running_jobs,json=runningJobs,proto3" json:"running_jobs,omitempty"` // 正在运行的任务数
+	Labels        string                 `protobuf:"bytes,10,opt,name=labels,proto3" json:"labels,omitempty"`                              // 节点配置的标签, key=value 多个以逗号分隔
+	Draining      bool                   `protobuf:"varint,11,opt,name=draining,proto3" json:"draining,omitempty"`                         // 节点正在排空, 不再接收新任务
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthResponse) Reset() {
+	*x = HealthResponse{}
+	mi := &file_task_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthResponse) ProtoMessage() {}
+
+func (x *HealthResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_task_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthResponse.ProtoReflect.Descriptor instead.
+func (*HealthResponse) Descriptor() ([]byte, []int) {
+	return file_task_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *HealthResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *HealthResponse) GetOs() string {
+	if x != nil {
+		return x.Os
+	}
+	return ""
+}
+
+func (x *HealthResponse) GetArch() string {
+	if x != nil {
+		return x.Arch
+	}
+	return ""
+}
+
+func (x *HealthResponse) GetLoad1() float64 {
+	if x != nil {
+		return x.Load1
+	}
+	return 0
+}
+
+func (x *HealthResponse) GetMemTotal() uint64 {
+	if x != nil {
+		return x.MemTotal
+	}
+	return 0
+}
+
+func (x *HealthResponse) GetMemFree() uint64 {
+	if x != nil {
+		return x.MemFree
+	}
+	return 0
+}
+
+func (x *HealthResponse) GetDiskTotal() uint64 {
+	if x != nil {
+		return x.DiskTotal
+	}
+	return 0
+}
+
+func (x *HealthResponse) GetDiskFree() uint64 {
+	if x != nil {
+		return x.DiskFree
+	}
+	return 0
+}
+
+func (x *HealthResponse) GetRunningJobs() int32 {
+	if x != nil {
+		return x.RunningJobs
+	}
+	return 0
+}
+
+func (x *HealthResponse) GetLabels() string {
+	if x != nil {
+		return x.Labels
+	}
+	return ""
+}
+
+func (x *HealthResponse) GetDraining() bool {
+	if x != nil {
+		return x.Draining
+	}
+	return false
+}
+
+// 查询分离执行的任务状态
+type JobStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`           // 执行任务唯一ID
+	Offset        int64                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`   // 输出读取位置(字节)
+	Release       bool                   `protobuf:"varint,3,opt,name=release,proto3" json:"release,omitempty"` // 任务已结束时删除节点保存的状态及输出
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobStatusRequest) Reset() {
+	*x = JobStatusRequest{}
+	mi := &file_task_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobStatusRequest) ProtoMessage() {}
+
+func (x *JobStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_task_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobStatusRequest.ProtoReflect.Descriptor instead.
+func (*JobStatusRequest) Descriptor() ([]byte, []int) {
+	return file_task_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *JobStatusRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *JobStatusRequest) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *JobStatusRequest) GetRelease() bool {
+	if x != nil {
+		return x.Release
+	}
+	return false
+}
+
+type JobStatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Found         bool                   `protobuf:"varint,1,opt,name=found,proto3" json:"found,omitempty"`   // 节点上是否存在该任务
+	Done          bool                   `protobuf:"varint,2,opt,name=done,proto3" json:"done,omitempty"`     // 任务已结束且输出已全部返回
+	Output        string                 `protobuf:"bytes,3,opt,name=output,proto3" json:"output,omitempty"`  // 从 offset 开始的输出
+	Offset        int64                  `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"` // 下次读取位置
+	Error         string                 `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`    // 任务结束时的错误
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobStatusResponse) Reset() {
+	*x = JobStatusResponse{}
+	mi := &file_task_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobStatusResponse) ProtoMessage() {}
+
+func (x *JobStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_task_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobStatusResponse.ProtoReflect.Descriptor instead.
+func (*JobStatusResponse) Descriptor() ([]byte, []int) {
+	return file_task_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *JobStatusResponse) GetFound() bool {
+	if x != nil {
+		return x.Found
+	}
+	return false
+}
+
+func (x *JobStatusResponse) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
+func (x *JobStatusResponse) GetOutput() string {
+	if x != nil {
+		return x.Output
+	}
+	return ""
+}
+
+func (x *JobStatusResponse) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *JobStatusResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// 更新节点令牌, 请求需携带节点当前的令牌
+type TokenRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"` // 新令牌, 为空时吊销: 节点在重新注册前拒绝所有请求
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TokenRequest) Reset() {
+	*x = TokenRequest{}
+	mi := &file_task_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TokenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TokenRequest) ProtoMessage() {}
+
+func (x *TokenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_task_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TokenRequest.ProtoReflect.Descriptor instead.
+func (*TokenRequest) Descriptor() ([]byte, []int) {
+	return file_task_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *TokenRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+type TokenResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TokenResponse) Reset() {
+	*x = TokenResponse{}
+	mi := &file_task_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TokenResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TokenResponse) ProtoMessage() {}
+
+func (x *TokenResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_task_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TokenResponse.ProtoReflect.Descriptor instead.
+func (*TokenResponse) Descriptor() ([]byte, []int) {
+	return file_task_proto_rawDescGZIP(), []int{7}
+}
+
+type AgentMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`         // hello: 建立连接, result: 执行结果, health: 健康状态, job_status: 任务状态
+	Seq           int64                  `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`          // 对应 ServerMessage 的序号
+	Hostname      string                 `protobuf:"bytes,3,opt,name=hostname,proto3" json:"hostname,omitempty"` // 节点主机名, 需与注册时一致
+	Response      *TaskResponse          `protobuf:"bytes,4,opt,name=response,proto3" json:"response,omitempty"`
+	Health        *HealthResponse        `protobuf:"bytes,5,opt,name=health,proto3" json:"health,omitempty"`
+	Token         string                 `protobuf:"bytes,6,opt,name=token,proto3" json:"token,omitempty"` // 节点令牌, hello 时发送
+	Job           *JobStatusResponse     `protobuf:"bytes,7,opt,name=job,proto3" json:"job,omitempty"`
+	Error         string                 `protobuf:"bytes,8,opt,name=error,proto3" json:"error,omitempty"` // 请求失败时的错误, 用于 set_token
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentMessage) Reset() {
+	*x = AgentMessage{}
+	mi := &file_task_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentMessage) ProtoMessage() {}
+
+func (x *AgentMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_task_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentMessage.ProtoReflect.Descriptor instead.
+func (*AgentMessage) Descriptor() ([]byte, []int) {
+	return file_task_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *AgentMessage) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *AgentMessage) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *AgentMessage) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *AgentMessage) GetResponse() *TaskResponse {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+func (x *AgentMessage) GetHealth() *HealthResponse {
+	if x != nil {
+		return x.Health
+	}
+	return nil
+}
+
+func (x *AgentMessage) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *AgentMessage) GetJob() *JobStatusResponse {
+	if x != nil {
+		return x.Job
+	}
+	return nil
+}
+
+func (x *AgentMessage) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ServerMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"` // run: 执行任务, cancel: 取消执行, health: 健康检查, job_status: 查询分离执行的任务, set_token: 更新节点令牌
+	Seq           int64                  `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`  // 请求序号
+	Request       *TaskRequest           `protobuf:"bytes,3,opt,name=request,proto3" json:"request,omitempty"`
+	Job           *JobStatusRequest      `protobuf:"bytes,4,opt,name=job,proto3" json:"job,omitempty"`
+	Token         *TokenRequest          `protobuf:"bytes,5,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServerMessage) Reset() {
+	*x = ServerMessage{}
+	mi := &file_task_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServerMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerMessage) ProtoMessage() {}
+
+func (x *ServerMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_task_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerMessage.ProtoReflect.Descriptor instead.
+func (*ServerMessage) Descriptor() ([]byte, []int) {
+	return file_task_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ServerMessage) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *ServerMessage) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *ServerMessage) GetRequest() *TaskRequest {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetJob() *JobStatusRequest {
+	if x != nil {
+		return x.Job
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetToken() *TokenRequest {
+	if x != nil {
+		return x.Token
+	}
+	return nil
+}
+
 var File_task_proto protoreflect.FileDescriptor
 
 const file_task_proto_rawDesc = "" +
 	"\n" +
 	"\n" +
-	"task.proto\x12\x03rpc\"Q\n" +
+	"task.proto\x12\x03rpc\"\xa4\x01\n" +
 	"\vTaskRequest\x12\x18\n" +
 	"\acommand\x18\x02 \x01(\tR\acommand\x12\x18\n" +
 	"\atimeout\x18\x03 \x01(\x05R\atimeout\x12\x0e\n" +
-	"\x02id\x18\x04 \x01(\x03R\x02id\"<\n" +
+	"\x02id\x18\x04 \x01(\x03R\x02id\x12\x1c\n" +
+	"\tsignature\x18\x05 \x01(\tR\tsignature\x12\x1a\n" +
+	"\bdetached\x18\x06 \x01(\bR\bdetached\x12\x17\n" +
+	"\atask_id\x18\a \x01(\x03R\x06taskId\"X\n" +
 	"\fTaskResponse\x12\x16\n" +
 	"\x06output\x18\x01 \x01(\tR\x06output\x12\x14\n" +
-	"\x05error\x18\x02 \x01(\tR\x05error24\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12\x1a\n" +
+	"\bdetached\x18\x03 \x01(\bR\bdetached\"\x0f\n" +
+	"\rHealthRequest\"\xaf\x02\n" +
+	"\x0eHealthResponse\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12\x0e\n" +
+	"\x02os\x18\x02 \x01(\tR\x02os\x12\x12\n" +
+	"\x04arch\x18\x03 \x01(\tR\x04arch\x12\x14\n" +
+	"\x05load1\x18\x04 \x01(\x01R\x05load1\x12\x1b\n" +
+	"\tmem_total\x18\x05 \x01(\x04R\bmemTotal\x12\x19\n" +
+	"\bmem_free\x18\x06 \x01(\x04R\amemFree\x12\x1d\n" +
+	"\n" +
+	"disk_total\x18\a \x01(\x04R\tdiskTotal\x12\x1b\n" +
+	"\tdisk_free\x18\b \x01(\x04R\bdiskFree\x12!\n" +
+	"\frunning_jobs\x18\t \x01(\x05R\vrunningJobs\x12\x16\n" +
+	"\x06labels\x18\n" +
+	" \x01(\tR\x06labels\x12\x1a\n" +
+	"\bdraining\x18\v \x01(\bR\bdraining\"T\n" +
+	"\x10JobStatusRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x03R\x06offset\x12\x18\n" +
+	"\arelease\x18\x03 \x01(\bR\arelease\"\x83\x01\n" +
+	"\x11JobStatusResponse\x12\x14\n" +
+	"\x05found\x18\x01 \x01(\bR\x05found\x12\x12\n" +
+	"\x04done\x18\x02 \x01(\bR\x04done\x12\x16\n" +
+	"\x06output\x18\x03 \x01(\tR\x06output\x12\x16\n" +
+	"\x06offset\x18\x04 \x01(\x03R\x06offset\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\"$\n" +
+	"\fTokenRequest\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\"\x0f\n" +
+	"\rTokenResponse\"\x82\x02\n" +
+	"\fAgentMessage\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x10\n" +
+	"\x03seq\x18\x02 \x01(\x03R\x03seq\x12\x1a\n" +
+	"\bhostname\x18\x03 \x01(\tR\bhostname\x12-\n" +
+	"\bresponse\x18\x04 \x01(\v2\x11.rpc.TaskResponseR\bresponse\x12+\n" +
+	"\x06health\x18\x05 \x01(\v2\x13.rpc.HealthResponseR\x06health\x12\x14\n" +
+	"\x05token\x18\x06 \x01(\tR\x05token\x12(\n" +
+	"\x03job\x18\a \x01(\v2\x16.rpc.JobStatusResponseR\x03job\x12\x14\n" +
+	"\x05error\x18\b \x01(\tR\x05error\"\xb3\x01\n" +
+	"\rServerMessage\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x10\n" +
+	"\x03seq\x18\x02 \x01(\x03R\x03seq\x12*\n" +
+	"\arequest\x18\x03 \x01(\v2\x10.rpc.TaskRequestR\arequest\x12'\n" +
+	"\x03job\x18\x04 \x01(\v2\x15.rpc.JobStatusRequestR\x03job\x12'\n" +
+	"\x05token\x18\x05 \x01(\v2\x11.rpc.TokenRequestR\x05token2\xdc\x01\n" +
 	"\x04Task\x12,\n" +
-	"\x03Run\x12\x10.rpc.TaskRequest\x1a\x11.rpc.TaskResponse\"\x00B7Z5github.com/tabortao/gocron/internal/modules/rpc/protob\x06proto3"
+	"\x03Run\x12\x10.rpc.TaskRequest\x1a\x11.rpc.TaskResponse\"\x00\x123\n" +
+	"\x06Health\x12\x12.rpc.HealthRequest\x1a\x13.rpc.HealthResponse\"\x00\x12<\n" +
+	"\tJobStatus\x12\x15.rpc.JobStatusRequest\x1a\x16.rpc.JobStatusResponse\"\x00\x123\n" +
+	"\bSetToken\x12\x11.rpc.TokenRequest\x1a\x12.rpc.TokenResponse\"\x002?\n" +
+	"\x05Agent\x126\n" +
+	"\aConnect\x12\x11.rpc.AgentMessage\x1a\x12.rpc.ServerMessage\"\x00(\x010\x01B7Z5github.com/tabortao/gocron/internal/modules/rpc/protob\x06proto3"
 
 var (
 	file_task_proto_rawDescOnce sync.Once
@@ -161,19 +799,41 @@ func file_task_proto_rawDescGZIP() []byte {
 	return file_task_proto_rawDescData
 }
 
-var file_task_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_task_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
 var file_task_proto_goTypes = []any{
-	(*TaskRequest)(nil),  // 0: rpc.TaskRequest
-	(*TaskResponse)(nil), // 1: rpc.TaskResponse
+	(*TaskRequest)(nil),       // 0: rpc.TaskRequest
+	(*TaskResponse)(nil),      // 1: rpc.TaskResponse
+	(*HealthRequest)(nil),     // 2: rpc.HealthRequest
+	(*HealthResponse)(nil),    // 3: rpc.HealthResponse
+	(*JobStatusRequest)(nil),  // 4: rpc.JobStatusRequest
+	(*JobStatusResponse)(nil), // 5: rpc.JobStatusResponse
+	(*TokenRequest)(nil),      // 6: rpc.TokenRequest
+	(*TokenResponse)(nil),     // 7: rpc.TokenResponse
+	(*AgentMessage)(nil),      // 8: rpc.AgentMessage
+	(*ServerMessage)(nil),     // 9: rpc.ServerMessage
 }
 var file_task_proto_depIdxs = []int32{
-	0, // 0: rpc.Task.Run:input_type -> rpc.TaskRequest
-	1, // 1: rpc.Task.Run:output_type -> rpc.TaskResponse
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	1,  // 0: rpc.AgentMessage.response:type_name -> rpc.TaskResponse
+	3,  // 1: rpc.AgentMessage.health:type_name -> rpc.HealthResponse
+	5,  // 2: rpc.AgentMessage.job:type_name -> rpc.JobStatusResponse
+	0,  // 3: rpc.ServerMessage.request:type_name -> rpc.TaskRequest
+	4,  // 4: rpc.ServerMessage.job:type_name -> rpc.JobStatusRequest
+	6,  // 5: rpc.ServerMessage.token:type_name -> rpc.TokenRequest
+	0,  // 6: rpc.Task.Run:input_type -> rpc.TaskRequest
+	2,  // 7: rpc.Task.Health:input_type -> rpc.HealthRequest
+	4,  // 8: rpc.Task.JobStatus:input_type -> rpc.JobStatusRequest
+	6,  // 9: rpc.Task.SetToken:input_type -> rpc.TokenRequest
+	8,  // 10: rpc.Agent.Connect:input_type -> rpc.AgentMessage
+	1,  // 11: rpc.Task.Run:output_type -> rpc.TaskResponse
+	3,  // 12: rpc.Task.Health:output_type -> rpc.HealthResponse
+	5,  // 13: rpc.Task.JobStatus:output_type -> rpc.JobStatusResponse
+	7,  // 14: rpc.Task.SetToken:output_type -> rpc.TokenResponse
+	9,  // 15: rpc.Agent.Connect:output_type -> rpc.ServerMessage
+	11, // [11:16] is the sub-list for method output_type
+	6,  // [6:11] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
 }
 
 func init() { file_task_proto_init() }
@@ -187,9 +847,9 @@ func file_task_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_task_proto_rawDesc), len(file_task_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   10,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   2,
 		},
 		GoTypes:           file_task_proto_goTypes,
 		DependencyIndexes: file_task_proto_depIdxs,
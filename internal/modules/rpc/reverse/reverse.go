@@ -0,0 +1,396 @@
+// Package reverse 实现节点反向连接, 用于服务端无法直接访问的节点(NAT、防火墙后)
+// 节点主动连接服务端并保持双向流, 服务端通过该流下发任务
+package reverse
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/tabortao/gocron/internal/modules/logger"
+	"github.com/tabortao/gocron/internal/modules/rpc/auth"
+	pb "github.com/tabortao/gocron/internal/modules/rpc/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// 消息类型
+const (
+	TypeHello  = "hello"
+	TypeResult = "result"
+	TypeHealth = "health"
+	TypeRun    = "run"
+	TypeCancel = "cancel"
+	// 查询分离执行的任务状态
+	TypeJobStatus = "job_status"
+	// 更新节点令牌
+	TypeSetToken = "set_token"
+)
+
+// 等待节点发送 hello 的超时时间
+const helloTimeout = 10 * time.Second
+
+var (
+	ErrDisconnected = errors.New("reverse connection closed")
+	ErrConnected    = errors.New("host is already connected")
+
+	registry = &Registry{conns: make(map[string]*Conn)}
+
+	keepAlivePolicy = keepalive.EnforcementPolicy{
+		MinTime:             10 * time.Second,
+		PermitWithoutStream: true,
+	}
+	keepAliveParams = keepalive.ServerParameters{
+		Time:    30 * time.Second,
+		Timeout: 3 * time.Second,
+	}
+
+	mu     sync.Mutex
+	server *grpc.Server
+)
+
+// Registry 已连接的节点, key为主机名
+type Registry struct {
+	mu    sync.RWMutex
+	conns map[string]*Conn
+}
+
+// Lookup 查找已反向连接的节点
+func Lookup(hostname string) (*Conn, bool) {
+	return registry.Lookup(hostname)
+}
+
+// Size 已反向连接的节点数
+func Size() int {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	return len(registry.conns)
+}
+
+func (r *Registry) Lookup(hostname string) (*Conn, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	conn, ok := r.conns[hostname]
+	return conn, ok
+}
+
+// Disconnect 断开节点的反向连接, 如节点令牌被吊销后
+func Disconnect(hostname string) {
+	if conn, ok := registry.Lookup(hostname); ok {
+		conn.close()
+	}
+}
+
+// 同一主机已有连接时拒绝新连接, 旧连接断开(含keepalive检测到失效)后才能重新连接
+func (r *Registry) add(conn *Conn) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if old, ok := r.conns[conn.hostname]; ok && !old.closed() {
+		return ErrConnected
+	}
+	r.conns[conn.hostname] = conn
+
+	return nil
+}
+
+func (r *Registry) remove(conn *Conn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conns[conn.hostname] == conn {
+		delete(r.conns, conn.hostname)
+	}
+}
+
+// Conn 单个节点的反向连接, 实现 pb.TaskClient, 可替代直连的gRPC客户端
+type Conn struct {
+	hostname string
+	stream   pb.Agent_ConnectServer
+	sendMu   sync.Mutex
+	mu       sync.Mutex
+	seq      int64
+	pending  map[int64]chan *pb.AgentMessage
+	done     chan struct{}
+	once     sync.Once
+}
+
+var _ pb.TaskClient = (*Conn)(nil)
+
+func newConn(hostname string, stream pb.Agent_ConnectServer) *Conn {
+	return &Conn{
+		hostname: hostname,
+		stream:   stream,
+		pending:  make(map[int64]chan *pb.AgentMessage),
+		done:     make(chan struct{}),
+	}
+}
+
+func (c *Conn) Run(ctx context.Context, req *pb.TaskRequest, _ ...grpc.CallOption) (*pb.TaskResponse, error) {
+	reply, err := c.call(ctx, &pb.ServerMessage{Type: TypeRun, Request: req})
+	if err != nil {
+		return nil, err
+	}
+	if reply.Response == nil {
+		return &pb.TaskResponse{}, nil
+	}
+
+	return reply.Response, nil
+}
+
+func (c *Conn) Health(ctx context.Context, _ *pb.HealthRequest, _ ...grpc.CallOption) (*pb.HealthResponse, error) {
+	reply, err := c.call(ctx, &pb.ServerMessage{Type: TypeHealth})
+	if err != nil {
+		return nil, err
+	}
+	if reply.Health == nil {
+		return nil, status.Error(codes.Unimplemented, "health not supported")
+	}
+
+	return reply.Health, nil
+}
+
+func (c *Conn) JobStatus(ctx context.Context, req *pb.JobStatusRequest, _ ...grpc.CallOption) (*pb.JobStatusResponse, error) {
+	reply, err := c.call(ctx, &pb.ServerMessage{Type: TypeJobStatus, Job: req})
+	if err != nil {
+		return nil, err
+	}
+	if reply.Job == nil {
+		return nil, status.Error(codes.Unimplemented, "job status not supported")
+	}
+
+	return reply.Job, nil
+}
+
+// SetToken 旧版本节点不处理该消息, 等待至 context 结束
+func (c *Conn) SetToken(ctx context.Context, req *pb.TokenRequest, _ ...grpc.CallOption) (*pb.TokenResponse, error) {
+	reply, err := c.call(ctx, &pb.ServerMessage{Type: TypeSetToken, Token: req})
+	if err != nil {
+		return nil, err
+	}
+	if reply.Error != "" {
+		return nil, status.Error(codes.FailedPrecondition, reply.Error)
+	}
+
+	return &pb.TokenResponse{}, nil
+}
+
+// 发送请求并等待节点返回, 错误以gRPC状态码返回, 与直连时保持一致
+// context 结束时通知节点取消执行
+func (c *Conn) call(ctx context.Context, msg *pb.ServerMessage) (*pb.AgentMessage, error) {
+	reply := make(chan *pb.AgentMessage, 1)
+	c.mu.Lock()
+	c.seq++
+	msg.Seq = c.seq
+	c.pending[msg.Seq] = reply
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, msg.Seq)
+		c.mu.Unlock()
+	}()
+
+	if err := c.send(msg); err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	select {
+	case r := <-reply:
+		return r, nil
+	case <-c.done:
+		return nil, status.Error(codes.Unavailable, ErrDisconnected.Error())
+	case <-ctx.Done():
+		if err := c.send(&pb.ServerMessage{Type: TypeCancel, Seq: msg.Seq}); err != nil {
+			logger.Warnf("反向连接#发送取消信号失败#%s#%v", c.hostname, err)
+		}
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// 连接关闭后不再发送, 避免在流结束后调用 Send
+func (c *Conn) send(msg *pb.ServerMessage) error {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	select {
+	case <-c.done:
+		return ErrDisconnected
+	default:
+	}
+	return c.stream.Send(msg)
+}
+
+// 将节点返回的结果交给等待中的请求
+func (c *Conn) dispatch(msg *pb.AgentMessage) {
+	c.mu.Lock()
+	reply, ok := c.pending[msg.Seq]
+	c.mu.Unlock()
+	if !ok {
+		return
+	}
+	select {
+	case reply <- msg:
+	default:
+	}
+}
+
+func (c *Conn) closed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *Conn) close() {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	c.once.Do(func() { close(c.done) })
+}
+
+// AuthorizeFunc 校验节点是否允许连接, certName 为节点TLS客户端证书的CN, 未提供证书时为空
+type AuthorizeFunc func(hostname, token, certName string) error
+
+type agentServer struct {
+	pb.UnimplementedAgentServer
+	authorize AuthorizeFunc
+}
+
+// Connect 节点建立连接后先发送 hello, 之后持续接收执行结果直到连接断开
+func (s *agentServer) Connect(stream pb.Agent_ConnectServer) error {
+	hello, err := recvHello(stream)
+	if err != nil {
+		return err
+	}
+	if s.authorize == nil {
+		return status.Error(codes.PermissionDenied, "reverse connections are not allowed")
+	}
+	if err := s.authorize(hello.Hostname, hello.Token, peerCertName(stream.Context())); err != nil {
+		logger.Warnf("反向连接#拒绝节点#%s#%v", hello.Hostname, err)
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	conn := newConn(hello.Hostname, stream)
+	if err := registry.add(conn); err != nil {
+		logger.Warnf("反向连接#拒绝节点#%s#%v", hello.Hostname, err)
+		return status.Error(codes.AlreadyExists, err.Error())
+	}
+	logger.Infof("反向连接#节点已连接#%s", conn.hostname)
+	defer func() {
+		registry.remove(conn)
+		conn.close()
+		logger.Infof("反向连接#节点已断开#%s", conn.hostname)
+	}()
+
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			switch msg.Type {
+			case TypeResult, TypeHealth, TypeJobStatus, TypeSetToken:
+				conn.dispatch(msg)
+			}
+		}
+	}()
+	// 连接断开或服务停止
+	select {
+	case <-recvErr:
+	case <-conn.done:
+	}
+
+	return nil
+}
+
+// 已通过校验的TLS客户端证书的CN
+func peerCertName(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return ""
+	}
+
+	return tlsInfo.State.VerifiedChains[0][0].Subject.CommonName
+}
+
+func recvHello(stream pb.Agent_ConnectServer) (*pb.AgentMessage, error) {
+	type result struct {
+		msg *pb.AgentMessage
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		msg, err := stream.Recv()
+		ch <- result{msg, err}
+	}()
+	select {
+	case r := <-ch:
+		if r.err != nil {
+			return nil, r.err
+		}
+		if r.msg.Type != TypeHello || r.msg.Hostname == "" {
+			return nil, status.Error(codes.InvalidArgument, "hello message required")
+		}
+		return r.msg, nil
+	case <-time.After(helloTimeout):
+		return nil, status.Error(codes.DeadlineExceeded, "waiting for hello timeout")
+	}
+}
+
+// Register 注册反向连接服务, authorize 校验节点是否允许连接
+func Register(s grpc.ServiceRegistrar, authorize AuthorizeFunc) {
+	pb.RegisterAgentServer(s, &agentServer{authorize: authorize})
+}
+
+// Start 监听反向连接, authorize 校验节点是否允许连接
+func Start(addr string, enableTLS bool, certificate auth.Certificate, authorize AuthorizeFunc) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	opts := []grpc.ServerOption{
+		grpc.KeepaliveParams(keepAliveParams),
+		grpc.KeepaliveEnforcementPolicy(keepAlivePolicy),
+	}
+	if enableTLS {
+		tlsConfig, err := certificate.GetTLSConfigForServer()
+		if err != nil {
+			l.Close()
+			return err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	s := grpc.NewServer(opts...)
+	Register(s, authorize)
+
+	mu.Lock()
+	server = s
+	mu.Unlock()
+	logger.Infof("反向连接#监听地址-%s", addr)
+	go func() {
+		if err := s.Serve(l); err != nil {
+			logger.Error("反向连接#服务异常退出", err)
+		}
+	}()
+
+	return nil
+}
+
+// Stop 关闭监听及所有节点连接
+func Stop() {
+	mu.Lock()
+	defer mu.Unlock()
+	if server != nil {
+		server.Stop()
+		server = nil
+	}
+}
@@ -0,0 +1,207 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tabortao/gocron/internal/modules/rpc/auth"
+	"gopkg.in/ini.v1"
+)
+
+// Config gocron-node 配置, 可由配置文件加载, 收到 SIGHUP 时重新加载
+//
+//	[node]
+//	listen = 0.0.0.0:5921
+//	log_level = info
+//	token_file = /opt/gocron-node/node.token
+//	; 反向连接模式, 设置后不再监听端口
+//	reverse_server =
+//	hostname =
+//	; 允许运行节点的系统用户, 多个以逗号分隔, 为空时不允许root用户
+//	allow_users =
+//	; 节点标签, 健康检查时同步到服务端
+//	labels = env=prod,role=web
+//	; 分离执行任务的状态目录, 为空时不支持分离执行
+//	state_dir = /opt/gocron-node/state
+//
+//	[tls]
+//	enable = false
+//	ca_file =
+//	cert_file =
+//	key_file =
+//	server_name =
+//	renew_url =
+//	; 只接受该名称的客户端证书, 设置了renew_url(内置CA签发)时默认为gocron-server
+//	client_name =
+//
+//	[limits]
+//	; 同时运行的最大任务数, 0不限制
+//	max_jobs = 0
+//	; 任务最大超时时间(秒), 0不限制
+//	max_timeout = 0
+//	; 停止节点时等待运行中任务结束的时间(秒), 超时后终止剩余任务
+//	drain_timeout = 60
+//
+//	[policy]
+//	; 命令执行策略, 见 LoadPolicy
+type Config struct {
+	Listen        string
+	LogLevel      string
+	TokenFile     string
+	ReverseServer string
+	Hostname      string
+	AllowUsers    []string
+	Labels        string
+	StateDir      string
+
+	EnableTLS  bool
+	CAFile     string
+	CertFile   string
+	KeyFile    string
+	ServerName string
+	RenewURL   string
+	ClientName string
+
+	MaxJobs      int
+	MaxTimeout   int
+	DrainTimeout int
+
+	Policy *Policy
+}
+
+// DefaultConfig 未使用配置文件时的默认配置
+func DefaultConfig() *Config {
+	return &Config{
+		Listen:       "0.0.0.0:5921",
+		LogLevel:     "info",
+		DrainTimeout: defaultDrainTimeout,
+		Policy:       &Policy{},
+	}
+}
+
+// LoadConfig 读取配置文件, 未配置的项使用默认值
+func LoadConfig(file string) (*Config, error) {
+	cfg, err := ini.LoadSources(ini.LoadOptions{AllowShadows: true}, file)
+	if err != nil {
+		return nil, err
+	}
+	c := DefaultConfig()
+
+	section := cfg.Section("node")
+	c.Listen = strings.TrimSpace(section.Key("listen").MustString(c.Listen))
+	c.LogLevel = strings.TrimSpace(section.Key("log_level").MustString(c.LogLevel))
+	c.TokenFile = strings.TrimSpace(section.Key("token_file").String())
+	c.ReverseServer = strings.TrimSpace(section.Key("reverse_server").String())
+	c.Hostname = strings.TrimSpace(section.Key("hostname").String())
+	c.AllowUsers = splitList(section.Key("allow_users").String())
+	c.Labels = strings.TrimSpace(section.Key("labels").String())
+	c.StateDir = strings.TrimSpace(section.Key("state_dir").String())
+
+	section = cfg.Section("tls")
+	c.EnableTLS = section.Key("enable").MustBool(false)
+	c.CAFile = strings.TrimSpace(section.Key("ca_file").String())
+	c.CertFile = strings.TrimSpace(section.Key("cert_file").String())
+	c.KeyFile = strings.TrimSpace(section.Key("key_file").String())
+	c.ServerName = strings.TrimSpace(section.Key("server_name").String())
+	c.RenewURL = strings.TrimSpace(section.Key("renew_url").String())
+	c.ClientName = strings.TrimSpace(section.Key("client_name").String())
+
+	section = cfg.Section("limits")
+	c.MaxJobs = section.Key("max_jobs").MustInt(0)
+	c.MaxTimeout = section.Key("max_timeout").MustInt(0)
+	c.DrainTimeout = section.Key("drain_timeout").MustInt(c.DrainTimeout)
+	if c.MaxJobs < 0 || c.MaxTimeout < 0 || c.DrainTimeout < 0 {
+		return nil, fmt.Errorf("max_jobs, max_timeout and drain_timeout must not be negative")
+	}
+
+	c.Policy, err = parsePolicy(cfg.Section("policy"))
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Certificate TLS证书配置
+func (c *Config) Certificate() auth.Certificate {
+	clientName := c.ClientName
+	// 内置CA同时签发节点和服务端证书, 只接受服务端证书, 其他节点的证书不能用于请求本节点
+	if clientName == "" && c.RenewURL != "" {
+		clientName = auth.BuiltinServerName
+	}
+
+	return auth.Certificate{
+		CAFile:     c.CAFile,
+		CertFile:   c.CertFile,
+		KeyFile:    c.KeyFile,
+		ServerName: c.ServerName,
+		ClientName: clientName,
+	}
+}
+
+// ReadToken 读取节点令牌文件, 未配置时返回空
+func (c *Config) ReadToken() (string, error) {
+	if c.TokenFile == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(c.TokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read node token file: %w", err)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// WriteToken 写入节点令牌文件, 直接覆盖原文件, 令牌文件所在目录可能没有写权限
+func (c *Config) WriteToken(token string) error {
+	if c.TokenFile == "" {
+		return errors.New("node.token_file is not configured")
+	}
+	if err := os.WriteFile(c.TokenFile, []byte(token+"\n"), 0o600); err != nil {
+		return fmt.Errorf("failed to write node token file: %w", err)
+	}
+
+	return nil
+}
+
+// 重新加载时不能生效, 需重启节点的配置项
+func (c *Config) restartRequired(newConfig *Config) []string {
+	items := make([]string, 0)
+	if c.ReverseServer != newConfig.ReverseServer {
+		items = append(items, "reverse_server")
+	}
+	if c.Hostname != newConfig.Hostname {
+		items = append(items, "hostname")
+	}
+	if strings.Join(c.AllowUsers, ",") != strings.Join(newConfig.AllowUsers, ",") {
+		items = append(items, "allow_users")
+	}
+	if c.StateDir != newConfig.StateDir {
+		items = append(items, "state_dir")
+	}
+	if c.EnableTLS != newConfig.EnableTLS {
+		items = append(items, "tls.enable")
+	}
+	if c.RenewURL != newConfig.RenewURL {
+		items = append(items, "tls.renew_url")
+	}
+	// 反向连接使用的gRPC连接创建后不能更换CA
+	if c.ReverseServer != "" && (c.CAFile != newConfig.CAFile || c.ServerName != newConfig.ServerName) {
+		items = append(items, "tls.ca_file", "tls.server_name")
+	}
+
+	return items
+}
+
+func splitList(s string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
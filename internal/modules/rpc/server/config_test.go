@@ -0,0 +1,204 @@
+package server
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tabortao/gocron/internal/modules/rpc/auth"
+	pb "github.com/tabortao/gocron/internal/modules/rpc/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "node.token")
+	os.WriteFile(tokenFile, []byte("secret\n"), 0600)
+	cfg, err := LoadConfig(writePolicyFile(t, `[node]
+listen = 127.0.0.1:6000
+log_level = debug
+token_file = `+tokenFile+`
+allow_users = gocron, deploy
+labels = env=prod
+state_dir = /var/lib/gocron-node
+
+[tls]
+enable = true
+ca_file = /etc/gocron-node/ca.crt
+
+[limits]
+max_jobs = 4
+max_timeout = 600
+
+[policy]
+interpreters = bash
+`))
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Listen != "127.0.0.1:6000" || cfg.LogLevel != "debug" || cfg.Labels != "env=prod" || cfg.StateDir != "/var/lib/gocron-node" ||
+		strings.Join(cfg.AllowUsers, ",") != "gocron,deploy" {
+		t.Fatalf("unexpected node config: %+v", cfg)
+	}
+	if !cfg.EnableTLS || cfg.CAFile != "/etc/gocron-node/ca.crt" || cfg.MaxJobs != 4 || cfg.MaxTimeout != 600 {
+		t.Fatalf("unexpected tls or limits config: %+v", cfg)
+	}
+	if token, err := cfg.ReadToken(); err != nil || token != "secret" {
+		t.Fatalf("ReadToken = %q, %v", token, err)
+	}
+	if cfg.Policy.Check(0, "sh -c id", "") == nil {
+		t.Fatal("policy section should be loaded")
+	}
+	if name := cfg.Certificate().ClientName; name != "" {
+		t.Fatalf("client name should be empty without renew_url, got %q", name)
+	}
+	// 内置CA签发的证书默认只接受服务端的客户端证书
+	cfg.RenewURL = "http://gocron-web:5920"
+	if name := cfg.Certificate().ClientName; name != auth.BuiltinServerName {
+		t.Fatalf("expected client name %q, got %q", auth.BuiltinServerName, name)
+	}
+
+	// 未配置的项使用默认值
+	cfg, err = LoadConfig(writePolicyFile(t, "[node]\n"))
+	if err != nil || cfg.Listen != "0.0.0.0:5921" || cfg.LogLevel != "info" {
+		t.Fatalf("expected default config, got %+v, %v", cfg, err)
+	}
+	if _, err := LoadConfig(writePolicyFile(t, "[limits]\nmax_jobs = -1\n")); err == nil {
+		t.Fatal("negative limit should be rejected")
+	}
+}
+
+func TestRestartRequired(t *testing.T) {
+	old := &Config{Listen: "0.0.0.0:5921", ReverseServer: "server:5922", CAFile: "a.crt"}
+	changed := *old
+	changed.Listen = "0.0.0.0:5923"
+	changed.MaxJobs = 2
+	if items := old.restartRequired(&changed); len(items) != 0 {
+		t.Fatalf("listen and limits can be reloaded, got %v", items)
+	}
+	changed.CAFile = "b.crt"
+	changed.EnableTLS = true
+	if items := strings.Join(old.restartRequired(&changed), ","); items != "tls.enable,tls.ca_file,tls.server_name" {
+		t.Fatalf("unexpected restart items: %s", items)
+	}
+}
+
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func dialTaskClient(t *testing.T, addr string) pb.TaskClient {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewTaskClient(conn)
+}
+
+func TestReloadKeepsRunningJobs(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "node.token")
+	os.WriteFile(tokenFile, []byte("token-1"), 0600)
+
+	cfg := DefaultConfig()
+	cfg.Listen = freeAddr(t)
+	cfg.TokenFile = tokenFile
+	s, err := newServer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unary, stream := grpcTokenOptions(s)
+	s.grpcServer = grpc.NewServer(unary, stream)
+	pb.RegisterTaskServer(s.grpcServer, s)
+	t.Cleanup(s.grpcServer.Stop)
+	if err := s.listen(cfg.Listen); err != nil {
+		t.Fatal(err)
+	}
+	oldClient := dialTaskClient(t, cfg.Listen)
+
+	// 重新加载前开始执行的任务
+	done := make(chan *pb.TaskResponse, 1)
+	go func() {
+		ctx := withTestToken("token-1")
+		resp, err := oldClient.Run(ctx, &pb.TaskRequest{Command: "sleep 1", Id: 1, Timeout: 10})
+		if err != nil {
+			resp = &pb.TaskResponse{Error: err.Error()}
+		}
+		done <- resp
+	}()
+	deadline := time.Now().Add(3 * time.Second)
+	for s.running.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	os.WriteFile(tokenFile, []byte("token-2"), 0600)
+	newConfig := *cfg
+	newConfig.Listen = freeAddr(t)
+	newConfig.MaxJobs = 1
+	newConfig.Labels = "env=test"
+	s.reload(func() (*Config, error) { return &newConfig, nil })
+
+	if resp := <-done; resp.Error != "" {
+		t.Fatalf("running job should not be interrupted by reload: %s", resp.Error)
+	}
+	newClient := dialTaskClient(t, newConfig.Listen)
+	ctx, cancel := context.WithTimeout(withTestToken("token-2"), 3*time.Second)
+	defer cancel()
+	health, err := newClient.Health(ctx, &pb.HealthRequest{})
+	if err != nil || health.Labels != "env=test" {
+		t.Fatalf("Health on new address = %+v, %v", health, err)
+	}
+	if _, err := newClient.Health(withTestToken("token-1"), &pb.HealthRequest{}); err == nil {
+		t.Fatal("old token should be rejected after reload")
+	}
+	if s.maxJobs.Load() != 1 {
+		t.Fatal("limits should be reloaded")
+	}
+
+	// 配置错误时保持原配置
+	broken := newConfig
+	broken.LogLevel = "invalid"
+	broken.MaxJobs = 5
+	s.reload(func() (*Config, error) { return &broken, nil })
+	if s.maxJobs.Load() != 1 || s.cfg.LogLevel != "info" {
+		t.Fatal("invalid config should not be applied")
+	}
+}
+
+func TestRunLimits(t *testing.T) {
+	s := &Server{}
+	s.maxJobs.Store(1)
+	s.running.Store(1)
+	resp, _ := s.Run(context.Background(), &pb.TaskRequest{Command: "echo 1", Id: 1, Timeout: 5})
+	if !strings.Contains(resp.Error, "max_jobs") {
+		t.Fatalf("expected busy error, got %+v", resp)
+	}
+
+	s.running.Store(0)
+	s.maxTimeout.Store(1)
+	start := time.Now()
+	resp, _ = s.Run(context.Background(), &pb.TaskRequest{Command: "sleep 5", Id: 2, Timeout: 60})
+	if resp.Error == "" || time.Since(start) > 4*time.Second {
+		t.Fatalf("task timeout should be capped by max_timeout, got %+v after %s", resp, time.Since(start))
+	}
+}
+
+func grpcTokenOptions(s *Server) (grpc.ServerOption, grpc.ServerOption) {
+	unary, stream := auth.TokenInterceptors(s.currentTokenHash)
+	return grpc.UnaryInterceptor(unary), grpc.StreamInterceptor(stream)
+}
+
+func withTestToken(token string) context.Context {
+	return auth.WithToken(context.Background(), token)
+}
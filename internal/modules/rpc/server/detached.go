@@ -0,0 +1,375 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tabortao/gocron/internal/modules/rpc/auth"
+	pb "github.com/tabortao/gocron/internal/modules/rpc/proto"
+)
+
+// 分离执行: 任务进程独立于节点进程运行, 节点启动任务后立即返回
+// 进程ID、输出及退出码保存在 state_dir, 节点重启后重新接管运行中的任务
+
+const (
+	jobPollInterval = time.Second
+	// 单次查询返回的最大输出
+	jobOutputChunk = 1 << 20
+	// 已结束但服务端未取回结果的任务保留时间
+	jobRetention = 7 * 24 * time.Hour
+	// 终止任务时等待进程退出的时间, 超时后强制结束
+	jobStopGrace = 2 * time.Second
+)
+
+// 节点未接管期间任务进程结束, 且未记录退出码(如主机重启)
+const jobLostMessage = "job process exited without exit code"
+
+var errDetachedDisabled = errors.New("detached execution requires node.state_dir")
+
+type detachedJob struct {
+	Id        int64     `json:"id"`
+	Pid       int       `json:"pid"`
+	ProcStart uint64    `json:"proc_start,omitempty"` // 进程启动时间, 用于确认PID未被复用
+	StartTime time.Time `json:"start_time"`
+	Timeout   int32     `json:"timeout"`
+	Done      bool      `json:"done"`
+	Error     string    `json:"error"`
+	EndTime   time.Time `json:"end_time"`
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// 分离执行的任务, 每个任务在 dir 下保存 job-<id>.json/.sh/.out/.exit
+type jobStore struct {
+	dir  string
+	mu   sync.Mutex
+	jobs map[int64]*detachedJob
+}
+
+// 打开状态目录并接管上次运行时未结束的任务
+func newJobStore(dir string) (*jobStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	st := &jobStore{dir: dir, jobs: make(map[int64]*detachedJob)}
+	st.adopt()
+
+	return st, nil
+}
+
+func (st *jobStore) path(id int64, ext string) string {
+	return filepath.Join(st.dir, fmt.Sprintf("job-%d%s", id, ext))
+}
+
+// 启动任务, 返回后任务在后台运行
+func (st *jobStore) start(id int64, command string, timeout int32) (int, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.cleanup()
+	if job, ok := st.jobs[id]; ok {
+		if !job.Done {
+			return 0, fmt.Errorf("job %d is already running", id)
+		}
+		st.remove(id)
+	}
+
+	scriptFile, outFile, exitFile := st.path(id, ".sh"), st.path(id, ".out"), st.path(id, ".exit")
+	cmd, err := detachedCommand(scriptFile, exitFile)
+	if err != nil {
+		return 0, err
+	}
+	command = strings.ReplaceAll(command, "\r\n", "\n")
+	if err := os.WriteFile(scriptFile, []byte(command), 0700); err != nil {
+		return 0, err
+	}
+	out, err := os.OpenFile(outFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer out.Close()
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		cmd.Dir = homeDir
+	}
+	if err := cmd.Start(); err != nil {
+		st.remove(id)
+		return 0, err
+	}
+	// 回收子进程, 执行结果由包装脚本写入退出码文件
+	go func() { _ = cmd.Wait() }()
+
+	job := &detachedJob{
+		Id:        id,
+		Pid:       cmd.Process.Pid,
+		ProcStart: processStartTime(cmd.Process.Pid),
+		StartTime: time.Now(),
+		Timeout:   timeout,
+		stop:      make(chan struct{}),
+	}
+	st.jobs[id] = job
+	if err := st.save(job); err != nil {
+		log.Warnf("[id: %d] failed to save detached job state: %v", id, err)
+	}
+	go st.monitor(job)
+
+	return job.Pid, nil
+}
+
+// 接管状态目录中的任务, 清理超过保留时间的已结束任务
+func (st *jobStore) adopt() {
+	files, err := filepath.Glob(filepath.Join(st.dir, "job-*.json"))
+	if err != nil {
+		return
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		job := new(detachedJob)
+		if err := json.Unmarshal(data, job); err != nil || job.Id == 0 {
+			log.Warnf("invalid detached job state %s: %v", file, err)
+			continue
+		}
+		job.stop = make(chan struct{})
+		st.jobs[job.Id] = job
+		if !job.Done {
+			if job.alive() {
+				log.Infof("[id: %d] Adopted detached job, pid %d", job.Id, job.Pid)
+			} else {
+				log.Warnf("[id: %d] Detached job process %d is gone", job.Id, job.Pid)
+			}
+			go st.monitor(job)
+		}
+	}
+	st.cleanup()
+}
+
+// 等待任务结束, 超时或收到停止信号时终止进程组
+func (st *jobStore) monitor(job *detachedJob) {
+	var deadline time.Time
+	if job.Timeout > 0 {
+		deadline = job.StartTime.Add(time.Duration(job.Timeout) * time.Second)
+	}
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-job.stop:
+			job.terminate()
+			st.finish(job, "manual stop")
+			return
+		case <-ticker.C:
+		}
+		if code, ok := st.exitCode(job.Id); ok {
+			st.finish(job, exitMessage(code))
+			return
+		}
+		if !job.alive() {
+			// 进程退出前已写入退出码, 再检查一次避免竞争
+			if code, ok := st.exitCode(job.Id); ok {
+				st.finish(job, exitMessage(code))
+			} else {
+				st.finish(job, jobLostMessage)
+			}
+			return
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			job.terminate()
+			st.finish(job, "timeout killed")
+			return
+		}
+	}
+}
+
+// 任务进程是否仍在运行, 记录了启动时间时还需一致, 否则PID已被其他进程复用
+func (job *detachedJob) alive() bool {
+	if !processAlive(job.Pid) {
+		return false
+	}
+
+	return job.ProcStart == 0 || processStartTime(job.Pid) == job.ProcStart
+}
+
+// 终止任务进程组, 进程已不是任务进程时不发送信号
+func (job *detachedJob) terminate() {
+	if job.alive() {
+		terminateProcessGroup(job.Pid)
+	}
+}
+
+func (st *jobStore) exitCode(id int64) (int, bool) {
+	data, err := os.ReadFile(st.path(id, ".exit"))
+	if err != nil {
+		return 0, false
+	}
+	var code int
+	if _, err := fmt.Sscanf(strings.TrimSpace(string(data)), "%d", &code); err != nil {
+		return 0, false
+	}
+
+	return code, true
+}
+
+// 与同步执行时的错误信息一致, 服务端据此解析退出码
+func exitMessage(code int) string {
+	if code == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("exit status %d", code)
+}
+
+func (st *jobStore) finish(job *detachedJob, message string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	job.Done = true
+	job.Error = message
+	job.EndTime = time.Now()
+	if err := st.save(job); err != nil {
+		log.Warnf("[id: %d] failed to save detached job state: %v", job.Id, err)
+	}
+	os.Remove(st.path(job.Id, ".sh"))
+	if message == "" {
+		log.Infof("[id: %d] Detached job finished", job.Id)
+	} else {
+		log.Infof("[id: %d] Detached job failed: %s", job.Id, message)
+	}
+}
+
+// 查询任务状态及 offset 之后的输出, 任务结束且输出已全部返回时 done 为true
+func (st *jobStore) status(req *pb.JobStatusRequest) *pb.JobStatusResponse {
+	st.mu.Lock()
+	job, ok := st.jobs[req.Id]
+	var done bool
+	var message string
+	if ok {
+		done, message = job.Done, job.Error
+	}
+	st.mu.Unlock()
+	if !ok {
+		return &pb.JobStatusResponse{}
+	}
+
+	output, offset, eof := readOutput(st.path(req.Id, ".out"), req.Offset, jobOutputChunk)
+	resp := &pb.JobStatusResponse{Found: true, Output: output, Offset: offset}
+	if done && eof {
+		resp.Done = true
+		resp.Error = message
+		if req.Release {
+			st.mu.Lock()
+			st.remove(req.Id)
+			st.mu.Unlock()
+		}
+	}
+
+	return resp
+}
+
+// 运行中任务的输出, 最多返回最后 jobOutputChunk 字节
+func (st *jobStore) tail(id int64) (string, bool) {
+	st.mu.Lock()
+	_, ok := st.jobs[id]
+	st.mu.Unlock()
+	if !ok {
+		return "", false
+	}
+	file := st.path(id, ".out")
+	var offset int64
+	if info, err := os.Stat(file); err == nil && info.Size() > jobOutputChunk {
+		offset = info.Size() - jobOutputChunk
+	}
+	output, _, _ := readOutput(file, offset, jobOutputChunk)
+
+	return output, true
+}
+
+// 停止运行中的任务, 任务不存在或已结束时返回false
+func (st *jobStore) stop(id int64) bool {
+	st.mu.Lock()
+	job, ok := st.jobs[id]
+	running := ok && !job.Done
+	st.mu.Unlock()
+	if !running {
+		return false
+	}
+	job.stopOnce.Do(func() { close(job.stop) })
+
+	return true
+}
+
+// 运行中的任务数
+func (st *jobStore) running() int32 {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	var n int32
+	for _, job := range st.jobs {
+		if !job.Done {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (st *jobStore) save(job *detachedJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	return auth.WriteFileAtomic(st.path(job.Id, ".json"), data, 0600)
+}
+
+// 删除任务及其文件, 调用时需持有锁
+func (st *jobStore) remove(id int64) {
+	delete(st.jobs, id)
+	for _, ext := range []string{".json", ".sh", ".out", ".exit"} {
+		os.Remove(st.path(id, ext))
+	}
+}
+
+// 删除超过保留时间仍未被服务端取回的任务, 调用时需持有锁
+func (st *jobStore) cleanup() {
+	for id, job := range st.jobs {
+		if job.Done && time.Since(job.EndTime) > jobRetention {
+			st.remove(id)
+		}
+	}
+}
+
+// 读取 offset 开始最多 limit 字节的输出, eof 表示已读到文件末尾
+func readOutput(file string, offset int64, limit int64) (string, int64, bool) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", offset, true
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || offset >= info.Size() {
+		return "", offset, true
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	buf := make([]byte, min(info.Size()-offset, limit))
+	n, err := f.ReadAt(buf, offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", offset, false
+	}
+	offset += int64(n)
+
+	return string(buf[:n]), offset, offset >= info.Size()
+}
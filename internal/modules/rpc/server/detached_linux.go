@@ -0,0 +1,33 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// 进程启动时间(系统启动后的时钟周期数), 用于确认PID未被其他进程复用, 读取失败时返回0
+func processStartTime(pid int) uint64 {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0
+	}
+	// 第2个字段为括号中的进程名, 可能包含空格, 从最后一个右括号之后解析
+	i := bytes.LastIndexByte(data, ')')
+	if i < 0 {
+		return 0
+	}
+	// 右括号后依次为第3个字段开始, starttime为第22个字段
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) < 20 {
+		return 0
+	}
+	start, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return start
+}
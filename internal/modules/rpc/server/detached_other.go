@@ -0,0 +1,8 @@
+//go:build !linux
+
+package server
+
+// 无法读取进程启动时间, 只通过PID判断进程是否存在
+func processStartTime(pid int) uint64 {
+	return 0
+}
@@ -0,0 +1,150 @@
+//go:build !windows
+
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "github.com/tabortao/gocron/internal/modules/rpc/proto"
+)
+
+func newDetachedServer(t *testing.T, dir string) *Server {
+	jobs, err := newJobStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Server{jobs: jobs}
+}
+
+// 轮询任务状态直到结束, 返回全部输出及最后的响应
+func waitDetached(t *testing.T, s *Server, id int64, release bool) (string, *pb.JobStatusResponse) {
+	var output string
+	var offset int64
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		resp, _ := s.JobStatus(context.Background(), &pb.JobStatusRequest{Id: id, Offset: offset, Release: release})
+		if !resp.Found {
+			t.Fatalf("job %d not found", id)
+		}
+		output += resp.Output
+		offset = resp.Offset
+		if resp.Done {
+			return output, resp
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("job %d did not finish", id)
+	return "", nil
+}
+
+func TestDetachedJob(t *testing.T) {
+	dir := t.TempDir()
+	s := newDetachedServer(t, dir)
+	resp, _ := s.Run(context.Background(), &pb.TaskRequest{Command: "echo hello\nexit 3", Id: 1, Timeout: 10, Detached: true})
+	if !resp.Detached || resp.Error != "" {
+		t.Fatalf("expected detached response, got %+v", resp)
+	}
+	if s.detachedRunning() != 1 {
+		t.Fatal("detached job should be counted as running")
+	}
+
+	output, status := waitDetached(t, s, 1, true)
+	if output != "hello\n" || status.Error != "exit status 3" {
+		t.Fatalf("unexpected result %q, %q", output, status.Error)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "job-1.*")); len(files) != 0 {
+		t.Fatalf("released job files should be removed: %v", files)
+	}
+	if resp, _ := s.JobStatus(context.Background(), &pb.JobStatusRequest{Id: 1}); resp.Found {
+		t.Fatal("released job should not be found")
+	}
+
+	// 未配置状态目录时不支持分离执行
+	resp, _ = (&Server{}).Run(context.Background(), &pb.TaskRequest{Command: "echo 1", Id: 2, Detached: true})
+	if resp.Error != errDetachedDisabled.Error() {
+		t.Fatalf("expected disabled error, got %+v", resp)
+	}
+}
+
+func TestDetachedJobAdoptedAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	s := newDetachedServer(t, dir)
+	s.Run(context.Background(), &pb.TaskRequest{Command: "sleep 1.5; echo done", Id: 1, Timeout: 10, Detached: true})
+
+	// 节点重启后从状态目录接管运行中的任务
+	restarted := newDetachedServer(t, dir)
+	if restarted.detachedRunning() != 1 {
+		t.Fatal("running job should be adopted")
+	}
+	output, status := waitDetached(t, restarted, 1, false)
+	if output != "done\n" || status.Error != "" {
+		t.Fatalf("unexpected result %q, %q", output, status.Error)
+	}
+
+	// 已结束未取回的任务, 重启后仍可查询结果
+	restarted = newDetachedServer(t, dir)
+	if output, _ := waitDetached(t, restarted, 1, true); output != "done\n" {
+		t.Fatalf("finished job output should be kept, got %q", output)
+	}
+}
+
+func TestDetachedJobStopAndTimeout(t *testing.T) {
+	s := newDetachedServer(t, t.TempDir())
+	s.Run(context.Background(), &pb.TaskRequest{Command: "sleep 30", Id: 1, Timeout: 60, Detached: true})
+	s.Run(context.Background(), &pb.TaskRequest{Command: "sleep 30", Id: 2, Timeout: 1, Detached: true})
+
+	s.Run(context.Background(), &pb.TaskRequest{Command: "__STOP__", Id: 1})
+	if _, status := waitDetached(t, s, 1, true); status.Error != "manual stop" {
+		t.Fatalf("expected manual stop, got %q", status.Error)
+	}
+	if _, status := waitDetached(t, s, 2, true); status.Error != "timeout killed" {
+		t.Fatalf("expected timeout, got %q", status.Error)
+	}
+}
+
+func TestDetachedJobLost(t *testing.T) {
+	dir := t.TempDir()
+	s := newDetachedServer(t, dir)
+	s.Run(context.Background(), &pb.TaskRequest{Command: "sleep 30", Id: 1, Timeout: 60, Detached: true})
+	s.jobs.mu.Lock()
+	pid := s.jobs.jobs[1].Pid
+	s.jobs.mu.Unlock()
+
+	// 进程被外部结束且未记录退出码
+	terminateProcessGroup(pid)
+	if _, status := waitDetached(t, s, 1, true); status.Error != jobLostMessage {
+		t.Fatalf("expected lost job error, got %q", status.Error)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "job-1.json")); !os.IsNotExist(err) {
+		t.Fatal("released job state should be removed")
+	}
+}
+
+func TestDetachedJobPidReused(t *testing.T) {
+	if processStartTime(os.Getpid()) == 0 {
+		t.Skip("process start time is not available")
+	}
+	dir := t.TempDir()
+	// 任务进程已结束, 保存的PID被其他进程(测试进程)复用
+	job := &detachedJob{
+		Id:        1,
+		Pid:       os.Getpid(),
+		ProcStart: processStartTime(os.Getpid()) + 1,
+		StartTime: time.Now().Add(-time.Hour),
+		Timeout:   1,
+	}
+	st := &jobStore{dir: dir, jobs: make(map[int64]*detachedJob)}
+	if err := st.save(job); err != nil {
+		t.Fatal(err)
+	}
+
+	// 接管时不把复用PID的进程当作任务进程, 超时也不会终止该进程
+	s := newDetachedServer(t, dir)
+	if _, status := waitDetached(t, s, 1, true); status.Error != jobLostMessage {
+		t.Fatalf("expected lost job error, got %q", status.Error)
+	}
+}
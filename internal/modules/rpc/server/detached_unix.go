@@ -0,0 +1,35 @@
+//go:build !windows
+
+package server
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// 包装脚本执行任务并写入退出码, 节点重启后仍能获取执行结果
+// 使用独立会话, 节点进程退出时任务进程不受影响
+func detachedCommand(scriptFile, exitFile string) (*exec.Cmd, error) {
+	cmd := exec.Command("/bin/bash", "-c",
+		`/bin/bash "$1"; echo $? > "$2.tmp" && mv "$2.tmp" "$2"`, "gocron-job", scriptFile, exitFile)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	return cmd, nil
+}
+
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// 先发送 SIGTERM, 进程未在 jobStopGrace 内退出时强制结束
+func terminateProcessGroup(pid int) {
+	_ = syscall.Kill(-pid, syscall.SIGTERM)
+	deadline := time.Now().Add(jobStopGrace)
+	for processAlive(pid) && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	_ = syscall.Kill(-pid, syscall.SIGKILL)
+}
@@ -0,0 +1,16 @@
+package server
+
+import (
+	"errors"
+	"os/exec"
+)
+
+func detachedCommand(scriptFile, exitFile string) (*exec.Cmd, error) {
+	return nil, errors.New("detached execution is not supported on windows")
+}
+
+func processAlive(pid int) bool {
+	return false
+}
+
+func terminateProcessGroup(pid int) {}
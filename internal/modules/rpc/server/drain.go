@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+const (
+	// 默认排空等待时间(秒)
+	defaultDrainTimeout = 60
+	// 终止剩余任务后等待结果返回给服务端的时间
+	drainReportTimeout = 10 * time.Second
+	drainPollInterval  = 100 * time.Millisecond
+)
+
+// 排空时拒绝新任务返回的错误, 服务端据此转移到其他节点
+const drainingMessage = "node is draining"
+
+// 排空超时被终止的任务返回的错误
+const drainInterruptedMessage = "node shutdown: job interrupted after drain timeout"
+
+// 排空节点: 停止接收新任务, 等待运行中的任务结束
+// 超时后终止剩余任务, 任务结果仍返回给服务端, 避免任务日志一直处于执行中
+func (s *Server) drain(timeout time.Duration) {
+	s.draining.Store(true)
+	running := s.running.Load()
+	if running == 0 {
+		return
+	}
+	log.Infof("Draining, waiting up to %s for %d running jobs", timeout, running)
+	if s.waitForJobs(timeout) {
+		log.Info("All running jobs finished")
+		return
+	}
+	log.Warnf("Drain timeout, stopping %d running jobs", s.running.Load())
+	s.interrupted.Store(true)
+	s.taskContexts.Range(func(key, value any) bool {
+		value.(context.CancelFunc)()
+		return true
+	})
+	if !s.waitForJobs(drainReportTimeout) {
+		log.Warnf("%d jobs did not exit after being stopped", s.running.Load())
+	}
+}
+
+// 等待运行中的任务结束, 超时返回false
+func (s *Server) waitForJobs(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for s.running.Load() > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(drainPollInterval)
+	}
+
+	return true
+}
+
+// 排空时间, 使用最新加载的配置
+func (s *Server) drainTimeout() time.Duration {
+	return time.Duration(s.cfg.DrainTimeout) * time.Second
+}
+
+// 任务结束后停止gRPC服务, 未结束的请求(如查看输出)最多等待 drainReportTimeout
+func gracefulStop(server *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(drainReportTimeout):
+		server.Stop()
+	}
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/tabortao/gocron/internal/modules/rpc/proto"
+)
+
+func runAsync(s *Server, req *pb.TaskRequest) <-chan *pb.TaskResponse {
+	done := make(chan *pb.TaskResponse, 1)
+	go func() {
+		resp, _ := s.Run(context.Background(), req)
+		done <- resp
+	}()
+	return done
+}
+
+func waitRunning(t *testing.T, s *Server, n int32) {
+	deadline := time.Now().Add(3 * time.Second)
+	for s.running.Load() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d running jobs, got %d", n, s.running.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDrainWaitsForRunningJobs(t *testing.T) {
+	s := &Server{}
+	done := runAsync(s, &pb.TaskRequest{Command: "sleep 0.3", Id: 1, Timeout: 10})
+	waitRunning(t, s, 1)
+
+	s.drain(5 * time.Second)
+	if s.running.Load() != 0 {
+		t.Fatal("drain should wait for running jobs")
+	}
+	if resp := <-done; resp.Error != "" {
+		t.Fatalf("job should finish normally, got %q", resp.Error)
+	}
+
+	// 排空后拒绝新任务, 健康检查报告排空中
+	resp, _ := s.Run(context.Background(), &pb.TaskRequest{Command: "echo 1", Id: 2, Timeout: 5})
+	if resp.Error != drainingMessage {
+		t.Fatalf("expected draining error, got %q", resp.Error)
+	}
+	health, _ := s.Health(context.Background(), &pb.HealthRequest{})
+	if !health.Draining {
+		t.Fatal("health should report draining")
+	}
+	if resp, _ := s.Run(context.Background(), &pb.TaskRequest{Command: "__TAIL__", Id: 1}); resp.Error != "" {
+		t.Fatalf("control commands should still be served, got %q", resp.Error)
+	}
+}
+
+func TestDrainTimeoutInterruptsJobs(t *testing.T) {
+	s := &Server{}
+	done := runAsync(s, &pb.TaskRequest{Command: "sleep 30", Id: 1, Timeout: 60})
+	waitRunning(t, s, 1)
+
+	start := time.Now()
+	s.drain(200 * time.Millisecond)
+	if time.Since(start) > 5*time.Second {
+		t.Fatalf("drain took too long: %s", time.Since(start))
+	}
+	select {
+	case resp := <-done:
+		if resp.Error != drainInterruptedMessage {
+			t.Fatalf("expected interrupted error, got %q", resp.Error)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("interrupted job should return a result")
+	}
+}
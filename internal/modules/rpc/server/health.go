@@ -0,0 +1,44 @@
+package server
+
+import (
+	"context"
+	"runtime"
+
+	pb "github.com/tabortao/gocron/internal/modules/rpc/proto"
+)
+
+// 节点版本, 由 gocron-node 启动时设置
+var AppVersion string
+
+// Health 返回节点版本、系统负载及正在运行的任务数
+func (s *Server) Health(ctx context.Context, req *pb.HealthRequest) (*pb.HealthResponse, error) {
+	info := readSysInfo()
+
+	return &pb.HealthResponse{
+		Version:     AppVersion,
+		Os:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		Load1:       info.load1,
+		MemTotal:    info.memTotal,
+		MemFree:     info.memFree,
+		DiskTotal:   info.diskTotal,
+		DiskFree:    info.diskFree,
+		RunningJobs: s.running.Load() + s.detachedRunning(),
+		Labels:      s.currentLabels(),
+		Draining:    s.draining.Load(),
+	}, nil
+}
+
+func (s *Server) currentLabels() string {
+	labels, _ := s.labels.Load().(string)
+	return labels
+}
+
+// 系统资源信息, 无法获取的项为0
+type sysInfo struct {
+	load1     float64
+	memTotal  uint64
+	memFree   uint64
+	diskTotal uint64
+	diskFree  uint64
+}
@@ -0,0 +1,60 @@
+//go:build linux
+
+package server
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+func readSysInfo() sysInfo {
+	info := sysInfo{}
+	if data, err := os.ReadFile("/proc/loadavg"); err == nil {
+		if fields := strings.Fields(string(data)); len(fields) > 0 {
+			info.load1, _ = strconv.ParseFloat(fields[0], 64)
+		}
+	}
+	if f, err := os.Open("/proc/meminfo"); err == nil {
+		defer f.Close()
+		info.memTotal, info.memFree = parseMeminfo(bufio.NewScanner(f))
+	}
+	var stat syscall.Statfs_t
+	if wd, err := os.Getwd(); err == nil && syscall.Statfs(wd, &stat) == nil {
+		info.diskTotal = stat.Blocks * uint64(stat.Bsize)
+		info.diskFree = stat.Bavail * uint64(stat.Bsize)
+	}
+
+	return info
+}
+
+// 解析 /proc/meminfo, 可用内存优先取 MemAvailable
+func parseMeminfo(scanner *bufio.Scanner) (total uint64, free uint64) {
+	var memFree, available uint64
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		value *= 1024
+		switch fields[0] {
+		case "MemTotal:":
+			total = value
+		case "MemFree:":
+			memFree = value
+		case "MemAvailable:":
+			available = value
+		}
+	}
+	if available > 0 {
+		return total, available
+	}
+
+	return total, memFree
+}
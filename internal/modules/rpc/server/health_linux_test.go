@@ -0,0 +1,22 @@
+//go:build linux
+
+package server
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestParseMeminfo(t *testing.T) {
+	total, free := parseMeminfo(bufio.NewScanner(strings.NewReader(
+		"MemTotal:        2048 kB\nMemFree:          512 kB\nMemAvailable:    1024 kB\n")))
+	if total != 2048*1024 || free != 1024*1024 {
+		t.Fatalf("total=%d free=%d", total, free)
+	}
+
+	_, free = parseMeminfo(bufio.NewScanner(strings.NewReader("MemTotal: 2048 kB\nMemFree: 512 kB\n")))
+	if free != 512*1024 {
+		t.Fatalf("free should fall back to MemFree, got %d", free)
+	}
+}
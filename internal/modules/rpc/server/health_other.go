@@ -0,0 +1,7 @@
+//go:build !linux
+
+package server
+
+func readSysInfo() sysInfo {
+	return sysInfo{}
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/tabortao/gocron/internal/modules/rpc/auth"
+	"gopkg.in/ini.v1"
+)
+
+// shell控制字符, 通配符及脚本参数中不允许出现, 防止拼接其他命令
+const shellMetaChars = ";&|`$<>()\n\r"
+
+// ErrCommandRejected 命令不满足节点执行策略
+var ErrCommandRejected = errors.New("command rejected by node policy")
+
+// Policy 节点命令执行策略, 未配置任何规则时不限制
+// 配置了白名单或受信任公钥后, 命令需匹配白名单或携带有效签名
+// 配置了解释器时, 命令调用的程序必须在解释器列表中
+type Policy struct {
+	patterns     []*regexp.Regexp
+	scriptDirs   []string
+	trustedKeys  []ed25519.PublicKey
+	interpreters []string
+}
+
+var commandPolicy atomic.Pointer[Policy]
+
+// SetPolicy 设置节点命令执行策略, nil 表示不限制
+func SetPolicy(p *Policy) {
+	commandPolicy.Store(p)
+}
+
+// LoadPolicy 从配置文件加载策略
+//
+//	[policy]
+//	; 允许执行的命令, 可配置多行, * 匹配任意不含shell控制字符的内容
+//	allow = /usr/local/bin/backup.sh *
+//	allow = systemctl restart nginx
+//	; 允许执行该目录下的脚本, 多个以逗号分隔
+//	script_dirs = /opt/gocron/scripts
+//	; 受信任的命令签名公钥文件
+//	trusted_keys = /etc/gocron-node/trusted_keys.pem
+//	; 允许调用的程序, 按程序名或完整路径匹配, 多个以逗号分隔
+//	interpreters = bash, sh, python3
+func LoadPolicy(file string) (*Policy, error) {
+	cfg, err := ini.LoadSources(ini.LoadOptions{AllowShadows: true}, file)
+	if err != nil {
+		return nil, err
+	}
+
+	return parsePolicy(cfg.Section("policy"))
+}
+
+func parsePolicy(section *ini.Section) (*Policy, error) {
+	var err error
+	p := &Policy{interpreters: splitList(section.Key("interpreters").String())}
+	for _, pattern := range section.Key("allow").ValueWithShadows() {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		p.patterns = append(p.patterns, compileCommandPattern(pattern))
+	}
+	for _, dir := range splitList(section.Key("script_dirs").String()) {
+		if !filepath.IsAbs(dir) {
+			return nil, fmt.Errorf("script dir must be an absolute path: %s", dir)
+		}
+		p.scriptDirs = append(p.scriptDirs, filepath.Clean(dir))
+	}
+	if keyFile := strings.TrimSpace(section.Key("trusted_keys").String()); keyFile != "" {
+		p.trustedKeys, err = auth.LoadTrustedKeys(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load trusted keys: %w", err)
+		}
+	}
+
+	return p, nil
+}
+
+// 通配符 * 转换为不匹配shell控制字符的正则
+func compileCommandPattern(pattern string) *regexp.Regexp {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	wildcard := "[^" + regexp.QuoteMeta(shellMetaChars) + "]*"
+
+	return regexp.MustCompile("^" + strings.Join(parts, wildcard) + "$")
+}
+
+// Check 校验命令是否允许执行, 签名需与任务ID匹配
+func (p *Policy) Check(taskId int64, command, signature string) error {
+	if p == nil {
+		return nil
+	}
+	command = strings.TrimSpace(command)
+	if len(p.interpreters) > 0 && !p.interpreterAllowed(command) {
+		return fmt.Errorf("%w: interpreter is not allowed", ErrCommandRejected)
+	}
+	if len(p.patterns) == 0 && len(p.scriptDirs) == 0 && len(p.trustedKeys) == 0 {
+		return nil
+	}
+	if p.allowed(command) {
+		return nil
+	}
+	if len(p.trustedKeys) > 0 && signature != "" {
+		if auth.VerifyCommand(p.trustedKeys, taskId, command, signature) {
+			return nil
+		}
+		return fmt.Errorf("%w: invalid command signature", ErrCommandRejected)
+	}
+	if len(p.trustedKeys) > 0 {
+		return fmt.Errorf("%w: command is not in the allow list and is not signed", ErrCommandRejected)
+	}
+
+	return fmt.Errorf("%w: command is not in the allow list", ErrCommandRejected)
+}
+
+func (p *Policy) allowed(command string) bool {
+	for _, pattern := range p.patterns {
+		if pattern.MatchString(command) {
+			return true
+		}
+	}
+	if len(p.scriptDirs) == 0 || strings.ContainsAny(command, shellMetaChars) {
+		return false
+	}
+	fields := strings.Fields(command)
+	if len(fields) == 0 || !filepath.IsAbs(fields[0]) {
+		return false
+	}
+	script := filepath.Clean(fields[0])
+	for _, dir := range p.scriptDirs {
+		if strings.HasPrefix(script, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// 命令调用的程序是否在允许的解释器中, 多条命令时每条都需满足
+func (p *Policy) interpreterAllowed(command string) bool {
+	for _, part := range strings.FieldsFunc(command, func(r rune) bool {
+		return strings.ContainsRune(";&|\n\r", r)
+	}) {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		// 命令替换可调用任意程序
+		if strings.Contains(part, "$(") || strings.ContainsRune(part, '`') || !slices.ContainsFunc(p.interpreters, func(name string) bool {
+			return fields[0] == name || filepath.Base(fields[0]) == name
+		}) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tabortao/gocron/internal/modules/rpc/auth"
+	pb "github.com/tabortao/gocron/internal/modules/rpc/proto"
+)
+
+func writePolicyFile(t *testing.T, content string) string {
+	file := filepath.Join(t.TempDir(), "node.ini")
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestPolicyAllowList(t *testing.T) {
+	p, err := LoadPolicy(writePolicyFile(t, `[policy]
+allow = /usr/local/bin/backup.sh *
+allow = systemctl restart nginx
+script_dirs = /opt/scripts, /srv/jobs
+`))
+	if err != nil {
+		t.Fatalf("LoadPolicy error: %v", err)
+	}
+
+	tests := []struct {
+		command string
+		allowed bool
+	}{
+		{"systemctl restart nginx", true},
+		{"  systemctl restart nginx\n", true},
+		{"systemctl stop nginx", false},
+		{"/usr/local/bin/backup.sh --full /data", true},
+		{"/usr/local/bin/backup.sh /data; rm -rf /", false},
+		{"/usr/local/bin/backup.sh $(id)", false},
+		{"/opt/scripts/clean.sh 7", true},
+		{"/srv/jobs/report/daily.sh", true},
+		{"/opt/scripts/../../bin/sh -c id", false},
+		{"/opt/scripts/clean.sh && id", false},
+		{"/opt/scriptsx/clean.sh", false},
+		{"clean.sh", false},
+	}
+	for _, tt := range tests {
+		err := p.Check(0, tt.command, "")
+		if (err == nil) != tt.allowed {
+			t.Errorf("Check(%q) = %v, want allowed=%v", tt.command, err, tt.allowed)
+		}
+		if err != nil && !errors.Is(err, ErrCommandRejected) {
+			t.Errorf("Check(%q) should return ErrCommandRejected, got %v", tt.command, err)
+		}
+	}
+}
+
+func TestPolicySignedCommands(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "sign.key")
+	if err := auth.GenerateSigningKey(keyFile); err != nil {
+		t.Fatal(err)
+	}
+	privateKey, err := auth.LoadSigningKey(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := LoadPolicy(writePolicyFile(t, "[policy]\ntrusted_keys = "+keyFile+".pub\n"))
+	if err != nil {
+		t.Fatalf("LoadPolicy error: %v", err)
+	}
+
+	command := "echo hello"
+	signature := auth.SignCommand(privateKey, 1, command)
+	if err := p.Check(1, command, signature); err != nil {
+		t.Errorf("signed command should be allowed: %v", err)
+	}
+	if err := p.Check(1, "echo hello; id", signature); err == nil {
+		t.Error("signature of another command should be rejected")
+	}
+	if err := p.Check(2, command, signature); err == nil {
+		t.Error("signature of another task should be rejected")
+	}
+	if err := p.Check(1, command, ""); err == nil || !strings.Contains(err.Error(), "not signed") {
+		t.Errorf("unsigned command should be rejected, got %v", err)
+	}
+	if err := p.Check(1, command, "invalid"); err == nil {
+		t.Error("invalid signature should be rejected")
+	}
+
+	// 其他密钥签名的命令
+	otherKey := filepath.Join(dir, "other.key")
+	if err := auth.GenerateSigningKey(otherKey); err != nil {
+		t.Fatal(err)
+	}
+	other, _ := auth.LoadSigningKey(otherKey)
+	if err := p.Check(1, command, auth.SignCommand(other, 1, command)); err == nil {
+		t.Error("command signed by an untrusted key should be rejected")
+	}
+}
+
+func TestPolicyLoadErrors(t *testing.T) {
+	if _, err := LoadPolicy(writePolicyFile(t, "[policy]\nscript_dirs = scripts\n")); err == nil {
+		t.Error("relative script dir should be rejected")
+	}
+	if _, err := LoadPolicy(writePolicyFile(t, "[policy]\ntrusted_keys = /nonexistent.pem\n")); err == nil {
+		t.Error("missing trusted keys file should be rejected")
+	}
+	// 未配置规则时不限制
+	p, err := LoadPolicy(writePolicyFile(t, "[policy]\n"))
+	if err != nil || p.Check(0, "rm -rf /tmp/x", "") != nil {
+		t.Errorf("empty policy should allow all commands: %v", err)
+	}
+}
+
+func TestRunRejectedByPolicy(t *testing.T) {
+	p, err := LoadPolicy(writePolicyFile(t, "[policy]\nallow = echo allowed\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetPolicy(p)
+	defer SetPolicy(nil)
+
+	s := &Server{}
+	resp, err := s.Run(context.Background(), &pb.TaskRequest{Command: "echo denied", Id: 1, Timeout: 5})
+	if err != nil || !strings.Contains(resp.Error, ErrCommandRejected.Error()) || resp.Output != "" {
+		t.Fatalf("expected rejection, got %+v, %v", resp, err)
+	}
+	resp, err = s.Run(context.Background(), &pb.TaskRequest{Command: "echo allowed", Id: 2, Timeout: 5})
+	if err != nil || resp.Error != "" {
+		t.Fatalf("expected allowed command to run, got %+v, %v", resp, err)
+	}
+	// 控制命令不受策略限制
+	if resp, _ := s.Run(context.Background(), &pb.TaskRequest{Command: "__TAIL__", Id: 3}); resp.Error != "" {
+		t.Fatalf("__TAIL__ should not be rejected: %+v", resp)
+	}
+}
+
+func TestPolicyInterpreters(t *testing.T) {
+	p, err := LoadPolicy(writePolicyFile(t, "[policy]\ninterpreters = bash, /usr/bin/python3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		command string
+		allowed bool
+	}{
+		{"bash /opt/job.sh", true},
+		{"/bin/bash -c 'echo $HOME'", true},
+		{"/usr/bin/python3 job.py && bash next.sh", true},
+		{"python3 job.py", false},
+		{"bash job.sh; curl http://example.com", false},
+		{"bash $(curl http://example.com)", false},
+		{"perl -e 1", false},
+	}
+	for _, tt := range tests {
+		if err := p.Check(0, tt.command, ""); (err == nil) != tt.allowed {
+			t.Errorf("Check(%q) = %v, want allowed=%v", tt.command, err, tt.allowed)
+		}
+	}
+}
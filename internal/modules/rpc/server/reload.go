@@ -0,0 +1,211 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tabortao/gocron/internal/modules/rpc/auth"
+	pb "github.com/tabortao/gocron/internal/modules/rpc/proto"
+	"github.com/tabortao/gocron/internal/modules/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newServer(cfg *Config) (*Server, error) {
+	s := &Server{}
+	if cfg.EnableTLS {
+		reloader, err := auth.NewKeyPairReloader(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		s.reloader = reloader
+		// 证书由服务端内置CA签发时, 到期前自动续期, 续期后无需重启
+		if cfg.RenewURL != "" {
+			s.renewer = &CertRenewer{
+				ServerURL: cfg.RenewURL,
+				Hostname:  cfg.Hostname,
+				Reloader:  reloader,
+			}
+		}
+	}
+	if err := s.applyConfig(cfg); err != nil {
+		return nil, err
+	}
+	if cfg.StateDir != "" {
+		jobs, err := newJobStore(cfg.StateDir)
+		if err != nil {
+			return nil, err
+		}
+		s.jobs = jobs
+	}
+	if s.renewer != nil {
+		if s.currentToken() == "" {
+			return nil, errors.New("tls.renew_url requires node.token_file")
+		}
+		s.renewer.Start()
+	}
+
+	return s, nil
+}
+
+// 应用可在运行时修改的配置, 出错时保持原配置不变
+func (s *Server) applyConfig(cfg *Config) error {
+	level, err := log.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return err
+	}
+	token, err := cfg.ReadToken()
+	if err != nil {
+		return err
+	}
+	var tlsConfig *tls.Config
+	if s.tlsConfig.Load() != nil {
+		if tlsConfig, err = s.serverTLSConfig(cfg); err != nil {
+			return err
+		}
+	}
+	if s.reloader != nil {
+		if err := s.reloader.SetFiles(cfg.CertFile, cfg.KeyFile); err != nil {
+			return err
+		}
+	}
+
+	log.SetLevel(level)
+	if tlsConfig != nil {
+		s.tlsConfig.Store(tlsConfig)
+	}
+	s.setToken(token, cfg)
+	s.maxJobs.Store(int32(cfg.MaxJobs))
+	s.maxTimeout.Store(int32(cfg.MaxTimeout))
+	s.labels.Store(cfg.Labels)
+	SetPolicy(cfg.Policy)
+	s.cfg = cfg
+
+	return nil
+}
+
+// 重新加载配置, 正在运行的任务及已建立的连接不受影响
+func (s *Server) reload(load func() (*Config, error)) {
+	if load == nil {
+		return
+	}
+	cfg, err := load()
+	if err != nil {
+		log.Errorf("failed to reload config: %v", err)
+		return
+	}
+	old := s.cfg
+	if items := old.restartRequired(cfg); len(items) > 0 {
+		log.Warnf("changes to %s take effect after restarting gocron-node", strings.Join(items, ", "))
+	}
+	if err := s.applyConfig(cfg); err != nil {
+		log.Errorf("failed to reload config: %v", err)
+		return
+	}
+	if s.grpcServer != nil && cfg.Listen != old.Listen {
+		if err := s.listen(cfg.Listen); err != nil {
+			log.Errorf("failed to listen on %s: %v", cfg.Listen, err)
+			cfg.Listen = old.Listen
+		}
+	}
+	log.Info("config reloaded")
+}
+
+// 服务端TLS配置, 每次握手时读取, 可重新加载CA
+func (s *Server) serverTLSConfig(cfg *Config) (*tls.Config, error) {
+	certificate := cfg.Certificate()
+	certificate.Reloader = s.reloader
+	tlsConfig, err := certificate.GetTLSConfigForServer()
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.NextProtos = []string{"h2"}
+
+	return tlsConfig, nil
+}
+
+// 监听地址, 先监听新地址再关闭原监听, 已建立的连接继续使用
+func (s *Server) listen(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s.listenMu.Lock()
+	old := s.listener
+	s.listener = l
+	s.listenMu.Unlock()
+
+	go func() {
+		err := s.grpcServer.Serve(l)
+		s.listenMu.Lock()
+		current := s.listener == l
+		s.listenMu.Unlock()
+		// 更换监听地址时关闭的原监听返回的错误忽略
+		if err != nil && current {
+			log.Fatal(err)
+		}
+	}()
+	if old != nil {
+		old.Close()
+	}
+	log.Infof("server listen on %s", addr)
+
+	return nil
+}
+
+func (s *Server) setToken(token string, cfg *Config) {
+	if s.renewer != nil {
+		s.renewer.Update(token, cfg.CertFile, cfg.KeyFile)
+	}
+	tokenHash := ""
+	if token != "" {
+		tokenHash = auth.HashToken(token)
+	}
+	s.token.Store(token)
+	s.tokenHash.Store(tokenHash)
+}
+
+// SetToken 服务端重新生成或吊销令牌时调用, 新令牌写入令牌文件后立即生效
+// 吊销时写入随机令牌, 节点在重新注册前拒绝所有请求
+// 未配置令牌的节点不校验请求, 拒绝更新令牌, 避免任何能访问节点的人设置令牌
+func (s *Server) SetToken(ctx context.Context, req *pb.TokenRequest) (*pb.TokenResponse, error) {
+	s.tokenMu.Lock()
+	defer s.tokenMu.Unlock()
+	if s.currentTokenHash() == "" {
+		return nil, status.Error(codes.FailedPrecondition, "node token is not provisioned")
+	}
+	cfg := s.cfg
+	if cfg == nil {
+		return nil, status.Error(codes.FailedPrecondition, "node.token_file is not configured")
+	}
+	token := req.Token
+	if token == "" {
+		token = utils.RandAuthToken()
+	}
+	if err := cfg.WriteToken(token); err != nil {
+		log.Errorf("failed to update node token: %v", err)
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	s.setToken(token, cfg)
+	if req.Token == "" {
+		log.Warn("node token revoked by server, re-register the node to issue a new token")
+	} else {
+		log.Info("node token updated by server")
+	}
+
+	return &pb.TokenResponse{}, nil
+}
+
+func (s *Server) currentToken() string {
+	token, _ := s.token.Load().(string)
+	return token
+}
+
+func (s *Server) currentTokenHash() string {
+	hash, _ := s.tokenHash.Load().(string)
+	return hash
+}
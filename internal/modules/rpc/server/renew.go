@@ -0,0 +1,123 @@
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tabortao/gocron/internal/modules/rpc/auth"
+)
+
+const (
+	certCheckInterval = 12 * time.Hour
+	renewTimeout      = 30 * time.Second
+)
+
+// CertRenewer 证书到期前向服务端申请新证书, 使用节点令牌认证
+type CertRenewer struct {
+	ServerURL string // gocron web 地址
+	Hostname  string // 注册的主机名, 为空时使用当前证书的CN
+	Token     string
+	CertFile  string
+	KeyFile   string
+	Reloader  *auth.KeyPairReloader
+	mu        sync.Mutex
+}
+
+// Update 重新加载配置后更新令牌及证书文件
+func (r *CertRenewer) Update(token, certFile, keyFile string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Token, r.CertFile, r.KeyFile = token, certFile, keyFile
+}
+
+// Start 定期检查证书有效期
+func (r *CertRenewer) Start() {
+	go func() {
+		for {
+			if err := r.RenewIfNeeded(); err != nil {
+				log.Errorf("certificate renewal failed: %v", err)
+			}
+			time.Sleep(certCheckInterval)
+		}
+	}()
+}
+
+// RenewIfNeeded 证书剩余有效期不足时续期, 新证书写入文件后立即生效
+func (r *CertRenewer) RenewIfNeeded() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cert, err := auth.LoadCertFile(r.CertFile)
+	if err != nil {
+		return err
+	}
+	if time.Until(cert.NotAfter) > auth.CertRenewBefore {
+		return nil
+	}
+	log.Infof("certificate expires at %s, renewing", cert.NotAfter.Format(time.RFC3339))
+
+	hostname := r.Hostname
+	if hostname == "" {
+		hostname = cert.Subject.CommonName
+	}
+	csrPEM, keyPEM, err := auth.NewCSR(hostname)
+	if err != nil {
+		return err
+	}
+	certPEM, err := r.request(hostname, csrPEM)
+	if err != nil {
+		return err
+	}
+	if err := auth.WriteFileAtomic(r.KeyFile, keyPEM, 0600); err != nil {
+		return err
+	}
+	if err := auth.WriteFileAtomic(r.CertFile, certPEM, 0644); err != nil {
+		return err
+	}
+	if r.Reloader != nil {
+		if err := r.Reloader.Reload(); err != nil {
+			return err
+		}
+	}
+	log.Info("certificate renewed")
+
+	return nil
+}
+
+func (r *CertRenewer) request(hostname string, csrPEM []byte) ([]byte, error) {
+	body, err := json.Marshal(map[string]string{
+		"hostname": hostname,
+		"token":    r.Token,
+		"csr":      base64.StdEncoding.EncodeToString(csrPEM),
+	})
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Timeout: renewTimeout}
+	resp, err := client.Post(strings.TrimRight(r.ServerURL, "/")+"/api/agent/renew", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Code    int               `json:"code"`
+		Message string            `json:"message"`
+		Data    map[string]string `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid renew response (HTTP %d): %w", resp.StatusCode, err)
+	}
+	if result.Code != 0 {
+		return nil, errors.New(result.Message)
+	}
+
+	return base64.StdEncoding.DecodeString(result.Data["certificate"])
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tabortao/gocron/internal/modules/rpc/auth"
+)
+
+// 生成即将过期的自签名证书
+func writeExpiringCert(t *testing.T, certFile, keyFile, hostname string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: hostname},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+}
+
+func TestCertRenewer(t *testing.T) {
+	dir := t.TempDir()
+	ca, err := auth.LoadOrCreateCA(filepath.Join(dir, "ca"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gotHostname, gotToken string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		gotHostname, gotToken = req["hostname"], req["token"]
+		csr, _ := base64.StdEncoding.DecodeString(req["csr"])
+		cert, err := ca.SignCSR(csr, req["hostname"])
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"code": 1, "message": err.Error()})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0, "data": map[string]string{"certificate": base64.StdEncoding.EncodeToString(cert)},
+		})
+	}))
+	defer ts.Close()
+
+	certFile, keyFile := filepath.Join(dir, "node.crt"), filepath.Join(dir, "node.key")
+	writeExpiringCert(t, certFile, keyFile, "10.0.0.8")
+	reloader, err := auth.NewKeyPairReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	renewer := &CertRenewer{ServerURL: ts.URL, Token: "node-token", CertFile: certFile, KeyFile: keyFile, Reloader: reloader}
+	if err := renewer.RenewIfNeeded(); err != nil {
+		t.Fatalf("RenewIfNeeded error: %v", err)
+	}
+	if gotHostname != "10.0.0.8" || gotToken != "node-token" {
+		t.Fatalf("unexpected renew request: hostname=%q token=%q", gotHostname, gotToken)
+	}
+	cert, err := auth.LoadCertFile(certFile)
+	if err != nil || time.Until(cert.NotAfter) < auth.CertRenewBefore {
+		t.Fatalf("certificate was not renewed: %v", err)
+	}
+	loaded, _ := reloader.GetCertificate(nil)
+	if leaf, _ := x509.ParseCertificate(loaded.Certificate[0]); leaf.SerialNumber.Cmp(cert.SerialNumber) != 0 {
+		t.Fatal("reloader should serve the renewed certificate")
+	}
+
+	// 证书有效期充足时不续期
+	gotHostname = ""
+	if err := renewer.RenewIfNeeded(); err != nil || gotHostname != "" {
+		t.Fatalf("unexpected renewal: %v", err)
+	}
+}
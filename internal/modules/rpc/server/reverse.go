@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	pb "github.com/tabortao/gocron/internal/modules/rpc/proto"
+	"github.com/tabortao/gocron/internal/modules/rpc/reverse"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	reconnectMinDelay = time.Second
+	reconnectMaxDelay = 30 * time.Second
+)
+
+var reverseKeepAliveParams = keepalive.ClientParameters{
+	Time:                20 * time.Second,
+	Timeout:             3 * time.Second,
+	PermitWithoutStream: true,
+}
+
+// StartReverse 反向连接模式: 主动连接服务端并保持双向流, 断开后自动重连
+// cfg.Hostname 需与服务端注册的主机名一致, 令牌为注册时颁发的节点令牌
+func StartReverse(cfg *Config, reload func() (*Config, error)) {
+	s, err := newServer(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	serverAddr, hostname := cfg.ReverseServer, cfg.Hostname
+	opts := []grpc.DialOption{grpc.WithKeepaliveParams(reverseKeepAliveParams)}
+	if cfg.EnableTLS {
+		certificate := cfg.Certificate()
+		certificate.Reloader = s.reloader
+		if host, _, err := net.SplitHostPort(serverAddr); err == nil && certificate.ServerName == "" {
+			certificate.ServerName = host
+		}
+		transportCreds, err := certificate.GetTransportCredsForClient()
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts = append(opts, grpc.WithTransportCredentials(transportCreds))
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	conn, err := grpc.NewClient(serverAddr, opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		delay := reconnectMinDelay
+		for {
+			start := time.Now()
+			// 每次连接读取最新的令牌, 重新加载配置后在下次重连时生效
+			err := s.connect(ctx, pb.NewAgentClient(conn), hostname, s.currentToken())
+			if ctx.Err() != nil {
+				return
+			}
+			// 连接保持一段时间后断开, 重置重连间隔
+			if time.Since(start) > reconnectMaxDelay {
+				delay = reconnectMinDelay
+			}
+			log.Warnf("reverse connection to %s closed: %v, reconnecting in %s", serverAddr, err, delay)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return
+			}
+			delay = min(delay*2, reconnectMaxDelay)
+		}
+	}()
+	log.Infof("reverse mode, connecting to %s as %s", serverAddr, hostname)
+
+	// 排空期间保持连接, 任务结果通过该连接返回
+	waitForSignal(func() {
+		s.drain(s.drainTimeout())
+		cancel()
+		conn.Close()
+	}, func() { s.reload(reload) })
+}
+
+// 建立一次连接并处理服务端请求, 直到连接断开
+func (s *Server) connect(ctx context.Context, client pb.AgentClient, hostname, token string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := client.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	var sendMu sync.Mutex
+	send := func(msg *pb.AgentMessage) {
+		sendMu.Lock()
+		defer sendMu.Unlock()
+		if err := stream.Send(msg); err != nil {
+			log.Warnf("reverse connection send failed: %v", err)
+		}
+	}
+	send(&pb.AgentMessage{Type: reverse.TypeHello, Hostname: hostname, Token: token})
+
+	// 各请求的取消函数, key为请求序号
+	var cancels sync.Map
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		switch msg.Type {
+		case reverse.TypeRun:
+			if msg.Request == nil {
+				continue
+			}
+			runCtx, runCancel := context.WithCancel(ctx)
+			cancels.Store(msg.Seq, runCancel)
+			go func(msg *pb.ServerMessage) {
+				defer func() {
+					cancels.Delete(msg.Seq)
+					runCancel()
+				}()
+				resp, _ := s.Run(runCtx, msg.Request)
+				send(&pb.AgentMessage{Type: reverse.TypeResult, Seq: msg.Seq, Response: resp})
+			}(msg)
+		case reverse.TypeCancel:
+			if v, ok := cancels.Load(msg.Seq); ok {
+				v.(context.CancelFunc)()
+			}
+		case reverse.TypeHealth:
+			go func(seq int64) {
+				resp, _ := s.Health(ctx, &pb.HealthRequest{})
+				send(&pb.AgentMessage{Type: reverse.TypeHealth, Seq: seq, Health: resp})
+			}(msg.Seq)
+		case reverse.TypeJobStatus:
+			if msg.Job == nil {
+				continue
+			}
+			go func(msg *pb.ServerMessage) {
+				resp, _ := s.JobStatus(ctx, msg.Job)
+				send(&pb.AgentMessage{Type: reverse.TypeJobStatus, Seq: msg.Seq, Job: resp})
+			}(msg)
+		case reverse.TypeSetToken:
+			if msg.Token == nil {
+				continue
+			}
+			reply := &pb.AgentMessage{Type: reverse.TypeSetToken, Seq: msg.Seq}
+			if _, err := s.SetToken(ctx, msg.Token); err != nil {
+				reply.Error = status.Convert(err).Message()
+			}
+			send(reply)
+		}
+	}
+}
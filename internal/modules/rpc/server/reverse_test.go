@@ -0,0 +1,195 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tabortao/gocron/internal/modules/logger"
+	pb "github.com/tabortao/gocron/internal/modules/rpc/proto"
+	"github.com/tabortao/gocron/internal/modules/rpc/reverse"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestMain(m *testing.M) {
+	_ = os.MkdirAll("log", 0o755)
+	logger.InitLogger()
+	os.Exit(m.Run())
+}
+
+// 启动内存中的反向连接服务, 只允许 allowed 主机连接
+func startReverseServer(t *testing.T, allowed string) pb.AgentClient {
+	listener := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer()
+	reverse.Register(s, func(hostname, token, certName string) error {
+		if hostname != allowed || token != "secret" {
+			return errors.New("not registered")
+		}
+		return nil
+	})
+	go s.Serve(listener)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewAgentClient(conn)
+}
+
+func waitForReverseConn(t *testing.T, hostname string) *reverse.Conn {
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if conn, ok := reverse.Lookup(hostname); ok {
+			return conn
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("node %s did not connect", hostname)
+	return nil
+}
+
+func TestReverseConnectDispatch(t *testing.T) {
+	client := startReverseServer(t, "node-a")
+	AppVersion = "v-test"
+	defer func() { AppVersion = "" }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- (&Server{}).connect(ctx, client, "node-a", "secret") }()
+	conn := waitForReverseConn(t, "node-a")
+
+	reqCtx, reqCancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer reqCancel()
+	health, err := conn.Health(reqCtx, &pb.HealthRequest{})
+	if err != nil || health.Version != "v-test" {
+		t.Fatalf("Health = %+v, %v", health, err)
+	}
+	resp, err := conn.Run(reqCtx, &pb.TaskRequest{Command: "__TAIL__", Id: 1, Timeout: 5})
+	if err != nil || resp.Error != "" {
+		t.Fatalf("Run = %+v, %v", resp, err)
+	}
+
+	// 节点断开后请求返回不可用
+	cancel()
+	<-done
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, ok := reverse.Lookup("node-a"); !ok {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, ok := reverse.Lookup("node-a"); ok {
+		t.Fatal("expected node to be removed after disconnect")
+	}
+	_, err = conn.Run(reqCtx, &pb.TaskRequest{Command: "__TAIL__", Id: 1})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable after disconnect, got %v", err)
+	}
+}
+
+func TestReverseConnectKeepsLiveConn(t *testing.T) {
+	client := startReverseServer(t, "node-c")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go (&Server{}).connect(ctx, client, "node-c", "secret")
+	live := waitForReverseConn(t, "node-c")
+
+	// 已有连接时新连接被拒绝, 不能替换已连接的节点
+	dupCtx, dupCancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer dupCancel()
+	err := (&Server{}).connect(dupCtx, client, "node-c", "secret")
+	if status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("expected AlreadyExists, got %v", err)
+	}
+	if conn, ok := reverse.Lookup("node-c"); !ok || conn != live {
+		t.Fatal("live connection should be kept")
+	}
+}
+
+func TestReverseSetToken(t *testing.T) {
+	client := startReverseServer(t, "node-d")
+	tokenFile := filepath.Join(t.TempDir(), "node.token")
+	s := &Server{cfg: &Config{TokenFile: tokenFile}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.connect(ctx, client, "node-d", "secret")
+	conn := waitForReverseConn(t, "node-d")
+
+	// 未配置令牌的节点拒绝设置令牌
+	reqCtx, reqCancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer reqCancel()
+	if _, err := conn.SetToken(reqCtx, &pb.TokenRequest{Token: "rotated"}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition for tokenless node, got %v", err)
+	}
+	if _, err := os.Stat(tokenFile); !os.IsNotExist(err) {
+		t.Fatal("token file should not be written on a tokenless node")
+	}
+
+	s.setToken("secret", s.cfg)
+	if _, err := conn.SetToken(reqCtx, &pb.TokenRequest{Token: "rotated"}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(tokenFile)
+	if strings.TrimSpace(string(data)) != "rotated" || s.currentToken() != "rotated" {
+		t.Fatalf("token file %q, current token %q", data, s.currentToken())
+	}
+
+	// 吊销后节点使用随机令牌, 拒绝携带原令牌的请求
+	if _, err := conn.SetToken(reqCtx, &pb.TokenRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if token := s.currentToken(); token == "" || token == "rotated" {
+		t.Fatalf("unexpected token after revoke %q", token)
+	}
+
+	// 未配置令牌文件时无法更新
+	s.cfg = &Config{}
+	_, err := conn.SetToken(reqCtx, &pb.TokenRequest{Token: "again"})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition, got %v", err)
+	}
+}
+
+func TestReverseConnectRejectsUnauthorized(t *testing.T) {
+	client := startReverseServer(t, "node-a")
+
+	tests := []struct {
+		hostname string
+		token    string
+	}{
+		{"node-b", "secret"},
+		{"node-a", "wrong"},
+		{"node-a", ""},
+	}
+	for _, tt := range tests {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		err := (&Server{}).connect(ctx, client, tt.hostname, tt.token)
+		cancel()
+		if status.Code(err) != codes.PermissionDenied {
+			t.Fatalf("%s/%q: expected PermissionDenied, got %v", tt.hostname, tt.token, err)
+		}
+		if _, ok := reverse.Lookup(tt.hostname); ok {
+			t.Fatalf("%s: rejected node should not be registered", tt.hostname)
+		}
+	}
+}
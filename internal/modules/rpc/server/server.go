@@ -3,11 +3,15 @@ package server
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
 	"io"
 	"net"
 	"os"
 	"os/signal"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -25,6 +29,24 @@ type Server struct {
 	taskContexts sync.Map // 存储正在运行的任务上下文
 	taskOutputs  sync.Map // 存储任务输出
 	stopChans    sync.Map // 存储停止通道
+	running      atomic.Int32
+	maxJobs      atomic.Int32
+	maxTimeout   atomic.Int32
+	labels       atomic.Value // 节点标签
+	token        atomic.Value // 节点令牌
+	tokenHash    atomic.Value // 节点令牌哈希, 为空时不校验
+	tokenMu      sync.Mutex   // 服务端更新令牌时写令牌文件
+	draining     atomic.Bool  // 排空中, 不再接收新任务
+	interrupted  atomic.Bool  // 排空超时, 剩余任务已被终止
+	jobs         *jobStore    // 分离执行的任务, 未配置 state_dir 时为nil
+
+	cfg        *Config
+	reloader   *auth.KeyPairReloader
+	renewer    *CertRenewer
+	tlsConfig  atomic.Pointer[tls.Config]
+	grpcServer *grpc.Server
+	listenMu   sync.Mutex
+	listener   net.Listener
 }
 
 type taskOutput struct {
@@ -74,6 +96,11 @@ func (s *Server) Run(ctx context.Context, req *pb.TaskRequest) (*pb.TaskResponse
 				Error:  "",
 			}, nil
 		}
+		if s.jobs != nil {
+			if output, ok := s.jobs.tail(req.Id); ok {
+				return &pb.TaskResponse{Output: output}, nil
+			}
+		}
 		return &pb.TaskResponse{
 			Output: "",
 			Error:  "",
@@ -85,14 +112,44 @@ func (s *Server) Run(ctx context.Context, req *pb.TaskRequest) (*pb.TaskResponse
 		if ch, ok := s.stopChans.Load(req.Id); ok {
 			close(ch.(chan struct{}))
 		}
+		if s.jobs != nil {
+			s.jobs.stop(req.Id)
+		}
 		return &pb.TaskResponse{
 			Output: "",
 			Error:  "",
 		}, nil
 	}
 
+	if s.draining.Load() {
+		log.Warnf("[id: %d] Rejected, node is draining", req.Id)
+		return &pb.TaskResponse{
+			Output: "",
+			Error:  drainingMessage,
+		}, nil
+	}
+
+	// 校验节点命令执行策略
+	if err := commandPolicy.Load().Check(req.TaskId, cleanedCmd, req.Signature); err != nil {
+		log.Warnf("[id: %d] %s#%s", req.Id, err.Error(), cleanedCmd)
+		return &pb.TaskResponse{
+			Output: "",
+			Error:  err.Error(),
+		}, nil
+	}
+
+	if req.Detached {
+		return s.runDetached(req, cleanedCmd), nil
+	}
+
+	running := s.running.Add(1)
+	defer s.running.Add(-1)
+	if resp := s.checkMaxJobs(req.Id, running+s.detachedRunning()); resp != nil {
+		return resp, nil
+	}
+
 	// 使用任务超时创建独立的 context
-	timeout := time.Duration(req.Timeout) * time.Second
+	timeout := time.Duration(s.taskTimeout(req.Timeout)) * time.Second
 	taskCtx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
@@ -135,6 +192,9 @@ func (s *Server) Run(ctx context.Context, req *pb.TaskRequest) (*pb.TaskResponse
 		if wasStopped {
 			resp.Error = "manual stop"
 			log.Infof("[id: %d] Manually stopped\n%s", req.Id, output)
+		} else if s.interrupted.Load() && errors.Is(taskCtx.Err(), context.Canceled) {
+			resp.Error = drainInterruptedMessage
+			log.Infof("[id: %d] Interrupted by node shutdown\n%s", req.Id, output)
 		} else {
 			resp.Error = execErr.Error()
 			log.Infof("[id: %d] Execution failed: %s\n%s", req.Id, execErr.Error(), output)
@@ -147,8 +207,67 @@ func (s *Server) Run(ctx context.Context, req *pb.TaskRequest) (*pb.TaskResponse
 	return resp, nil
 }
 
-func Start(addr string, enableTLS bool, certificate auth.Certificate) {
-	l, err := net.Listen("tcp", addr)
+// 分离执行: 启动任务后立即返回, 服务端通过 JobStatus 查询结果
+func (s *Server) runDetached(req *pb.TaskRequest, command string) *pb.TaskResponse {
+	if s.jobs == nil {
+		return &pb.TaskResponse{Error: errDetachedDisabled.Error()}
+	}
+	if resp := s.checkMaxJobs(req.Id, s.running.Load()+s.detachedRunning()+1); resp != nil {
+		return resp
+	}
+	pid, err := s.jobs.start(req.Id, command, s.taskTimeout(req.Timeout))
+	if err != nil {
+		log.Warnf("[id: %d] Failed to start detached job: %v", req.Id, err)
+		return &pb.TaskResponse{Error: err.Error()}
+	}
+	log.Infof("[id: %d] Detached job started, pid %d", req.Id, pid)
+
+	return &pb.TaskResponse{Detached: true}
+}
+
+// JobStatus 查询分离执行的任务状态及输出
+func (s *Server) JobStatus(ctx context.Context, req *pb.JobStatusRequest) (*pb.JobStatusResponse, error) {
+	if s.jobs == nil {
+		return &pb.JobStatusResponse{}, nil
+	}
+
+	return s.jobs.status(req), nil
+}
+
+// 超过节点配置的最大任务数时返回错误响应
+func (s *Server) checkMaxJobs(id int64, running int32) *pb.TaskResponse {
+	maxJobs := s.maxJobs.Load()
+	if maxJobs <= 0 || running <= maxJobs {
+		return nil
+	}
+	log.Warnf("[id: %d] node is busy, max_jobs %d reached", id, maxJobs)
+
+	return &pb.TaskResponse{
+		Output: "",
+		Error:  fmt.Sprintf("node is busy: max_jobs %d reached", maxJobs),
+	}
+}
+
+// 任务超时时间, 不超过节点配置的最大超时时间
+func (s *Server) taskTimeout(timeout int32) int32 {
+	if maxTimeout := s.maxTimeout.Load(); maxTimeout > 0 && (timeout <= 0 || timeout > maxTimeout) {
+		return maxTimeout
+	}
+
+	return timeout
+}
+
+func (s *Server) detachedRunning() int32 {
+	if s.jobs == nil {
+		return 0
+	}
+
+	return s.jobs.running()
+}
+
+// Start 监听端口, 收到 SIGHUP 时通过 reload 重新加载配置
+func Start(cfg *Config, reload func() (*Config, error)) {
+	s, err := newServer(cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -156,25 +275,38 @@ func Start(addr string, enableTLS bool, certificate auth.Certificate) {
 		grpc.KeepaliveParams(keepAliveParams),
 		grpc.KeepaliveEnforcementPolicy(keepAlivePolicy),
 	}
-	if enableTLS {
-		tlsConfig, err := certificate.GetTLSConfigForServer()
+	if cfg.EnableTLS {
+		tlsConfig, err := s.serverTLSConfig(cfg)
 		if err != nil {
 			log.Fatal(err)
 		}
-		opt := grpc.Creds(credentials.NewTLS(tlsConfig))
-		opts = append(opts, opt)
+		s.tlsConfig.Store(tlsConfig)
+		// 每次握手使用最新的配置, 重新加载后无需重启
+		opts = append(opts, grpc.Creds(credentials.NewTLS(&tls.Config{
+			GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+				return s.tlsConfig.Load(), nil
+			},
+		})))
+	}
+	unary, stream := auth.TokenInterceptors(s.currentTokenHash)
+	opts = append(opts, grpc.UnaryInterceptor(unary), grpc.StreamInterceptor(stream))
+	if s.currentTokenHash() == "" {
+		log.Warn("node token is not set, any client that can reach this port can run commands")
+	}
+	s.grpcServer = grpc.NewServer(opts...)
+	pb.RegisterTaskServer(s.grpcServer, s)
+	if err := s.listen(cfg.Listen); err != nil {
+		log.Fatal(err)
 	}
-	server := grpc.NewServer(opts...)
-	pb.RegisterTaskServer(server, &Server{})
-	log.Infof("server listen on %s", addr)
 
-	go func() {
-		err = server.Serve(l)
-		if err != nil {
-			log.Fatal(err)
-		}
-	}()
+	waitForSignal(func() {
+		s.drain(s.drainTimeout())
+		gracefulStop(s.grpcServer)
+	}, func() { s.reload(reload) })
+}
 
+// 等待信号, 收到 SIGHUP 时执行 reload, 收到退出信号时执行 stop
+func waitForSignal(stop func(), reload func()) {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
 	for {
@@ -182,12 +314,16 @@ func Start(addr string, enableTLS bool, certificate auth.Certificate) {
 		log.Infoln("Received signal -- ", s)
 		switch s {
 		case syscall.SIGHUP:
-			log.Infoln("Received terminal disconnect signal, ignoring")
+			if reload == nil {
+				log.Infoln("Received terminal disconnect signal, ignoring")
+				continue
+			}
+			log.Info("Reloading config")
+			reload()
 		case syscall.SIGINT, syscall.SIGTERM:
 			log.Info("Application preparing to exit")
-			server.GracefulStop()
+			stop()
 			return
 		}
 	}
-
 }
@@ -37,8 +37,44 @@ type Setting struct {
 	CertFile  string
 	KeyFile   string
 
-	ConcurrencyQueue int
-	AuthSecret       string
+	ConcurrencyQueue   int
+	AuthSecret         string
+	HostHealthInterval int    // 节点健康检查间隔(秒), 0表示不检查
+	AgentListen        string // 节点反向连接监听地址, 为空不启用
+	CAEnable           bool   // 启用内置CA, 注册节点时签发证书
+
+	RPCPoolCheckInterval int // 节点连接池检查间隔(秒), 0表示不检查
+	RPCPoolIdleTimeout   int // 空闲连接关闭时间(秒), 0表示不关闭
+	RPCPoolMaxAge        int // 连接最长使用时间(秒), 到期后重新建立以使用更新后的证书, 0表示不限制
+
+	// OIDC单点登录, 首次登录时自动创建用户
+	OIDCEnable        bool
+	OIDCIssuer        string
+	OIDCClientId      string
+	OIDCClientSecret  string
+	OIDCRedirectUrl   string // 回调地址, 如 https://gocron.example.com/api/user/oidc/callback
+	OIDCScopes        string // 空格分隔
+	OIDCUsernameClaim string // 作为用户名的声明
+	OIDCGroupsClaim   string // 用户组声明
+	OIDCAdminGroups   string // 属于其中任一组的用户为管理员, 逗号分隔, 为空时不同步管理员身份
+	OIDCGroupRoles    string // 用户组对应的项目角色, 格式 组=项目ID:角色, 逗号分隔, 角色为 viewer、operator、editor、admin
+
+	// LDAP认证, 本地不存在的用户通过LDAP验证后自动创建
+	LDAPEnable             bool
+	LDAPUrl                string // 如 ldap://ldap.example.com:389 或 ldaps://ldap.example.com:636
+	LDAPStartTLS           bool
+	LDAPInsecureSkipVerify bool
+	LDAPCAFile             string
+	LDAPBindDN             string // 查询用户的账户, 为空时匿名查询
+	LDAPBindPassword       string
+	LDAPBaseDN             string
+	LDAPUserFilter         string // %s替换为用户名
+	LDAPUsernameAttribute  string
+	LDAPEmailAttribute     string
+	LDAPGroupBaseDN        string // 为空时使用BaseDN
+	LDAPGroupFilter        string // %s替换为用户DN, 为空时不查询用户组
+	LDAPGroupAttribute     string // 作为组名的属性
+	LDAPAdminGroups        string // 属于其中任一组的用户为管理员, 逗号分隔, 为空时不同步管理员身份
 }
 
 // 读取配置
@@ -98,6 +134,43 @@ func Read(filename string) (*Setting, error) {
 	s.ApiSecret = section.Key("api.secret").MustString("")
 	s.ApiSignEnable = section.Key("api.sign.enable").MustBool(true)
 	s.ConcurrencyQueue = section.Key("concurrency.queue").MustInt(500)
+	s.HostHealthInterval = section.Key("host.health.interval").MustInt(30)
+	s.AgentListen = section.Key("agent.listen").MustString("")
+	s.CAEnable = section.Key("ca.enable").MustBool(true)
+	s.RPCPoolCheckInterval = section.Key("rpc.pool.check_interval").MustInt(30)
+	s.RPCPoolIdleTimeout = section.Key("rpc.pool.idle_timeout").MustInt(600)
+	s.RPCPoolMaxAge = section.Key("rpc.pool.max_age").MustInt(3600)
+	s.OIDCEnable = section.Key("oidc.enable").MustBool(false)
+	s.OIDCIssuer = section.Key("oidc.issuer").MustString("")
+	s.OIDCClientId = section.Key("oidc.client_id").MustString("")
+	s.OIDCClientSecret = section.Key("oidc.client_secret").MustString("")
+	if envClientSecret := os.Getenv("GOCRON_OIDC_CLIENT_SECRET"); envClientSecret != "" {
+		s.OIDCClientSecret = envClientSecret
+	}
+	s.OIDCRedirectUrl = section.Key("oidc.redirect_url").MustString("")
+	s.OIDCScopes = section.Key("oidc.scopes").MustString("openid profile email")
+	s.OIDCUsernameClaim = section.Key("oidc.username_claim").MustString("preferred_username")
+	s.OIDCGroupsClaim = section.Key("oidc.groups_claim").MustString("groups")
+	s.OIDCAdminGroups = section.Key("oidc.admin_groups").MustString("")
+	s.OIDCGroupRoles = section.Key("oidc.group_roles").MustString("")
+	s.LDAPEnable = section.Key("ldap.enable").MustBool(false)
+	s.LDAPUrl = section.Key("ldap.url").MustString("")
+	s.LDAPStartTLS = section.Key("ldap.start_tls").MustBool(false)
+	s.LDAPInsecureSkipVerify = section.Key("ldap.insecure_skip_verify").MustBool(false)
+	s.LDAPCAFile = section.Key("ldap.ca_file").MustString("")
+	s.LDAPBindDN = section.Key("ldap.bind_dn").MustString("")
+	s.LDAPBindPassword = section.Key("ldap.bind_password").MustString("")
+	if envBindPassword := os.Getenv("GOCRON_LDAP_BIND_PASSWORD"); envBindPassword != "" {
+		s.LDAPBindPassword = envBindPassword
+	}
+	s.LDAPBaseDN = section.Key("ldap.base_dn").MustString("")
+	s.LDAPUserFilter = section.Key("ldap.user_filter").MustString("(uid=%s)")
+	s.LDAPUsernameAttribute = section.Key("ldap.username_attribute").MustString("uid")
+	s.LDAPEmailAttribute = section.Key("ldap.email_attribute").MustString("mail")
+	s.LDAPGroupBaseDN = section.Key("ldap.group_base_dn").MustString("")
+	s.LDAPGroupFilter = section.Key("ldap.group_filter").MustString("")
+	s.LDAPGroupAttribute = section.Key("ldap.group_attribute").MustString("cn")
+	s.LDAPAdminGroups = section.Key("ldap.admin_groups").MustString("")
 	s.AuthSecret = section.Key("auth_secret").MustString("")
 	if envAuthSecret := os.Getenv("GOCRON_AUTH_SECRET"); envAuthSecret != "" {
 		s.AuthSecret = envAuthSecret
@@ -2,12 +2,14 @@ package agent
 
 import (
 	"crypto/rand"
+	"encoding/base64"
 	"encoding/hex"
 	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,7 +17,9 @@ import (
 	"github.com/tabortao/gocron/internal/models"
 	"github.com/tabortao/gocron/internal/modules/i18n"
 	"github.com/tabortao/gocron/internal/modules/logger"
+	"github.com/tabortao/gocron/internal/modules/rpc/auth"
 	"github.com/tabortao/gocron/internal/routers/base"
+	"github.com/tabortao/gocron/internal/service"
 )
 
 const tokenExpiration = 3 * time.Hour
@@ -24,12 +28,22 @@ var githubRepoPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
 
 // GenerateToken 生成注册token
 func GenerateToken(c *gin.Context) {
+	// 可选: 通过该token注册的主机默认使用的分组和标签
+	group := strings.TrimSpace(c.PostForm("group"))
+	labels, err := models.NormalizeLabels(c.PostForm("labels"))
+	if err != nil || !models.ValidHostGroup(group) {
+		base.RespondError(c, i18n.T(c, "agent_labels_invalid"))
+		return
+	}
+
 	token := generateRandomToken()
 	expiresAt := time.Now().Add(tokenExpiration)
 
 	agentToken := &models.AgentToken{
 		Token:     token,
 		ExpiresAt: expiresAt,
+		HostGroup: group,
+		Labels:    labels,
 	}
 
 	if err := agentToken.Create(); err != nil {
@@ -117,6 +131,7 @@ fi
 GOCRON_SERVER="` + getServerURL(c) + `"
 GITHUB_REPO="` + repo + `"
 GITHUB_TAG="` + tag + `"
+BUILTIN_CA="` + strconv.FormatBool(service.BuiltinCA != nil) + `"
 INSTALL_DIR="/opt/gocron-node"
 SERVICE_NAME="gocron-node"
 
@@ -203,8 +218,14 @@ $SUDO cp -r gocron-node*/* "$INSTALL_DIR/"
 $SUDO chmod +x "$INSTALL_DIR/gocron-node"
 
 echo "Registering agent..."
+# 可通过环境变量 GOCRON_NODE_REVERSE_SERVER(ip:port) 使用反向连接模式, 适用于服务端无法访问的节点
+REVERSE="false"
 # 获取本机IP地址，如果失败则使用hostname
-if [ "$OS" = "darwin" ]; then
+if [ -n "${GOCRON_NODE_REVERSE_SERVER:-}" ]; then
+    REVERSE="true"
+    HOSTNAME=$(hostname)
+    NODE_ARGS="$NODE_ARGS -reverse-server ${GOCRON_NODE_REVERSE_SERVER} -hostname ${HOSTNAME}"
+elif [ "$OS" = "darwin" ]; then
     HOSTNAME=$(ipconfig getifaddr en0 2>/dev/null || hostname)
 elif [ "$OS" = "linux" ]; then
     HOSTNAME=$(hostname -I 2>/dev/null | awk '{print $1}' || hostname)
@@ -212,10 +233,40 @@ else
     HOSTNAME=$(hostname)
 fi
 echo "Using hostname/IP: $HOSTNAME"
+
+# 生成节点私钥及证书签名请求, 服务端启用内置CA时签发证书并开启TLS
+TLS_DIR="$INSTALL_DIR/tls"
+CSR=""
+if [ "$BUILTIN_CA" = "true" ]; then
+    if ! command -v openssl >/dev/null 2>&1; then
+        echo "Error: openssl is required, the server issues TLS certificates for nodes"
+        exit 1
+    fi
+    $SUDO mkdir -p "$TLS_DIR"
+    $SUDO chown "$(whoami)" "$TLS_DIR"
+    $SUDO chmod 700 "$TLS_DIR"
+    openssl ecparam -name prime256v1 -genkey -noout -out "$TLS_DIR/node.key"
+    chmod 600 "$TLS_DIR/node.key"
+    openssl req -new -key "$TLS_DIR/node.key" -subj "/CN=$HOSTNAME" -out "$TMP_DIR/node.csr"
+    CSR=$(base64 < "$TMP_DIR/node.csr" | tr -d '\n')
+fi
+
 REGISTER_URL="${GOCRON_SERVER}/api/agent/register"
+# 可通过环境变量 GOCRON_NODE_GROUP、GOCRON_NODE_LABELS 指定主机分组和标签
+# 重新安装已注册的节点时需提供当前的节点令牌, 默认读取已保存的令牌, 也可通过 GOCRON_NODE_TOKEN 指定
+EXISTING_NODE_TOKEN="${GOCRON_NODE_TOKEN:-}"
+if [ -z "$EXISTING_NODE_TOKEN" ] && [ -f "$INSTALL_DIR/node.token" ]; then
+    EXISTING_NODE_TOKEN=$($SUDO cat "$INSTALL_DIR/node.token")
+fi
+# 以表单提交并由curl编码, 避免取值中的引号等字符破坏请求
 RESPONSE=$(curl -fsSL -X POST "$REGISTER_URL" \
-    -H "Content-Type: application/json" \
-    -d "{\"token\":\"$TOKEN\",\"hostname\":\"$HOSTNAME\"}")
+    --data-urlencode "token=$TOKEN" \
+    --data-urlencode "node_token=$EXISTING_NODE_TOKEN" \
+    --data-urlencode "hostname=$HOSTNAME" \
+    --data-urlencode "group=${GOCRON_NODE_GROUP:-}" \
+    --data-urlencode "labels=${GOCRON_NODE_LABELS:-}" \
+    --data-urlencode "reverse=$REVERSE" \
+    --data-urlencode "csr=$CSR")
 
 if echo "$RESPONSE" | grep -q '"code":0'; then
     echo "Agent registered successfully"
@@ -224,6 +275,33 @@ else
     exit 1
 fi
 
+# 保存节点令牌, 节点只接受携带该令牌的请求
+NODE_TOKEN=$(echo "$RESPONSE" | sed -n 's/.*"node_token":"\([^"]*\)".*/\1/p')
+if [ -n "$NODE_TOKEN" ]; then
+    echo "$NODE_TOKEN" | $SUDO tee "$INSTALL_DIR/node.token" > /dev/null
+    $SUDO chown "$(whoami)" "$INSTALL_DIR/node.token"
+    $SUDO chmod 600 "$INSTALL_DIR/node.token"
+    NODE_ARGS="$NODE_ARGS -token-file $INSTALL_DIR/node.token"
+fi
+
+# 分离执行任务的状态目录, 节点重启后从中接管运行中的任务
+$SUDO mkdir -p "$INSTALL_DIR/state"
+$SUDO chown "$(whoami)" "$INSTALL_DIR/state"
+NODE_ARGS="$NODE_ARGS -state-dir $INSTALL_DIR/state"
+
+# 保存内置CA签发的证书, 证书到期前节点通过服务端自动续期
+CERTIFICATE=$(echo "$RESPONSE" | sed -n 's/.*"certificate":"\([^"]*\)".*/\1/p')
+CA_CERTIFICATE=$(echo "$RESPONSE" | sed -n 's/.*"ca_certificate":"\([^"]*\)".*/\1/p')
+if [ -n "$CERTIFICATE" ] && [ -n "$CA_CERTIFICATE" ]; then
+    echo "$CERTIFICATE" | base64 --decode > "$TLS_DIR/node.crt"
+    echo "$CA_CERTIFICATE" | base64 --decode > "$TLS_DIR/ca.crt"
+    NODE_ARGS="$NODE_ARGS -enable-tls -ca-file $TLS_DIR/ca.crt -cert-file $TLS_DIR/node.crt -key-file $TLS_DIR/node.key -cert-renew-url $GOCRON_SERVER"
+    if [ "$REVERSE" = "true" ]; then
+        NODE_ARGS="$NODE_ARGS -server-name gocron-server"
+    fi
+    echo "TLS enabled with certificate issued by the server"
+fi
+
 if [ "$OS" = "linux" ]; then
     $SUDO tee /etc/systemd/system/${SERVICE_NAME}.service > /dev/null <<EOF
 [Unit]
@@ -235,6 +313,9 @@ Type=simple
 User=$(whoami)
 WorkingDirectory=$INSTALL_DIR
 ExecStart=$INSTALL_DIR/gocron-node $NODE_ARGS
+ExecReload=/bin/kill -HUP \$MAINPID
+# 停止节点时只结束节点进程, 分离执行的任务继续运行
+KillMode=process
 Restart=on-failure
 RestartSec=5s
 
@@ -298,17 +379,29 @@ echo "========================================"
 	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(script))
 }
 
-// Register agent注册
+// Register agent注册, 请求可以是json或表单
 func Register(c *gin.Context) {
 	var req struct {
-		Token    string `json:"token" binding:"required"`
-		Hostname string `json:"hostname" binding:"required"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
+		Token    string `json:"token" form:"token" binding:"required"`
+		Hostname string `json:"hostname" form:"hostname" binding:"required"`
+		Group    string `json:"group" form:"group"`
+		Labels   string `json:"labels" form:"labels"`
+		// 节点位于NAT或防火墙后, 由节点主动连接服务端
+		Reverse bool `json:"reverse" form:"reverse"`
+		// 节点生成的证书签名请求(base64编码的PEM), 启用内置CA时签发证书
+		CSR string `json:"csr" form:"csr"`
+		// 主机已注册时需提供当前的节点令牌, 管理员可在主机列表轮换令牌后提供给节点
+		NodeToken string `json:"node_token" form:"node_token"`
+	}
+
+	if err := c.ShouldBind(&req); err != nil {
 		base.RespondError(c, "Invalid request", err)
 		return
 	}
+	if service.IsReservedHostname(req.Hostname) {
+		base.RespondError(c, "Invalid hostname")
+		return
+	}
 
 	agentToken := &models.AgentToken{}
 	if err := agentToken.FindByToken(req.Token); err != nil {
@@ -322,19 +415,63 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	host := &models.Host{
-		Name:   req.Hostname,
-		Alias:  req.Hostname,
-		Port:   5921,
-		Remark: "Auto registered",
-	}
-
-	exists, err := host.NameExists(req.Hostname, 0)
+	exists, err := new(models.Host).NameExists(req.Hostname, 0)
 	if err != nil {
 		logger.Error("检查主机是否存在失败:", err)
 		base.RespondError(c, "Operation failed", err)
 		return
 	}
+	existing := new(models.Host)
+	if exists {
+		if err := existing.FindByName(req.Hostname); err != nil {
+			logger.Error("获取主机失败:", err)
+			base.RespondError(c, "Operation failed", err)
+			return
+		}
+		// 只凭注册token不能接管已注册的主机
+		if !auth.VerifyToken(req.NodeToken, existing.TokenHash) {
+			logger.Warnf("主机已注册, 节点令牌无效: %s", req.Hostname)
+			base.RespondError(c, "Host already registered, a valid node token is required")
+			return
+		}
+	}
+
+	// 请求中的分组优先, 其次保留已有分组, 最后使用token的分组
+	// 标签依次合并token的标签、已有标签和请求中的标签, 重复注册不覆盖在界面上设置的标签
+	group := strings.TrimSpace(req.Group)
+	if group == "" {
+		group = existing.Group
+	}
+	if group == "" {
+		group = agentToken.HostGroup
+	}
+	labels, err := models.ParseLabels(agentToken.Labels)
+	if err != nil {
+		labels = map[string]string{}
+	}
+	if existingLabels, err := models.ParseLabels(existing.Labels); err == nil {
+		for key, value := range existingLabels {
+			labels[key] = value
+		}
+	}
+	requestLabels, err := models.ParseLabels(req.Labels)
+	if err != nil || !models.ValidHostGroup(group) {
+		base.RespondError(c, "Invalid group or labels", err)
+		return
+	}
+	for key, value := range requestLabels {
+		labels[key] = value
+	}
+
+	host := &models.Host{
+		Name:    req.Hostname,
+		Alias:   req.Hostname,
+		Port:    5921,
+		Remark:  "Auto registered",
+		Group:   group,
+		Labels:  models.FormatLabels(labels),
+		Reverse: req.Reverse,
+	}
 
 	if !exists {
 		if _, err := host.Create(); err != nil {
@@ -343,11 +480,97 @@ func Register(c *gin.Context) {
 			return
 		}
 		logger.Infof("主机注册成功: %s", req.Hostname)
+	} else if host.Group != "" || host.Labels != "" {
+		// 重复注册时更新分组和标签
+		if err := host.UpdateTarget(req.Hostname); err != nil {
+			logger.Error("更新主机标签失败:", err)
+			base.RespondError(c, "Operation failed", err)
+			return
+		}
+		logger.Infof("主机已存在，更新分组和标签: %s", req.Hostname)
 	} else {
 		logger.Infof("主机已存在，跳过创建: %s", req.Hostname)
 	}
+	if exists {
+		if err := host.SetReverse(req.Hostname, req.Reverse); err != nil {
+			logger.Error("更新主机连接方式失败:", err)
+			base.RespondError(c, "Operation failed", err)
+			return
+		}
+	}
+
+	// 每次注册颁发新的节点令牌, 节点使用 -token-file 启用令牌校验
+	registered := new(models.Host)
+	if err := registered.FindByName(req.Hostname); err != nil {
+		logger.Error("获取主机失败:", err)
+		base.RespondError(c, "Operation failed", err)
+		return
+	}
+	nodeToken, err := service.IssueNodeToken(registered.Id)
+	if err != nil {
+		logger.Error("生成节点令牌失败:", err)
+		base.RespondError(c, "Operation failed", err)
+		return
+	}
+
+	data := map[string]string{"node_token": nodeToken}
+	if req.CSR != "" && service.BuiltinCA != nil {
+		cert, err := issueCertificate(*registered, req.CSR)
+		if err != nil {
+			logger.Error("签发节点证书失败:", err)
+			base.RespondError(c, "Failed to issue certificate", err)
+			return
+		}
+		data["certificate"] = cert
+		data["ca_certificate"] = base64.StdEncoding.EncodeToString(service.BuiltinCA.CertPEM())
+	}
+
+	base.RespondSuccess(c, "Registration successful", data)
+}
+
+// RenewCertificate 节点证书续期, 使用注册时颁发的节点令牌认证
+func RenewCertificate(c *gin.Context) {
+	var req struct {
+		Hostname string `json:"hostname" binding:"required"`
+		Token    string `json:"token" binding:"required"`
+		CSR      string `json:"csr" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		base.RespondError(c, "Invalid request", err)
+		return
+	}
+	host := new(models.Host)
+	if err := host.FindByName(req.Hostname); err != nil || !auth.VerifyToken(req.Token, host.TokenHash) {
+		base.RespondError(c, "Invalid token")
+		return
+	}
+	if service.BuiltinCA == nil {
+		base.RespondError(c, "Built-in CA is not enabled")
+		return
+	}
+	cert, err := issueCertificate(*host, req.CSR)
+	if err != nil {
+		logger.Error("节点证书续期失败:", err)
+		base.RespondError(c, "Failed to issue certificate", err)
+		return
+	}
+	logger.Infof("节点证书已续期: %s", req.Hostname)
+
+	base.RespondSuccess(c, "Certificate renewed", map[string]string{"certificate": cert})
+}
+
+// 签发节点证书, CSR及返回的证书均为base64编码的PEM
+func issueCertificate(host models.Host, csr string) (string, error) {
+	csrPEM, err := base64.StdEncoding.DecodeString(csr)
+	if err != nil {
+		return "", err
+	}
+	cert, err := service.IssueNodeCertificate(host, csrPEM)
+	if err != nil {
+		return "", err
+	}
 
-	base.RespondSuccess(c, "Registration successful", nil)
+	return base64.StdEncoding.EncodeToString(cert), nil
 }
 
 // Download 优先从本地 gocron-node-package 目录下载，如果不存在则重定向到 GitHub Release
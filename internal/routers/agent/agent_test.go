@@ -0,0 +1,123 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/app"
+	"github.com/tabortao/gocron/internal/modules/logger"
+	"github.com/tabortao/gocron/internal/modules/setting"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func TestMain(m *testing.M) {
+	_ = os.MkdirAll("log", 0o755)
+	logger.InitLogger()
+	os.Exit(m.Run())
+}
+
+func setupAgentDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{SingularTable: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&models.Host{}, &models.AgentToken{}); err != nil {
+		t.Fatal(err)
+	}
+	oldDb, oldSetting := models.Db, app.Setting
+	models.Db, app.Setting = db, &setting.Setting{AuthSecret: "auth-secret"}
+	t.Cleanup(func() { models.Db, app.Setting = oldDb, oldSetting })
+
+	return db
+}
+
+// 提交注册请求, 返回响应码及节点令牌
+func register(form url.Values) (int, string) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/agent/register", Register)
+	req := httptest.NewRequest(http.MethodPost, "/api/agent/register", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var resp struct {
+		Code int               `json:"code"`
+		Data map[string]string `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+
+	return resp.Code, resp.Data["node_token"]
+}
+
+// 重复注册时合并标签, 保留在界面上设置的分组和标签
+func TestRegisterMergesLabels(t *testing.T) {
+	setupAgentDB(t)
+	token := &models.AgentToken{Token: "agent-token", ExpiresAt: time.Now().Add(time.Hour), Labels: "env=prod"}
+	if err := token.Create(); err != nil {
+		t.Fatal(err)
+	}
+	code, nodeToken := register(url.Values{"token": {"agent-token"}, "hostname": {"node-1"}, "labels": {"zone=a"}})
+	if code != 0 {
+		t.Fatalf("register code = %d", code)
+	}
+	host := new(models.Host)
+	_ = host.FindByName("node-1")
+	if host.Labels != "env=prod,zone=a" {
+		t.Fatalf("labels = %q", host.Labels)
+	}
+	_, _ = host.Update(host.Id, models.CommonMap{"host_group": "web", "labels": "env=staging,role=api,zone=a"})
+
+	if code, _ := register(url.Values{"token": {"agent-token"}, "hostname": {"node-1"}, "labels": {"zone=b"}, "node_token": {nodeToken}}); code != 0 {
+		t.Fatalf("re-register code = %d", code)
+	}
+	_ = host.FindByName("node-1")
+	if host.Group != "web" || host.Labels != "env=staging,role=api,zone=b" {
+		t.Errorf("group = %q, labels = %q", host.Group, host.Labels)
+	}
+}
+
+// 只凭注册token不能重新注册已有主机, 需提供当前的节点令牌
+func TestRegisterRequiresNodeToken(t *testing.T) {
+	setupAgentDB(t)
+	token := &models.AgentToken{Token: "agent-token", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := token.Create(); err != nil {
+		t.Fatal(err)
+	}
+	code, nodeToken := register(url.Values{"token": {"agent-token"}, "hostname": {"node-1"}})
+	if code != 0 || nodeToken == "" {
+		t.Fatalf("register = %d, %q", code, nodeToken)
+	}
+	registered := new(models.Host)
+	_ = registered.FindByName("node-1")
+
+	for _, form := range []url.Values{
+		{"token": {"agent-token"}, "hostname": {"node-1"}, "reverse": {"true"}, "labels": {"env=evil"}},
+		{"token": {"agent-token"}, "hostname": {"node-1"}, "reverse": {"true"}, "node_token": {"gct_wrong"}},
+	} {
+		if code, token := register(form); code == 0 || token != "" {
+			t.Errorf("re-register without node token should fail, got %d, %q", code, token)
+		}
+	}
+	host := new(models.Host)
+	_ = host.FindByName("node-1")
+	if host.TokenHash != registered.TokenHash || host.Reverse || host.Labels != "" {
+		t.Fatalf("host should be unchanged, got %+v", host)
+	}
+
+	code, rotated := register(url.Values{"token": {"agent-token"}, "hostname": {"node-1"}, "node_token": {nodeToken}})
+	if code != 0 || rotated == "" || rotated == nodeToken {
+		t.Errorf("re-register with node token = %d, %q", code, rotated)
+	}
+}
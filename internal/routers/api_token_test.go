@@ -0,0 +1,138 @@
+package routers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/app"
+	"github.com/tabortao/gocron/internal/modules/logger"
+	"github.com/tabortao/gocron/internal/modules/setting"
+	"github.com/tabortao/gocron/internal/modules/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func TestMain(m *testing.M) {
+	_ = os.MkdirAll("log", 0o755)
+	logger.InitLogger()
+	os.Exit(m.Run())
+}
+
+func TestApiTokenAuth(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{SingularTable: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&models.User{}, &models.ApiToken{}, &models.Task{}, &models.ProjectMember{}); err != nil {
+		t.Fatal(err)
+	}
+	oldDb, oldSetting, oldInstalled := models.Db, app.Setting, app.Installed
+	models.Db, app.Installed = db, true
+	app.Setting = &setting.Setting{AuthSecret: "auth-secret", ApiSignEnable: true, ApiKey: "key", ApiSecret: "secret"}
+	defer func() { models.Db, app.Setting, app.Installed = oldDb, oldSetting, oldInstalled }()
+
+	admin := models.User{Name: "admin", Email: "admin@example.com", Password: "password", IsAdmin: 1}
+	member := models.User{Name: "bob", Email: "bob@example.com", Password: "password"}
+	_, _ = admin.Create()
+	_, _ = member.Create()
+	task := models.Task{Name: "backup", ProjectId: 2}
+	task.Id, _ = task.Create()
+	_ = db.Create(&models.ProjectMember{ProjectId: 1, UserId: member.Id, Role: models.RoleOperator}).Error
+
+	newToken := func(userId int, scopes string, expiresAt *time.Time) string {
+		token := &models.ApiToken{UserId: userId, Name: "test", Scopes: scopes, ExpiresAt: expiresAt}
+		plain, err := token.Create()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return plain
+	}
+	readToken := newToken(admin.Id, "task:read,task:write", nil)
+	past := time.Now().Add(-time.Hour)
+	expiredToken := newToken(admin.Id, "task:read", &past)
+	memberToken := newToken(member.Id, "task:run", nil)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(userAuth, permissionAuth)
+	ok := func(c *gin.Context) { c.String(http.StatusOK, new(utils.JsonResponse).Success("", nil)) }
+	r.GET("/api/task", ok)
+	r.GET("/api/task/run/:id", ok)
+	r.GET("/api/user/api-tokens", ok)
+	r.POST("/api/v1/task/enable/:id", apiAuth, ok)
+
+	request := func(method, target, token string) int {
+		req := httptest.NewRequest(method, target, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var resp struct {
+			Code int `json:"code"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Code
+	}
+
+	runTask := "/api/task/run/" + strconv.Itoa(task.Id)
+	cases := []struct {
+		name, method, target, token string
+		want                        int
+	}{
+		{"scope granted", http.MethodGet, "/api/task", readToken, utils.ResponseSuccess},
+		{"scope missing", http.MethodGet, runTask, readToken, utils.UnauthorizedError},
+		{"route not allowed for tokens", http.MethodGet, "/api/user/api-tokens", readToken, utils.UnauthorizedError},
+		{"invalid token", http.MethodGet, "/api/task", "gct_invalid", utils.AuthError},
+		{"expired token", http.MethodGet, "/api/task", expiredToken, utils.AuthError},
+		{"member without project role", http.MethodGet, runTask, memberToken, utils.UnauthorizedError},
+		{"v1 with token skips signature", http.MethodPost, "/api/v1/task/enable/1", readToken, utils.ResponseSuccess},
+		{"v1 token scope missing", http.MethodPost, "/api/v1/task/enable/1", memberToken, utils.UnauthorizedError},
+		{"v1 without token requires signature", http.MethodPost, "/api/v1/task/enable/1", "", utils.AuthError},
+		{"v1 invalid signature requires login", http.MethodPost, "/api/v1/task/enable/1?time=1&sign=bad", "", utils.AuthError},
+	}
+	for _, tc := range cases {
+		if got := request(tc.method, tc.target, tc.token); got != tc.want {
+			t.Errorf("%s: code = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+
+	// 接口签名方式保持兼容
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	sign := utils.Sha256("key" + now + "/api/v1/task/enable/1" + "secret")
+	if got := request(http.MethodPost, "/api/v1/task/enable/1?time="+now+"&sign="+sign, ""); got != utils.ResponseSuccess {
+		t.Errorf("signed v1 request: code = %d", got)
+	}
+	// 未启用接口签名时, v1接口需要API令牌或登录
+	app.Setting.ApiSignEnable = false
+	if got := request(http.MethodPost, "/api/v1/task/enable/1", ""); got != utils.AuthError {
+		t.Errorf("v1 without signature enabled: code = %d", got)
+	}
+	app.Setting.ApiSignEnable = true
+
+	// 项目成员按角色访问, 用户禁用后令牌失效
+	_ = db.Model(&models.Task{}).Where("id = ?", task.Id).Update("project_id", 1).Error
+	if got := request(http.MethodGet, runTask, memberToken); got != utils.ResponseSuccess {
+		t.Errorf("member with operator role: code = %d", got)
+	}
+	_, _ = member.Disable(member.Id)
+	if got := request(http.MethodGet, runTask, memberToken); got != utils.AuthError {
+		t.Errorf("disabled user token: code = %d", got)
+	}
+
+	var used models.ApiToken
+	db.Where("user_id = ?", admin.Id).First(&used)
+	if used.LastUsedAt == nil {
+		t.Error("last used time should be recorded")
+	}
+}
@@ -0,0 +1,90 @@
+package apitoken
+
+// 个人API令牌管理, 令牌以 Authorization: Bearer 请求头访问接口
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/i18n"
+	"github.com/tabortao/gocron/internal/modules/utils"
+	"github.com/tabortao/gocron/internal/routers/audit"
+	"github.com/tabortao/gocron/internal/routers/base"
+	"github.com/tabortao/gocron/internal/routers/user"
+)
+
+// ApiTokenForm 创建API令牌表单
+type ApiTokenForm struct {
+	Name   string   `json:"name" binding:"required,max=64"`
+	Scopes []string `json:"scopes" binding:"required,min=1"`
+	// 有效天数, 为0时不过期
+	ExpiresDays int `json:"expires_days" binding:"min=0,max=3650"`
+}
+
+// Index 当前用户的API令牌及可选的权限范围
+func Index(c *gin.Context) {
+	tokens, err := new(models.ApiToken).List(user.Uid(c))
+	if err != nil {
+		base.RespondErrorWithDefaultMsg(c, err)
+		return
+	}
+
+	base.RespondSuccess(c, utils.SuccessContent, map[string]interface{}{
+		"tokens": tokens,
+		"scopes": models.ApiScopes,
+	})
+}
+
+// Store 创建API令牌, 令牌明文只在创建时返回
+func Store(c *gin.Context) {
+	var form ApiTokenForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		base.RespondError(c, i18n.T(c, "param_error"), err)
+		return
+	}
+	scopes := models.ValidScopes(form.Scopes)
+	if len(scopes) != len(form.Scopes) {
+		base.RespondError(c, i18n.T(c, "api_token_scope_invalid"))
+		return
+	}
+	token := &models.ApiToken{
+		UserId: user.Uid(c),
+		Name:   strings.TrimSpace(form.Name),
+		Scopes: strings.Join(scopes, ","),
+	}
+	if form.ExpiresDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, form.ExpiresDays)
+		token.ExpiresAt = &expiresAt
+	}
+	plain, err := token.Create()
+	if err != nil {
+		base.RespondErrorWithDefaultMsg(c, err)
+		return
+	}
+	audit.Record(c, models.AuditActionCreate, models.AuditResourceApiToken, token.Id, token.Name, nil, token)
+
+	base.RespondSuccess(c, i18n.T(c, "api_token_created"), map[string]interface{}{
+		"token": plain,
+		"item":  token,
+	})
+}
+
+// Remove 吊销当前用户的API令牌
+func Remove(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	token := new(models.ApiToken)
+	if err := token.Find(id, user.Uid(c)); err != nil || token.Id == 0 {
+		base.RespondError(c, i18n.T(c, "api_token_not_found"), err)
+		return
+	}
+	if err := token.Delete(id); err != nil {
+		base.RespondErrorWithDefaultMsg(c, err)
+		return
+	}
+	audit.Record(c, models.AuditActionDelete, models.AuditResourceApiToken, token.Id, token.Name, token, nil)
+
+	base.RespondSuccessWithDefaultMsg(c, nil)
+}
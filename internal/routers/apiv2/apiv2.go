@@ -0,0 +1,229 @@
+package apiv2
+
+// v2接口, 使用JSON请求体、HTTP状态码及游标分页, 路由与OpenAPI文档由同一份接口定义生成
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/i18n"
+	"github.com/tabortao/gocron/internal/routers/base"
+	"github.com/tabortao/gocron/internal/routers/host"
+	"github.com/tabortao/gocron/internal/routers/manage"
+	"github.com/tabortao/gocron/internal/routers/task"
+	"github.com/tabortao/gocron/internal/routers/user"
+)
+
+// Prefix v2接口的路由前缀
+const Prefix = "/api/v2"
+
+// 列表每页数量
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+// 接口定义
+type endpoint struct {
+	method  string
+	path    string
+	handler gin.HandlerFunc
+	tag     string
+	summary string
+	// 查询参数
+	query []param
+	// 请求体类型, 为nil时没有请求体, 请求体中的id由路径决定
+	body interface{}
+	// 响应data的类型, 列表接口为单条记录的类型
+	result interface{}
+	// 成功时的HTTP状态码
+	status int
+	// 是否为游标分页的列表
+	list bool
+}
+
+// 查询参数
+type param struct {
+	name        string
+	kind        string // integer, string
+	description string
+}
+
+// Page 游标分页的列表, NextCursor为空时没有下一页
+type Page struct {
+	Items      interface{} `json:"items"`
+	NextCursor string      `json:"next_cursor"`
+}
+
+// RunResult 手动运行任务的结果
+type RunResult struct {
+	LogId int64 `json:"log_id"`
+}
+
+// LogOutput 任务日志的输出, 运行中的Shell任务为实时输出
+type LogOutput struct {
+	Output string        `json:"output"`
+	Status models.Status `json:"status"`
+}
+
+// PingResult 测试主机连接的结果
+type PingResult struct {
+	Message string `json:"message"`
+}
+
+var pageParams = []param{
+	{"cursor", "string", "上一页返回的next_cursor"},
+	{"limit", "integer", "每页数量, 默认20, 最大100"},
+}
+
+var endpoints = []endpoint{
+	{method: http.MethodGet, path: "/tasks", handler: listTasks, tag: "tasks", summary: "任务列表", query: taskParams, result: models.Task{}, status: http.StatusOK, list: true},
+	{method: http.MethodPost, path: "/tasks", handler: createTask, tag: "tasks", summary: "创建任务", body: task.TaskForm{}, result: models.Task{}, status: http.StatusCreated},
+	{method: http.MethodGet, path: "/tasks/:id", handler: getTask, tag: "tasks", summary: "任务详情", result: models.Task{}, status: http.StatusOK},
+	{method: http.MethodPut, path: "/tasks/:id", handler: updateTask, tag: "tasks", summary: "修改任务", body: task.TaskForm{}, result: models.Task{}, status: http.StatusOK},
+	{method: http.MethodDelete, path: "/tasks/:id", handler: deleteTask, tag: "tasks", summary: "删除任务", status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/tasks/:id/run", handler: runTask, tag: "tasks", summary: "手动运行任务", result: RunResult{}, status: http.StatusAccepted},
+	{method: http.MethodPost, path: "/tasks/:id/enable", handler: enableTask, tag: "tasks", summary: "启用任务", result: models.Task{}, status: http.StatusOK},
+	{method: http.MethodPost, path: "/tasks/:id/disable", handler: disableTask, tag: "tasks", summary: "停用任务", result: models.Task{}, status: http.StatusOK},
+
+	{method: http.MethodGet, path: "/hosts", handler: listHosts, tag: "hosts", summary: "主机列表", query: hostParams, result: models.Host{}, status: http.StatusOK, list: true},
+	{method: http.MethodPost, path: "/hosts", handler: createHost, tag: "hosts", summary: "创建主机", body: host.HostForm{}, result: models.Host{}, status: http.StatusCreated},
+	{method: http.MethodGet, path: "/hosts/:id", handler: getHost, tag: "hosts", summary: "主机详情", result: models.Host{}, status: http.StatusOK},
+	{method: http.MethodPut, path: "/hosts/:id", handler: updateHost, tag: "hosts", summary: "修改主机", body: host.HostForm{}, result: models.Host{}, status: http.StatusOK},
+	{method: http.MethodDelete, path: "/hosts/:id", handler: deleteHost, tag: "hosts", summary: "删除主机", status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/hosts/:id/ping", handler: pingHost, tag: "hosts", summary: "测试主机连接", result: PingResult{}, status: http.StatusOK},
+
+	{method: http.MethodGet, path: "/logs", handler: listLogs, tag: "logs", summary: "任务日志列表", query: logParams, result: models.TaskLog{}, status: http.StatusOK, list: true},
+	{method: http.MethodGet, path: "/logs/:id", handler: getLog, tag: "logs", summary: "任务日志详情", result: models.TaskLog{}, status: http.StatusOK},
+	{method: http.MethodGet, path: "/logs/:id/output", handler: logOutput, tag: "logs", summary: "任务日志输出", result: LogOutput{}, status: http.StatusOK},
+	{method: http.MethodPost, path: "/logs/:id/stop", handler: stopLog, tag: "logs", summary: "停止运行中的任务", status: http.StatusAccepted},
+	{method: http.MethodDelete, path: "/logs/:id", handler: deleteLog, tag: "logs", summary: "删除任务日志", status: http.StatusNoContent},
+
+	{method: http.MethodGet, path: "/notification-channels", handler: listChannels, tag: "notification-channels", summary: "通知渠道列表", query: channelParams, result: models.NotifyChannel{}, status: http.StatusOK, list: true},
+	{method: http.MethodPost, path: "/notification-channels", handler: createChannel, tag: "notification-channels", summary: "创建通知渠道", body: manage.NotifyChannelForm{}, result: models.NotifyChannel{}, status: http.StatusCreated},
+	{method: http.MethodGet, path: "/notification-channels/:id", handler: getChannel, tag: "notification-channels", summary: "通知渠道详情", result: models.NotifyChannel{}, status: http.StatusOK},
+	{method: http.MethodPut, path: "/notification-channels/:id", handler: updateChannel, tag: "notification-channels", summary: "修改通知渠道", body: manage.NotifyChannelForm{}, result: models.NotifyChannel{}, status: http.StatusOK},
+	{method: http.MethodDelete, path: "/notification-channels/:id", handler: deleteChannel, tag: "notification-channels", summary: "删除通知渠道", status: http.StatusNoContent},
+
+	{method: http.MethodGet, path: "/users", handler: listUsers, tag: "users", summary: "用户列表", query: userParams, result: models.User{}, status: http.StatusOK, list: true},
+	{method: http.MethodPost, path: "/users", handler: createUser, tag: "users", summary: "创建用户", body: user.UserForm{}, result: models.User{}, status: http.StatusCreated},
+	{method: http.MethodGet, path: "/users/:id", handler: getUser, tag: "users", summary: "用户详情", result: models.User{}, status: http.StatusOK},
+	{method: http.MethodPut, path: "/users/:id", handler: updateUser, tag: "users", summary: "修改用户", body: user.UserForm{}, result: models.User{}, status: http.StatusOK},
+	{method: http.MethodDelete, path: "/users/:id", handler: deleteUser, tag: "users", summary: "删除用户", status: http.StatusNoContent},
+}
+
+// Register 注册v2接口, group为/api/v2路由组
+func Register(group *gin.RouterGroup, scopes map[string]string) {
+	spec := OpenAPI(scopes)
+	group.GET("/openapi.json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, spec)
+	})
+	for _, e := range endpoints {
+		group.Handle(e.method, e.path, e.handler)
+	}
+}
+
+// 路径中的资源id
+func idParam(c *gin.Context) (int, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return 0, base.NewError(http.StatusNotFound, i18n.T(c, "page_not_found"))
+	}
+
+	return id, nil
+}
+
+// 整数查询参数, 未传时返回默认值
+func intQuery(c *gin.Context, name string, value int) (int, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return value, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, base.NewError(http.StatusBadRequest, i18n.T(c, "param_error")+": "+name)
+	}
+
+	return value, nil
+}
+
+// 整数筛选条件, 参数名转为模型查询条件的名称, 如project_id转为ProjectId
+func queryParams(c *gin.Context, names []string) (models.CommonMap, error) {
+	params := models.CommonMap{}
+	for _, name := range names {
+		key := ""
+		for _, word := range strings.Split(name, "_") {
+			key += strings.ToUpper(word[:1]) + word[1:]
+		}
+		value, err := intQuery(c, name, 0)
+		if err != nil {
+			return nil, err
+		}
+		params[key] = value
+	}
+
+	return params, nil
+}
+
+// 解析游标及每页数量, 多查询一条用于判断是否还有下一页
+func parsePage(c *gin.Context, params models.CommonMap) (limit int, before int64, err error) {
+	limit, err = intQuery(c, "limit", defaultLimit)
+	if err != nil {
+		return 0, 0, err
+	}
+	if limit < 1 || limit > maxLimit {
+		return 0, 0, base.NewError(http.StatusBadRequest, i18n.T(c, "param_error")+": limit")
+	}
+	if cursor := c.Query("cursor"); cursor != "" {
+		before, err = decodeCursor(cursor)
+		if err != nil {
+			return 0, 0, base.NewError(http.StatusBadRequest, i18n.T(c, "invalid_cursor"))
+		}
+	}
+	params["Page"] = 1
+	params["PageSize"] = limit + 1
+
+	return limit, before, nil
+}
+
+// 返回一页数据, 以本页最后一条的id作为下一页的游标
+func respondPage[T any](c *gin.Context, items []T, limit int, id func(T) int64) {
+	page := Page{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		page.NextCursor = encodeCursor(id(items[limit-1]))
+	}
+
+	base.RespondStatus(c, http.StatusOK, page)
+}
+
+func encodeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	value, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseInt(string(value), 10, 64)
+	if err == nil && id <= 0 {
+		err = strconv.ErrRange
+	}
+
+	return id, err
+}
+
+// 绑定JSON请求体
+func bindJSON(c *gin.Context, body interface{}) bool {
+	if err := c.ShouldBindJSON(body); err != nil {
+		base.RespondValidationError(c, err)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,122 @@
+package apiv2
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/i18n"
+	"github.com/tabortao/gocron/internal/routers/base"
+	"github.com/tabortao/gocron/internal/routers/host"
+	"github.com/tabortao/gocron/internal/routers/user"
+)
+
+var hostParams = append([]param{
+	{"name", "string", "主机名"},
+	{"group", "string", "主机分组"},
+	{"project_id", "integer", "所属项目"},
+}, pageParams...)
+
+func listHosts(c *gin.Context) {
+	params, err := queryParams(c, []string{"project_id"})
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+	params["Name"] = strings.TrimSpace(c.Query("name"))
+	params["Group"] = strings.TrimSpace(c.Query("group"))
+	limit, before, err := parsePage(c, params)
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+	params["BeforeId"] = int(before)
+	user.FilterProjects(c, params)
+	hosts, err := new(models.Host).List(params)
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	respondPage(c, hosts, limit, func(h models.Host) int64 { return int64(h.Id) })
+}
+
+func getHost(c *gin.Context) {
+	id, err := idParam(c)
+	var detail models.Host
+	if err == nil {
+		detail, err = findHost(c, id)
+	}
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondStatus(c, http.StatusOK, detail)
+}
+
+func createHost(c *gin.Context) {
+	saveHost(c, 0, http.StatusCreated)
+}
+
+func updateHost(c *gin.Context) {
+	id, err := idParam(c)
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+	saveHost(c, id, http.StatusOK)
+}
+
+func saveHost(c *gin.Context, id int, status int) {
+	var form host.HostForm
+	if !bindJSON(c, &form) {
+		return
+	}
+	form.Id = id
+	saved, err := host.Save(c, form)
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondStatus(c, status, saved)
+}
+
+func deleteHost(c *gin.Context) {
+	id, err := idParam(c)
+	if err == nil {
+		err = host.Delete(c, id)
+	}
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondStatus(c, http.StatusNoContent, nil)
+}
+
+func pingHost(c *gin.Context) {
+	id, err := idParam(c)
+	var message string
+	if err == nil {
+		message, err = host.Check(c, id)
+	}
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondStatus(c, http.StatusOK, PingResult{Message: message})
+}
+
+// 查询主机, 不存在时返回404
+func findHost(c *gin.Context, id int) (models.Host, error) {
+	detail := models.Host{}
+	if err := detail.Find(id); err != nil || detail.Id == 0 {
+		return detail, base.NewError(http.StatusNotFound, i18n.T(c, "host_not_exist"))
+	}
+
+	return detail, nil
+}
@@ -0,0 +1,123 @@
+package apiv2
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/i18n"
+	"github.com/tabortao/gocron/internal/routers/audit"
+	"github.com/tabortao/gocron/internal/routers/base"
+	"github.com/tabortao/gocron/internal/routers/tasklog"
+	"github.com/tabortao/gocron/internal/routers/user"
+)
+
+var logParams = append([]param{
+	{"task_id", "integer", "任务ID"},
+	{"protocol", "integer", "执行方式 1:HTTP 2:Shell"},
+	{"status", "integer", "状态 0:失败 1:执行中 2:成功 3:取消"},
+}, pageParams...)
+
+func listLogs(c *gin.Context) {
+	params, err := queryParams(c, []string{"task_id", "protocol"})
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+	if params["Status"], err = intQuery(c, "status", -1); err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+	limit, before, err := parsePage(c, params)
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+	params["BeforeId"] = before
+	user.FilterProjects(c, params)
+	logs, err := new(models.TaskLog).List(params)
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	respondPage(c, logs, limit, func(l models.TaskLog) int64 { return l.Id })
+}
+
+func getLog(c *gin.Context) {
+	taskLog, err := findLog(c)
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondStatus(c, http.StatusOK, taskLog)
+}
+
+func logOutput(c *gin.Context) {
+	id, err := logIdParam(c)
+	var result LogOutput
+	if err == nil {
+		result.Output, result.Status, err = tasklog.Tail(c, id)
+	}
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondStatus(c, http.StatusOK, result)
+}
+
+func stopLog(c *gin.Context) {
+	taskLog, err := findLog(c)
+	if err == nil {
+		err = tasklog.Kill(c, taskLog.Id, taskLog.TaskId)
+	}
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondStatus(c, http.StatusAccepted, nil)
+}
+
+func deleteLog(c *gin.Context) {
+	taskLog, err := findLog(c)
+	if err == nil {
+		_, err = new(models.TaskLog).Delete(taskLog.Id)
+	}
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+	audit.Record(c, models.AuditActionDelete, models.AuditResourceTaskLog, int(taskLog.Id), taskLog.Name, nil, nil)
+
+	base.RespondStatus(c, http.StatusNoContent, nil)
+}
+
+func logIdParam(c *gin.Context) (int64, error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, base.NewError(http.StatusNotFound, i18n.T(c, "invalid_log_id"))
+	}
+
+	return id, nil
+}
+
+// 查询任务日志, 不存在时返回404
+func findLog(c *gin.Context) (models.TaskLog, error) {
+	id, err := logIdParam(c)
+	if err != nil {
+		return models.TaskLog{}, err
+	}
+	taskLog, err := new(models.TaskLog).Detail(id)
+	if err != nil {
+		return taskLog, err
+	}
+	if taskLog.Id == 0 {
+		return taskLog, base.NewError(http.StatusNotFound, i18n.T(c, "invalid_log_id"))
+	}
+
+	return taskLog, nil
+}
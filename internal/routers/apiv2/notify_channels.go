@@ -0,0 +1,95 @@
+package apiv2
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/i18n"
+	"github.com/tabortao/gocron/internal/routers/base"
+	"github.com/tabortao/gocron/internal/routers/manage"
+)
+
+var channelParams = append([]param{
+	{"type", "string", "渠道类型 mail, slack, webhook, serverchan3, bark"},
+	{"project_id", "integer", "所属项目"},
+}, pageParams...)
+
+func listChannels(c *gin.Context) {
+	params, err := queryParams(c, []string{"project_id"})
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+	params["Type"] = c.Query("type")
+	limit, before, err := parsePage(c, params)
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+	params["BeforeId"] = int(before)
+	channels, err := new(models.NotifyChannel).List(params)
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	respondPage(c, channels, limit, func(ch models.NotifyChannel) int64 { return int64(ch.Id) })
+}
+
+func getChannel(c *gin.Context) {
+	id, err := idParam(c)
+	channel := models.NotifyChannel{}
+	if err == nil {
+		err = channel.Find(id)
+	}
+	if err == nil && channel.Id == 0 {
+		err = base.NewError(http.StatusNotFound, i18n.T(c, "notify_channel_not_exist"))
+	}
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondStatus(c, http.StatusOK, channel)
+}
+
+func createChannel(c *gin.Context) {
+	saveChannel(c, 0, http.StatusCreated)
+}
+
+func updateChannel(c *gin.Context) {
+	id, err := idParam(c)
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+	saveChannel(c, id, http.StatusOK)
+}
+
+func saveChannel(c *gin.Context, id int, status int) {
+	var form manage.NotifyChannelForm
+	if !bindJSON(c, &form) {
+		return
+	}
+	channel, err := manage.SaveChannel(c, id, form)
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondStatus(c, status, channel)
+}
+
+func deleteChannel(c *gin.Context) {
+	id, err := idParam(c)
+	if err == nil {
+		err = manage.DeleteChannel(c, id)
+	}
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondStatus(c, http.StatusNoContent, nil)
+}
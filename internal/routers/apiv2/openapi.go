@@ -0,0 +1,290 @@
+package apiv2
+
+// 按接口定义及请求、响应结构体的json标签生成OpenAPI文档
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type object = map[string]interface{}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// OpenAPI 生成v2接口的OpenAPI文档, scopes为接口可使用API令牌访问时需要的权限范围
+// key为请求方法和完整路由, 未列出的接口只能使用登录令牌访问
+func OpenAPI(scopes map[string]string) object {
+	g := &generator{schemas: object{}}
+	paths := object{}
+	for _, e := range endpoints {
+		path := openAPIPath(e.path)
+		item, ok := paths[path].(object)
+		if !ok {
+			item = object{}
+			paths[path] = item
+		}
+		item[strings.ToLower(e.method)] = g.operation(e, scopes[e.method+" "+Prefix+e.path])
+	}
+	g.schemas["Error"] = envelope(object{"nullable": true})
+
+	return object{
+		"openapi": "3.0.3",
+		"info": object{
+			"title":       "gocron API",
+			"version":     "2.0",
+			"description": "响应内容为{code, message, data}, 失败时返回对应的HTTP状态码; 列表接口使用游标分页, 将next_cursor作为下一页的cursor参数, 为空时没有下一页",
+		},
+		"servers": []object{{"url": Prefix}},
+		"paths":   paths,
+		"components": object{
+			"schemas": g.schemas,
+			"securitySchemes": object{
+				"apiToken": object{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "个人API令牌, 需拥有接口的x-token-scope权限范围",
+				},
+				"sessionToken": object{
+					"type": "apiKey",
+					"in":   "header",
+					"name": "Auth-Token",
+				},
+			},
+		},
+	}
+}
+
+type generator struct {
+	schemas object
+}
+
+func (g *generator) operation(e endpoint, scope string) object {
+	op := object{
+		"tags":        []string{e.tag},
+		"summary":     e.summary,
+		"operationId": operationId(e),
+	}
+	parameters := make([]object, 0)
+	if strings.Contains(e.path, ":id") {
+		parameters = append(parameters, object{
+			"name": "id", "in": "path", "required": true,
+			"schema": object{"type": "integer", "format": "int64"},
+		})
+	}
+	for _, p := range e.query {
+		parameters = append(parameters, object{
+			"name": p.name, "in": "query", "description": p.description,
+			"schema": object{"type": p.kind},
+		})
+	}
+	if len(parameters) > 0 {
+		op["parameters"] = parameters
+	}
+	if e.body != nil {
+		op["requestBody"] = object{
+			"required": true,
+			"content":  object{"application/json": object{"schema": g.body(reflect.TypeOf(e.body))}},
+		}
+	}
+
+	success := object{"description": http.StatusText(e.status)}
+	if e.status != http.StatusNoContent {
+		data := object{"nullable": true}
+		if e.result != nil {
+			data = g.schema(reflect.TypeOf(e.result))
+		}
+		if e.list {
+			data = object{
+				"type": "object",
+				"properties": object{
+					"items":       object{"type": "array", "items": data},
+					"next_cursor": object{"type": "string"},
+				},
+			}
+		}
+		success["content"] = object{"application/json": object{"schema": envelope(data)}}
+	}
+	op["responses"] = object{
+		strconv.Itoa(e.status): success,
+		"default": object{
+			"description": "失败, code为错误码",
+			"content":     object{"application/json": object{"schema": object{"$ref": "#/components/schemas/Error"}}},
+		},
+	}
+
+	security := []object{{"sessionToken": []string{}}}
+	if scope != "" {
+		security = append([]object{{"apiToken": []string{}}}, security...)
+		op["x-token-scope"] = scope
+	}
+	op["security"] = security
+
+	return op
+}
+
+// 请求体的结构, id由路径决定, 不在请求体中列出
+func (g *generator) body(t reflect.Type) object {
+	schema := g.schema(t)
+	if component, ok := g.schemas[t.Name()].(object); ok {
+		delete(component["properties"].(object), "id")
+	}
+
+	return schema
+}
+
+// 类型对应的结构, 结构体保存到components中并返回引用
+func (g *generator) schema(t reflect.Type) object {
+	if t.Kind() == reflect.Ptr {
+		schema := g.schema(t.Elem())
+		if _, ok := schema["$ref"]; ok {
+			return object{"allOf": []object{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	}
+	if t == timeType {
+		return object{"type": "string", "format": "date-time"}
+	}
+	// 自定义序列化的时间类型
+	if t.Implements(marshalerType) {
+		return object{"type": "string"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return object{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return object{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return object{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return object{"type": "number"}
+	case reflect.String:
+		return object{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return object{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return object{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		ref := object{"$ref": "#/components/schemas/" + t.Name()}
+		if _, ok := g.schemas[t.Name()]; ok {
+			return ref
+		}
+		// 先占位, 避免结构体相互引用时死循环
+		g.schemas[t.Name()] = object{}
+		properties := object{}
+		required := make([]string, 0)
+		g.fields(t, properties, &required)
+		component := object{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			component["required"] = required
+		}
+		g.schemas[t.Name()] = component
+		return ref
+	default:
+		return object{}
+	}
+}
+
+// 结构体字段, 匿名嵌入的结构体字段展开到上层
+func (g *generator) fields(t reflect.Type, properties object, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			g.fields(field.Type, properties, required)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema := g.schema(field.Type)
+		if applyBinding(schema, field.Tag.Get("binding")) {
+			*required = append(*required, name)
+		}
+		properties[name] = schema
+	}
+}
+
+// 按表单验证规则补充取值范围, 返回是否必填
+func applyBinding(schema object, binding string) bool {
+	required := false
+	for _, rule := range strings.Split(binding, ",") {
+		name, value, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "email":
+			schema["format"] = "email"
+		case "url":
+			schema["format"] = "uri"
+		case "oneof":
+			enum := make([]interface{}, 0)
+			for _, item := range strings.Fields(value) {
+				if n, err := strconv.Atoi(item); err == nil && schema["type"] == "integer" {
+					enum = append(enum, n)
+				} else {
+					enum = append(enum, item)
+				}
+			}
+			schema["enum"] = enum
+		case "min", "max":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				continue
+			}
+			key := map[string]string{"min": "minimum", "max": "maximum"}[name]
+			if schema["type"] == "string" {
+				key = map[string]string{"min": "minLength", "max": "maxLength"}[name]
+			}
+			schema[key] = n
+		}
+	}
+
+	return required
+}
+
+// 统一的响应结构
+func envelope(data object) object {
+	return object{
+		"type": "object",
+		"properties": object{
+			"code":    object{"type": "integer"},
+			"message": object{"type": "string"},
+			"data":    data,
+		},
+	}
+}
+
+// gin路由参数转为OpenAPI路径参数, 如/tasks/:id转为/tasks/{id}
+func openAPIPath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") {
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+
+	return strings.Join(parts, "/")
+}
+
+// 接口标识, 使用处理函数名, 如listTasks
+func operationId(e endpoint) string {
+	name := runtime.FuncForPC(reflect.ValueOf(e.handler).Pointer()).Name()
+
+	return name[strings.LastIndex(name, ".")+1:]
+}
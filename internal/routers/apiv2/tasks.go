@@ -0,0 +1,166 @@
+package apiv2
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/i18n"
+	"github.com/tabortao/gocron/internal/routers/base"
+	"github.com/tabortao/gocron/internal/routers/task"
+	"github.com/tabortao/gocron/internal/routers/user"
+	"github.com/tabortao/gocron/internal/service"
+)
+
+var taskParams = append([]param{
+	{"name", "string", "任务名称, 模糊匹配"},
+	{"tag", "string", "标签"},
+	{"project_id", "integer", "所属项目"},
+	{"host_id", "integer", "运行的主机"},
+	{"protocol", "integer", "执行方式 1:HTTP 2:Shell"},
+	{"status", "integer", "状态 0:停用 1:启用"},
+	{"created_by", "integer", "创建人"},
+}, pageParams...)
+
+func listTasks(c *gin.Context) {
+	params, err := queryParams(c, []string{"project_id", "host_id", "protocol", "created_by"})
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+	params["Name"] = strings.TrimSpace(c.Query("name"))
+	params["Tag"] = strings.TrimSpace(c.Query("tag"))
+	if params["Status"], err = intQuery(c, "status", -1); err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+	limit, before, err := parsePage(c, params)
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+	params["BeforeId"] = int(before)
+	user.FilterProjects(c, params)
+	tasks, err := new(models.Task).List(params)
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+	for i, item := range tasks {
+		tasks[i].NextRunTime = models.NextRunTime(service.ServiceTask.NextRunTime(item))
+	}
+
+	respondPage(c, tasks, limit, func(t models.Task) int64 { return int64(t.Id) })
+}
+
+func getTask(c *gin.Context) {
+	id, err := idParam(c)
+	var detail models.Task
+	if err == nil {
+		detail, err = findTask(c, id)
+	}
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondStatus(c, http.StatusOK, detail)
+}
+
+func createTask(c *gin.Context) {
+	saveTask(c, 0, http.StatusCreated)
+}
+
+func updateTask(c *gin.Context) {
+	id, err := idParam(c)
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+	saveTask(c, id, http.StatusOK)
+}
+
+func saveTask(c *gin.Context, id int, status int) {
+	var form task.TaskForm
+	if !bindJSON(c, &form) {
+		return
+	}
+	form.Id = id
+	saved, err := task.Save(c, form)
+	if err == nil {
+		saved, err = findTask(c, saved.Id)
+	}
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondStatus(c, status, saved)
+}
+
+func deleteTask(c *gin.Context) {
+	id, err := idParam(c)
+	if err == nil {
+		err = task.Delete(c, id)
+	}
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondStatus(c, http.StatusNoContent, nil)
+}
+
+func runTask(c *gin.Context) {
+	id, err := idParam(c)
+	var logId int64
+	if err == nil {
+		logId, err = task.Start(c, id)
+	}
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondStatus(c, http.StatusAccepted, RunResult{LogId: logId})
+}
+
+func enableTask(c *gin.Context) {
+	setTaskStatus(c, models.Enabled)
+}
+
+func disableTask(c *gin.Context) {
+	setTaskStatus(c, models.Disabled)
+}
+
+func setTaskStatus(c *gin.Context, status models.Status) {
+	id, err := idParam(c)
+	if err == nil {
+		err = task.SetStatus(c, id, status)
+	}
+	var detail models.Task
+	if err == nil {
+		detail, err = findTask(c, id)
+	}
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondStatus(c, http.StatusOK, detail)
+}
+
+// 查询任务详情及下次执行时间, 不存在时返回404
+func findTask(c *gin.Context, id int) (models.Task, error) {
+	detail, err := new(models.Task).Detail(id)
+	if err != nil {
+		return detail, err
+	}
+	if detail.Id == 0 {
+		return detail, base.NewError(http.StatusNotFound, i18n.T(c, "get_task_detail_failed"))
+	}
+	detail.NextRunTime = models.NextRunTime(service.ServiceTask.NextRunTime(detail))
+
+	return detail, nil
+}
@@ -0,0 +1,94 @@
+package apiv2
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/i18n"
+	"github.com/tabortao/gocron/internal/routers/base"
+	"github.com/tabortao/gocron/internal/routers/user"
+)
+
+var userParams = append([]param{
+	{"name", "string", "用户名, 模糊匹配"},
+	{"status", "integer", "状态 0:禁用 1:启用"},
+}, pageParams...)
+
+func listUsers(c *gin.Context) {
+	params := models.CommonMap{"Name": strings.TrimSpace(c.Query("name"))}
+	var err error
+	if params["Status"], err = intQuery(c, "status", -1); err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+	limit, before, err := parsePage(c, params)
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+	params["BeforeId"] = int(before)
+	users, err := new(models.User).List(params)
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	respondPage(c, users, limit, func(u models.User) int64 { return int64(u.Id) })
+}
+
+func getUser(c *gin.Context) {
+	id, err := idParam(c)
+	detail := models.User{}
+	if err == nil && (detail.Find(id) != nil || detail.Id == 0) {
+		err = base.NewError(http.StatusNotFound, i18n.T(c, "user_not_exist"))
+	}
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondStatus(c, http.StatusOK, detail)
+}
+
+func createUser(c *gin.Context) {
+	saveUser(c, 0, http.StatusCreated)
+}
+
+func updateUser(c *gin.Context) {
+	id, err := idParam(c)
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+	saveUser(c, id, http.StatusOK)
+}
+
+func saveUser(c *gin.Context, id int, status int) {
+	var form user.UserForm
+	if !bindJSON(c, &form) {
+		return
+	}
+	form.Id = id
+	saved, err := user.Save(c, form)
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondStatus(c, status, saved)
+}
+
+func deleteUser(c *gin.Context) {
+	id, err := idParam(c)
+	if err == nil {
+		err = user.Delete(c, id)
+	}
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondStatus(c, http.StatusNoContent, nil)
+}
@@ -0,0 +1,203 @@
+package routers
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/app"
+	"github.com/tabortao/gocron/internal/modules/setting"
+	"github.com/tabortao/gocron/internal/modules/utils"
+	"github.com/tabortao/gocron/internal/routers/apiv2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+var update = flag.Bool("update", false, "更新docs/openapi.json")
+
+const openAPIFile = "../../docs/openapi.json"
+
+// 提交的OpenAPI文档须与接口定义一致, 修改接口后使用 go test ./internal/routers -update 重新生成
+func TestOpenAPISpec(t *testing.T) {
+	spec, err := json.MarshalIndent(apiv2.OpenAPI(tokenScopes), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec = append(spec, '\n')
+	if *update {
+		if err = os.WriteFile(openAPIFile, spec, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	committed, err := os.ReadFile(openAPIFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(committed, spec) {
+		t.Error("docs/openapi.json is out of date, run go test ./internal/routers -update")
+	}
+}
+
+type v2Response struct {
+	status int
+	Code   int             `json:"code"`
+	Data   json.RawMessage `json:"data"`
+}
+
+func TestApiV2(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{SingularTable: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.AutoMigrate(&models.User{}, &models.ApiToken{}, &models.Project{}, &models.ProjectMember{},
+		&models.Task{}, &models.TaskHost{}, &models.TaskLog{}, &models.TaskLogAttempt{}, &models.TaskLogHost{},
+		&models.Host{}, &models.Setting{}, &models.AuditLog{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldDb, oldSetting, oldInstalled := models.Db, app.Setting, app.Installed
+	models.Db, app.Installed = db, true
+	app.Setting = &setting.Setting{AuthSecret: "auth-secret"}
+	defer func() { models.Db, app.Setting, app.Installed = oldDb, oldSetting, oldInstalled }()
+
+	admin := models.User{Name: "admin", Email: "admin@example.com", Password: "password", IsAdmin: 1}
+	member := models.User{Name: "bob", Email: "bob@example.com", Password: "password"}
+	_, _ = admin.Create()
+	_, _ = member.Create()
+	_ = db.Create(&models.Project{Name: "default"}).Error
+	_ = db.Create(&models.Project{Name: "other"}).Error
+	_ = db.Create(&models.ProjectMember{ProjectId: 1, UserId: member.Id, Role: models.RoleViewer}).Error
+	adminToken, _ := (&models.ApiToken{UserId: admin.Id, Name: "admin", Scopes: strings.Join(models.ApiScopes, ",")}).Create()
+	memberToken, _ := (&models.ApiToken{UserId: member.Id, Name: "bob", Scopes: strings.Join(models.ApiScopes, ",")}).Create()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(userAuth, permissionAuth)
+	apiv2.Register(r.Group(apiv2.Prefix), tokenScopes)
+
+	request := func(method, target, token string, body interface{}) v2Response {
+		var reader *bytes.Reader
+		if body != nil {
+			data, _ := json.Marshal(body)
+			reader = bytes.NewReader(data)
+		} else {
+			reader = bytes.NewReader(nil)
+		}
+		req := httptest.NewRequest(method, apiv2.Prefix+target, reader)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		resp := v2Response{status: w.Code}
+		if w.Body.Len() > 0 {
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("%s %s: invalid json %q", method, target, w.Body.String())
+			}
+		}
+		return resp
+	}
+
+	// 创建主机, 返回201及创建的主机
+	hostIds := make([]int, 0)
+	for i := 1; i <= 3; i++ {
+		resp := request(http.MethodPost, "/hosts", adminToken, map[string]interface{}{
+			"name": "host" + strconv.Itoa(i), "alias": "host", "port": 5921,
+		})
+		var created models.Host
+		_ = json.Unmarshal(resp.Data, &created)
+		if resp.status != http.StatusCreated || created.Id == 0 || created.ProjectId != models.DefaultProjectId {
+			t.Fatalf("create host: status = %d, data = %s", resp.status, resp.Data)
+		}
+		hostIds = append(hostIds, created.Id)
+	}
+
+	// 游标分页, 按id倒序
+	var page struct {
+		Items      []models.Host `json:"items"`
+		NextCursor string        `json:"next_cursor"`
+	}
+	resp := request(http.MethodGet, "/hosts?limit=2", adminToken, nil)
+	_ = json.Unmarshal(resp.Data, &page)
+	if resp.status != http.StatusOK || len(page.Items) != 2 || page.Items[0].Id != hostIds[2] || page.NextCursor == "" {
+		t.Fatalf("first page: status = %d, data = %s", resp.status, resp.Data)
+	}
+	resp = request(http.MethodGet, "/hosts?limit=2&cursor="+page.NextCursor, adminToken, nil)
+	page.Items, page.NextCursor = nil, ""
+	_ = json.Unmarshal(resp.Data, &page)
+	if len(page.Items) != 1 || page.Items[0].Id != hostIds[0] || page.NextCursor != "" {
+		t.Fatalf("last page: data = %s", resp.Data)
+	}
+	resp = request(http.MethodGet, "/hosts?name=host2", adminToken, nil)
+	page.Items = nil
+	_ = json.Unmarshal(resp.Data, &page)
+	if len(page.Items) != 1 || page.Items[0].Name != "host2" {
+		t.Errorf("filter by name: data = %s", resp.Data)
+	}
+
+	// 任务日志按状态筛选
+	task := models.Task{Name: "backup", ProjectId: 1}
+	task.Id, _ = task.Create()
+	for _, status := range []models.Status{models.Finish, models.Cancel, models.Finish} {
+		_, _ = (&models.TaskLog{TaskId: task.Id, Name: task.Name, Status: status}).Create()
+	}
+	var logs struct {
+		Items []models.TaskLog `json:"items"`
+	}
+	resp = request(http.MethodGet, "/logs?status=3&task_id="+strconv.Itoa(task.Id), memberToken, nil)
+	_ = json.Unmarshal(resp.Data, &logs)
+	if resp.status != http.StatusOK || len(logs.Items) != 1 || logs.Items[0].Status != models.Cancel {
+		t.Errorf("filter logs by status: status = %d, data = %s", resp.status, resp.Data)
+	}
+
+	cases := []struct {
+		name, method, target, token string
+		body                        interface{}
+		status, code                int
+	}{
+		{"get host", http.MethodGet, "/hosts/" + strconv.Itoa(hostIds[0]), adminToken, nil, http.StatusOK, utils.ResponseSuccess},
+		{"host not found", http.MethodGet, "/hosts/999", adminToken, nil, http.StatusNotFound, utils.NotFound},
+		{"task not found", http.MethodGet, "/tasks/999", adminToken, nil, http.StatusNotFound, utils.NotFound},
+		{"duplicate hostname", http.MethodPost, "/hosts", adminToken, map[string]interface{}{"name": "host1", "alias": "dup", "port": 5921}, http.StatusConflict, utils.ResponseFailure},
+		{"validation failed", http.MethodPost, "/hosts", adminToken, map[string]interface{}{"name": "host9"}, http.StatusBadRequest, utils.ResponseFailure},
+		{"invalid cursor", http.MethodGet, "/hosts?cursor=%21", adminToken, nil, http.StatusBadRequest, utils.ResponseFailure},
+		{"invalid limit", http.MethodGet, "/hosts?limit=1000", adminToken, nil, http.StatusBadRequest, utils.ResponseFailure},
+		{"unauthenticated", http.MethodGet, "/hosts", "", nil, http.StatusUnauthorized, utils.AuthError},
+		{"viewer cannot edit", http.MethodDelete, "/hosts/" + strconv.Itoa(hostIds[0]), memberToken, nil, http.StatusForbidden, utils.UnauthorizedError},
+		{"admin only", http.MethodGet, "/users", memberToken, nil, http.StatusForbidden, utils.UnauthorizedError},
+		{"delete host", http.MethodDelete, "/hosts/" + strconv.Itoa(hostIds[0]), adminToken, nil, http.StatusNoContent, 0},
+		{"deleted host", http.MethodDelete, "/hosts/" + strconv.Itoa(hostIds[0]), adminToken, nil, http.StatusNotFound, utils.NotFound},
+		{"invalid channel", http.MethodPost, "/notification-channels", adminToken, map[string]interface{}{"type": "webhook", "name": "ops", "target": "not a url"}, http.StatusBadRequest, utils.ResponseFailure},
+		{"create channel", http.MethodPost, "/notification-channels", adminToken, map[string]interface{}{"type": "mail", "name": "ops", "target": "ops@example.com"}, http.StatusCreated, utils.ResponseSuccess},
+		{"create user", http.MethodPost, "/users", adminToken, map[string]interface{}{"name": "carol", "email": "carol@example.com", "password": "Passw0rd!", "confirm_password": "Passw0rd!"}, http.StatusCreated, utils.ResponseSuccess},
+		{"duplicate user", http.MethodPost, "/users", adminToken, map[string]interface{}{"name": "carol", "email": "c2@example.com", "password": "Passw0rd!", "confirm_password": "Passw0rd!"}, http.StatusConflict, utils.ResponseFailure},
+		{"openapi is public", http.MethodGet, "/openapi.json", "", nil, http.StatusOK, 0},
+	}
+	for _, tc := range cases {
+		resp := request(tc.method, tc.target, tc.token, tc.body)
+		if resp.status != tc.status || resp.Code != tc.code {
+			t.Errorf("%s: status = %d, code = %d, want %d, %d", tc.name, resp.status, resp.Code, tc.status, tc.code)
+		}
+	}
+
+	var channels struct {
+		Items []models.NotifyChannel `json:"items"`
+	}
+	resp = request(http.MethodGet, "/notification-channels?type=mail", adminToken, nil)
+	_ = json.Unmarshal(resp.Data, &channels)
+	if len(channels.Items) != 1 || channels.Items[0].Target != "ops@example.com" {
+		t.Errorf("list channels: data = %s", resp.Data)
+	}
+}
@@ -0,0 +1,72 @@
+package audit
+
+// 操作审计日志
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/logger"
+	"github.com/tabortao/gocron/internal/modules/utils"
+	"github.com/tabortao/gocron/internal/routers/base"
+	"github.com/tabortao/gocron/internal/routers/user"
+)
+
+// Record 记录当前用户的操作, before、after为操作前后的资源, 新增时before为nil, 删除时after为nil
+// 审计日志写入失败不影响操作结果
+func Record(c *gin.Context, action, resource string, resourceId int, resourceName string, before, after interface{}) {
+	auditLog := &models.AuditLog{
+		UserId:       user.Uid(c),
+		Username:     user.Username(c),
+		Ip:           c.ClientIP(),
+		Action:       action,
+		Resource:     resource,
+		ResourceId:   resourceId,
+		ResourceName: resourceName,
+		Diff:         models.AuditDiff(before, after),
+	}
+	if _, err := auditLog.Create(); err != nil {
+		logger.Error("写入审计日志失败", err)
+	}
+}
+
+// Index 审计日志列表
+func Index(c *gin.Context) {
+	auditLogModel := new(models.AuditLog)
+	params := parseQueryParams(c)
+	total, err := auditLogModel.Total(params)
+	if err != nil {
+		logger.Error(err)
+	}
+	logs, err := auditLogModel.List(params)
+	if err != nil {
+		logger.Error(err)
+	}
+
+	base.RespondSuccess(c, utils.SuccessContent, map[string]interface{}{
+		"total": total,
+		"data":  logs,
+	})
+}
+
+// 解析查询参数, 时间格式为 2006-01-02, 结束日期包含当天
+func parseQueryParams(c *gin.Context) models.CommonMap {
+	params := models.CommonMap{}
+	params["Username"] = strings.TrimSpace(c.Query("username"))
+	params["Action"] = strings.TrimSpace(c.Query("action"))
+	params["Resource"] = strings.TrimSpace(c.Query("resource"))
+	params["ResourceId"], _ = strconv.Atoi(c.Query("resource_id"))
+	params["Keyword"] = strings.TrimSpace(c.Query("keyword"))
+	if start, err := time.ParseInLocation(time.DateOnly, c.Query("start_date"), time.Local); err == nil {
+		params["StartTime"] = start
+	}
+	if end, err := time.ParseInLocation(time.DateOnly, c.Query("end_date"), time.Local); err == nil {
+		params["EndTime"] = end.AddDate(0, 0, 1)
+	}
+	base.ParsePageAndPageSize(c, params)
+
+	return params
+}
@@ -0,0 +1,32 @@
+package base
+
+// Error 接口处理失败的错误, Status为对应的HTTP状态码
+// 页面接口及v1接口始终返回200, 由响应中的code区分错误类型, v2接口返回实际的HTTP状态码
+type Error struct {
+	Status  int
+	Message string
+	// 需记录日志的内部错误
+	Err error
+}
+
+// NewError 创建接口错误, err为需记录日志的内部错误
+func NewError(status int, message string, err ...error) *Error {
+	e := &Error{Status: status, Message: message}
+	if len(err) > 0 {
+		e.Err = err[0]
+	}
+
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
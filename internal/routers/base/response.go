@@ -1,25 +1,41 @@
 package base
 
 import (
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tabortao/gocron/internal/modules/i18n"
 	"github.com/tabortao/gocron/internal/modules/logger"
 	"github.com/tabortao/gocron/internal/modules/utils"
 )
 
+// 使用HTTP状态码的版本化接口
+const versionedPrefix = "/api/v2/"
+
 // RespondSuccess 返回成功响应
 func RespondSuccess(c *gin.Context, message string, data interface{}) {
 	json := utils.JsonResponse{}
 	result := json.Success(message, data)
-	c.String(http.StatusOK, result)
+	write(c, utils.ResponseSuccess, result)
 }
 
 // RespondSuccessWithDefaultMsg 返回成功响应（使用默认消息）
 func RespondSuccessWithDefaultMsg(c *gin.Context, data interface{}) {
 	json := utils.JsonResponse{}
 	result := json.Success(utils.SuccessContent, data)
-	c.String(http.StatusOK, result)
+	write(c, utils.ResponseSuccess, result)
+}
+
+// RespondStatus 返回成功响应及HTTP状态码, 用于v2接口的创建、异步执行等, 204时不返回内容
+func RespondStatus(c *gin.Context, status int, data interface{}) {
+	if status == http.StatusNoContent {
+		c.Status(status)
+		return
+	}
+	json := utils.JsonResponse{}
+	c.Data(status, "application/json; charset=utf-8", []byte(json.Success(utils.SuccessContent, data)))
 }
 
 // RespondError 返回错误响应
@@ -29,7 +45,7 @@ func RespondError(c *gin.Context, message string, err ...error) {
 		logger.Error(err[0])
 	}
 	result := json.CommonFailure(message)
-	c.String(http.StatusOK, result)
+	write(c, utils.ResponseFailure, result)
 }
 
 // RespondErrorWithDefaultMsg 返回错误响应（使用默认消息）
@@ -39,19 +55,108 @@ func RespondErrorWithDefaultMsg(c *gin.Context, err ...error) {
 		logger.Error(err[0])
 	}
 	result := json.CommonFailure(utils.FailureContent)
-	c.String(http.StatusOK, result)
+	write(c, utils.ResponseFailure, result)
 }
 
 // RespondValidationError 返回表单验证错误响应
 func RespondValidationError(c *gin.Context, err error) {
 	json := utils.JsonResponse{}
 	result := json.CommonFailure(utils.FailureContent, err)
-	c.String(http.StatusOK, result)
+	write(c, utils.ResponseFailure, result)
 }
 
 // RespondAuthError 返回认证错误响应
 func RespondAuthError(c *gin.Context, message string) {
 	json := utils.JsonResponse{}
 	result := json.Failure(utils.AuthError, message)
+	write(c, utils.AuthError, result)
+}
+
+// RespondUnauthorized 返回无权限响应
+func RespondUnauthorized(c *gin.Context) {
+	json := utils.JsonResponse{}
+	result := json.Failure(utils.UnauthorizedError, i18n.T(c, "unauthorized"))
+	write(c, utils.UnauthorizedError, result)
+}
+
+// RespondCode 返回指定响应码的失败响应
+func RespondCode(c *gin.Context, code int, message string) {
+	json := utils.JsonResponse{}
+	write(c, code, json.Failure(code, message))
+}
+
+// RespondFailure 按错误返回失败响应, 不是*Error的错误作为服务端错误处理
+func RespondFailure(c *gin.Context, err error) {
+	var e *Error
+	if !errors.As(err, &e) {
+		e = NewError(http.StatusInternalServerError, utils.FailureContent, err)
+	}
+	if e.Err != nil {
+		logger.Error(e.Err)
+	}
+	code := ErrorCode(e.Status)
+	// 页面接口及v1接口保持原有的响应码
+	if !Versioned(c) && code != utils.AuthError && code != utils.UnauthorizedError {
+		code = utils.ResponseFailure
+	}
+	json := utils.JsonResponse{}
+	result := json.Failure(code, e.Message)
+	if Versioned(c) {
+		c.Data(e.Status, "application/json; charset=utf-8", []byte(result))
+		return
+	}
+	write(c, code, result)
+}
+
+// Versioned 是否为返回HTTP状态码的v2接口
+func Versioned(c *gin.Context) bool {
+	return strings.HasPrefix(c.Request.URL.Path, versionedPrefix)
+}
+
+// HttpStatus 响应码对应的HTTP状态码, 页面接口及v1接口始终返回200
+func HttpStatus(c *gin.Context, code int) int {
+	if !Versioned(c) {
+		return http.StatusOK
+	}
+	switch code {
+	case utils.ResponseSuccess:
+		return http.StatusOK
+	case utils.ResponseFailure:
+		return http.StatusBadRequest
+	case utils.AuthError:
+		return http.StatusUnauthorized
+	case utils.UnauthorizedError:
+		return http.StatusForbidden
+	case utils.NotFound:
+		return http.StatusNotFound
+	case utils.AppNotInstall:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ErrorCode HTTP状态码对应的响应码
+func ErrorCode(status int) int {
+	switch {
+	case status == http.StatusUnauthorized:
+		return utils.AuthError
+	case status == http.StatusForbidden:
+		return utils.UnauthorizedError
+	case status == http.StatusNotFound:
+		return utils.NotFound
+	case status >= http.StatusInternalServerError:
+		return utils.ServerError
+	default:
+		return utils.ResponseFailure
+	}
+}
+
+// 写入响应, v2接口使用JSON内容类型及对应的HTTP状态码
+func write(c *gin.Context, code int, result string) {
+	if Versioned(c) {
+		c.Data(HttpStatus(c, code), "application/json; charset=utf-8", []byte(result))
+		return
+	}
 	c.String(http.StatusOK, result)
 }
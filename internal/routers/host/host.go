@@ -1,8 +1,10 @@
 package host
 
 import (
+	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"strconv"
 	"strings"
 
@@ -14,7 +16,9 @@ import (
 	"github.com/tabortao/gocron/internal/modules/rpc/grpcpool"
 	rpc "github.com/tabortao/gocron/internal/modules/rpc/proto"
 	"github.com/tabortao/gocron/internal/modules/utils"
+	"github.com/tabortao/gocron/internal/routers/audit"
 	"github.com/tabortao/gocron/internal/routers/base"
+	"github.com/tabortao/gocron/internal/routers/user"
 	"github.com/tabortao/gocron/internal/service"
 )
 
@@ -44,7 +48,10 @@ func Index(c *gin.Context) {
 func All(c *gin.Context) {
 	hostModel := new(models.Host)
 	hostModel.PageSize = -1
-	hosts, err := hostModel.List(models.CommonMap{})
+	params := models.CommonMap{}
+	params["ProjectId"], _ = strconv.Atoi(c.Query("project_id"))
+	user.FilterProjects(c, params)
+	hosts, err := hostModel.List(params)
 	if err != nil {
 		logger.Error(err)
 	}
@@ -66,11 +73,16 @@ func Detail(c *gin.Context) {
 }
 
 type HostForm struct {
-	Id     int    `form:"id" json:"id"`
-	Name   string `form:"name" json:"name" binding:"required,max=64"`
-	Alias  string `form:"alias" json:"alias" binding:"required,max=32"`
-	Port   int    `form:"port" json:"port" binding:"required,min=1,max=65535"`
-	Remark string `form:"remark" json:"remark"`
+	Id        int    `form:"id" json:"id"`
+	ProjectId int    `form:"project_id" json:"project_id"`
+	Name      string `form:"name" json:"name" binding:"required,max=64"`
+	Alias     string `form:"alias" json:"alias" binding:"required,max=32"`
+	Port      int    `form:"port" json:"port" binding:"required,min=1,max=65535"`
+	Remark    string `form:"remark" json:"remark"`
+	Group     string `form:"group" json:"group" binding:"max=64"`
+	Labels    string `form:"labels" json:"labels" binding:"max=512"`
+	// 节点主动连接服务端
+	Reverse bool `form:"reverse" json:"reverse"`
 }
 
 // Store 保存、修改主机信息
@@ -80,31 +92,73 @@ func Store(c *gin.Context) {
 		base.RespondValidationError(c, err)
 		return
 	}
+	if _, err := Save(c, form); err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondSuccess(c, i18n.T(c, "save_success"), nil)
+}
+
+// Save 校验并保存主机, form.Id为0时新增, 返回保存后的主机
+func Save(c *gin.Context, form HostForm) (models.Host, error) {
+	// 未指定项目时归属默认项目, 兼容旧版本的调用
+	if form.ProjectId <= 0 {
+		form.ProjectId = models.DefaultProjectId
+	}
+	if !user.Can(c, form.ProjectId, models.ActionEdit) {
+		return models.Host{}, base.NewError(http.StatusForbidden, i18n.T(c, "unauthorized"))
+	}
+	projectModel := new(models.Project)
+	if err := projectModel.Find(form.ProjectId); err != nil || projectModel.Id == 0 {
+		return models.Host{}, base.NewError(http.StatusBadRequest, i18n.T(c, "project_not_exist"), err)
+	}
 
 	hostModel := new(models.Host)
 	id := form.Id
 	nameExist, err := hostModel.NameExists(form.Name, form.Id)
 	if err != nil {
-		base.RespondError(c, i18n.T(c, "operation_failed"), err)
-		return
+		return models.Host{}, base.NewError(http.StatusInternalServerError, i18n.T(c, "operation_failed"), err)
 	}
 	if nameExist {
-		base.RespondError(c, i18n.T(c, "hostname_exists"))
-		return
+		return models.Host{}, base.NewError(http.StatusConflict, i18n.T(c, "hostname_exists"))
 	}
 
 	hostModel.Name = strings.TrimSpace(form.Name)
+	hostModel.ProjectId = form.ProjectId
 	hostModel.Alias = strings.TrimSpace(form.Alias)
 	hostModel.Port = form.Port
 	hostModel.Remark = strings.TrimSpace(form.Remark)
+	hostModel.Group = strings.TrimSpace(form.Group)
+	hostModel.Reverse = form.Reverse
+	if !models.ValidHostGroup(hostModel.Group) {
+		return models.Host{}, base.NewError(http.StatusBadRequest, i18n.T(c, "host_group_invalid"))
+	}
+	hostModel.Labels, err = models.NormalizeLabels(form.Labels)
+	if err != nil {
+		return models.Host{}, base.NewError(http.StatusBadRequest, i18n.T(c, "host_labels_invalid"))
+	}
 	isCreate := false
 	oldHostModel := new(models.Host)
 
 	if id > 0 {
-		err = oldHostModel.Find(int(id))
-		if err != nil {
-			base.RespondError(c, i18n.T(c, "host_not_exist"))
-			return
+		err = oldHostModel.Find(id)
+		if err != nil || oldHostModel.Id == 0 {
+			return models.Host{}, base.NewError(http.StatusNotFound, i18n.T(c, "host_not_exist"))
+		}
+		// 移动到其他项目时需同时拥有原项目的编辑权限
+		if !user.Can(c, oldHostModel.ProjectId, models.ActionEdit) {
+			return models.Host{}, base.NewError(http.StatusForbidden, i18n.T(c, "unauthorized"))
+		}
+		// 仍被原项目任务直接关联的主机不能移动到其他项目
+		if oldHostModel.ProjectId != hostModel.ProjectId {
+			used, err := models.HostUsedOutsideProject(id, hostModel.ProjectId)
+			if err != nil {
+				return models.Host{}, err
+			}
+			if used {
+				return models.Host{}, base.NewError(http.StatusBadRequest, i18n.T(c, "host_used_by_other_project"))
+			}
 		}
 		_, err = hostModel.UpdateBean(id)
 	} else {
@@ -112,8 +166,7 @@ func Store(c *gin.Context) {
 		id, err = hostModel.Create()
 	}
 	if err != nil {
-		base.RespondError(c, i18n.T(c, "save_failed"), err)
-		return
+		return models.Host{}, base.NewError(http.StatusInternalServerError, i18n.T(c, "save_failed"), err)
 	}
 
 	if !isCreate {
@@ -126,13 +179,56 @@ func Store(c *gin.Context) {
 		taskModel := new(models.Task)
 		tasks, err := taskModel.ActiveListByHostId(id)
 		if err != nil {
-			base.RespondError(c, i18n.T(c, "refresh_task_host_failed"), err)
-			return
+			return models.Host{}, base.NewError(http.StatusInternalServerError, i18n.T(c, "refresh_task_host_failed"), err)
 		}
 		service.ServiceTask.BatchAdd(tasks)
 	}
 
-	base.RespondSuccess(c, i18n.T(c, "save_success"), nil)
+	after := new(models.Host)
+	_ = after.Find(id)
+	if isCreate {
+		audit.Record(c, models.AuditActionCreate, models.AuditResourceHost, id, after.Name, nil, after)
+	} else {
+		audit.Record(c, models.AuditActionUpdate, models.AuditResourceHost, id, after.Name, oldHostModel, after)
+	}
+
+	return *after, nil
+}
+
+// Groups 所有主机分组
+func Groups(c *gin.Context) {
+	hostModel := new(models.Host)
+	params := models.CommonMap{}
+	params["ProjectId"], _ = strconv.Atoi(c.Query("project_id"))
+	user.FilterProjects(c, params)
+	groups, err := hostModel.Groups(params)
+	if err != nil {
+		base.RespondErrorWithDefaultMsg(c, err)
+		return
+	}
+
+	base.RespondSuccess(c, utils.SuccessContent, groups)
+}
+
+// Match 预览主机分组和标签选择器匹配的主机
+func Match(c *gin.Context) {
+	group := strings.TrimSpace(c.Query("group"))
+	selector := strings.TrimSpace(c.Query("selector"))
+	if group == "" && selector == "" {
+		base.RespondSuccess(c, utils.SuccessContent, []models.Host{})
+		return
+	}
+	hostModel := new(models.Host)
+	params := models.CommonMap{}
+	params["ProjectId"], _ = strconv.Atoi(c.Query("project_id"))
+	user.FilterProjects(c, params)
+	hosts, err := hostModel.MatchTarget(group, selector, params)
+	if err != nil {
+		base.RespondError(c, i18n.T(c, "host_selector_invalid"))
+		return
+	}
+
+	base.RespondSuccess(c, utils.SuccessContent, hosts)
 }
 
 // Remove 删除主机
@@ -142,32 +238,91 @@ func Remove(c *gin.Context) {
 		base.RespondError(c, i18n.T(c, "param_error"), err)
 		return
 	}
+	if err = Delete(c, id); err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondSuccess(c, i18n.T(c, "operation_success"), nil)
+}
+
+// Delete 删除未被任务使用的主机
+func Delete(c *gin.Context, id int) error {
 	taskHostModel := new(models.TaskHost)
 	exist, err := taskHostModel.HostIdExist(id)
 	if err != nil {
-		base.RespondError(c, i18n.T(c, "operation_failed"), err)
-		return
+		return base.NewError(http.StatusInternalServerError, i18n.T(c, "operation_failed"), err)
 	}
 	if exist {
-		base.RespondError(c, i18n.T(c, "host_in_use_cannot_delete"))
-		return
+		return base.NewError(http.StatusConflict, i18n.T(c, "host_in_use_cannot_delete"))
 	}
 
 	hostModel := new(models.Host)
-	err = hostModel.Find(int(id))
+	err = hostModel.Find(id)
+	if err != nil || hostModel.Id == 0 {
+		return base.NewError(http.StatusNotFound, i18n.T(c, "host_not_exist"))
+	}
+
+	_, err = hostModel.Delete(id)
+	if err != nil {
+		return base.NewError(http.StatusInternalServerError, i18n.T(c, "operation_failed"), err)
+	}
+
+	addr := fmt.Sprintf("%s:%d", hostModel.Name, hostModel.Port)
+	grpcpool.Pool.Release(addr)
+	audit.Record(c, models.AuditActionDelete, models.AuditResourceHost, id, hostModel.Name, hostModel, nil)
+
+	return nil
+}
+
+// RotateToken 重新生成节点令牌并下发到节点, 节点更新后旧令牌失效, 新令牌只返回一次
+func RotateToken(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
+		base.RespondError(c, i18n.T(c, "param_error"), err)
+		return
+	}
+	hostModel := new(models.Host)
+	if err = hostModel.Find(id); err != nil {
 		base.RespondError(c, i18n.T(c, "host_not_exist"))
 		return
 	}
+	token, err := service.RotateNodeToken(*hostModel)
+	if err != nil {
+		base.RespondError(c, i18n.T(c, "node_token_push_failed"), err)
+		return
+	}
+	after := new(models.Host)
+	_ = after.Find(id)
+	audit.Record(c, models.AuditActionUpdate, models.AuditResourceHost, id, hostModel.Name, hostModel, after)
 
-	_, err = hostModel.Delete(id)
+	base.RespondSuccess(c, i18n.T(c, "operation_success"), map[string]string{"token": token})
+}
+
+// RevokeToken 吊销节点令牌并通知节点, 节点在重新注册前拒绝服务端的请求
+func RevokeToken(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
+		base.RespondError(c, i18n.T(c, "param_error"), err)
+		return
+	}
+	hostModel := new(models.Host)
+	if err = hostModel.Find(id); err != nil {
+		base.RespondError(c, i18n.T(c, "host_not_exist"))
+		return
+	}
+	err = service.RevokeNodeToken(*hostModel)
+	if err != nil && !errors.Is(err, service.ErrNodeTokenNotPushed) {
 		base.RespondError(c, i18n.T(c, "operation_failed"), err)
 		return
 	}
-
-	addr := fmt.Sprintf("%s:%d", hostModel.Name, hostModel.Port)
-	grpcpool.Pool.Release(addr)
+	after := new(models.Host)
+	_ = after.Find(id)
+	audit.Record(c, models.AuditActionUpdate, models.AuditResourceHost, id, hostModel.Name, hostModel, after)
+	if err != nil {
+		base.RespondError(c, i18n.T(c, "node_token_revoke_not_pushed"), err)
+		return
+	}
 
 	base.RespondSuccess(c, i18n.T(c, "operation_success"), nil)
 }
@@ -175,11 +330,21 @@ func Remove(c *gin.Context) {
 // Ping 测试主机是否可连接
 func Ping(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
+	message, err := Check(c, id)
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondSuccess(c, message, nil)
+}
+
+// Check 测试主机是否可连接, 返回提示信息, 连接失败时返回502错误
+func Check(c *gin.Context, id int) (string, error) {
 	hostModel := new(models.Host)
 	err := hostModel.Find(id)
 	if err != nil || hostModel.Id <= 0 {
-		base.RespondError(c, i18n.T(c, "host_not_exist"), err)
-		return
+		return "", base.NewError(http.StatusNotFound, i18n.T(c, "host_not_exist"), err)
 	}
 
 	taskReq := &rpc.TaskRequest{}
@@ -197,11 +362,9 @@ func Ping(c *gin.Context) {
 				grpcpool.Pool.Release(fmt.Sprintf("%s:%d", oldName, hostModel.Port))
 
 				if i18n.GetLocale(c) == i18n.EnUS {
-					base.RespondSuccess(c, "Connection successful (auto-fixed host to 127.0.0.1)", nil)
-				} else {
-					base.RespondSuccess(c, "连接成功（已自动将主机名修正为 127.0.0.1）", nil)
+					return "Connection successful (auto-fixed host to 127.0.0.1)", nil
 				}
-				return
+				return "连接成功（已自动将主机名修正为 127.0.0.1）", nil
 			}
 		}
 		msg := i18n.T(c, "connection_failed") + "-" + err.Error() + " " + output
@@ -220,10 +383,10 @@ func Ping(c *gin.Context) {
 			}
 		}
 		logger.Error(msg)
-		base.RespondError(c, msg, err)
-		return
+		return "", base.NewError(http.StatusBadGateway, msg, err)
 	}
-	base.RespondSuccess(c, i18n.T(c, "connection_success"), nil)
+
+	return i18n.T(c, "connection_success"), nil
 }
 
 // 解析查询参数
@@ -232,6 +395,9 @@ func parseQueryParams(c *gin.Context) models.CommonMap {
 	id, _ := strconv.Atoi(c.Query("id"))
 	params["Id"] = id
 	params["Name"] = strings.TrimSpace(c.Query("name"))
+	params["Group"] = strings.TrimSpace(c.Query("group"))
+	params["ProjectId"], _ = strconv.Atoi(c.Query("project_id"))
+	user.FilterProjects(c, params)
 	base.ParsePageAndPageSize(c, params)
 
 	return params
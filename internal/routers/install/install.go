@@ -95,8 +95,8 @@ func Store(c *gin.Context) {
 
 	// 标记为已安装
 	app.Installed = true
-	// 初始化定时任务
-	service.ServiceTask.Initialize()
+	// 初始化定时任务及后台服务
+	service.StartBackground()
 
 	base.RespondSuccess(c, "安装成功", nil)
 }
@@ -126,6 +126,37 @@ func writeConfig(form InstallForm) error {
 		"api.secret", "",
 		"enable_tls", "false",
 		"concurrency.queue", "500",
+		"host.health.interval", "30",
+		"agent.listen", "",
+		"ca.enable", "true",
+		"rpc.pool.check_interval", "30",
+		"rpc.pool.idle_timeout", "600",
+		"rpc.pool.max_age", "3600",
+		"oidc.enable", "false",
+		"oidc.issuer", "",
+		"oidc.client_id", "",
+		"oidc.client_secret", "",
+		"oidc.redirect_url", "",
+		"oidc.scopes", "openid profile email",
+		"oidc.username_claim", "preferred_username",
+		"oidc.groups_claim", "groups",
+		"oidc.admin_groups", "",
+		"oidc.group_roles", "",
+		"ldap.enable", "false",
+		"ldap.url", "",
+		"ldap.start_tls", "false",
+		"ldap.insecure_skip_verify", "false",
+		"ldap.ca_file", "",
+		"ldap.bind_dn", "",
+		"ldap.bind_password", "",
+		"ldap.base_dn", "",
+		"ldap.user_filter", "(uid=%s)",
+		"ldap.username_attribute", "uid",
+		"ldap.email_attribute", "mail",
+		"ldap.group_base_dn", "",
+		"ldap.group_filter", "",
+		"ldap.group_attribute", "cn",
+		"ldap.admin_groups", "",
 		"auth_secret", utils.RandAuthToken(),
 		"ca_file", "",
 		"cert_file", "",
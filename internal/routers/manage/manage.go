@@ -7,8 +7,13 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/app"
+	"github.com/tabortao/gocron/internal/modules/i18n"
 	"github.com/tabortao/gocron/internal/modules/logger"
+	"github.com/tabortao/gocron/internal/modules/rpc/grpcpool"
+	"github.com/tabortao/gocron/internal/modules/rpc/reverse"
 	"github.com/tabortao/gocron/internal/modules/utils"
+	"github.com/tabortao/gocron/internal/routers/audit"
 	"github.com/tabortao/gocron/internal/routers/base"
 	"github.com/tabortao/gocron/internal/service"
 )
@@ -33,10 +38,13 @@ func UpdateSlack(c *gin.Context) {
 	}
 
 	settingModel := new(models.Setting)
+	before, _ := settingModel.Slack()
 	err := settingModel.UpdateSlack(form.Url, form.Template)
 	if err != nil {
 		base.RespondErrorWithDefaultMsg(c, err)
 	} else {
+		after, _ := settingModel.Slack()
+		recordSetting(c, models.AuditActionUpdate, 0, models.SlackCode, before, after)
 		base.RespondSuccessWithDefaultMsg(c, nil)
 	}
 }
@@ -49,7 +57,11 @@ func CreateSlackChannel(c *gin.Context) {
 		return
 	}
 
+	if !projectExists(c, form.ProjectId) {
+		return
+	}
 	settingModel := new(models.Setting)
+	settingModel.ProjectId = form.ProjectId
 	if settingModel.IsChannelExist(form.Channel) {
 		base.RespondError(c, "Channel已存在")
 	} else {
@@ -57,6 +69,7 @@ func CreateSlackChannel(c *gin.Context) {
 		if err != nil {
 			base.RespondErrorWithDefaultMsg(c, err)
 		} else {
+			recordSetting(c, models.AuditActionCreate, settingModel.Id, models.SlackCode, nil, settingModel)
 			base.RespondSuccessWithDefaultMsg(c, nil)
 		}
 	}
@@ -65,10 +78,12 @@ func CreateSlackChannel(c *gin.Context) {
 func RemoveSlackChannel(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
 	settingModel := new(models.Setting)
+	_ = settingModel.Find(id)
 	_, err := settingModel.RemoveChannel(id)
 	if err != nil {
 		base.RespondErrorWithDefaultMsg(c, err)
 	} else {
+		recordSetting(c, models.AuditActionDelete, id, models.SlackCode, settingModel, nil)
 		base.RespondSuccessWithDefaultMsg(c, nil)
 	}
 }
@@ -97,8 +112,9 @@ type MailServerForm struct {
 
 // CreateMailUserForm 创建邮件用户表单
 type CreateMailUserForm struct {
-	Username string `form:"username" json:"username" binding:"required,max=50"`
-	Email    string `form:"email" json:"email" binding:"required,email,max=100"`
+	Username  string `form:"username" json:"username" binding:"required,max=50"`
+	Email     string `form:"email" json:"email" binding:"required,email,max=100"`
+	ProjectId int    `form:"project_id" json:"project_id" binding:"min=0"` // 为0时所有项目共享
 }
 
 // UpdateSlackForm 更新Slack配置表单
@@ -114,8 +130,9 @@ type UpdateWebHookForm struct {
 
 // CreateWebhookUrlForm 创建Webhook地址表单
 type CreateWebhookUrlForm struct {
-	Name string `form:"name" json:"name" binding:"required,max=50"`
-	Url  string `form:"url" json:"url" binding:"required,url,max=200"`
+	Name      string `form:"name" json:"name" binding:"required,max=50"`
+	Url       string `form:"url" json:"url" binding:"required,url,max=200"`
+	ProjectId int    `form:"project_id" json:"project_id" binding:"min=0"` // 为0时所有项目共享
 }
 
 type UpdateServerChan3Form struct {
@@ -124,8 +141,9 @@ type UpdateServerChan3Form struct {
 }
 
 type CreateServerChan3UrlForm struct {
-	Name string `form:"name" json:"name" binding:"required,max=50"`
-	Url  string `form:"url" json:"url" binding:"required,url,max=200"`
+	Name      string `form:"name" json:"name" binding:"required,max=50"`
+	Url       string `form:"url" json:"url" binding:"required,url,max=200"`
+	ProjectId int    `form:"project_id" json:"project_id" binding:"min=0"` // 为0时所有项目共享
 }
 
 type UpdateBarkForm struct {
@@ -134,13 +152,15 @@ type UpdateBarkForm struct {
 }
 
 type CreateBarkUrlForm struct {
-	Name string `form:"name" json:"name" binding:"required,max=50"`
-	Url  string `form:"url" json:"url" binding:"required,url,max=200"`
+	Name      string `form:"name" json:"name" binding:"required,max=50"`
+	Url       string `form:"url" json:"url" binding:"required,url,max=200"`
+	ProjectId int    `form:"project_id" json:"project_id" binding:"min=0"` // 为0时所有项目共享
 }
 
 // CreateSlackChannelForm 创建Slack频道表单
 type CreateSlackChannelForm struct {
-	Channel string `form:"channel" json:"channel" binding:"required,max=50"`
+	Channel   string `form:"channel" json:"channel" binding:"required,max=50"`
+	ProjectId int    `form:"project_id" json:"project_id" binding:"min=0"` // 为0时所有项目共享
 }
 
 func UpdateMail(c *gin.Context) {
@@ -182,10 +202,13 @@ func UpdateMail(c *gin.Context) {
 	jsonByte, _ := json.Marshal(serverConfig)
 
 	settingModel := new(models.Setting)
+	before, _ := settingModel.Mail()
 	err := settingModel.UpdateMail(string(jsonByte), template)
 	if err != nil {
 		base.RespondErrorWithDefaultMsg(c, err)
 	} else {
+		after, _ := settingModel.Mail()
+		recordSetting(c, models.AuditActionUpdate, 0, models.MailCode, before, after)
 		base.RespondSuccessWithDefaultMsg(c, nil)
 	}
 }
@@ -198,11 +221,16 @@ func CreateMailUser(c *gin.Context) {
 		return
 	}
 
+	if !projectExists(c, form.ProjectId) {
+		return
+	}
 	settingModel := new(models.Setting)
+	settingModel.ProjectId = form.ProjectId
 	_, err := settingModel.CreateMailUser(form.Username, form.Email)
 	if err != nil {
 		base.RespondErrorWithDefaultMsg(c, err)
 	} else {
+		recordSetting(c, models.AuditActionCreate, settingModel.Id, models.MailCode, nil, settingModel)
 		base.RespondSuccessWithDefaultMsg(c, nil)
 	}
 }
@@ -210,10 +238,12 @@ func CreateMailUser(c *gin.Context) {
 func RemoveMailUser(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
 	settingModel := new(models.Setting)
+	_ = settingModel.Find(id)
 	_, err := settingModel.RemoveMailUser(id)
 	if err != nil {
 		base.RespondErrorWithDefaultMsg(c, err)
 	} else {
+		recordSetting(c, models.AuditActionDelete, id, models.MailCode, settingModel, nil)
 		base.RespondSuccessWithDefaultMsg(c, nil)
 	}
 }
@@ -238,10 +268,13 @@ func UpdateWebHook(c *gin.Context) {
 	}
 
 	settingModel := new(models.Setting)
+	before, _ := settingModel.Webhook()
 	err := settingModel.UpdateWebHook(form.Template)
 	if err != nil {
 		base.RespondErrorWithDefaultMsg(c, err)
 	} else {
+		after, _ := settingModel.Webhook()
+		recordSetting(c, models.AuditActionUpdate, 0, models.WebhookCode, before, after)
 		base.RespondSuccessWithDefaultMsg(c, nil)
 	}
 }
@@ -254,11 +287,16 @@ func CreateWebhookUrl(c *gin.Context) {
 		return
 	}
 
+	if !projectExists(c, form.ProjectId) {
+		return
+	}
 	settingModel := new(models.Setting)
+	settingModel.ProjectId = form.ProjectId
 	_, err := settingModel.CreateWebhookUrl(form.Name, form.Url)
 	if err != nil {
 		base.RespondErrorWithDefaultMsg(c, err)
 	} else {
+		recordSetting(c, models.AuditActionCreate, settingModel.Id, models.WebhookCode, nil, settingModel)
 		base.RespondSuccessWithDefaultMsg(c, nil)
 	}
 }
@@ -266,10 +304,12 @@ func CreateWebhookUrl(c *gin.Context) {
 func RemoveWebhookUrl(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
 	settingModel := new(models.Setting)
+	_ = settingModel.Find(id)
 	_, err := settingModel.RemoveWebhookUrl(id)
 	if err != nil {
 		base.RespondErrorWithDefaultMsg(c, err)
 	} else {
+		recordSetting(c, models.AuditActionDelete, id, models.WebhookCode, settingModel, nil)
 		base.RespondSuccessWithDefaultMsg(c, nil)
 	}
 }
@@ -294,10 +334,13 @@ func UpdateServerChan3(c *gin.Context) {
 	}
 
 	settingModel := new(models.Setting)
+	before, _ := settingModel.ServerChan3()
 	err := settingModel.UpdateServerChan3(form.TitleTemplate, form.DespTemplate)
 	if err != nil {
 		base.RespondErrorWithDefaultMsg(c, err)
 	} else {
+		after, _ := settingModel.ServerChan3()
+		recordSetting(c, models.AuditActionUpdate, 0, models.ServerChan3Code, before, after)
 		base.RespondSuccessWithDefaultMsg(c, nil)
 	}
 }
@@ -310,11 +353,16 @@ func CreateServerChan3Url(c *gin.Context) {
 		return
 	}
 
+	if !projectExists(c, form.ProjectId) {
+		return
+	}
 	settingModel := new(models.Setting)
+	settingModel.ProjectId = form.ProjectId
 	_, err := settingModel.CreateServerChan3Url(form.Name, form.Url)
 	if err != nil {
 		base.RespondErrorWithDefaultMsg(c, err)
 	} else {
+		recordSetting(c, models.AuditActionCreate, settingModel.Id, models.ServerChan3Code, nil, settingModel)
 		base.RespondSuccessWithDefaultMsg(c, nil)
 	}
 }
@@ -322,10 +370,12 @@ func CreateServerChan3Url(c *gin.Context) {
 func RemoveServerChan3Url(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
 	settingModel := new(models.Setting)
+	_ = settingModel.Find(id)
 	_, err := settingModel.RemoveServerChan3Url(id)
 	if err != nil {
 		base.RespondErrorWithDefaultMsg(c, err)
 	} else {
+		recordSetting(c, models.AuditActionDelete, id, models.ServerChan3Code, settingModel, nil)
 		base.RespondSuccessWithDefaultMsg(c, nil)
 	}
 }
@@ -350,10 +400,13 @@ func UpdateBark(c *gin.Context) {
 	}
 
 	settingModel := new(models.Setting)
+	before, _ := settingModel.Bark()
 	err := settingModel.UpdateBark(form.TitleTemplate, form.BodyTemplate)
 	if err != nil {
 		base.RespondErrorWithDefaultMsg(c, err)
 	} else {
+		after, _ := settingModel.Bark()
+		recordSetting(c, models.AuditActionUpdate, 0, models.BarkCode, before, after)
 		base.RespondSuccessWithDefaultMsg(c, nil)
 	}
 }
@@ -366,11 +419,16 @@ func CreateBarkUrl(c *gin.Context) {
 		return
 	}
 
+	if !projectExists(c, form.ProjectId) {
+		return
+	}
 	settingModel := new(models.Setting)
+	settingModel.ProjectId = form.ProjectId
 	_, err := settingModel.CreateBarkUrl(form.Name, form.Url)
 	if err != nil {
 		base.RespondErrorWithDefaultMsg(c, err)
 	} else {
+		recordSetting(c, models.AuditActionCreate, settingModel.Id, models.BarkCode, nil, settingModel)
 		base.RespondSuccessWithDefaultMsg(c, nil)
 	}
 }
@@ -378,10 +436,12 @@ func CreateBarkUrl(c *gin.Context) {
 func RemoveBarkUrl(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
 	settingModel := new(models.Setting)
+	_ = settingModel.Find(id)
 	_, err := settingModel.RemoveBarkUrl(id)
 	if err != nil {
 		base.RespondErrorWithDefaultMsg(c, err)
 	} else {
+		recordSetting(c, models.AuditActionDelete, id, models.BarkCode, settingModel, nil)
 		base.RespondSuccessWithDefaultMsg(c, nil)
 	}
 }
@@ -390,15 +450,18 @@ func RemoveBarkUrl(c *gin.Context) {
 
 // region 系统配置
 func GetLogRetentionDays(c *gin.Context) {
+	base.RespondSuccess(c, "", logRetention())
+}
+
+// 日志保留配置
+func logRetention() map[string]interface{} {
 	settingModel := new(models.Setting)
-	days := settingModel.GetLogRetentionDays()
-	cleanupTime := settingModel.GetLogCleanupTime()
-	fileSizeLimit := settingModel.GetLogFileSizeLimit()
-	base.RespondSuccess(c, "", map[string]interface{}{
-		"days":            days,
-		"cleanup_time":    cleanupTime,
-		"file_size_limit": fileSizeLimit,
-	})
+	return map[string]interface{}{
+		"days":            settingModel.GetLogRetentionDays(),
+		"cleanup_time":    settingModel.GetLogCleanupTime(),
+		"file_size_limit": settingModel.GetLogFileSizeLimit(),
+		"audit_days":      settingModel.GetAuditLogRetentionDays(),
+	}
 }
 
 func UpdateLogRetentionDays(c *gin.Context) {
@@ -406,12 +469,14 @@ func UpdateLogRetentionDays(c *gin.Context) {
 		Days          int    `json:"days" binding:"min=0,max=3650"`
 		CleanupTime   string `json:"cleanup_time" binding:"required"`
 		FileSizeLimit int    `json:"file_size_limit" binding:"min=0,max=10240"`
+		AuditDays     int    `json:"audit_days" binding:"min=0,max=3650"`
 	}
 	if err := c.ShouldBindJSON(&form); err != nil {
 		base.RespondError(c, "表单验证失败, 请检测输入")
 		return
 	}
 
+	before := logRetention()
 	settingModel := new(models.Setting)
 	err := settingModel.UpdateLogRetentionDays(form.Days)
 	if err != nil {
@@ -428,9 +493,105 @@ func UpdateLogRetentionDays(c *gin.Context) {
 		base.RespondErrorWithDefaultMsg(c, err)
 		return
 	}
+	err = settingModel.UpdateAuditLogRetentionDays(form.AuditDays)
+	if err != nil {
+		base.RespondErrorWithDefaultMsg(c, err)
+		return
+	}
+	recordSetting(c, models.AuditActionUpdate, 0, models.SystemCode, before, logRetention())
 	// 重新加载日志清理任务
 	service.ServiceTask.ReloadLogCleanupTask()
 	base.RespondSuccessWithDefaultMsg(c, nil)
 }
 
+// LoginSetting 登录方式配置, 单点登录在配置文件中开启
+func LoginSetting(c *gin.Context) {
+	base.RespondSuccess(c, utils.SuccessContent, loginSetting())
+}
+
+func loginSetting() map[string]interface{} {
+	disabled, breakGlassUser := new(models.Setting).PasswordLogin()
+	return map[string]interface{}{
+		"oidc_enable":             app.Setting.OIDCEnable,
+		"password_login_disabled": disabled,
+		"break_glass_user":        breakGlassUser,
+	}
+}
+
+// UpdateLoginSetting 禁用密码登录时需指定可用的管理员作为应急账户, 避免单点登录故障时无法登录
+func UpdateLoginSetting(c *gin.Context) {
+	var form struct {
+		PasswordLoginDisabled bool   `json:"password_login_disabled"`
+		BreakGlassUser        string `json:"break_glass_user" binding:"max=32"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		base.RespondError(c, i18n.T(c, "param_error"), err)
+		return
+	}
+	form.BreakGlassUser = strings.TrimSpace(form.BreakGlassUser)
+	if form.PasswordLoginDisabled {
+		userModel := new(models.User)
+		err := userModel.FindByName(form.BreakGlassUser)
+		if err != nil || form.BreakGlassUser == "" || userModel.Id == 0 ||
+			userModel.IsAdmin != 1 || userModel.Status != models.Enabled {
+			base.RespondError(c, i18n.T(c, "break_glass_user_invalid"), err)
+			return
+		}
+	}
+
+	before := loginSetting()
+	if err := new(models.Setting).UpdatePasswordLogin(form.PasswordLoginDisabled, form.BreakGlassUser); err != nil {
+		base.RespondErrorWithDefaultMsg(c, err)
+		return
+	}
+	recordSetting(c, models.AuditActionUpdate, 0, models.SystemCode, before, loginSetting())
+	base.RespondSuccessWithDefaultMsg(c, nil)
+}
+
 // endregion
+
+// region 节点连接池
+
+// RPCPool 节点连接池中的连接及反向连接的节点数
+func RPCPool(c *gin.Context) {
+	base.RespondSuccess(c, utils.SuccessContent, map[string]interface{}{
+		"conns":   grpcpool.Pool.Stats(),
+		"reverse": reverse.Size(),
+	})
+}
+
+// ReleaseRPCConn 关闭指定节点的连接, 下次请求时重新建立
+func ReleaseRPCConn(c *gin.Context) {
+	var form struct {
+		Addr string `json:"addr" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		base.RespondError(c, i18n.T(c, "param_error"), err)
+		return
+	}
+	addr := strings.TrimSpace(form.Addr)
+	grpcpool.Pool.Release(addr)
+	audit.Record(c, models.AuditActionRelease, models.AuditResourceHost, 0, addr, nil, nil)
+	base.RespondSuccessWithDefaultMsg(c, nil)
+}
+
+// endregion
+
+// 记录系统配置的审计日志, name为配置分类
+func recordSetting(c *gin.Context, action string, id int, name string, before, after interface{}) {
+	audit.Record(c, action, models.AuditResourceSetting, id, name, before, after)
+}
+
+// 通知接收者可归属指定项目, 为0时所有项目共享
+func projectExists(c *gin.Context, projectId int) bool {
+	if projectId == 0 {
+		return true
+	}
+	projectModel := new(models.Project)
+	if err := projectModel.Find(projectId); err != nil || projectModel.Id == 0 {
+		base.RespondError(c, i18n.T(c, "project_not_exist"), err)
+		return false
+	}
+
+	return true
+}
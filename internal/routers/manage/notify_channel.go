@@ -0,0 +1,98 @@
+package manage
+
+// 通知渠道, 供v2接口统一管理邮件用户、Slack频道及各类通知地址
+
+import (
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/i18n"
+	"github.com/tabortao/gocron/internal/modules/utils"
+	"github.com/tabortao/gocron/internal/routers/base"
+)
+
+type NotifyChannelForm struct {
+	Type string `form:"type" json:"type" binding:"required"`
+	// 邮件用户名、Slack频道名或地址名称
+	Name string `form:"name" json:"name" binding:"required,max=50"`
+	// 邮箱或通知地址, Slack频道不需要
+	Target    string `form:"target" json:"target" binding:"max=200"`
+	ProjectId int    `form:"project_id" json:"project_id" binding:"min=0"` // 为0时所有项目共享
+}
+
+// SaveChannel 校验并保存通知渠道, id为0时新增, 修改时不能变更类型
+func SaveChannel(c *gin.Context, id int, form NotifyChannelForm) (models.NotifyChannel, error) {
+	channel := models.NotifyChannel{}
+	form.Name = strings.TrimSpace(form.Name)
+	form.Target = strings.TrimSpace(form.Target)
+	if !utils.InStringSlice(models.NotifyChannelTypes, form.Type) {
+		return channel, base.NewError(http.StatusBadRequest, i18n.T(c, "notify_channel_type_invalid"))
+	}
+	if form.Type != models.SlackCode && !validTarget(form.Type, form.Target) {
+		return channel, base.NewError(http.StatusBadRequest, i18n.T(c, "notify_channel_target_required"))
+	}
+	if form.ProjectId > 0 {
+		projectModel := new(models.Project)
+		if err := projectModel.Find(form.ProjectId); err != nil || projectModel.Id == 0 {
+			return channel, base.NewError(http.StatusBadRequest, i18n.T(c, "project_not_exist"))
+		}
+	}
+
+	var before *models.NotifyChannel
+	if id > 0 {
+		if err := channel.Find(id); err != nil {
+			return channel, err
+		}
+		if channel.Id == 0 || channel.Type != form.Type {
+			return channel, base.NewError(http.StatusNotFound, i18n.T(c, "notify_channel_not_exist"))
+		}
+		old := channel
+		before = &old
+	}
+	channel = models.NotifyChannel{Id: id, Type: form.Type, Name: form.Name, ProjectId: form.ProjectId}
+	if form.Type != models.SlackCode {
+		channel.Target = form.Target
+	}
+	if err := channel.Save(); err != nil {
+		return channel, base.NewError(http.StatusInternalServerError, i18n.T(c, "save_failed"), err)
+	}
+	if before == nil {
+		recordSetting(c, models.AuditActionCreate, channel.Id, channel.Type, nil, channel)
+	} else {
+		recordSetting(c, models.AuditActionUpdate, channel.Id, channel.Type, before, channel)
+	}
+
+	return channel, nil
+}
+
+// DeleteChannel 删除通知渠道
+func DeleteChannel(c *gin.Context, id int) error {
+	channel := new(models.NotifyChannel)
+	if err := channel.Find(id); err != nil {
+		return err
+	}
+	if channel.Id == 0 {
+		return base.NewError(http.StatusNotFound, i18n.T(c, "notify_channel_not_exist"))
+	}
+	if _, err := channel.Delete(id); err != nil {
+		return base.NewError(http.StatusInternalServerError, i18n.T(c, "delete_failed"), err)
+	}
+	recordSetting(c, models.AuditActionDelete, id, channel.Type, channel, nil)
+
+	return nil
+}
+
+// 邮件渠道须为有效邮箱, 其他渠道须为http(s)地址
+func validTarget(channelType, target string) bool {
+	if channelType == models.MailCode {
+		_, err := mail.ParseAddress(target)
+		return err == nil
+	}
+	u, err := url.ParseRequestURI(target)
+
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,233 @@
+package routers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/app"
+	"github.com/tabortao/gocron/internal/modules/logger"
+	"github.com/tabortao/gocron/internal/routers/base"
+	"github.com/tabortao/gocron/internal/routers/user"
+)
+
+// 接口权限
+type permission struct {
+	// 需要的操作权限, 为0时登录用户均可访问
+	action models.Action
+	// 解析请求资源所属的项目, 为nil时由接口按项目过滤列表或自行校验
+	project func(c *gin.Context) (int, error)
+}
+
+// 非管理员可访问的接口, key为请求方法和路由, 未列出的接口只允许系统管理员访问
+var permissions = map[string]permission{
+	"GET /":                                {},
+	"GET /api/healthz":                     {},
+	"GET /api/install/status":              {},
+	"POST /api/user/login":                 {},
+	"GET /api/user/login-options":          {},
+	"GET /api/user/oidc/login":             {},
+	"GET /api/user/oidc/callback":          {},
+	"POST /api/user/editMyPassword":        {},
+	"GET /api/user/2fa/status":             {},
+	"GET /api/user/2fa/setup":              {},
+	"POST /api/user/2fa/enable":            {},
+	"POST /api/user/2fa/disable":           {},
+	"GET /api/user/api-tokens":             {},
+	"POST /api/user/api-tokens/store":      {},
+	"POST /api/user/api-tokens/remove/:id": {},
+	"GET /api/statistics/overview":         {},
+	"GET /api/agent/install.sh":            {},
+	"POST /api/agent/register":             {},
+	"POST /api/agent/renew":                {},
+	"GET /api/agent/download":              {},
+	"GET /api/project":                     {},
+	"GET /api/project/members/:id":         {action: models.ActionManage, project: projectParam},
+	"POST /api/project/members/:id":        {action: models.ActionManage, project: projectParam},
+	"GET /api/task":                        {action: models.ActionView},
+	"GET /api/task/:id":                    {action: models.ActionView, project: taskParam},
+	"GET /api/task/notify-receivers":       {action: models.ActionEdit, project: projectQuery},
+	"POST /api/task/store":                 {action: models.ActionEdit},
+	"POST /api/task/remove/:id":            {action: models.ActionEdit, project: taskParam},
+	"POST /api/task/enable/:id":            {action: models.ActionRun, project: taskParam},
+	"POST /api/task/disable/:id":           {action: models.ActionRun, project: taskParam},
+	"POST /api/task/batch-enable":          {action: models.ActionRun},
+	"POST /api/task/batch-disable":         {action: models.ActionRun},
+	"POST /api/task/batch-remove":          {action: models.ActionEdit},
+	"GET /api/task/run/:id":                {action: models.ActionRun, project: taskParam},
+	"POST /api/task/backfill/:id":          {action: models.ActionRun, project: taskParam},
+	"GET /api/task/revisions/:id":          {action: models.ActionView, project: taskParam},
+	"GET /api/task/revision-diff/:id":      {action: models.ActionView, project: taskParam},
+	"POST /api/task/rollback/:id":          {action: models.ActionEdit, project: taskParam},
+	"GET /api/task/log":                    {action: models.ActionView},
+	"GET /api/task/log/output":             {action: models.ActionView, project: taskLogQuery},
+	"GET /api/task/log/attempts":           {action: models.ActionView, project: taskLogQuery},
+	"GET /api/task/log/hosts":              {action: models.ActionView, project: taskLogQuery},
+	"POST /api/task/log/stop":              {action: models.ActionRun, project: taskForm},
+	"GET /api/host":                        {action: models.ActionView},
+	"GET /api/host/all":                    {action: models.ActionView},
+	"GET /api/host/groups":                 {action: models.ActionView},
+	"GET /api/host/match":                  {action: models.ActionView},
+	"GET /api/host/:id":                    {action: models.ActionView, project: hostParam},
+	"POST /api/host/store":                 {action: models.ActionEdit},
+	"POST /api/host/remove/:id":            {action: models.ActionEdit, project: hostParam},
+	"GET /api/host/ping/:id":               {action: models.ActionRun, project: hostParam},
+	"POST /api/host/token/:id":             {action: models.ActionManage, project: hostParam},
+	"POST /api/host/token/revoke/:id":      {action: models.ActionManage, project: hostParam},
+	"POST /api/v1/task/enable/:id":         {action: models.ActionRun, project: taskParam},
+	"POST /api/v1/task/disable/:id":        {action: models.ActionRun, project: taskParam},
+	"GET /api/v2/openapi.json":             {},
+	"GET /api/v2/tasks":                    {action: models.ActionView},
+	"POST /api/v2/tasks":                   {action: models.ActionEdit},
+	"GET /api/v2/tasks/:id":                {action: models.ActionView, project: taskParam},
+	"PUT /api/v2/tasks/:id":                {action: models.ActionEdit, project: taskParam},
+	"DELETE /api/v2/tasks/:id":             {action: models.ActionEdit, project: taskParam},
+	"POST /api/v2/tasks/:id/run":           {action: models.ActionRun, project: taskParam},
+	"POST /api/v2/tasks/:id/enable":        {action: models.ActionRun, project: taskParam},
+	"POST /api/v2/tasks/:id/disable":       {action: models.ActionRun, project: taskParam},
+	"GET /api/v2/hosts":                    {action: models.ActionView},
+	"POST /api/v2/hosts":                   {action: models.ActionEdit},
+	"GET /api/v2/hosts/:id":                {action: models.ActionView, project: hostParam},
+	"PUT /api/v2/hosts/:id":                {action: models.ActionEdit, project: hostParam},
+	"DELETE /api/v2/hosts/:id":             {action: models.ActionEdit, project: hostParam},
+	"POST /api/v2/hosts/:id/ping":          {action: models.ActionRun, project: hostParam},
+	"GET /api/v2/logs":                     {action: models.ActionView},
+	"GET /api/v2/logs/:id":                 {action: models.ActionView, project: taskLogParam},
+	"GET /api/v2/logs/:id/output":          {action: models.ActionView, project: taskLogParam},
+	"POST /api/v2/logs/:id/stop":           {action: models.ActionRun, project: taskLogParam},
+}
+
+// API令牌可访问的接口及需要的权限范围, 未列出的接口不能使用API令牌访问
+var tokenScopes = map[string]string{
+	"GET /api/task":                   models.ScopeTaskRead,
+	"GET /api/task/:id":               models.ScopeTaskRead,
+	"GET /api/task/revisions/:id":     models.ScopeTaskRead,
+	"POST /api/task/store":            models.ScopeTaskWrite,
+	"POST /api/task/remove/:id":       models.ScopeTaskWrite,
+	"POST /api/task/rollback/:id":     models.ScopeTaskWrite,
+	"POST /api/task/enable/:id":       models.ScopeTaskWrite,
+	"POST /api/task/disable/:id":      models.ScopeTaskWrite,
+	"GET /api/task/run/:id":           models.ScopeTaskRun,
+	"POST /api/task/log/stop":         models.ScopeTaskRun,
+	"GET /api/task/log":               models.ScopeLogRead,
+	"GET /api/task/log/output":        models.ScopeLogRead,
+	"GET /api/task/log/attempts":      models.ScopeLogRead,
+	"GET /api/task/log/hosts":         models.ScopeLogRead,
+	"GET /api/host":                   models.ScopeHostRead,
+	"GET /api/host/all":               models.ScopeHostRead,
+	"GET /api/host/:id":               models.ScopeHostRead,
+	"GET /api/host/ping/:id":          models.ScopeHostRead,
+	"POST /api/host/store":            models.ScopeHostWrite,
+	"POST /api/host/remove/:id":       models.ScopeHostWrite,
+	"POST /api/v1/task/enable/:id":    models.ScopeTaskWrite,
+	"POST /api/v1/task/disable/:id":   models.ScopeTaskWrite,
+	"POST /api/v1/tasklog/remove/:id": models.ScopeLogWrite,
+
+	"GET /api/v2/tasks":                        models.ScopeTaskRead,
+	"GET /api/v2/tasks/:id":                    models.ScopeTaskRead,
+	"POST /api/v2/tasks":                       models.ScopeTaskWrite,
+	"PUT /api/v2/tasks/:id":                    models.ScopeTaskWrite,
+	"DELETE /api/v2/tasks/:id":                 models.ScopeTaskWrite,
+	"POST /api/v2/tasks/:id/enable":            models.ScopeTaskWrite,
+	"POST /api/v2/tasks/:id/disable":           models.ScopeTaskWrite,
+	"POST /api/v2/tasks/:id/run":               models.ScopeTaskRun,
+	"POST /api/v2/logs/:id/stop":               models.ScopeTaskRun,
+	"GET /api/v2/logs":                         models.ScopeLogRead,
+	"GET /api/v2/logs/:id":                     models.ScopeLogRead,
+	"GET /api/v2/logs/:id/output":              models.ScopeLogRead,
+	"DELETE /api/v2/logs/:id":                  models.ScopeLogWrite,
+	"GET /api/v2/hosts":                        models.ScopeHostRead,
+	"GET /api/v2/hosts/:id":                    models.ScopeHostRead,
+	"POST /api/v2/hosts/:id/ping":              models.ScopeHostRead,
+	"POST /api/v2/hosts":                       models.ScopeHostWrite,
+	"PUT /api/v2/hosts/:id":                    models.ScopeHostWrite,
+	"DELETE /api/v2/hosts/:id":                 models.ScopeHostWrite,
+	"GET /api/v2/notification-channels":        models.ScopeNotifyRead,
+	"GET /api/v2/notification-channels/:id":    models.ScopeNotifyRead,
+	"POST /api/v2/notification-channels":       models.ScopeNotifyWrite,
+	"PUT /api/v2/notification-channels/:id":    models.ScopeNotifyWrite,
+	"DELETE /api/v2/notification-channels/:id": models.ScopeNotifyWrite,
+	"GET /api/v2/users":                        models.ScopeUserRead,
+	"GET /api/v2/users/:id":                    models.ScopeUserRead,
+	"POST /api/v2/users":                       models.ScopeUserWrite,
+	"PUT /api/v2/users/:id":                    models.ScopeUserWrite,
+	"DELETE /api/v2/users/:id":                 models.ScopeUserWrite,
+}
+
+func projectParam(c *gin.Context) (int, error) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	return id, nil
+}
+
+func projectQuery(c *gin.Context) (int, error) {
+	id, _ := strconv.Atoi(c.Query("project_id"))
+	return id, nil
+}
+
+func taskParam(c *gin.Context) (int, error) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	return models.TaskProjectId(id)
+}
+
+func taskForm(c *gin.Context) (int, error) {
+	id, _ := strconv.Atoi(c.PostForm("task_id"))
+	return models.TaskProjectId(id)
+}
+
+func taskLogQuery(c *gin.Context) (int, error) {
+	id, _ := strconv.ParseInt(c.Query("id"), 10, 64)
+	return models.TaskLogProjectId(id)
+}
+
+func taskLogParam(c *gin.Context) (int, error) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	return models.TaskLogProjectId(id)
+}
+
+func hostParam(c *gin.Context) (int, error) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	return models.HostProjectId(id)
+}
+
+// 按项目角色校验接口权限, 替代原来普通用户的URL白名单
+func permissionAuth(c *gin.Context) {
+	if !app.Installed {
+		c.Next()
+		return
+	}
+	// 静态文件等未注册的路由
+	path := c.FullPath()
+	if path == "" {
+		c.Next()
+		return
+	}
+	key := c.Request.Method + " " + path
+	// API令牌只能访问已授权权限范围的接口, 再按所属用户的角色校验
+	if token := user.ApiToken(c); token != nil && !token.HasScope(tokenScopes[key]) {
+		base.RespondUnauthorized(c)
+		c.Abort()
+		return
+	}
+	// v1接口未使用API令牌时由接口签名认证
+	if user.IsAdmin(c) || (user.ApiToken(c) == nil && strings.HasPrefix(path, urlPrefix+"/v1/")) {
+		c.Next()
+		return
+	}
+
+	rule, ok := permissions[key]
+	if ok && rule.action > 0 && rule.project != nil {
+		projectId, err := rule.project(c)
+		if err != nil {
+			logger.Error("解析资源所属项目失败", err)
+		}
+		ok = err == nil && user.Can(c, projectId, rule.action)
+	}
+	if !ok {
+		base.RespondUnauthorized(c)
+		c.Abort()
+		return
+	}
+
+	c.Next()
+}
@@ -0,0 +1,28 @@
+package routers
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 权限规则须对应已注册的路由, 避免路由调整后规则失效
+func TestPermissionRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	Register(r)
+	routes := make(map[string]bool)
+	for _, route := range r.Routes() {
+		routes[route.Method+" "+route.Path] = true
+	}
+	for key := range permissions {
+		if !routes[key] {
+			t.Errorf("permission rule %q has no matching route", key)
+		}
+	}
+	for key := range tokenScopes {
+		if !routes[key] {
+			t.Errorf("token scope rule %q has no matching route", key)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package project
+
+// 项目及成员管理
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/i18n"
+	"github.com/tabortao/gocron/internal/modules/utils"
+	"github.com/tabortao/gocron/internal/routers/audit"
+	"github.com/tabortao/gocron/internal/routers/base"
+	"github.com/tabortao/gocron/internal/routers/user"
+)
+
+type ProjectForm struct {
+	Id     int    `form:"id" json:"id"`
+	Name   string `form:"name" json:"name" binding:"required,max=32"`
+	Remark string `form:"remark" json:"remark" binding:"max=100"`
+	// 是否允许与其他项目的任务建立依赖
+	AllowCrossDependency int8 `form:"allow_cross_dependency" json:"allow_cross_dependency" binding:"oneof=0 1"`
+}
+
+type MembersForm struct {
+	Members []struct {
+		UserId int         `json:"user_id"`
+		Role   models.Role `json:"role"`
+	} `json:"members"`
+}
+
+// Index 当前用户可访问的项目及其角色, 系统管理员返回全部项目
+func Index(c *gin.Context) {
+	projectModel := new(models.Project)
+	var (
+		projects []models.Project
+		err      error
+	)
+	if user.IsAdmin(c) {
+		projects, err = projectModel.List()
+	} else {
+		projects, err = projectModel.ListByUser(user.Uid(c))
+	}
+	if err != nil {
+		base.RespondErrorWithDefaultMsg(c, err)
+		return
+	}
+
+	base.RespondSuccess(c, utils.SuccessContent, projects)
+}
+
+// Store 新增、修改项目
+func Store(c *gin.Context) {
+	var form ProjectForm
+	if err := c.ShouldBind(&form); err != nil {
+		base.RespondValidationError(c, err)
+		return
+	}
+	form.Name = strings.TrimSpace(form.Name)
+	projectModel := new(models.Project)
+	exists, err := projectModel.NameExists(form.Name, form.Id)
+	if err != nil {
+		base.RespondErrorWithDefaultMsg(c, err)
+		return
+	}
+	if exists {
+		base.RespondError(c, i18n.T(c, "project_name_exists"))
+		return
+	}
+
+	before := new(models.Project)
+	if form.Id > 0 {
+		_ = before.Find(form.Id)
+	}
+	projectModel.Name = form.Name
+	projectModel.Remark = strings.TrimSpace(form.Remark)
+	projectModel.AllowCrossDependency = form.AllowCrossDependency
+	id := form.Id
+	if id > 0 {
+		_, err = projectModel.Update(id)
+	} else {
+		id, err = projectModel.Create()
+	}
+	if err != nil {
+		base.RespondError(c, i18n.T(c, "save_failed"), err)
+		return
+	}
+	after := new(models.Project)
+	_ = after.Find(id)
+	if form.Id > 0 {
+		audit.Record(c, models.AuditActionUpdate, models.AuditResourceProject, id, after.Name, before, after)
+	} else {
+		audit.Record(c, models.AuditActionCreate, models.AuditResourceProject, id, after.Name, nil, after)
+	}
+
+	base.RespondSuccess(c, i18n.T(c, "save_success"), nil)
+}
+
+// Remove 删除项目, 项目下仍有任务或主机时不能删除
+func Remove(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if id == models.DefaultProjectId {
+		base.RespondError(c, i18n.T(c, "default_project_cannot_delete"))
+		return
+	}
+	projectModel := new(models.Project)
+	_ = projectModel.Find(id)
+	inUse, err := projectModel.InUse(id)
+	if err != nil {
+		base.RespondErrorWithDefaultMsg(c, err)
+		return
+	}
+	if inUse {
+		base.RespondError(c, i18n.T(c, "project_in_use_cannot_delete"))
+		return
+	}
+	if err = projectModel.Delete(id); err != nil {
+		base.RespondErrorWithDefaultMsg(c, err)
+		return
+	}
+	audit.Record(c, models.AuditActionDelete, models.AuditResourceProject, id, projectModel.Name, projectModel, nil)
+
+	base.RespondSuccessWithDefaultMsg(c, nil)
+}
+
+// Members 项目成员及可添加的用户
+func Members(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	memberModel := new(models.ProjectMember)
+	members, err := memberModel.List(id)
+	if err != nil {
+		base.RespondErrorWithDefaultMsg(c, err)
+		return
+	}
+	users, err := memberModel.Candidates()
+	if err != nil {
+		base.RespondErrorWithDefaultMsg(c, err)
+		return
+	}
+
+	base.RespondSuccess(c, utils.SuccessContent, map[string]interface{}{
+		"members": members,
+		"users":   users,
+	})
+}
+
+// UpdateMembers 替换项目成员
+func UpdateMembers(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var form MembersForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		base.RespondError(c, i18n.T(c, "param_error"))
+		return
+	}
+	projectModel := new(models.Project)
+	if err := projectModel.Find(id); err != nil || projectModel.Id == 0 {
+		base.RespondError(c, i18n.T(c, "project_not_exist"), err)
+		return
+	}
+
+	memberModel := new(models.ProjectMember)
+	users, err := memberModel.Candidates()
+	if err != nil {
+		base.RespondErrorWithDefaultMsg(c, err)
+		return
+	}
+	valid := make(map[int]bool, len(users))
+	for _, u := range users {
+		valid[u.Id] = true
+	}
+	members := make([]models.ProjectMember, 0, len(form.Members))
+	seen := make(map[int]bool, len(form.Members))
+	for _, item := range form.Members {
+		if !valid[item.UserId] || seen[item.UserId] || !item.Role.Valid() {
+			base.RespondError(c, i18n.T(c, "project_member_invalid"))
+			return
+		}
+		seen[item.UserId] = true
+		members = append(members, models.ProjectMember{UserId: item.UserId, Role: item.Role})
+	}
+	before, _ := memberModel.List(id)
+	if err = memberModel.Replace(id, members); err != nil {
+		base.RespondError(c, i18n.T(c, "save_failed"), err)
+		return
+	}
+	after, _ := memberModel.List(id)
+	audit.Record(c, models.AuditActionUpdate, models.AuditResourceProject, id, projectModel.Name,
+		memberRoles(before), memberRoles(after))
+
+	base.RespondSuccess(c, i18n.T(c, "save_success"), nil)
+}
+
+// 成员及角色, 用于审计日志比较成员变化
+func memberRoles(members []models.ProjectMember) map[string]interface{} {
+	roles := make(map[string]models.Role, len(members))
+	for _, member := range members {
+		roles[member.Username] = member.Role
+	}
+
+	return map[string]interface{}{"members": roles}
+}
@@ -15,11 +15,16 @@ import (
 	"github.com/tabortao/gocron/internal/modules/logger"
 	"github.com/tabortao/gocron/internal/modules/utils"
 	"github.com/tabortao/gocron/internal/routers/agent"
+	"github.com/tabortao/gocron/internal/routers/apitoken"
+	"github.com/tabortao/gocron/internal/routers/apiv2"
+	"github.com/tabortao/gocron/internal/routers/audit"
+	"github.com/tabortao/gocron/internal/routers/base"
 	"github.com/tabortao/gocron/internal/routers/health"
 	"github.com/tabortao/gocron/internal/routers/host"
 	"github.com/tabortao/gocron/internal/routers/install"
 	"github.com/tabortao/gocron/internal/routers/loginlog"
 	"github.com/tabortao/gocron/internal/routers/manage"
+	"github.com/tabortao/gocron/internal/routers/project"
 	"github.com/tabortao/gocron/internal/routers/statistics"
 	"github.com/tabortao/gocron/internal/routers/task"
 	"github.com/tabortao/gocron/internal/routers/tasklog"
@@ -64,10 +69,17 @@ func Register(r *gin.Engine) {
 		userGroup.POST("/store", user.Store)
 		userGroup.POST("/remove/:id", user.Remove)
 		userGroup.POST("/login", user.ValidateLogin)
+		userGroup.GET("/login-options", user.LoginOptions)
+		userGroup.GET("/oidc/login", user.OIDCLogin)
+		userGroup.GET("/oidc/callback", user.OIDCCallback)
 		userGroup.POST("/enable/:id", user.Enable)
 		userGroup.POST("/disable/:id", user.Disable)
 		userGroup.POST("/editMyPassword", user.UpdateMyPassword)
 		userGroup.POST("/editPassword/:id", user.UpdatePassword)
+		userGroup.GET("/api-tokens", apitoken.Index)
+		userGroup.POST("/api-tokens/store", apitoken.Store)
+		userGroup.POST("/api-tokens/remove/:id", apitoken.Remove)
+
 		// 2FA相关路由
 		userGroup.GET("/2fa/status", user.Get2FAStatus)
 		userGroup.GET("/2fa/setup", user.Setup2FA)
@@ -79,10 +91,13 @@ func Register(r *gin.Engine) {
 	taskGroup := api.Group("/task")
 	{
 		taskGroup.POST("/store", task.Store)
+		taskGroup.GET("/notify-receivers", task.NotifyReceivers)
 		taskGroup.GET("/:id", task.Detail)
 		taskGroup.GET("", task.Index)
 		taskGroup.GET("/log", tasklog.Index)
 		taskGroup.GET("/log/output", tasklog.Output)
+		taskGroup.GET("/log/attempts", tasklog.Attempts)
+		taskGroup.GET("/log/hosts", tasklog.Hosts)
 		taskGroup.POST("/log/clear", tasklog.Clear)
 		taskGroup.POST("/log/stop", tasklog.Stop)
 		taskGroup.POST("/remove/:id", task.Remove)
@@ -92,6 +107,10 @@ func Register(r *gin.Engine) {
 		taskGroup.POST("/batch-disable", task.BatchDisable)
 		taskGroup.POST("/batch-remove", task.BatchRemove)
 		taskGroup.GET("/run/:id", task.Run)
+		taskGroup.POST("/backfill/:id", task.Backfill)
+		taskGroup.GET("/revisions/:id", task.Revisions)
+		taskGroup.GET("/revision-diff/:id", task.RevisionDiff)
+		taskGroup.POST("/rollback/:id", task.Rollback)
 	}
 
 	// 主机
@@ -101,8 +120,22 @@ func Register(r *gin.Engine) {
 		hostGroup.POST("/store", host.Store)
 		hostGroup.GET("", host.Index)
 		hostGroup.GET("/all", host.All)
+		hostGroup.GET("/groups", host.Groups)
+		hostGroup.GET("/match", host.Match)
 		hostGroup.GET("/ping/:id", host.Ping)
 		hostGroup.POST("/remove/:id", host.Remove)
+		hostGroup.POST("/token/:id", host.RotateToken)
+		hostGroup.POST("/token/revoke/:id", host.RevokeToken)
+	}
+
+	// 项目
+	projectGroup := api.Group("/project")
+	{
+		projectGroup.GET("", project.Index)
+		projectGroup.POST("/store", project.Store)
+		projectGroup.POST("/remove/:id", project.Remove)
+		projectGroup.GET("/members/:id", project.Members)
+		projectGroup.POST("/members/:id", project.UpdateMembers)
 	}
 
 	// Agent注册
@@ -111,6 +144,7 @@ func Register(r *gin.Engine) {
 		agentGroup.POST("/generate-token", agent.GenerateToken)
 		agentGroup.GET("/install.sh", agent.InstallScript)
 		agentGroup.POST("/register", agent.Register)
+		agentGroup.POST("/renew", agent.RenewCertificate)
 		agentGroup.GET("/download", agent.Download)
 	}
 
@@ -153,8 +187,13 @@ func Register(r *gin.Engine) {
 			barkGroup.POST("/url/remove/:id", manage.RemoveBarkUrl)
 		}
 		systemGroup.GET("/login-log", loginlog.Index)
+		systemGroup.GET("/audit-log", audit.Index)
 		systemGroup.GET("/log-retention", manage.GetLogRetentionDays)
 		systemGroup.POST("/log-retention", manage.UpdateLogRetentionDays)
+		systemGroup.GET("/login-setting", manage.LoginSetting)
+		systemGroup.POST("/login-setting", manage.UpdateLoginSetting)
+		systemGroup.GET("/rpc-pool", manage.RPCPool)
+		systemGroup.POST("/rpc-pool/release", manage.ReleaseRPCConn)
 	}
 
 	// 统计
@@ -172,6 +211,9 @@ func Register(r *gin.Engine) {
 		v1Group.POST("/task/disable/:id", task.Disable)
 	}
 
+	// v2 API, 使用HTTP状态码及游标分页
+	apiv2.Register(r.Group(apiv2.Prefix), tokenScopes)
+
 	// 首页路由（根路径）
 	r.GET("/", func(c *gin.Context) {
 		file, err := staticFS.Open("index.html")
@@ -230,7 +272,7 @@ func RegisterMiddleware(r *gin.Engine) {
 	r.Use(checkAppInstall)
 	r.Use(ipAuth)
 	r.Use(userAuth)
-	r.Use(urlAuth)
+	r.Use(permissionAuth)
 }
 
 // region 自定义中间件
@@ -246,10 +288,7 @@ func checkAppInstall(c *gin.Context) {
 		c.Next()
 		return
 	}
-	jsonResp := utils.JsonResponse{}
-	data := jsonResp.Failure(utils.AppNotInstall, i18n.T(c, "app_not_installed"))
-	c.String(http.StatusOK, data)
-	c.Abort()
+	abort(c, utils.AppNotInstall, i18n.T(c, "app_not_installed"))
 }
 
 // IP验证, 通过反向代理访问gocron，需设置Header X-Real-IP才能获取到客户端真实IP
@@ -270,10 +309,7 @@ func ipAuth(c *gin.Context) {
 		return
 	}
 	logger.Warnf("非法IP访问-%s", clientIp)
-	jsonResp := utils.JsonResponse{}
-	data := jsonResp.Failure(utils.UnauthorizedError, i18n.T(c, "unauthorized"))
-	c.String(http.StatusOK, data)
-	c.Abort()
+	abort(c, utils.UnauthorizedError, i18n.T(c, "unauthorized"))
 }
 
 // 用户认证
@@ -292,7 +328,7 @@ func userAuth(c *gin.Context) {
 
 	uri := strings.TrimRight(path, "/")
 	// 登录接口和安装状态接口不需要认证
-	excludePaths := []string{"", "/api/healthz", "/api/user/login", "/api/install/status", "/api/agent/install.sh", "/api/agent/register", "/api/agent/download"}
+	excludePaths := []string{"", "/api/healthz", "/api/user/login", "/api/user/login-options", "/api/user/oidc/login", "/api/user/oidc/callback", "/api/install/status", "/api/agent/install.sh", "/api/agent/register", "/api/agent/renew", "/api/agent/download", apiv2.Prefix + "/openapi.json"}
 	for _, p := range excludePaths {
 		if uri == p {
 			c.Next()
@@ -300,8 +336,19 @@ func userAuth(c *gin.Context) {
 		}
 	}
 
-	// v1 API接口使用单独的认证
-	if strings.HasPrefix(uri, "/v1") {
+	// 使用个人API令牌访问
+	if ok, err := user.RestoreApiToken(c); ok {
+		if err != nil {
+			logger.Warnf("API令牌认证失败: %v, path: %s", err, path)
+			abort(c, utils.AuthError, i18n.T(c, "auth_failed"))
+			return
+		}
+		c.Next()
+		return
+	}
+
+	// v1 API接口未使用API令牌时, 接口签名有效则不需要登录, 否则验证登录状态
+	if strings.HasPrefix(uri, urlPrefix+"/v1") && app.Setting.ApiSignEnable && apiSignError(c) == "" {
 		c.Next()
 		return
 	}
@@ -310,10 +357,7 @@ func userAuth(c *gin.Context) {
 	newToken, err := user.RestoreToken(c)
 	if err != nil {
 		logger.Warnf("token解析失败: %v, path: %s", err, path)
-		jsonResp := utils.JsonResponse{}
-		data := jsonResp.Failure(utils.AuthError, i18n.T(c, "auth_failed"))
-		c.String(http.StatusOK, data)
-		c.Abort()
+		abort(c, utils.AuthError, i18n.T(c, "auth_failed"))
 		return
 	}
 	// 如果token被刷新，返回新token给前端
@@ -322,121 +366,65 @@ func userAuth(c *gin.Context) {
 	}
 
 	if !user.IsLogin(c) {
-		jsonResp := utils.JsonResponse{}
-		data := jsonResp.Failure(utils.AuthError, i18n.T(c, "auth_failed"))
-		c.String(http.StatusOK, data)
-		c.Abort()
+		abort(c, utils.AuthError, i18n.T(c, "auth_failed"))
 		return
 	}
 
 	c.Next()
 }
 
-// URL权限验证
-func urlAuth(c *gin.Context) {
+/** API接口签名验证 **/
+func apiAuth(c *gin.Context) {
 	if !app.Installed {
 		c.Next()
 		return
 	}
-
-	path := c.Request.URL.Path
-	// 静态文件直接放行
-	if strings.HasSuffix(path, ".js") || strings.HasSuffix(path, ".css") || strings.HasSuffix(path, ".png") || strings.HasSuffix(path, ".jpg") || strings.HasSuffix(path, ".svg") {
-		c.Next()
-		return
-	}
-
-	if user.IsAdmin(c) {
+	// 已通过API令牌认证, 未启用接口签名时已验证登录状态
+	if !app.Setting.ApiSignEnable || user.ApiToken(c) != nil {
 		c.Next()
 		return
 	}
-	uri := strings.TrimRight(path, "/")
-	if strings.HasPrefix(uri, "/v1") {
-		c.Next()
+	if msg := apiSignError(c); msg != "" {
+		json := utils.JsonResponse{}
+		c.String(http.StatusOK, json.CommonFailure(i18n.T(c, msg)))
+		c.Abort()
 		return
 	}
-	// 普通用户允许访问的URL地址
-	allowPaths := []string{
-		"",
-		"/api/healthz",
-		"/api/install/status",
-		"/api/task",
-		"/api/task/log",
-		"/api/host",
-		"/api/host/all",
-		"/api/user/login",
-		"/api/user/editMyPassword",
-		"/api/user/2fa/status",
-		"/api/user/2fa/setup",
-		"/api/user/2fa/enable",
-		"/api/user/2fa/disable",
-		"/api/statistics/overview",
-		"/api/agent/install.sh",
-		"/api/agent/register",
-		"/api/agent/download",
-	}
-	for _, p := range allowPaths {
-		if p == uri {
-			c.Next()
-			return
-		}
-	}
-
-	jsonResp := utils.JsonResponse{}
-	data := jsonResp.Failure(utils.UnauthorizedError, i18n.T(c, "unauthorized"))
-	c.String(http.StatusOK, data)
-	c.Abort()
+	c.Next()
 }
 
-/** API接口签名验证 **/
-func apiAuth(c *gin.Context) {
-	if !app.Installed {
-		c.Next()
-		return
-	}
-	if !app.Setting.ApiSignEnable {
-		c.Next()
-		return
-	}
+// 校验接口签名, 返回错误信息的翻译key, 签名有效时返回空
+func apiSignError(c *gin.Context) string {
 	apiKey := strings.TrimSpace(app.Setting.ApiKey)
 	apiSecret := strings.TrimSpace(app.Setting.ApiSecret)
-	json := utils.JsonResponse{}
 	if apiKey == "" || apiSecret == "" {
-		msg := json.CommonFailure(i18n.T(c, "api_key_required"))
-		c.String(http.StatusOK, msg)
-		c.Abort()
-		return
+		return "api_key_required"
 	}
 	currentTimestamp := time.Now().Unix()
 	timeParam, err := strconv.ParseInt(c.Query("time"), 10, 64)
 	if err != nil || timeParam <= 0 {
-		msg := json.CommonFailure(i18n.T(c, "param_time_required"))
-		c.String(http.StatusOK, msg)
-		c.Abort()
-		return
+		return "param_time_required"
 	}
 	if timeParam < (currentTimestamp - 1800) {
-		msg := json.CommonFailure(i18n.T(c, "param_time_invalid"))
-		c.String(http.StatusOK, msg)
-		c.Abort()
-		return
+		return "param_time_invalid"
 	}
 	sign := strings.TrimSpace(c.Query("sign"))
 	if sign == "" {
-		msg := json.CommonFailure(i18n.T(c, "param_sign_required"))
-		c.String(http.StatusOK, msg)
-		c.Abort()
-		return
+		return "param_sign_required"
 	}
 	raw := apiKey + strconv.FormatInt(timeParam, 10) + strings.TrimSpace(c.Request.URL.Path) + apiSecret
 	realSign := utils.Sha256(raw)
 	if sign != realSign {
-		msg := json.CommonFailure(i18n.T(c, "sign_verify_failed"))
-		c.String(http.StatusOK, msg)
-		c.Abort()
-		return
+		return "sign_verify_failed"
 	}
-	c.Next()
+
+	return ""
+}
+
+// 中止请求并返回错误, v2接口返回对应的HTTP状态码
+func abort(c *gin.Context, code int, message string) {
+	base.RespondCode(c, code, message)
+	c.Abort()
 }
 
 // endregion
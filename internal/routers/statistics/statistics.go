@@ -6,6 +6,7 @@ import (
 	"github.com/tabortao/gocron/internal/modules/logger"
 	"github.com/tabortao/gocron/internal/modules/utils"
 	"github.com/tabortao/gocron/internal/routers/base"
+	"github.com/tabortao/gocron/internal/routers/user"
 )
 
 // OverviewData 概览统计数据
@@ -21,9 +22,16 @@ type OverviewData struct {
 func Overview(c *gin.Context) {
 	taskModel := models.Task{}
 	taskLogModel := models.TaskLog{}
+	// 非管理员只统计有权查看的项目
+	params := models.CommonMap{}
+	user.FilterProjects(c, params)
 
 	// 1. 获取启用的任务总数
-	totalTasks, err := taskModel.Total(models.CommonMap{"Status": int(models.Enabled)})
+	taskParams := models.CommonMap{"Status": int(models.Enabled)}
+	if projectIds, ok := params["ProjectIds"]; ok {
+		taskParams["ProjectIds"] = projectIds
+	}
+	totalTasks, err := taskModel.Total(taskParams)
 	if err != nil {
 		logger.Error("Failed to get total tasks:", err)
 		base.RespondError(c, "Failed to get total tasks", err)
@@ -31,7 +39,7 @@ func Overview(c *gin.Context) {
 	}
 
 	// 2. 获取今日统计数据
-	todayTotal, todaySuccess, todayFailed, err := taskLogModel.GetTodayStats()
+	todayTotal, todaySuccess, todayFailed, err := taskLogModel.GetTodayStats(params)
 	if err != nil {
 		logger.Error("Failed to get today's statistics:", err)
 		base.RespondError(c, "Failed to get today's statistics", err)
@@ -47,7 +55,7 @@ func Overview(c *gin.Context) {
 	}
 
 	// 4. 获取最近7天趋势
-	last7Days, err := taskLogModel.GetLast7DaysTrend()
+	last7Days, err := taskLogModel.GetLast7DaysTrend(params)
 	if err != nil {
 		logger.Error("Failed to get trend data:", err)
 		base.RespondError(c, "Failed to get trend data", err)
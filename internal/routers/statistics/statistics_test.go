@@ -0,0 +1,84 @@
+package statistics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func TestMain(m *testing.M) {
+	_ = os.MkdirAll("log", 0o755)
+	logger.InitLogger()
+	os.Exit(m.Run())
+}
+
+// 非管理员只统计所在项目的任务及日志
+func TestOverviewFiltersProjects(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{SingularTable: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.AutoMigrate(&models.Project{}, &models.ProjectMember{}, &models.Task{}, &models.TaskHost{}, &models.TaskLog{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldDb := models.Db
+	models.Db = db
+	defer func() { models.Db = oldDb }()
+
+	_ = db.Create(&models.Project{Name: "default"}).Error
+	_ = db.Create(&models.Project{Name: "other"}).Error
+	_ = db.Create(&models.ProjectMember{ProjectId: 1, UserId: 2, Role: models.RoleViewer}).Error
+	for i, projectId := range []int{1, 2, 2} {
+		task := models.Task{Id: i + 1, Name: "task", ProjectId: projectId, Status: models.Enabled}
+		if err := db.Create(&task).Error; err != nil {
+			t.Fatal(err)
+		}
+		taskLog := models.TaskLog{Id: int64(i + 1), TaskId: task.Id, Name: task.Name, Status: models.Finish}
+		if err := db.Create(&taskLog).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	overview := func(uid, isAdmin int) OverviewData {
+		gin.SetMode(gin.TestMode)
+		r := gin.New()
+		r.GET("/statistics/overview", func(c *gin.Context) {
+			c.Set("uid", uid)
+			c.Set("is_admin", isAdmin)
+			Overview(c)
+		})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/statistics/overview", nil))
+		var resp struct {
+			Data OverviewData `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid response %q", w.Body.String())
+		}
+		return resp.Data
+	}
+
+	admin := overview(1, 1)
+	if admin.TotalTasks != 3 || admin.TodayExecutions != 3 || admin.SuccessRate != 100 {
+		t.Fatalf("admin overview = %+v", admin)
+	}
+	member := overview(2, 0)
+	if member.TotalTasks != 1 || member.TodayExecutions != 1 || member.SuccessRate != 100 {
+		t.Fatalf("member overview = %+v", member)
+	}
+	if len(member.Last7Days) != 1 || member.Last7Days[0].Success != 1 {
+		t.Fatalf("member trend = %+v", member.Last7Days)
+	}
+}
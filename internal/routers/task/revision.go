@@ -0,0 +1,129 @@
+package task
+
+// 任务版本历史及回滚
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/i18n"
+	"github.com/tabortao/gocron/internal/modules/logger"
+	"github.com/tabortao/gocron/internal/modules/utils"
+	"github.com/tabortao/gocron/internal/routers/audit"
+	"github.com/tabortao/gocron/internal/routers/base"
+	"github.com/tabortao/gocron/internal/routers/user"
+	"github.com/tabortao/gocron/internal/service"
+)
+
+// Revisions 任务的版本列表
+func Revisions(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	revisions, err := new(models.TaskRevision).List(id)
+	if err != nil {
+		base.RespondErrorWithDefaultMsg(c, err)
+		return
+	}
+
+	base.RespondSuccess(c, utils.SuccessContent, revisions)
+}
+
+// RevisionDiff 比较任务的两个版本, 返回 {"字段": [from版本的值, to版本的值]}
+func RevisionDiff(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	from, _ := strconv.Atoi(c.Query("from"))
+	to, _ := strconv.Atoi(c.Query("to"))
+	fromRevision := new(models.TaskRevision)
+	toRevision := new(models.TaskRevision)
+	if !findRevision(c, fromRevision, id, from) || !findRevision(c, toRevision, id, to) {
+		return
+	}
+
+	base.RespondSuccess(c, utils.SuccessContent, map[string]interface{}{
+		"from": from,
+		"to":   to,
+		"diff": models.DiffFields(fromRevision.Fields(), toRevision.Fields()),
+	})
+}
+
+// Rollback 恢复任务到指定版本的配置并重新调度, 恢复后生成新版本
+// 回滚不改变任务所属项目, 版本的配置需通过与保存任务相同的校验
+func Rollback(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	version, _ := strconv.Atoi(c.PostForm("version"))
+	revision := new(models.TaskRevision)
+	if !findRevision(c, revision, id, version) {
+		return
+	}
+	before, err := new(models.Task).Detail(id)
+	if err != nil || before.Id == 0 {
+		base.RespondError(c, i18n.T(c, "get_task_detail_failed"), err)
+		return
+	}
+	restored, hostIds, err := revision.Snapshot()
+	if err != nil {
+		base.RespondErrorWithDefaultMsg(c, err)
+		return
+	}
+	restored.ProjectId = before.ProjectId
+	if err = validateTask(c, id, &restored, hostIds); err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	if _, err = restored.UpdateBean(id); err != nil {
+		base.RespondError(c, i18n.T(c, "save_failed"), err)
+		return
+	}
+	taskHostModel := new(models.TaskHost)
+	if restored.Protocol == models.TaskRPC {
+		err = taskHostModel.Add(id, hostIds)
+	} else {
+		err = taskHostModel.Remove(id)
+	}
+	if err != nil {
+		base.RespondError(c, i18n.T(c, "save_failed"), err)
+		return
+	}
+
+	after, err := new(models.Task).Detail(id)
+	if err != nil {
+		base.RespondError(c, i18n.T(c, "get_task_detail_failed"), err)
+		return
+	}
+	after.Revision = saveRevision(c, after, version)
+	// 恢复的版本不再是启用的主任务时, 从调度中移除
+	if after.Status == models.Enabled && after.Level == models.TaskLevelParent {
+		service.ServiceTask.RemoveAndAdd(after)
+	} else {
+		service.ServiceTask.Remove(id)
+	}
+	audit.Record(c, models.AuditActionRollback, models.AuditResourceTask, id, after.Name, before, after)
+
+	base.RespondSuccess(c, i18n.T(c, "task_rollback_success"), map[string]int{"revision": after.Revision})
+}
+
+// 保存任务当前配置为新版本, 返回当前版本号, 回滚时source为恢复的版本号
+func saveRevision(c *gin.Context, task models.Task, source int) int {
+	revision := &models.TaskRevision{
+		Source:   source,
+		UserId:   user.Uid(c),
+		Username: user.Username(c),
+	}
+	version, err := revision.Create(task)
+	if err != nil {
+		logger.Error("保存任务版本失败", err)
+		return task.Revision
+	}
+
+	return version
+}
+
+func findRevision(c *gin.Context, revision *models.TaskRevision, taskId, version int) bool {
+	if err := revision.Find(taskId, version); err != nil || revision.Id == 0 {
+		base.RespondError(c, i18n.T(c, "task_revision_not_found"), err)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,107 @@
+package task
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/logger"
+	"github.com/tabortao/gocron/internal/modules/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func TestMain(m *testing.M) {
+	_ = os.MkdirAll("log", 0o755)
+	logger.InitLogger()
+	os.Exit(m.Run())
+}
+
+// 回滚的版本需通过与保存任务相同的校验, 校验失败时不修改任务
+func TestRollbackRejectsInvalidSnapshot(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{SingularTable: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.AutoMigrate(&models.Project{}, &models.Task{}, &models.TaskHost{}, &models.TaskRevision{},
+		&models.Host{}, &models.Setting{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldDb := models.Db
+	models.Db = db
+	defer func() { models.Db = oldDb }()
+
+	_ = db.Create(&models.Project{Name: "default"}).Error
+	current := models.Task{
+		Id: 1, Name: "backup", ProjectId: 1, Level: models.TaskLevelParent, Spec: "0 0 * * * *",
+		Protocol: models.TaskHTTP, Command: "https://example.com", DependencyStatus: models.TaskDependencyStatusStrong,
+		Status: models.Enabled,
+	}
+	other := models.Task{Id: 2, Name: "cleanup", ProjectId: 1, Level: models.TaskLevelParent, Spec: "0 0 * * * *",
+		Protocol: models.TaskHTTP, Command: "https://example.com", Status: models.Enabled}
+	for _, task := range []models.Task{current, other} {
+		if err := db.Create(&task).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(task *models.Task)
+	}{
+		{"invalid spec", func(task *models.Task) { task.Spec = "invalid" }},
+		{"duplicate name", func(task *models.Task) { task.Name = other.Name }},
+		{"invalid retry", func(task *models.Task) { task.RetryOn = "unknown" }},
+		{"signed schedule variable", func(task *models.Task) {
+			task.Protocol = models.TaskRPC
+			task.HostGroup = "web"
+			task.Command = "backup.sh " + "${GOCRON_SCHEDULE_DATE}"
+			task.CommandSignature = "signature"
+		}},
+	}
+	for _, tt := range tests {
+		snapshot := current
+		tt.modify(&snapshot)
+		revision := &models.TaskRevision{}
+		version, err := revision.Create(snapshot)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		gin.SetMode(gin.TestMode)
+		r := gin.New()
+		r.POST("/task/rollback/:id", func(c *gin.Context) {
+			c.Set("uid", 1)
+			c.Set("is_admin", 1)
+			Rollback(c)
+		})
+		form := url.Values{"version": {strconv.Itoa(version)}}
+		req := httptest.NewRequest(http.MethodPost, "/task/rollback/1", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var resp struct {
+			Code int `json:"code"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp.Code != utils.ResponseFailure {
+			t.Errorf("%s: expected rollback to be rejected, got %s", tt.name, w.Body.String())
+		}
+
+		saved, _ := new(models.Task).Detail(current.Id)
+		if saved.Name != current.Name || saved.Spec != current.Spec || saved.Command != current.Command {
+			t.Errorf("%s: task should not be modified, got %+v", tt.name, saved)
+		}
+	}
+}
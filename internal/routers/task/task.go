@@ -1,9 +1,11 @@
 package task
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gocronx-team/cron"
@@ -11,7 +13,9 @@ import (
 	"github.com/tabortao/gocron/internal/modules/i18n"
 	"github.com/tabortao/gocron/internal/modules/logger"
 	"github.com/tabortao/gocron/internal/modules/utils"
+	"github.com/tabortao/gocron/internal/routers/audit"
 	"github.com/tabortao/gocron/internal/routers/base"
+	"github.com/tabortao/gocron/internal/routers/user"
 	"github.com/tabortao/gocron/internal/service"
 )
 
@@ -21,15 +25,30 @@ type TaskForm struct {
 	DependencyStatus models.TaskDependencyStatus `form:"dependency_status" json:"dependency_status" binding:"oneof=1 2"`
 	DependencyTaskId string                      `form:"dependency_task_id" json:"dependency_task_id"`
 	Name             string                      `form:"name" json:"name" binding:"required,max=32"`
+	ProjectId        int                         `form:"project_id" json:"project_id"`
 	Spec             string                      `form:"spec" json:"spec"`
 	Protocol         models.TaskProtocol         `form:"protocol" json:"protocol" binding:"oneof=1 2"`
 	Command          string                      `form:"command" json:"command" binding:"required,max=65535"`
+	CommandSignature string                      `form:"command_signature" json:"command_signature" binding:"max=128"`
 	HttpMethod       models.TaskHTTPMethod       `form:"http_method" json:"http_method" binding:"oneof=1 2"`
 	Timeout          int                         `form:"timeout" json:"timeout" binding:"min=0,max=86400"`
 	Multi            int8                        `form:"multi" json:"multi" binding:"oneof=0 1"`
+	Detached         int8                        `form:"detached" json:"detached" binding:"oneof=0 1"`
+	HostSelection    models.TaskHostSelection    `form:"host_selection" json:"host_selection" binding:"oneof=0 1 2 3 4"`
+	BatchSize        int                         `form:"batch_size" json:"batch_size" binding:"min=0,max=10000"`
+	BatchUnit        models.TaskBatchUnit        `form:"batch_unit" json:"batch_unit" binding:"oneof=0 1"`
+	BatchInterval    int                         `form:"batch_interval" json:"batch_interval" binding:"min=0,max=86400"`
+	MaxFailures      int                         `form:"max_failures" json:"max_failures" binding:"min=0,max=10000"`
 	RetryTimes       int8                        `form:"retry_times" json:"retry_times"`
 	RetryInterval    int16                       `form:"retry_interval" json:"retry_interval"`
+	RetryStrategy    models.TaskRetryStrategy    `form:"retry_strategy" json:"retry_strategy" binding:"oneof=0 1 2 3"`
+	RetryMaxInterval int                         `form:"retry_max_interval" json:"retry_max_interval" binding:"min=0,max=86400"`
+	RetryJitter      int8                        `form:"retry_jitter" json:"retry_jitter" binding:"min=0,max=100"`
+	RetryOn          string                      `form:"retry_on" json:"retry_on"`
+	RetryExitCodes   string                      `form:"retry_exit_codes" json:"retry_exit_codes"`
 	HostId           string                      `form:"host_id" json:"host_id"`
+	HostGroup        string                      `form:"host_group" json:"host_group" binding:"max=64"`
+	HostSelector     string                      `form:"host_selector" json:"host_selector" binding:"max=256"`
 	Tag              string                      `form:"tag" json:"tag"`
 	Remark           string                      `form:"remark" json:"remark"`
 	NotifyStatus     int8                        `form:"notify_status" json:"notify_status" binding:"oneof=0 1 2 3"`
@@ -84,25 +103,42 @@ func Store(c *gin.Context) {
 		base.RespondValidationError(c, err)
 		return
 	}
-
-	taskModel := models.Task{}
-	var id = form.Id
-	nameExists, err := taskModel.NameExist(form.Name, form.Id)
-	if err != nil {
-		base.RespondErrorWithDefaultMsg(c, err)
-		return
-	}
-	if nameExists {
-		base.RespondError(c, i18n.T(c, "task_name_exists"))
+	if _, err := Save(c, form); err != nil {
+		base.RespondFailure(c, err)
 		return
 	}
 
-	if form.Protocol == models.TaskRPC && form.HostId == "" {
-		base.RespondError(c, i18n.T(c, "select_hostname"))
-		return
+	base.RespondSuccess(c, i18n.T(c, "save_success"), nil)
+}
+
+// Save 校验并保存任务, form.Id为0时新增, 返回保存后的任务
+func Save(c *gin.Context, form TaskForm) (models.Task, error) {
+	// 未指定项目时归属默认项目, 兼容旧版本的调用
+	if form.ProjectId <= 0 {
+		form.ProjectId = models.DefaultProjectId
+	}
+	if !user.Can(c, form.ProjectId, models.ActionEdit) {
+		return models.Task{}, errUnauthorized(c)
+	}
+	projectModel := new(models.Project)
+	if err := projectModel.Find(form.ProjectId); err != nil || projectModel.Id == 0 {
+		return models.Task{}, base.NewError(http.StatusBadRequest, i18n.T(c, "project_not_exist"), err)
+	}
+	if form.Id > 0 {
+		// 移动到其他项目时需同时拥有原项目的编辑权限
+		oldProjectId, err := models.TaskProjectId(form.Id)
+		if err != nil || oldProjectId == 0 {
+			return models.Task{}, base.NewError(http.StatusNotFound, i18n.T(c, "get_task_detail_failed"), err)
+		}
+		if !user.Can(c, oldProjectId, models.ActionEdit) {
+			return models.Task{}, errUnauthorized(c)
+		}
 	}
 
+	taskModel := models.Task{}
+	var id = form.Id
 	taskModel.Name = form.Name
+	taskModel.ProjectId = form.ProjectId
 	taskModel.Protocol = form.Protocol
 	// 清理命令中的 HTML 实体编码
 	originalCmd := strings.TrimSpace(form.Command)
@@ -111,17 +147,30 @@ func Store(c *gin.Context) {
 		logger.Infof("[HTML Entity Cleaned] Task: %s, Original length: %d, Cleaned length: %d", form.Name, len(originalCmd), len(cleanedCmd))
 	}
 	taskModel.Command = cleanedCmd
+	taskModel.CommandSignature = strings.TrimSpace(form.CommandSignature)
+	taskModel.Detached = form.Detached
+	taskModel.HostGroup = strings.TrimSpace(form.HostGroup)
+	taskModel.HostSelector = form.HostSelector
 	taskModel.Timeout = form.Timeout
 	taskModel.Tag = form.Tag
 	taskModel.Remark = form.Remark
 	taskModel.Multi = form.Multi
+	taskModel.HostSelection = form.HostSelection
+	taskModel.BatchSize = form.BatchSize
+	taskModel.BatchUnit = form.BatchUnit
+	taskModel.BatchInterval = form.BatchInterval
+	taskModel.MaxFailures = form.MaxFailures
 	taskModel.RetryTimes = form.RetryTimes
 	taskModel.RetryInterval = form.RetryInterval
+	taskModel.RetryStrategy = form.RetryStrategy
+	taskModel.RetryMaxInterval = form.RetryMaxInterval
+	taskModel.RetryJitter = form.RetryJitter
+	taskModel.RetryOn = form.RetryOn
+	taskModel.RetryExitCodes = form.RetryExitCodes
 	taskModel.NotifyStatus = form.NotifyStatus
 	notifyTypeMask, err := models.NormalizeNotifyTypeMask(form.NotifyType)
 	if err != nil {
-		base.RespondError(c, i18n.T(c, "param_error"))
-		return
+		return models.Task{}, base.NewError(http.StatusBadRequest, i18n.T(c, "param_error"))
 	}
 	taskModel.NotifyType = notifyTypeMask
 	taskModel.NotifyReceiverId = form.NotifyReceiverId
@@ -130,49 +179,158 @@ func Store(c *gin.Context) {
 	taskModel.Level = form.Level
 	taskModel.DependencyStatus = form.DependencyStatus
 	taskModel.DependencyTaskId = strings.TrimSpace(form.DependencyTaskId)
-	if taskModel.NotifyStatus > 0 {
-		receiverId := strings.TrimSpace(taskModel.NotifyReceiverId)
-		if taskModel.NotifyType&(models.NotifyTypeMailMask|models.NotifyTypeSlackMask) != 0 && receiverId == "" {
-			base.RespondError(c, i18n.T(c, "select_at_least_one_receiver"))
-			return
+	taskModel.HttpMethod = form.HttpMethod
+
+	hostIds := parseIds(form.HostId)
+	if err = validateTask(c, id, &taskModel, hostIds); err != nil {
+		return models.Task{}, err
+	}
+
+	var before *models.Task
+	if id > 0 {
+		oldTask, err := new(models.Task).Detail(id)
+		if err == nil && oldTask.Id > 0 {
+			before = &oldTask
 		}
 	}
-	taskModel.HttpMethod = form.HttpMethod
-	if taskModel.Protocol == models.TaskHTTP {
+	if id == 0 {
+		taskModel.Status = models.Running
+		taskModel.CreatedBy = user.Uid(c)
+		logger.Infof("[Task Create] Before Create - Multi: %d", taskModel.Multi)
+		id, err = taskModel.Create()
+		if err == nil {
+			// 立即读取验证
+			verifyTask, _ := taskModel.Detail(id)
+			logger.Infof("[Task Create] After Create - ID: %d, Multi in DB: %d", id, verifyTask.Multi)
+		}
+	} else {
+		logger.Infof("[Task Update] Before Update - ID: %d, Multi: %d", id, taskModel.Multi)
+		_, err = taskModel.UpdateBean(id)
+		if err == nil {
+			// 立即读取验证
+			verifyTask, _ := taskModel.Detail(id)
+			logger.Infof("[Task Update] After Update - ID: %d, Multi in DB: %d", id, verifyTask.Multi)
+		}
+	}
+
+	if err != nil {
+		return models.Task{}, base.NewError(http.StatusInternalServerError, i18n.T(c, "save_failed"), err)
+	}
+
+	taskHostModel := new(models.TaskHost)
+	if form.Protocol == models.TaskRPC {
+		_ = taskHostModel.Add(id, hostIds)
+	} else {
+		_ = taskHostModel.Remove(id)
+	}
+
+	// 升级前没有版本的任务, 先保存修改前的配置作为第一个版本
+	if before != nil && before.Revision == 0 {
+		if _, err = new(models.TaskRevision).Create(*before); err != nil {
+			logger.Error("保存任务版本失败", err)
+		}
+	}
+	after, _ := new(models.Task).Detail(id)
+	after.Revision = saveRevision(c, after, 0)
+
+	status, _ := taskModel.GetStatus(id)
+	if status == models.Enabled && taskModel.Level == models.TaskLevelParent {
+		addTaskToTimer(id)
+	}
+
+	if before == nil {
+		audit.Record(c, models.AuditActionCreate, models.AuditResourceTask, id, after.Name, nil, after)
+	} else {
+		audit.Record(c, models.AuditActionUpdate, models.AuditResourceTask, id, after.Name, before, after)
+	}
+
+	return after, nil
+}
+
+// 校验并规范化任务配置, 保存及回滚时调用, id为0时表示新增
+func validateTask(c *gin.Context, id int, taskModel *models.Task, hostIds []int) error {
+	nameExists, err := taskModel.NameExist(taskModel.Name, id)
+	if err != nil {
+		return err
+	}
+	if nameExists {
+		return base.NewError(http.StatusBadRequest, i18n.T(c, "task_name_exists"))
+	}
+
+	switch taskModel.Protocol {
+	case models.TaskRPC:
+		if !models.ValidHostGroup(taskModel.HostGroup) {
+			return base.NewError(http.StatusBadRequest, i18n.T(c, "host_group_invalid"))
+		}
+		taskModel.HostSelector, err = models.NormalizeLabelSelector(taskModel.HostSelector)
+		if err != nil {
+			return base.NewError(http.StatusBadRequest, i18n.T(c, "host_selector_invalid"))
+		}
+		if len(hostIds) == 0 && !taskModel.HasHostTarget() {
+			return base.NewError(http.StatusBadRequest, i18n.T(c, "select_hostname"))
+		}
+		// 调度变量在服务端替换, 替换后的命令与签名不一致
+		if taskModel.CommandSignature != "" && service.HasScheduleVariables(taskModel.Command) {
+			return base.NewError(http.StatusBadRequest, i18n.T(c, "command_signature_schedule_var"))
+		}
+	case models.TaskHTTP:
+		taskModel.HostGroup = ""
+		taskModel.HostSelector = ""
+		taskModel.CommandSignature = ""
+		taskModel.Detached = 0
 		command := strings.ToLower(taskModel.Command)
 		if !strings.HasPrefix(command, "http://") && !strings.HasPrefix(command, "https://") {
-			base.RespondError(c, i18n.T(c, "invalid_url"))
-			return
+			return base.NewError(http.StatusBadRequest, i18n.T(c, "invalid_url"))
 		}
 		if taskModel.Timeout > 300 {
-			base.RespondError(c, i18n.T(c, "http_task_timeout_max_300"))
-			return
+			return base.NewError(http.StatusBadRequest, i18n.T(c, "http_task_timeout_max_300"))
 		}
+	default:
+		return base.NewError(http.StatusBadRequest, i18n.T(c, "param_error"))
+	}
+
+	if taskModel.NotifyStatus > 0 {
+		receiverId := strings.TrimSpace(taskModel.NotifyReceiverId)
+		if taskModel.NotifyType&(models.NotifyTypeMailMask|models.NotifyTypeSlackMask) != 0 && receiverId == "" {
+			return base.NewError(http.StatusBadRequest, i18n.T(c, "select_at_least_one_receiver"))
+		}
+	}
+
+	if taskModel.BatchUnit == models.BatchUnitPercent && taskModel.BatchSize > 100 {
+		return base.NewError(http.StatusBadRequest, i18n.T(c, "batch_percent_range_0_100"))
 	}
 
 	if taskModel.RetryTimes > 10 || taskModel.RetryTimes < 0 {
-		base.RespondError(c, i18n.T(c, "retry_times_range_0_10"))
-		return
+		return base.NewError(http.StatusBadRequest, i18n.T(c, "retry_times_range_0_10"))
 	}
 
 	if taskModel.RetryInterval > 3600 || taskModel.RetryInterval < 0 {
-		base.RespondError(c, i18n.T(c, "retry_interval_range_0_3600"))
-		return
+		return base.NewError(http.StatusBadRequest, i18n.T(c, "retry_interval_range_0_3600"))
+	}
+
+	taskModel.RetryOn, err = service.ParseRetryOn(taskModel.RetryOn)
+	if err != nil {
+		return base.NewError(http.StatusBadRequest, i18n.T(c, "retry_on_invalid"))
+	}
+	taskModel.RetryExitCodes, err = service.ParseRetryExitCodes(taskModel.RetryExitCodes)
+	if err != nil {
+		return base.NewError(http.StatusBadRequest, i18n.T(c, "retry_exit_codes_invalid"))
+	}
+	if taskModel.RetryExitCodes == "" && strings.Contains(taskModel.RetryOn, models.RetryOnExitCode) {
+		return base.NewError(http.StatusBadRequest, i18n.T(c, "retry_exit_codes_required"))
 	}
 
 	if taskModel.DependencyStatus != models.TaskDependencyStatusStrong &&
 		taskModel.DependencyStatus != models.TaskDependencyStatusWeak {
-		base.RespondError(c, i18n.T(c, "select_dependency"))
-		return
+		return base.NewError(http.StatusBadRequest, i18n.T(c, "select_dependency"))
 	}
 
 	if taskModel.Level == models.TaskLevelParent {
 		err = utils.PanicToError(func() {
-			cron.Parse(form.Spec)
+			cron.Parse(taskModel.Spec)
 		})
 		if err != nil {
-			base.RespondError(c, i18n.T(c, "crontab_parse_failed"), err)
-			return
+			return base.NewError(http.StatusBadRequest, i18n.T(c, "crontab_parse_failed"), err)
 		}
 	} else {
 		taskModel.DependencyTaskId = ""
@@ -182,68 +340,126 @@ func Store(c *gin.Context) {
 	if id > 0 && taskModel.DependencyTaskId != "" {
 		dependencyTaskIds := strings.Split(taskModel.DependencyTaskId, ",")
 		if utils.InStringSlice(dependencyTaskIds, strconv.Itoa(id)) {
-			base.RespondError(c, i18n.T(c, "cannot_set_self_as_child"))
-			return
+			return base.NewError(http.StatusBadRequest, i18n.T(c, "cannot_set_self_as_child"))
 		}
 	}
 
-	if id == 0 {
-		taskModel.Status = models.Running
-		logger.Infof("[Task Create] Before Create - Multi: %d", taskModel.Multi)
-		id, err = taskModel.Create()
-		if err == nil {
-			// 立即读取验证
-			verifyTask, _ := taskModel.Detail(id)
-			logger.Infof("[Task Create] After Create - ID: %d, Multi in DB: %d", id, verifyTask.Multi)
+	return checkProjectScope(c, taskModel, hostIds)
+}
+
+// 主机、通知接收者及子任务需与任务属于同一项目
+func checkProjectScope(c *gin.Context, task *models.Task, hostIds []int) error {
+	if task.Protocol == models.TaskRPC {
+		inProject, err := models.HostsInProject(hostIds, task.ProjectId)
+		if err != nil {
+			return err
 		}
-	} else {
-		logger.Infof("[Task Update] Before Update - ID: %d, Multi: %d", id, taskModel.Multi)
-		_, err = taskModel.UpdateBean(id)
-		if err == nil {
-			// 立即读取验证
-			verifyTask, _ := taskModel.Detail(id)
-			logger.Infof("[Task Update] After Update - ID: %d, Multi in DB: %d", id, verifyTask.Multi)
+		if !inProject {
+			return base.NewError(http.StatusBadRequest, i18n.T(c, "host_not_in_project"))
+		}
+	}
+	if task.NotifyStatus > 0 {
+		inProject, err := new(models.Setting).ReceiversInProject(receiverIds(task.NotifyReceiverId), task.ProjectId)
+		if err != nil {
+			return err
+		}
+		if !inProject {
+			return base.NewError(http.StatusBadRequest, i18n.T(c, "notify_receiver_not_in_project"))
 		}
 	}
 
+	return checkDependencyProjects(c, task.ProjectId, parseIds(task.DependencyTaskId))
+}
+
+// NotifyReceivers 任务所属项目可选的通知接收者, 包括共享的接收者
+func NotifyReceivers(c *gin.Context) {
+	projectId, _ := strconv.Atoi(c.Query("project_id"))
+	receivers, err := new(models.Setting).NotifyReceivers(projectId)
 	if err != nil {
-		base.RespondError(c, i18n.T(c, "save_failed"), err)
+		base.RespondErrorWithDefaultMsg(c, err)
 		return
 	}
 
-	taskHostModel := new(models.TaskHost)
-	if form.Protocol == models.TaskRPC {
-		hostIdStrList := strings.Split(form.HostId, ",")
-		hostIds := make([]int, len(hostIdStrList))
-		for i, hostIdStr := range hostIdStrList {
-			hostIds[i], _ = strconv.Atoi(hostIdStr)
+	base.RespondSuccess(c, utils.SuccessContent, receivers)
+}
+
+// 跨项目依赖需父子任务所在项目都允许, 且当前用户可执行子任务
+func checkDependencyProjects(c *gin.Context, projectId int, childIds []int) error {
+	projectIds, err := models.TaskProjectIds(childIds)
+	if err != nil {
+		return err
+	}
+	for _, childProjectId := range projectIds {
+		if childProjectId == projectId {
+			continue
+		}
+		allowed, err := models.CrossDependencyAllowed(projectId, childProjectId)
+		if err != nil {
+			return err
+		}
+		if !allowed || !user.Can(c, childProjectId, models.ActionRun) {
+			return base.NewError(http.StatusBadRequest, i18n.T(c, "cross_project_dependency_forbidden"))
 		}
-		_ = taskHostModel.Add(id, hostIds)
-	} else {
-		_ = taskHostModel.Remove(id)
 	}
 
-	status, _ := taskModel.GetStatus(id)
-	if status == models.Enabled && taskModel.Level == models.TaskLevelParent {
-		addTaskToTimer(id)
+	return nil
+}
+
+// 解析逗号分隔的ID, 忽略无效及重复的ID
+func parseIds(value string) []int {
+	ids := make([]int, 0)
+	seen := make(map[int]bool)
+	for _, item := range strings.Split(value, ",") {
+		id, _ := strconv.Atoi(strings.TrimSpace(item))
+		if id <= 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
 	}
 
-	base.RespondSuccess(c, i18n.T(c, "save_success"), nil)
+	return ids
+}
+
+// 解析通知接收者ID, 格式为 m:1,w:2 或旧版本的 1,2, 选择全部(w:*)时在发送时按项目过滤
+func receiverIds(value string) []int {
+	items := strings.Split(value, ",")
+	for i, item := range items {
+		if pos := strings.Index(item, ":"); pos >= 0 {
+			items[i] = item[pos+1:]
+		}
+	}
+
+	return parseIds(strings.Join(items, ","))
 }
 
 // 删除任务
 func Remove(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
+	if err := Delete(c, id); err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondSuccessWithDefaultMsg(c, nil)
+}
+
+// Delete 删除任务及关联的主机、版本记录, 并从定时器中移除
+func Delete(c *gin.Context, id int) error {
 	taskModel := new(models.Task)
-	_, err := taskModel.Delete(id)
-	if err != nil {
-		base.RespondErrorWithDefaultMsg(c, err)
-	} else {
-		taskHostModel := new(models.TaskHost)
-		_ = taskHostModel.Remove(id)
-		service.ServiceTask.Remove(id)
-		base.RespondSuccessWithDefaultMsg(c, nil)
+	before, err := taskModel.Detail(id)
+	if err != nil || before.Id == 0 {
+		return base.NewError(http.StatusNotFound, i18n.T(c, "get_task_detail_failed"), err)
 	}
+	if _, err = taskModel.Delete(id); err != nil {
+		return err
+	}
+	_ = new(models.TaskHost).Remove(id)
+	_ = new(models.TaskRevision).Remove(id)
+	service.ServiceTask.Remove(id)
+	audit.Record(c, models.AuditActionDelete, models.AuditResourceTask, id, before.Name, before, nil)
+
+	return nil
 }
 
 // 激活任务
@@ -258,16 +474,107 @@ func Disable(c *gin.Context) {
 
 // 手动运行任务
 func Run(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if _, err := Start(c, id); err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondSuccess(c, i18n.T(c, "task_started_check_log"), nil)
+}
+
+// Start 手动运行任务, 返回本次执行的任务日志ID
+func Start(c *gin.Context, id int) (int64, error) {
+	task, err := new(models.Task).Detail(id)
+	if err != nil || task.Id <= 0 {
+		return 0, base.NewError(http.StatusNotFound, i18n.T(c, "get_task_detail_failed"), err)
+	}
+	audit.Record(c, models.AuditActionRun, models.AuditResourceTask, id, task.Name, nil, nil)
+	task.Spec = i18n.T(c, "manual_run")
+
+	return service.ServiceTask.Start(task)
+}
+
+type BackfillForm struct {
+	Start       string `form:"start" json:"start" binding:"required"`
+	End         string `form:"end" json:"end" binding:"required"`
+	Concurrency int    `form:"concurrency" json:"concurrency" binding:"min=0,max=10"`
+	DryRun      bool   `form:"dry_run" json:"dry_run"`
+}
+
+// Backfill 按cron表达式补跑历史时间范围内的任务
+func Backfill(c *gin.Context) {
+	var form BackfillForm
+	if err := c.ShouldBind(&form); err != nil {
+		base.RespondValidationError(c, err)
+		return
+	}
+	start, err := time.ParseInLocation(models.DefaultTimeFormat, strings.TrimSpace(form.Start), time.Local)
+	if err != nil {
+		base.RespondError(c, i18n.T(c, "backfill_time_invalid"))
+		return
+	}
+	end, err := time.ParseInLocation(models.DefaultTimeFormat, strings.TrimSpace(form.End), time.Local)
+	if err != nil {
+		base.RespondError(c, i18n.T(c, "backfill_time_invalid"))
+		return
+	}
+
 	id, _ := strconv.Atoi(c.Param("id"))
 	taskModel := new(models.Task)
 	task, err := taskModel.Detail(id)
 	if err != nil || task.Id <= 0 {
 		base.RespondError(c, i18n.T(c, "get_task_detail_failed"), err)
-	} else {
-		task.Spec = i18n.T(c, "manual_run")
-		service.ServiceTask.Run(task)
-		base.RespondSuccess(c, i18n.T(c, "task_started_check_log"), nil)
+		return
+	}
+	if task.Level != models.TaskLevelParent {
+		base.RespondError(c, i18n.T(c, "backfill_child_task_unsupported"))
+		return
 	}
+
+	times, err := service.BackfillTimes(task.Spec, start, end)
+	switch {
+	case errors.Is(err, service.ErrBackfillInvalidRange):
+		base.RespondError(c, i18n.T(c, "backfill_range_invalid"))
+		return
+	case errors.Is(err, service.ErrBackfillTooManyRuns):
+		base.RespondError(c, i18n.T(c, "backfill_too_many_runs"))
+		return
+	case errors.Is(err, service.ErrBackfillUnsupported):
+		base.RespondError(c, i18n.T(c, "backfill_reboot_unsupported"))
+		return
+	case err != nil:
+		base.RespondError(c, i18n.T(c, "crontab_parse_failed"), err)
+		return
+	}
+	if len(times) == 0 {
+		base.RespondError(c, i18n.T(c, "backfill_no_runs"))
+		return
+	}
+
+	scheduleTimes := make([]string, len(times))
+	for i, t := range times {
+		scheduleTimes[i] = t.Format(models.DefaultTimeFormat)
+	}
+	data := map[string]interface{}{
+		"total": len(times),
+		"times": scheduleTimes,
+	}
+	if form.DryRun {
+		base.RespondSuccessWithDefaultMsg(c, data)
+		return
+	}
+
+	if err = service.ServiceTask.StartBackfill(task, times, form.Concurrency); err != nil {
+		base.RespondError(c, i18n.T(c, "backfill_running"))
+		return
+	}
+	audit.Record(c, models.AuditActionRun, models.AuditResourceTask, id, task.Name, nil, map[string]interface{}{
+		"backfill_start": form.Start,
+		"backfill_end":   form.End,
+		"backfill_runs":  len(times),
+	})
+	base.RespondSuccess(c, i18n.T(c, "backfill_started"), data)
 }
 
 // 批量启用任务
@@ -290,19 +597,10 @@ func batchChangeStatus(c *gin.Context, status models.Status) {
 		return
 	}
 
-	taskModel := new(models.Task)
 	successCount := 0
 	for _, id := range form.Ids {
-		_, err := taskModel.Update(id, models.CommonMap{
-			"status": status,
-		})
-		if err == nil {
+		if canTask(c, id, models.ActionRun) && SetStatus(c, id, status) == nil {
 			successCount++
-			if status == models.Enabled {
-				addTaskToTimer(id)
-			} else {
-				service.ServiceTask.Remove(id)
-			}
 		}
 	}
 
@@ -322,15 +620,10 @@ func BatchRemove(c *gin.Context) {
 		return
 	}
 
-	taskModel := new(models.Task)
-	taskHostModel := new(models.TaskHost)
 	successCount := 0
 	for _, id := range form.Ids {
-		_, err := taskModel.Delete(id)
-		if err == nil {
+		if canTask(c, id, models.ActionEdit) && Delete(c, id) == nil {
 			successCount++
-			_ = taskHostModel.Remove(id)
-			service.ServiceTask.Remove(id)
 		}
 	}
 
@@ -343,20 +636,49 @@ func BatchRemove(c *gin.Context) {
 // 改变任务状态
 func changeStatus(c *gin.Context, status models.Status) {
 	id, _ := strconv.Atoi(c.Param("id"))
+	if err := SetStatus(c, id, status); err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondSuccessWithDefaultMsg(c, nil)
+}
+
+// SetStatus 启用或禁用任务, 同时更新定时器并记录审计日志
+func SetStatus(c *gin.Context, id int, status models.Status) error {
 	taskModel := new(models.Task)
-	_, err := taskModel.Update(id, models.CommonMap{
-		"status": status,
-	})
-	if err != nil {
-		base.RespondErrorWithDefaultMsg(c, err)
+	task, err := taskModel.Detail(id)
+	if err != nil || task.Id == 0 {
+		return base.NewError(http.StatusNotFound, i18n.T(c, "get_task_detail_failed"), err)
+	}
+	if _, err = taskModel.Update(id, models.CommonMap{"status": status}); err != nil {
+		return err
+	}
+	action := models.AuditActionDisable
+	if status == models.Enabled {
+		addTaskToTimer(id)
+		action = models.AuditActionEnable
 	} else {
-		if status == models.Enabled {
-			addTaskToTimer(id)
-		} else {
-			service.ServiceTask.Remove(id)
-		}
-		base.RespondSuccessWithDefaultMsg(c, nil)
+		service.ServiceTask.Remove(id)
+	}
+	audit.Record(c, action, models.AuditResourceTask, id, task.Name, nil, nil)
+
+	return nil
+}
+
+func errUnauthorized(c *gin.Context) error {
+	return base.NewError(http.StatusForbidden, i18n.T(c, "unauthorized"))
+}
+
+// 当前用户对任务是否拥有操作权限, 批量操作时跳过无权限的任务
+func canTask(c *gin.Context, id int, action models.Action) bool {
+	projectId, err := models.TaskProjectId(id)
+	if err != nil {
+		logger.Error(err)
+		return false
 	}
+
+	return user.Can(c, projectId, action)
 }
 
 // 添加任务到定时器
@@ -383,6 +705,9 @@ func parseQueryParams(c *gin.Context) models.CommonMap {
 	params["Name"] = strings.TrimSpace(c.Query("name"))
 	params["Protocol"] = protocol
 	params["Tag"] = strings.TrimSpace(c.Query("tag"))
+	params["ProjectId"], _ = strconv.Atoi(c.Query("project_id"))
+	params["CreatedBy"], _ = strconv.Atoi(c.Query("created_by"))
+	user.FilterProjects(c, params)
 	if status >= 0 {
 		status -= 1
 	}
@@ -4,6 +4,7 @@ package tasklog
 
 import (
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
 
@@ -13,7 +14,9 @@ import (
 	"github.com/tabortao/gocron/internal/modules/logger"
 	rpcClient "github.com/tabortao/gocron/internal/modules/rpc/client"
 	"github.com/tabortao/gocron/internal/modules/utils"
+	"github.com/tabortao/gocron/internal/routers/audit"
 	"github.com/tabortao/gocron/internal/routers/base"
+	"github.com/tabortao/gocron/internal/routers/user"
 	"github.com/tabortao/gocron/internal/service"
 )
 
@@ -37,10 +40,13 @@ func Index(c *gin.Context) {
 // 清空日志
 func Clear(c *gin.Context) {
 	taskLogModel := new(models.TaskLog)
-	_, err := taskLogModel.Clear()
+	count, err := taskLogModel.Clear()
 	if err != nil {
 		base.RespondErrorWithDefaultMsg(c, err)
 	} else {
+		audit.Record(c, models.AuditActionClear, models.AuditResourceTaskLog, 0, "", nil, map[string]interface{}{
+			"deleted_rows": count,
+		})
 		base.RespondSuccessWithDefaultMsg(c, nil)
 	}
 }
@@ -51,35 +57,40 @@ func Output(c *gin.Context) {
 		base.RespondError(c, i18n.T(c, "invalid_log_id"))
 		return
 	}
+	output, status, err := Tail(c, logId)
+	if err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondSuccess(c, utils.SuccessContent, map[string]interface{}{
+		"output": output,
+		"status": status,
+	})
+}
 
+// Tail 读取日志输出, 运行中的Shell任务从各节点实时读取
+func Tail(c *gin.Context, logId int64) (string, models.Status, error) {
 	taskLogModel := new(models.TaskLog)
 	taskLog, err := taskLogModel.Detail(logId)
 	if err != nil {
-		base.RespondErrorWithDefaultMsg(c, err)
-		return
+		return "", 0, err
 	}
 	if taskLog.Id <= 0 {
-		base.RespondError(c, i18n.T(c, "invalid_log_id"))
-		return
+		return "", 0, base.NewError(http.StatusNotFound, i18n.T(c, "invalid_log_id"))
 	}
 
 	if taskLog.Protocol != models.TaskRPC || taskLog.Status != models.Running {
-		base.RespondSuccess(c, utils.SuccessContent, map[string]interface{}{
-			"output": taskLog.Result,
-			"status": taskLog.Status,
-		})
-		return
+		return taskLog.Result, taskLog.Status, nil
 	}
 
 	taskModel := new(models.Task)
 	task, err := taskModel.Detail(taskLog.TaskId)
 	if err != nil {
-		base.RespondError(c, i18n.T(c, "get_task_info_failed")+"#"+err.Error(), err)
-		return
+		return "", 0, base.NewError(http.StatusInternalServerError, i18n.T(c, "get_task_info_failed")+"#"+err.Error(), err)
 	}
 	if len(task.Hosts) == 0 {
-		base.RespondError(c, i18n.T(c, "task_node_list_empty"))
-		return
+		return "", 0, base.NewError(http.StatusConflict, i18n.T(c, "task_node_list_empty"))
 	}
 
 	aggregationResult := ""
@@ -94,10 +105,43 @@ func Output(c *gin.Context) {
 		aggregationResult += outputMessage
 	}
 
-	base.RespondSuccess(c, utils.SuccessContent, map[string]interface{}{
-		"output": aggregationResult,
-		"status": taskLog.Status,
-	})
+	return aggregationResult, taskLog.Status, nil
+}
+
+// 任务每次执行尝试的记录
+func Attempts(c *gin.Context) {
+	logId, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err != nil || logId <= 0 {
+		base.RespondError(c, i18n.T(c, "invalid_log_id"))
+		return
+	}
+
+	attemptModel := new(models.TaskLogAttempt)
+	attempts, err := attemptModel.List(logId)
+	if err != nil {
+		base.RespondErrorWithDefaultMsg(c, err)
+		return
+	}
+
+	base.RespondSuccessWithDefaultMsg(c, attempts)
+}
+
+// 多主机任务每台主机的执行记录
+func Hosts(c *gin.Context) {
+	logId, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err != nil || logId <= 0 {
+		base.RespondError(c, i18n.T(c, "invalid_log_id"))
+		return
+	}
+
+	logHostModel := new(models.TaskLogHost)
+	hosts, err := logHostModel.List(logId)
+	if err != nil {
+		base.RespondErrorWithDefaultMsg(c, err)
+		return
+	}
+
+	base.RespondSuccessWithDefaultMsg(c, hosts)
 }
 
 // 停止运行中的任务
@@ -112,25 +156,38 @@ func Stop(c *gin.Context) {
 		base.RespondError(c, i18n.T(c, "invalid_task_id"))
 		return
 	}
+	if err = Kill(c, id, taskId); err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondSuccess(c, i18n.T(c, "stop_task_sent"), nil)
+}
+
+// Kill 停止任务在各节点上运行的进程, 日志须属于该任务
+func Kill(c *gin.Context, id int64, taskId int) error {
+	logModel := new(models.TaskLog)
+	taskLog, err := logModel.Detail(id)
+	if err != nil || taskLog.TaskId != taskId {
+		return base.NewError(http.StatusNotFound, i18n.T(c, "invalid_log_id"), err)
+	}
 	taskModel := new(models.Task)
 	task, err := taskModel.Detail(taskId)
 	if err != nil {
-		base.RespondError(c, i18n.T(c, "get_task_info_failed")+"#"+err.Error(), err)
-		return
+		return base.NewError(http.StatusInternalServerError, i18n.T(c, "get_task_info_failed")+"#"+err.Error(), err)
 	}
 	if task.Protocol != models.TaskRPC {
-		base.RespondError(c, i18n.T(c, "only_shell_task_can_stop"))
-		return
+		return base.NewError(http.StatusConflict, i18n.T(c, "only_shell_task_can_stop"))
 	}
 	if len(task.Hosts) == 0 {
-		base.RespondError(c, i18n.T(c, "task_node_list_empty"))
-		return
+		return base.NewError(http.StatusConflict, i18n.T(c, "task_node_list_empty"))
 	}
 	for _, host := range task.Hosts {
 		service.ServiceTask.Stop(host.Name, host.Port, id)
 	}
+	audit.Record(c, models.AuditActionStop, models.AuditResourceTaskLog, int(id), task.Name, nil, nil)
 
-	base.RespondSuccess(c, i18n.T(c, "stop_task_sent"), nil)
+	return nil
 }
 
 // 删除N个月前的日志
@@ -141,10 +198,14 @@ func Remove(c *gin.Context) {
 		return
 	}
 	taskLogModel := new(models.TaskLog)
-	_, err := taskLogModel.Remove(month)
+	count, err := taskLogModel.Remove(month)
 	if err != nil {
 		base.RespondError(c, i18n.T(c, "delete_failed"), err)
 	} else {
+		audit.Record(c, models.AuditActionClear, models.AuditResourceTaskLog, 0, "", nil, map[string]interface{}{
+			"before_months": month,
+			"deleted_rows":  count,
+		})
 		base.RespondSuccess(c, i18n.T(c, "delete_success"), nil)
 	}
 }
@@ -157,6 +218,7 @@ func parseQueryParams(c *gin.Context) models.CommonMap {
 	status, _ := strconv.Atoi(c.Query("status"))
 	params["TaskId"] = taskId
 	params["Protocol"] = protocol
+	user.FilterProjects(c, params)
 	if status >= 0 {
 		status -= 1
 	}
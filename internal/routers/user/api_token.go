@@ -0,0 +1,51 @@
+package user
+
+// 个人API令牌认证
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/logger"
+)
+
+// RestoreApiToken 使用请求头中的API令牌认证, 以令牌所属用户的身份访问, 没有令牌时ok为false
+func RestoreApiToken(c *gin.Context) (ok bool, err error) {
+	header := c.GetHeader("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		return false, nil
+	}
+	token := new(models.ApiToken)
+	if err = token.FindByToken(strings.TrimSpace(header[7:])); err != nil {
+		return true, err
+	}
+	if token.Id == 0 || token.Expired() {
+		return true, errors.New("api token is invalid or expired")
+	}
+	userModel := new(models.User)
+	if err = userModel.Find(token.UserId); err != nil || userModel.Status != models.Enabled {
+		return true, errors.New("api token user is invalid or disabled")
+	}
+	c.Set("uid", userModel.Id)
+	c.Set("username", userModel.Name)
+	c.Set("is_admin", int(userModel.IsAdmin))
+	c.Set("api_token", token)
+	if err = token.Touch(c.ClientIP()); err != nil {
+		logger.Warnf("更新API令牌使用时间失败: %v", err)
+	}
+
+	return true, nil
+}
+
+// ApiToken 当前请求使用的API令牌, 使用登录令牌或接口签名访问时为nil
+func ApiToken(c *gin.Context) *models.ApiToken {
+	value, ok := c.Get("api_token")
+	if !ok {
+		return nil
+	}
+	token, _ := value.(*models.ApiToken)
+
+	return token
+}
@@ -0,0 +1,99 @@
+package user
+
+// LDAP认证, 本地不存在的用户通过LDAP验证后自动创建, 按用户组同步管理员身份
+
+import (
+	"errors"
+
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/app"
+	"github.com/tabortao/gocron/internal/modules/ldap"
+	"github.com/tabortao/gocron/internal/modules/logger"
+)
+
+const ldapSource = "ldap"
+
+var ldapAuthenticate = ldap.Authenticate
+
+// 验证用户名和密码, 启用LDAP时LDAP用户及本地不存在的用户通过LDAP验证, 其他用户验证本地密码
+func matchUser(userModel *models.User, username, password string) bool {
+	ldapUsername, ok := ldapLoginName(username)
+	if !ok {
+		return userModel.Match(username, password)
+	}
+
+	entry, err := ldapAuthenticate(ldapConfig(), ldapUsername, password)
+	if err != nil {
+		if !errors.Is(err, ldap.ErrInvalidCredentials) {
+			logger.Error("LDAP认证失败", err)
+		}
+		return false
+	}
+	user, err := provisionLDAPUser(entry)
+	if err != nil {
+		logger.Error("创建LDAP用户失败", err)
+		return false
+	}
+	*userModel = *user
+
+	return userModel.Status == models.Enabled
+}
+
+// 登录是否通过LDAP验证, 返回LDAP用户名, 未启用LDAP或本地已有非LDAP用户时返回false
+func ldapLoginName(username string) (string, bool) {
+	if !app.Setting.LDAPEnable {
+		return "", false
+	}
+	existing := new(models.User)
+	if err := existing.FindByName(username); err == nil && existing.Id == 0 {
+		err = existing.FindByEmail(username)
+	}
+	if existing.Id == 0 {
+		return username, true
+	}
+	if existing.Source != ldapSource {
+		return "", false
+	}
+
+	return existing.ExternalId, true
+}
+
+// 按LDAP用户名查找用户, 不存在时创建
+func provisionLDAPUser(entry *ldap.Entry) (*models.User, error) {
+	userModel := new(models.User)
+	if err := userModel.FindByExternalId(ldapSource, entry.Username); err != nil {
+		return nil, err
+	}
+	if userModel.Id == 0 {
+		if err := createExternalUser(userModel, ldapSource, entry.Username, entry.Username, entry.Email); err != nil {
+			return nil, err
+		}
+	}
+	isAdmin, ok := adminByGroups(app.Setting.LDAPAdminGroups, entry.Groups)
+	if ok && isAdmin != userModel.IsAdmin {
+		if _, err := userModel.Update(userModel.Id, models.CommonMap{"is_admin": isAdmin}); err != nil {
+			return nil, err
+		}
+		userModel.IsAdmin = isAdmin
+	}
+
+	return userModel, nil
+}
+
+func ldapConfig() ldap.Config {
+	return ldap.Config{
+		Url:                app.Setting.LDAPUrl,
+		StartTLS:           app.Setting.LDAPStartTLS,
+		InsecureSkipVerify: app.Setting.LDAPInsecureSkipVerify,
+		CAFile:             app.Setting.LDAPCAFile,
+		BindDN:             app.Setting.LDAPBindDN,
+		BindPassword:       app.Setting.LDAPBindPassword,
+		BaseDN:             app.Setting.LDAPBaseDN,
+		UserFilter:         app.Setting.LDAPUserFilter,
+		UsernameAttribute:  app.Setting.LDAPUsernameAttribute,
+		EmailAttribute:     app.Setting.LDAPEmailAttribute,
+		GroupBaseDN:        app.Setting.LDAPGroupBaseDN,
+		GroupFilter:        app.Setting.LDAPGroupFilter,
+		GroupAttribute:     app.Setting.LDAPGroupAttribute,
+	}
+}
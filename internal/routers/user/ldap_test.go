@@ -0,0 +1,94 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/app"
+	"github.com/tabortao/gocron/internal/modules/ldap"
+	"github.com/tabortao/gocron/internal/modules/setting"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func TestLDAPLogin(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{SingularTable: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&models.User{}, &models.LoginLog{}, &models.Setting{}); err != nil {
+		t.Fatal(err)
+	}
+	oldDb, oldSetting, oldAuthenticate := models.Db, app.Setting, ldapAuthenticate
+	models.Db = db
+	app.Setting = &setting.Setting{AuthSecret: "auth-secret", LDAPEnable: true, LDAPAdminGroups: "ops"}
+	defer func() { models.Db, app.Setting, ldapAuthenticate = oldDb, oldSetting, oldAuthenticate }()
+
+	local := models.User{Name: "root", Email: "root@example.com", Password: "password", IsAdmin: 1}
+	if _, err = local.Create(); err != nil {
+		t.Fatal(err)
+	}
+	// 模拟LDAP目录, alice的用户组可修改
+	groups := []string{"ops"}
+	ldapAuthenticate = func(config ldap.Config, username, password string) (*ldap.Entry, error) {
+		if username == "root" {
+			t.Error("local user should not be authenticated by ldap")
+		}
+		if username != "alice" || password != "alicepw" {
+			return nil, ldap.ErrInvalidCredentials
+		}
+		return &ldap.Entry{DN: "uid=alice,dc=example,dc=org", Username: "alice", Groups: groups}, nil
+	}
+	alice := func() *models.User {
+		user := new(models.User)
+		_ = user.FindByExternalId(ldapSource, "alice")
+		return user
+	}
+
+	if !passwordLogin("root", "password") {
+		t.Error("local user should log in with local password")
+	}
+	if passwordLogin("alice", "wrong") || alice().Id != 0 {
+		t.Error("wrong ldap password should not log in or create user")
+	}
+	if !passwordLogin("alice", "alicepw") {
+		t.Fatal("ldap user should log in")
+	}
+	if user := alice(); user.Id == 0 || user.IsAdmin != 1 || user.Email != "alice@sso.invalid" {
+		t.Errorf("ldap user should be created as admin, got %+v", user)
+	}
+
+	// 用户组变化后再次登录时同步管理员身份
+	groups = []string{"dev"}
+	if !passwordLogin("alice", "alicepw") || alice().IsAdmin != 0 {
+		t.Errorf("admin should be revoked, got %+v", alice())
+	}
+	var count int64
+	db.Model(&models.User{}).Count(&count)
+	if count != 2 {
+		t.Errorf("users = %d, want 2", count)
+	}
+
+	// 禁用密码登录只限制本地用户, LDAP用户仍可登录
+	carol := models.User{Name: "carol", Email: "carol@example.com", Password: "password"}
+	if _, err = carol.Create(); err != nil {
+		t.Fatal(err)
+	}
+	if err = new(models.Setting).UpdatePasswordLogin(true, "root"); err != nil {
+		t.Fatal(err)
+	}
+	if !passwordLogin("alice", "alicepw") {
+		t.Error("ldap user should log in when password login is disabled")
+	}
+	if !passwordLogin("root", "password") || passwordLogin("carol", "password") {
+		t.Error("only the break-glass admin should log in with a local password")
+	}
+
+	_, _ = alice().Disable(alice().Id)
+	if passwordLogin("alice", "alicepw") {
+		t.Error("disabled ldap user should not log in")
+	}
+}
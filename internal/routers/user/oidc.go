@@ -0,0 +1,358 @@
+package user
+
+// OIDC单点登录, 首次登录时自动创建用户, 按用户组同步管理员身份及项目角色
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/app"
+	"github.com/tabortao/gocron/internal/modules/i18n"
+	"github.com/tabortao/gocron/internal/modules/logger"
+	"github.com/tabortao/gocron/internal/modules/oidc"
+	"github.com/tabortao/gocron/internal/modules/utils"
+	"github.com/tabortao/gocron/internal/routers/base"
+)
+
+const (
+	oidcSource       = "oidc"
+	oidcStateCookie  = "gocron_oidc_state"
+	oidcStateTimeout = 10 * time.Minute
+	oidcCallbackPath = "/api/user/oidc/callback"
+)
+
+var (
+	oidcProvider   *oidc.Provider
+	oidcProviderMu sync.Mutex
+)
+
+// 首次使用时读取身份提供方的发现文档, 失败时下次请求重试
+func getOIDCProvider(ctx context.Context) (*oidc.Provider, error) {
+	oidcProviderMu.Lock()
+	defer oidcProviderMu.Unlock()
+	if oidcProvider != nil {
+		return oidcProvider, nil
+	}
+	provider, err := oidc.NewProvider(ctx, oidc.Config{
+		Issuer:       app.Setting.OIDCIssuer,
+		ClientId:     app.Setting.OIDCClientId,
+		ClientSecret: app.Setting.OIDCClientSecret,
+		RedirectUrl:  app.Setting.OIDCRedirectUrl,
+		Scopes:       strings.Fields(app.Setting.OIDCScopes),
+	})
+	if err != nil {
+		return nil, err
+	}
+	oidcProvider = provider
+
+	return provider, nil
+}
+
+// LoginOptions 登录页可用的登录方式, 启用LDAP时禁用本地密码登录仍显示密码登录表单
+func LoginOptions(c *gin.Context) {
+	disabled, _ := new(models.Setting).PasswordLogin()
+	base.RespondSuccess(c, utils.SuccessContent, map[string]interface{}{
+		"oidc":           app.Setting.OIDCEnable,
+		"password_login": !disabled || app.Setting.LDAPEnable,
+	})
+}
+
+// OIDCLogin 跳转到身份提供方登录, state和nonce签名后保存在cookie中
+func OIDCLogin(c *gin.Context) {
+	if !app.Setting.OIDCEnable {
+		base.RespondError(c, i18n.T(c, "sso_not_enabled"))
+		return
+	}
+	provider, err := getOIDCProvider(c.Request.Context())
+	if err != nil {
+		logger.Error("读取OIDC身份提供方配置失败", err)
+		redirectLogin(c, url.Values{"sso_error": {i18n.T(c, "sso_login_failed")}})
+		return
+	}
+	state := utils.RandAuthToken()
+	nonce := utils.RandAuthToken()
+	value, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"state": state,
+		"nonce": nonce,
+		"exp":   time.Now().Add(oidcStateTimeout).Unix(),
+	}).SignedString(oidcStateKey())
+	if err != nil {
+		base.RespondErrorWithDefaultMsg(c, err)
+		return
+	}
+	setStateCookie(c, value, int(oidcStateTimeout.Seconds()))
+
+	c.Redirect(http.StatusFound, provider.AuthCodeURL(state, nonce))
+}
+
+// OIDCCallback 身份提供方登录后的回调, 校验后签发登录令牌并跳转到前端
+func OIDCCallback(c *gin.Context) {
+	userModel, err := oidcAuthenticate(c)
+	if err != nil {
+		logger.Warnf("OIDC登录失败: %v", err)
+		message := i18n.T(c, "sso_login_failed")
+		if errors.Is(err, errUserDisabled) {
+			message = i18n.T(c, "sso_user_disabled")
+		}
+		redirectLogin(c, url.Values{"sso_error": {message}})
+		return
+	}
+	recordLogin(c, userModel)
+	token, err := generateToken(userModel, false)
+	if err != nil {
+		logger.Errorf("生成jwt失败: %s", err)
+		redirectLogin(c, url.Values{"sso_error": {i18n.T(c, "auth_failed")}})
+		return
+	}
+
+	redirectLogin(c, url.Values{
+		"token":    {token},
+		"uid":      {strconv.Itoa(userModel.Id)},
+		"username": {userModel.Name},
+		"is_admin": {strconv.Itoa(int(userModel.IsAdmin))},
+	})
+}
+
+var errUserDisabled = errors.New("user disabled")
+
+func oidcAuthenticate(c *gin.Context) (*models.User, error) {
+	if !app.Setting.OIDCEnable {
+		return nil, errors.New("oidc not enabled")
+	}
+	if errCode := c.Query("error"); errCode != "" {
+		return nil, errors.New(errCode + ": " + c.Query("error_description"))
+	}
+	cookie, _ := c.Cookie(oidcStateCookie)
+	setStateCookie(c, "", -1)
+	nonce, err := verifyState(cookie, c.Query("state"))
+	if err != nil {
+		return nil, err
+	}
+	provider, err := getOIDCProvider(c.Request.Context())
+	if err != nil {
+		return nil, err
+	}
+	rawIdToken, err := provider.Exchange(c.Request.Context(), c.Query("code"))
+	if err != nil {
+		return nil, err
+	}
+	claims, err := provider.Verify(c.Request.Context(), rawIdToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return provisionUser(claims)
+}
+
+// 校验回调中的state与cookie中签名的一致, 返回登录时的nonce
+func verifyState(cookie, state string) (string, error) {
+	if cookie == "" || state == "" {
+		return "", errors.New("missing state")
+	}
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(cookie, claims, func(token *jwt.Token) (interface{}, error) {
+		return oidcStateKey(), nil
+	}, jwt.WithValidMethods([]string{"HS256"}), jwt.WithExpirationRequired())
+	if err != nil {
+		return "", err
+	}
+	if value, _ := claims["state"].(string); value != state {
+		return "", errors.New("state mismatch")
+	}
+	nonce, _ := claims["nonce"].(string)
+
+	return nonce, nil
+}
+
+// state签名密钥与登录令牌区分, 避免cookie被当作登录令牌使用
+func oidcStateKey() []byte {
+	return []byte("oidc-state:" + app.Setting.AuthSecret)
+}
+
+func setStateCookie(c *gin.Context, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, value, maxAge, "/api/user/oidc", "", strings.HasPrefix(app.Setting.OIDCRedirectUrl, "https://"), true)
+}
+
+// 跳转到前端单点登录页, 参数放在hash中不会发送到服务端
+func redirectLogin(c *gin.Context, params url.Values) {
+	root := strings.TrimSuffix(app.Setting.OIDCRedirectUrl, oidcCallbackPath)
+	if root == app.Setting.OIDCRedirectUrl {
+		root = ""
+	}
+	c.Redirect(http.StatusFound, root+"/#/user/sso?"+params.Encode())
+}
+
+// 按身份提供方的用户标识查找用户, 不存在时按已验证的邮箱关联已有用户, 否则创建新用户
+// 管理员、应急账户及启用两步验证的用户不自动关联, 避免身份提供方接管账户并绕过两步验证
+func provisionUser(claims jwt.MapClaims) (*models.User, error) {
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, errors.New("id token without sub")
+	}
+	email, _ := claims["email"].(string)
+	emailVerified, _ := claims["email_verified"].(bool)
+	userModel := new(models.User)
+	if err := userModel.FindByExternalId(oidcSource, subject); err != nil {
+		return nil, err
+	}
+	if userModel.Id == 0 && email != "" && emailVerified {
+		if err := userModel.FindByEmail(email); err != nil {
+			return nil, err
+		}
+		if userModel.Id > 0 && !linkableByEmail(userModel) {
+			logger.Warnf("OIDC登录#邮箱对应的用户不能自动关联, 创建新用户#%s", userModel.Name)
+			userModel = new(models.User)
+		}
+		if userModel.Id > 0 {
+			_, err := userModel.Update(userModel.Id, models.CommonMap{"source": oidcSource, "external_id": subject})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if userModel.Id == 0 {
+		name, _ := claims[app.Setting.OIDCUsernameClaim].(string)
+		if strings.TrimSpace(name) == "" {
+			name = subject
+		}
+		if err := createExternalUser(userModel, oidcSource, subject, name, email); err != nil {
+			return nil, err
+		}
+	}
+	if userModel.Status != models.Enabled {
+		return nil, errUserDisabled
+	}
+
+	groups := oidc.Groups(claims, app.Setting.OIDCGroupsClaim)
+	if isAdmin, ok := adminByGroups(app.Setting.OIDCAdminGroups, groups); ok && isAdmin != userModel.IsAdmin {
+		if _, err := userModel.Update(userModel.Id, models.CommonMap{"is_admin": isAdmin}); err != nil {
+			return nil, err
+		}
+		userModel.IsAdmin = isAdmin
+	}
+	if app.Setting.OIDCGroupRoles != "" {
+		projectIds, roles := projectRolesByGroups(app.Setting.OIDCGroupRoles, groups)
+		if err := new(models.ProjectMember).SyncRoles(userModel.Id, projectIds, roles); err != nil {
+			return nil, err
+		}
+	}
+
+	return userModel, nil
+}
+
+// 已有用户是否可按邮箱自动关联到身份提供方
+func linkableByEmail(userModel *models.User) bool {
+	if userModel.IsAdmin == 1 || userModel.TwoFactorOn == 1 {
+		return false
+	}
+	_, breakGlassUser := new(models.Setting).PasswordLogin()
+
+	return breakGlassUser == "" || userModel.Name != breakGlassUser
+}
+
+// 创建单点登录或LDAP用户, 用户名已存在时加上序号, 没有邮箱时使用占位邮箱, 密码随机不可用于本地登录
+func createExternalUser(userModel *models.User, source, externalId, name, email string) error {
+	name = strings.TrimSpace(name)
+	if len(name) > 28 {
+		name = name[:28]
+	}
+	username := name
+	for i := 2; ; i++ {
+		count, err := userModel.UsernameExists(username, 0)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			break
+		}
+		username = name + strconv.Itoa(i)
+	}
+	if email != "" {
+		count, err := userModel.EmailExists(email, 0)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			email = ""
+		}
+	}
+	if email == "" {
+		email = username + "@sso.invalid"
+	}
+	userModel.Name = username
+	userModel.Email = email
+	userModel.Password = utils.RandAuthToken()
+	userModel.Source = source
+	userModel.ExternalId = externalId
+	_, err := userModel.Create()
+
+	return err
+}
+
+// 用户是否属于管理员组, 未配置管理员组时ok为false, 不同步管理员身份
+func adminByGroups(adminGroups string, groups []string) (isAdmin int8, ok bool) {
+	admins := splitList(adminGroups)
+	if len(admins) == 0 {
+		return 0, false
+	}
+	for _, group := range groups {
+		if utils.InStringSlice(admins, group) {
+			return 1, true
+		}
+	}
+
+	return 0, true
+}
+
+// 解析用户组对应的项目角色, 返回配置中涉及的全部项目及用户在各项目中的最高角色
+// 配置格式 组=项目ID:角色, 逗号分隔
+func projectRolesByGroups(groupRoles string, groups []string) ([]int, map[int]models.Role) {
+	projectIds := make([]int, 0)
+	roles := make(map[int]models.Role)
+	for _, item := range splitList(groupRoles) {
+		group, value, found := strings.Cut(item, "=")
+		if !found {
+			logger.Warnf("OIDC用户组角色配置无效: %s", item)
+			continue
+		}
+		project, roleName, _ := strings.Cut(value, ":")
+		projectId, _ := strconv.Atoi(strings.TrimSpace(project))
+		role := models.ParseRole(strings.TrimSpace(roleName))
+		if projectId <= 0 || !role.Valid() {
+			logger.Warnf("OIDC用户组角色配置无效: %s", item)
+			continue
+		}
+		if _, ok := roles[projectId]; !ok {
+			roles[projectId] = 0
+			projectIds = append(projectIds, projectId)
+		}
+		if utils.InStringSlice(groups, strings.TrimSpace(group)) && role > roles[projectId] {
+			roles[projectId] = role
+		}
+	}
+	sort.Ints(projectIds)
+
+	return projectIds, roles
+}
+
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,318 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/app"
+	"github.com/tabortao/gocron/internal/modules/logger"
+	"github.com/tabortao/gocron/internal/modules/setting"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func TestMain(m *testing.M) {
+	_ = os.MkdirAll("log", 0o755)
+	logger.InitLogger()
+	os.Exit(m.Run())
+}
+
+// 模拟的身份提供方, 授权码对应用户组
+func newMockIdP(t *testing.T, codes map[string][]string, nonce *string) *httptest.Server {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 server.URL,
+			"authorization_endpoint": server.URL + "/authorize",
+			"token_endpoint":         server.URL + "/token",
+			"jwks_uri":               server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		groups, ok := codes[r.PostFormValue("code")]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		token, _ := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":                server.URL,
+			"aud":                "gocron",
+			"sub":                "idp-alice",
+			"exp":                time.Now().Add(time.Minute).Unix(),
+			"nonce":              *nonce,
+			"preferred_username": "alice",
+			"email":              "alice@example.com",
+			"email_verified":     true,
+			"groups":             groups,
+		}).SignedString(key)
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": token})
+	})
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestOIDCLogin(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{SingularTable: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&models.User{}, &models.LoginLog{}, &models.Setting{}, &models.ProjectMember{}); err != nil {
+		t.Fatal(err)
+	}
+	oldDb, oldSetting := models.Db, app.Setting
+	models.Db = db
+	defer func() {
+		models.Db, app.Setting, oidcProvider = oldDb, oldSetting, nil
+	}()
+
+	codes := map[string][]string{"code-admin": {"ops", "dev"}, "code-qa": {"qa"}}
+	nonce := ""
+	idp := newMockIdP(t, codes, &nonce)
+	app.Setting = &setting.Setting{
+		AuthSecret:        "auth-secret",
+		OIDCEnable:        true,
+		OIDCIssuer:        idp.URL,
+		OIDCClientId:      "gocron",
+		OIDCClientSecret:  "secret",
+		OIDCRedirectUrl:   "http://gocron.test/api/user/oidc/callback",
+		OIDCScopes:        "openid profile",
+		OIDCUsernameClaim: "preferred_username",
+		OIDCGroupsClaim:   "groups",
+		OIDCAdminGroups:   "ops",
+		OIDCGroupRoles:    "dev=1:editor, qa=1:viewer, dev=2:operator",
+	}
+	oidcProvider = nil
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/user/oidc/login", OIDCLogin)
+	r.GET("/api/user/oidc/callback", OIDCCallback)
+
+	// 跳转到身份提供方登录后回调, 返回前端跳转地址中的参数
+	login := func(code string, tamperState bool) url.Values {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/user/oidc/login", nil))
+		authURL, err := url.Parse(w.Header().Get("Location"))
+		if err != nil || !strings.HasPrefix(authURL.String(), idp.URL+"/authorize") {
+			t.Fatalf("login should redirect to idp, got %q", w.Header().Get("Location"))
+		}
+		nonce = authURL.Query().Get("nonce")
+		state := authURL.Query().Get("state")
+		if tamperState {
+			state = "other"
+		}
+		req := httptest.NewRequest(http.MethodGet, "/api/user/oidc/callback?code="+code+"&state="+state, nil)
+		for _, cookie := range w.Result().Cookies() {
+			req.AddCookie(cookie)
+		}
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		location := w.Header().Get("Location")
+		prefix := "http://gocron.test/#/user/sso?"
+		if !strings.HasPrefix(location, prefix) {
+			t.Fatalf("callback should redirect to frontend, got %q", location)
+		}
+		values, _ := url.ParseQuery(strings.TrimPrefix(location, prefix))
+		return values
+	}
+	role := func(projectId, userId int) models.Role {
+		role, _ := new(models.ProjectMember).FindRole(projectId, userId)
+		return role
+	}
+
+	values := login("code-admin", false)
+	if values.Get("token") == "" || values.Get("username") != "alice" || values.Get("is_admin") != "1" {
+		t.Fatalf("first login = %v", values)
+	}
+	created := new(models.User)
+	_ = created.FindByExternalId(oidcSource, "idp-alice")
+	if created.Id == 0 || created.Email != "alice@example.com" || created.IsAdmin != 1 {
+		t.Fatalf("user should be provisioned as admin, got %+v", created)
+	}
+	if role(1, created.Id) != models.RoleEditor || role(2, created.Id) != models.RoleOperator {
+		t.Errorf("roles = %d, %d, want editor and operator", role(1, created.Id), role(2, created.Id))
+	}
+
+	// 再次登录时按用户组同步, 不重复创建用户
+	values = login("code-qa", false)
+	if values.Get("uid") != strconv.Itoa(created.Id) || values.Get("is_admin") != "0" {
+		t.Errorf("second login = %v", values)
+	}
+	var count int64
+	db.Model(&models.User{}).Count(&count)
+	if count != 1 {
+		t.Errorf("users = %d, want 1", count)
+	}
+	if role(1, created.Id) != models.RoleViewer || role(2, created.Id) != 0 {
+		t.Errorf("roles = %d, %d, want viewer and none", role(1, created.Id), role(2, created.Id))
+	}
+
+	if values = login("code-admin", true); values.Get("token") != "" || values.Get("sso_error") == "" {
+		t.Errorf("tampered state should fail, got %v", values)
+	}
+	if values = login("unknown", false); values.Get("token") != "" || values.Get("sso_error") == "" {
+		t.Errorf("invalid code should fail, got %v", values)
+	}
+
+	// 禁用的用户不能登录
+	_, _ = created.Disable(created.Id)
+	if values = login("code-admin", false); values.Get("token") != "" || values.Get("sso_error") == "" {
+		t.Errorf("disabled user should fail, got %v", values)
+	}
+}
+
+// 使用密码登录, 返回是否成功
+func passwordLogin(username, password string) bool {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/user/login", ValidateLogin)
+	form := url.Values{"username": {username}, "password": {password}}
+	req := httptest.NewRequest(http.MethodPost, "/api/user/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var resp struct {
+		Code int `json:"code"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+
+	return resp.Code == 0
+}
+
+// 禁用密码登录后只有应急管理员可使用密码登录
+func TestPasswordLoginDisabled(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{SingularTable: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&models.User{}, &models.LoginLog{}, &models.Setting{}); err != nil {
+		t.Fatal(err)
+	}
+	oldDb, oldSetting := models.Db, app.Setting
+	models.Db, app.Setting = db, &setting.Setting{AuthSecret: "auth-secret"}
+	defer func() { models.Db, app.Setting = oldDb, oldSetting }()
+
+	for _, user := range []models.User{
+		{Name: "root", Email: "root@example.com", Password: "password", IsAdmin: 1},
+		{Name: "bob", Email: "bob@example.com", Password: "password", IsAdmin: 1},
+		{Name: "carol", Email: "carol@example.com", Password: "password"},
+	} {
+		if _, err = user.Create(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	login := func(username string) bool {
+		return passwordLogin(username, "password")
+	}
+
+	if !login("bob") || !login("carol") {
+		t.Fatal("password login should be allowed by default")
+	}
+	settingModel := new(models.Setting)
+	if err = settingModel.UpdatePasswordLogin(true, "root"); err != nil {
+		t.Fatal(err)
+	}
+	if !login("root") {
+		t.Error("break-glass admin should log in with password")
+	}
+	if login("bob") || login("carol") || login("root@example.com") {
+		t.Error("other users should not log in with password")
+	}
+	// 应急账户不再是管理员时同样不能登录
+	_, _ = new(models.User).Update(1, models.CommonMap{"is_admin": 0})
+	if login("root") {
+		t.Error("break-glass user without admin should not log in")
+	}
+}
+
+// 管理员、应急账户及启用两步验证的用户不按邮箱自动关联
+func TestProvisionUserEmailLink(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{SingularTable: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&models.User{}, &models.Setting{}, &models.ProjectMember{}); err != nil {
+		t.Fatal(err)
+	}
+	oldDb, oldSetting := models.Db, app.Setting
+	models.Db, app.Setting = db, &setting.Setting{AuthSecret: "auth-secret", OIDCUsernameClaim: "preferred_username"}
+	defer func() { models.Db, app.Setting = oldDb, oldSetting }()
+
+	users := map[string]*models.User{
+		"root":  {Name: "root", Email: "root@example.com", Password: "password"},
+		"admin": {Name: "admin", Email: "admin@example.com", Password: "password", IsAdmin: 1},
+		"totp":  {Name: "totp", Email: "totp@example.com", Password: "password", TwoFactorOn: 1},
+		"carol": {Name: "carol", Email: "carol@example.com", Password: "password"},
+	}
+	for _, user := range users {
+		if _, err = user.Create(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = new(models.Setting).UpdatePasswordLogin(false, "root"); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, user := range users {
+		provisioned, err := provisionUser(jwt.MapClaims{
+			"sub":                "idp-" + name,
+			"email":              user.Email,
+			"email_verified":     true,
+			"preferred_username": name,
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		linked := provisioned.Id == user.Id
+		if linked != (name == "carol") {
+			t.Errorf("%s: linked = %v", name, linked)
+		}
+		if !linked && (provisioned.IsAdmin != 0 || provisioned.Email == user.Email) {
+			t.Errorf("%s: new user should not inherit the account, got %+v", name, provisioned)
+		}
+		local := new(models.User)
+		_ = local.FindByEmail(user.Email)
+		if (local.Source == oidcSource) != linked {
+			t.Errorf("%s: source = %q", name, local.Source)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/logger"
+)
+
+// Can 当前用户在项目中是否拥有操作权限, 系统管理员拥有全部权限
+func Can(c *gin.Context, projectId int, action models.Action) bool {
+	if IsAdmin(c) {
+		return true
+	}
+	if projectId <= 0 || !IsLogin(c) {
+		return false
+	}
+	role, err := new(models.ProjectMember).FindRole(projectId, Uid(c))
+	if err != nil {
+		logger.Error("查询项目角色失败", err)
+		return false
+	}
+
+	return role.Can(action)
+}
+
+// ProjectIds 当前用户拥有操作权限的项目, 系统管理员返回 all 为true
+func ProjectIds(c *gin.Context, action models.Action) (ids []int, all bool) {
+	if IsAdmin(c) {
+		return nil, true
+	}
+	ids, err := new(models.ProjectMember).ProjectIds(Uid(c), action)
+	if err != nil {
+		logger.Error("查询用户项目失败", err)
+		return []int{}, false
+	}
+
+	return ids, false
+}
+
+// FilterProjects 列表查询参数中加入当前用户可查看的项目
+func FilterProjects(c *gin.Context, params models.CommonMap) {
+	if ids, all := ProjectIds(c, models.ActionView); !all {
+		params["ProjectIds"] = ids
+	}
+}
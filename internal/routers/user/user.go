@@ -3,6 +3,7 @@ package user
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
@@ -16,6 +17,7 @@ import (
 	"github.com/tabortao/gocron/internal/modules/logger"
 	"github.com/tabortao/gocron/internal/modules/utils"
 	"github.com/tabortao/gocron/internal/routers/base"
+	"gorm.io/gorm"
 )
 
 const (
@@ -89,57 +91,63 @@ func Detail(c *gin.Context) {
 	}
 }
 
-// 保存任务
+// 保存用户
 func Store(c *gin.Context) {
 	var form UserForm
 	if err := c.ShouldBind(&form); err != nil {
 		base.RespondValidationError(c, err)
 		return
 	}
+	if _, err := Save(c, form); err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondSuccess(c, i18n.T(c, "save_success"), nil)
+}
 
+// Save 校验并保存用户, form.Id为0时新增, 修改时不变更密码
+func Save(c *gin.Context, form UserForm) (models.User, error) {
 	form.Name = strings.TrimSpace(form.Name)
 	form.Email = strings.TrimSpace(form.Email)
 	form.Password = strings.TrimSpace(form.Password)
 	form.ConfirmPassword = strings.TrimSpace(form.ConfirmPassword)
 
 	userModel := models.User{}
+	if form.Id > 0 {
+		if err := userModel.Find(form.Id); err != nil || userModel.Id == 0 {
+			return userModel, base.NewError(http.StatusNotFound, i18n.T(c, "user_not_exist"), ignoreNotFound(err))
+		}
+	}
 	nameExists, err := userModel.UsernameExists(form.Name, form.Id)
 	if err != nil {
-		base.RespondErrorWithDefaultMsg(c, err)
-		return
+		return userModel, err
 	}
 	if nameExists > 0 {
-		base.RespondError(c, i18n.T(c, "username_exists"))
-		return
+		return userModel, base.NewError(http.StatusConflict, i18n.T(c, "username_exists"))
 	}
 
 	emailExists, err := userModel.EmailExists(form.Email, form.Id)
 	if err != nil {
-		base.RespondErrorWithDefaultMsg(c, err)
-		return
+		return userModel, err
 	}
 	if emailExists > 0 {
-		base.RespondError(c, i18n.T(c, "email_exists"))
-		return
+		return userModel, base.NewError(http.StatusConflict, i18n.T(c, "email_exists"))
 	}
 
 	if form.Id == 0 {
 		if form.Password == "" {
-			base.RespondError(c, i18n.T(c, "password_required"))
-			return
+			return userModel, base.NewError(http.StatusBadRequest, i18n.T(c, "password_required"))
 		}
 		if form.ConfirmPassword == "" {
-			base.RespondError(c, i18n.T(c, "password_confirm_required"))
-			return
+			return userModel, base.NewError(http.StatusBadRequest, i18n.T(c, "password_confirm_required"))
 		}
 		// 验证密码复杂度
 		if valid, errKey := utils.ValidatePassword(form.Password); !valid {
-			base.RespondError(c, i18n.T(c, errKey))
-			return
+			return userModel, base.NewError(http.StatusBadRequest, i18n.T(c, errKey))
 		}
 		if form.Password != form.ConfirmPassword {
-			base.RespondError(c, i18n.T(c, "password_mismatch"))
-			return
+			return userModel, base.NewError(http.StatusBadRequest, i18n.T(c, "password_mismatch"))
 		}
 	}
 	userModel.Name = form.Name
@@ -151,35 +159,58 @@ func Store(c *gin.Context) {
 	if form.Id == 0 {
 		_, err = userModel.Create()
 		if err != nil {
-			base.RespondError(c, i18n.T(c, "save_failed"), err)
-			return
-		}
-	} else {
-		_, err = userModel.Update(form.Id, models.CommonMap{
-			"name":     form.Name,
-			"email":    form.Email,
-			"status":   form.Status,
-			"is_admin": form.IsAdmin,
-		})
-		if err != nil {
-			base.RespondError(c, i18n.T(c, "update_failed"), err)
-			return
+			return userModel, base.NewError(http.StatusInternalServerError, i18n.T(c, "save_failed"), err)
 		}
+		return userModel, nil
 	}
+	_, err = userModel.Update(form.Id, models.CommonMap{
+		"name":     form.Name,
+		"email":    form.Email,
+		"status":   form.Status,
+		"is_admin": form.IsAdmin,
+	})
+	if err != nil {
+		return userModel, base.NewError(http.StatusInternalServerError, i18n.T(c, "update_failed"), err)
+	}
+	err = userModel.Find(form.Id)
 
-	base.RespondSuccess(c, i18n.T(c, "save_success"), nil)
+	return userModel, err
 }
 
 // 删除用户
 func Remove(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
+	if err := Delete(c, id); err != nil {
+		base.RespondFailure(c, err)
+		return
+	}
+
+	base.RespondSuccessWithDefaultMsg(c, nil)
+}
+
+// Delete 删除用户及其项目角色和API令牌
+func Delete(c *gin.Context, id int) error {
 	userModel := new(models.User)
-	_, err := userModel.Delete(id)
+	count, err := userModel.Delete(id)
 	if err != nil {
-		base.RespondErrorWithDefaultMsg(c, err)
-	} else {
-		base.RespondSuccessWithDefaultMsg(c, nil)
+		return err
+	}
+	if count == 0 {
+		return base.NewError(http.StatusNotFound, i18n.T(c, "user_not_exist"))
 	}
+	_ = new(models.ProjectMember).DeleteByUser(id)
+	_ = new(models.ApiToken).DeleteByUser(id)
+
+	return nil
+}
+
+// 查询不到记录不作为内部错误记录日志
+func ignoreNotFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return err
 }
 
 // 激活用户
@@ -280,6 +311,14 @@ func ValidateLogin(c *gin.Context) {
 		return
 	}
 
+	// 禁用密码登录后只有应急管理员可使用本地密码登录, LDAP登录不受影响
+	disabled, breakGlassUser := new(models.Setting).PasswordLogin()
+	_, viaLDAP := ldapLoginName(username)
+	if disabled && !viaLDAP && username != breakGlassUser {
+		base.RespondError(c, i18n.T(c, "password_login_disabled"))
+		return
+	}
+
 	// 获取登录限制器
 	limiter := utils.GetLoginLimiter()
 
@@ -294,7 +333,7 @@ func ValidateLogin(c *gin.Context) {
 	}
 
 	userModel := new(models.User)
-	if !userModel.Match(username, password) {
+	if !matchUser(userModel, username, password) {
 		// 记录登录失败
 		limiter.RecordFailure(username)
 		remaining := limiter.GetRemainingAttempts(username)
@@ -306,6 +345,10 @@ func ValidateLogin(c *gin.Context) {
 		}
 		return
 	}
+	if disabled && !viaLDAP && userModel.IsAdmin != 1 {
+		base.RespondError(c, i18n.T(c, "password_login_disabled"))
+		return
+	}
 
 	// 检查是否启用2FA
 	if userModel.TwoFactorOn == 1 {
@@ -327,18 +370,7 @@ func ValidateLogin(c *gin.Context) {
 
 	// 登录成功，清除失败记录
 	limiter.RecordSuccess(username)
-
-	loginLogModel := new(models.LoginLog)
-	loginLogModel.Username = userModel.Name
-	ip := c.ClientIP()
-	if ip == "::1" {
-		ip = "127.0.0.1"
-	}
-	loginLogModel.Ip = ip
-	_, err := loginLogModel.Create()
-	if err != nil {
-		logger.Error("记录用户登录日志失败", err)
-	}
+	recordLogin(c, userModel)
 
 	token, err := generateToken(userModel, rememberMe)
 	if err != nil {
@@ -355,6 +387,21 @@ func ValidateLogin(c *gin.Context) {
 	})
 }
 
+// 记录登录日志
+func recordLogin(c *gin.Context, userModel *models.User) {
+	loginLogModel := new(models.LoginLog)
+	loginLogModel.Username = userModel.Name
+	ip := c.ClientIP()
+	if ip == "::1" {
+		ip = "127.0.0.1"
+	}
+	loginLogModel.Ip = ip
+	_, err := loginLogModel.Create()
+	if err != nil {
+		logger.Error("记录用户登录日志失败", err)
+	}
+}
+
 // Username 获取session中的用户名
 func Username(c *gin.Context) string {
 	usernameInterface, ok := c.Get("username")
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocronx-team/cron"
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/logger"
+)
+
+const (
+	// 单次补跑最多生成的执行次数
+	BackfillMaxRuns = 1000
+	// 补跑最大并发数
+	BackfillMaxConcurrency = 10
+)
+
+// 调度变量, 执行前替换为本次调度的逻辑时间
+const (
+	ScheduleTimeVar      = "${GOCRON_SCHEDULE_TIME}"      // 2006-01-02 15:04:05
+	ScheduleDateVar      = "${GOCRON_SCHEDULE_DATE}"      // 2006-01-02
+	ScheduleTimestampVar = "${GOCRON_SCHEDULE_TIMESTAMP}" // unix时间戳(秒)
+)
+
+var (
+	ErrBackfillInvalidRange = errors.New("backfill end time must be after start time")
+	ErrBackfillTooManyRuns  = fmt.Errorf("backfill exceeds the limit of %d runs", BackfillMaxRuns)
+	ErrBackfillUnsupported  = errors.New("@reboot tasks can not be backfilled")
+	ErrBackfillRunning      = errors.New("backfill of the task is already running")
+)
+
+// 正在后台补跑的任务ID
+var backfillRunning sync.Map
+
+// BackfillTimes 按任务的cron表达式计算 [start, end] 区间内的所有调度时间点
+func BackfillTimes(spec string, start, end time.Time) ([]time.Time, error) {
+	if !end.After(start) {
+		return nil, ErrBackfillInvalidRange
+	}
+	schedule, err := cron.ParseWithError(strings.TrimSpace(spec))
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := schedule.(*cron.RebootSchedule); ok {
+		return nil, ErrBackfillUnsupported
+	}
+
+	times := make([]time.Time, 0)
+	// Next 返回严格晚于参数的时间点, 往前退一秒使起始时间包含在内
+	next := schedule.Next(start.Add(-time.Second))
+	for !next.IsZero() && !next.After(end) {
+		if len(times) >= BackfillMaxRuns {
+			return nil, ErrBackfillTooManyRuns
+		}
+		times = append(times, next)
+		next = schedule.Next(next)
+	}
+
+	return times, nil
+}
+
+// StartBackfill 在后台补跑任务, 同一任务同时只能有一个补跑
+func (task Task) StartBackfill(taskModel models.Task, times []time.Time, concurrency int) error {
+	if _, loaded := backfillRunning.LoadOrStore(taskModel.Id, struct{}{}); loaded {
+		return ErrBackfillRunning
+	}
+	go func() {
+		defer backfillRunning.Delete(taskModel.Id)
+		task.Backfill(taskModel, times, concurrency)
+	}()
+
+	return nil
+}
+
+// Backfill 按给定的逻辑时间逐次执行任务, 阻塞直到全部执行完成
+func (task Task) Backfill(taskModel models.Task, times []time.Time, concurrency int) {
+	if createHandler(taskModel) == nil {
+		logger.Error("Backfill#Unsupported task protocol#", taskModel.Protocol)
+		return
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	if concurrency > BackfillMaxConcurrency {
+		concurrency = BackfillMaxConcurrency
+	}
+	// 单实例任务同一时刻只能运行一个, 并发补跑会被直接取消
+	if taskModel.Multi == 0 {
+		concurrency = 1
+	}
+	logger.Infof("Backfill started#Task ID-%d#Runs-%d#Concurrency-%d", taskModel.Id, len(times), concurrency)
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, scheduleTime := range times {
+		item := taskModel
+		item.ScheduleTime = scheduleTime
+		item.Spec = fmt.Sprintf("Backfill (%s)", scheduleTime.Format(models.DefaultTimeFormat))
+		taskFunc := createJob(item)
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			taskFunc()
+		}()
+	}
+	wg.Wait()
+	logger.Infof("Backfill completed#Task ID-%d#Runs-%d", taskModel.Id, len(times))
+}
+
+// HasScheduleVariables 命令中是否包含调度变量
+func HasScheduleVariables(command string) bool {
+	return strings.Contains(command, "${GOCRON_SCHEDULE_")
+}
+
+// 绑定调度变量, 未指定逻辑时间时使用当前时间
+func bindScheduleVariables(taskModel models.Task) models.Task {
+	if taskModel.ScheduleTime.IsZero() {
+		taskModel.ScheduleTime = time.Now().Truncate(time.Second)
+	}
+	if !HasScheduleVariables(taskModel.Command) {
+		return taskModel
+	}
+	t := taskModel.ScheduleTime
+	replacer := strings.NewReplacer(
+		ScheduleTimeVar, t.Format(models.DefaultTimeFormat),
+		ScheduleDateVar, t.Format("2006-01-02"),
+		ScheduleTimestampVar, strconv.FormatInt(t.Unix(), 10),
+	)
+	taskModel.Command = replacer.Replace(taskModel.Command)
+
+	return taskModel
+}
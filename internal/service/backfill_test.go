@@ -0,0 +1,89 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/tabortao/gocron/internal/models"
+)
+
+func TestBackfillTimesIncludesStart(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	end := time.Date(2024, 1, 7, 0, 0, 0, 0, time.Local)
+
+	times, err := BackfillTimes("0 0 0 * * *", start, end)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(times) != 7 {
+		t.Fatalf("expected 7 runs, got %d", len(times))
+	}
+	if !times[0].Equal(start) || !times[6].Equal(end) {
+		t.Fatalf("unexpected range %s - %s", times[0], times[6])
+	}
+}
+
+func TestBackfillTimesErrors(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+
+	if _, err := BackfillTimes("0 0 0 * * *", start, start); !errors.Is(err, ErrBackfillInvalidRange) {
+		t.Fatalf("expected invalid range error, got %v", err)
+	}
+	if _, err := BackfillTimes("* * * * * *", start, start.Add(time.Hour)); !errors.Is(err, ErrBackfillTooManyRuns) {
+		t.Fatalf("expected too many runs error, got %v", err)
+	}
+	if _, err := BackfillTimes("@reboot", start, start.Add(time.Hour)); !errors.Is(err, ErrBackfillUnsupported) {
+		t.Fatalf("expected unsupported error, got %v", err)
+	}
+	if _, err := BackfillTimes("invalid", start, start.Add(time.Hour)); err == nil {
+		t.Fatal("expected parse error")
+	}
+}
+
+func TestBindScheduleVariables(t *testing.T) {
+	scheduleTime := time.Date(2024, 3, 5, 8, 30, 0, 0, time.Local)
+	task := models.Task{
+		Command:      "run.sh ${GOCRON_SCHEDULE_DATE} '${GOCRON_SCHEDULE_TIME}' ${GOCRON_SCHEDULE_TIMESTAMP}",
+		ScheduleTime: scheduleTime,
+	}
+
+	bound := bindScheduleVariables(task)
+	expected := "run.sh 2024-03-05 '2024-03-05 08:30:00' " + strconv.FormatInt(scheduleTime.Unix(), 10)
+	if bound.Command != expected {
+		t.Fatalf("expected %q, got %q", expected, bound.Command)
+	}
+
+	plain := bindScheduleVariables(models.Task{Command: "echo hello"})
+	if plain.Command != "echo hello" || plain.ScheduleTime.IsZero() {
+		t.Fatalf("unexpected task %+v", plain)
+	}
+	if !HasScheduleVariables(task.Command) || HasScheduleVariables(plain.Command) {
+		t.Fatal("unexpected schedule variable detection")
+	}
+}
+
+func TestStartBackfillRejectsRunning(t *testing.T) {
+	taskModel := models.Task{Id: 9527}
+	backfillRunning.Store(taskModel.Id, struct{}{})
+	if err := ServiceTask.StartBackfill(taskModel, nil, 1); !errors.Is(err, ErrBackfillRunning) {
+		t.Fatalf("expected running error, got %v", err)
+	}
+	backfillRunning.Delete(taskModel.Id)
+
+	if err := ServiceTask.StartBackfill(taskModel, nil, 1); err != nil {
+		t.Fatal(err)
+	}
+	// 补跑结束后释放, 可以再次补跑
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, ok := backfillRunning.Load(taskModel.Id); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("backfill not released")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/app"
+	"github.com/tabortao/gocron/internal/modules/logger"
+	"github.com/tabortao/gocron/internal/modules/rpc/auth"
+	"github.com/tabortao/gocron/internal/modules/rpc/grpcpool"
+	"google.golang.org/grpc/credentials"
+)
+
+// 检查服务端证书是否需要续期的间隔
+const serverCertCheckInterval = 12 * time.Hour
+
+var (
+	// BuiltinCA 内置CA, 未启用时为nil
+	BuiltinCA *auth.CA
+
+	serverCertReloader *auth.KeyPairReloader
+
+	// 服务端证书续期, 多次初始化只启动一次
+	caMu        sync.Mutex
+	caRenewStop chan struct{}
+)
+
+var errReservedHostname = fmt.Errorf("hostname %q is reserved for the server certificate", auth.BuiltinServerName)
+
+// IsReservedHostname 内置CA签发服务端证书使用的名称, 节点使用该名称的证书可冒充服务端
+func IsReservedHostname(name string) bool {
+	return strings.EqualFold(strings.TrimSpace(name), auth.BuiltinServerName)
+}
+
+// InitBuiltinCA 加载或生成内置CA及服务端证书, 并定期续期服务端证书
+// 配置了 enable_tls 时使用手动配置的证书, 不启用内置CA
+func InitBuiltinCA() {
+	caMu.Lock()
+	defer caMu.Unlock()
+	if !app.Setting.CAEnable || app.Setting.EnableTLS || BuiltinCA != nil {
+		return
+	}
+	ca, err := auth.LoadOrCreateCA(filepath.Join(app.ConfDir, "ca"))
+	if err != nil {
+		logger.Error("初始化内置CA失败", err)
+		return
+	}
+	certificate, err := ca.ServerCertificate()
+	if err != nil {
+		logger.Error("签发服务端证书失败", err)
+		return
+	}
+	reloader, err := auth.NewKeyPairReloader(certificate.CertFile, certificate.KeyFile)
+	if err != nil {
+		logger.Error("加载服务端证书失败", err)
+		return
+	}
+	BuiltinCA, serverCertReloader = ca, reloader
+	grpcpool.TransportCredentials = builtinTransportCredentials
+	logger.Info("内置CA已启用")
+
+	caRenewStop = make(chan struct{})
+	go renewServerCertificate(ca, reloader, caRenewStop)
+}
+
+// StopBuiltinCA 停止服务端证书续期
+func StopBuiltinCA() {
+	caMu.Lock()
+	defer caMu.Unlock()
+	if caRenewStop != nil {
+		close(caRenewStop)
+		caRenewStop = nil
+	}
+}
+
+// 按间隔检查服务端证书, 到期前重新签发并加载
+func renewServerCertificate(ca *auth.CA, reloader *auth.KeyPairReloader, stop chan struct{}) {
+	ticker := time.NewTicker(serverCertCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := ca.ServerCertificate(); err != nil {
+				logger.Error("续期服务端证书失败", err)
+				continue
+			}
+			if err := reloader.Reload(); err != nil {
+				logger.Error("加载服务端证书失败", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// 内置CA签发的服务端证书, 用于连接节点及反向连接监听
+func builtinCertificate(serverName string) auth.Certificate {
+	return auth.Certificate{
+		CAFile:     BuiltinCA.CertFile(),
+		ServerName: serverName,
+		Reloader:   serverCertReloader,
+	}
+}
+
+// 已颁发证书的节点使用TLS连接, 其他节点不加密
+func builtinTransportCredentials(addr string) (credentials.TransportCredentials, error) {
+	name, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	host := new(models.Host)
+	if err := host.FindByName(name); err != nil || !host.TLS {
+		return nil, nil
+	}
+
+	return builtinCertificate(name).GetTransportCredsForClient()
+}
+
+// IssueNodeCertificate 使用内置CA为节点签发证书
+func IssueNodeCertificate(host models.Host, csrPEM []byte) ([]byte, error) {
+	if BuiltinCA == nil {
+		return nil, errors.New("built-in CA is not enabled")
+	}
+	if IsReservedHostname(host.Name) {
+		return nil, errReservedHostname
+	}
+	cert, err := BuiltinCA.SignCSR(csrPEM, host.Name)
+	if err != nil {
+		return nil, err
+	}
+	if !host.TLS {
+		if _, err := host.Update(host.Id, models.CommonMap{"tls": true}); err != nil {
+			return nil, err
+		}
+		// 之前建立的非加密连接不再使用
+		grpcpool.Pool.Release(fmt.Sprintf("%s:%d", host.Name, host.Port))
+	}
+
+	return cert, nil
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/tabortao/gocron/internal/modules/app"
+	"github.com/tabortao/gocron/internal/modules/rpc/grpcpool"
+	"github.com/tabortao/gocron/internal/modules/setting"
+)
+
+// 重复初始化只启动一次证书续期, 停止后可再次停止
+func TestInitBuiltinCAOnce(t *testing.T) {
+	oldSetting, oldConfDir, oldCredentials := app.Setting, app.ConfDir, grpcpool.TransportCredentials
+	app.Setting, app.ConfDir = &setting.Setting{CAEnable: true}, t.TempDir()
+	defer func() {
+		StopBuiltinCA()
+		app.Setting, app.ConfDir, grpcpool.TransportCredentials = oldSetting, oldConfDir, oldCredentials
+		BuiltinCA, serverCertReloader = nil, nil
+	}()
+
+	InitBuiltinCA()
+	if BuiltinCA == nil || caRenewStop == nil {
+		t.Fatal("built-in CA should be initialized")
+	}
+	ca, stop := BuiltinCA, caRenewStop
+	InitBuiltinCA()
+	if BuiltinCA != ca || caRenewStop != stop {
+		t.Fatal("second initialization should be ignored")
+	}
+	StopBuiltinCA()
+	StopBuiltinCA()
+	if caRenewStop != nil {
+		t.Fatal("renewal should be stopped")
+	}
+	select {
+	case <-stop:
+	default:
+		t.Fatal("stop channel should be closed")
+	}
+}
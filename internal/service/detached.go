@@ -0,0 +1,90 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/logger"
+	rpcClient "github.com/tabortao/gocron/internal/modules/rpc/client"
+)
+
+var resumeDetachedFunc = rpcClient.ResumeDetached
+
+// 服务重启后所有节点上都找不到该任务
+var errDetachedJobNotFound = errors.New("detached job not found on any host after server restart")
+
+// 服务重启后, 继续查询节点上仍在运行的分离执行任务, 结果写回原任务日志
+func (task Task) resumeDetachedLogs() {
+	taskLogModel := new(models.TaskLog)
+	logs, err := taskLogModel.RunningList(models.TaskRPC)
+	if err != nil {
+		logger.Errorf("Failed to get running task logs#%s", err.Error())
+		return
+	}
+	taskModel := new(models.Task)
+	for _, taskLog := range logs {
+		item, err := taskModel.Detail(taskLog.TaskId)
+		if err != nil {
+			logger.Errorf("Failed to get task detail#ID-%d#%s", taskLog.TaskId, err.Error())
+			continue
+		}
+		if item.Id == 0 || item.Detached != 1 {
+			continue
+		}
+		if item.HasHostTarget() {
+			if err := item.RefreshHosts(); err != nil {
+				logger.Errorf("Failed to resolve task hosts#ID-%d#%s", item.Id, err.Error())
+			}
+		}
+		logger.Infof("Resuming detached task#ID-%d#taskLogId-%d", item.Id, taskLog.Id)
+		taskCount.Add()
+		// 在加载定时任务前标记运行中, 避免重复执行
+		if item.Multi == 0 {
+			runInstance.add(item.Id)
+		}
+		go func(taskModel models.Task, taskLog models.TaskLog) {
+			defer taskCount.Done()
+			if taskModel.Multi == 0 {
+				defer runInstance.done(taskModel.Id)
+			}
+			afterExecJob(taskModel, resumeDetachedJob(taskModel, taskLog), taskLog.Id)
+		}(item, taskLog)
+	}
+}
+
+// 在任务关联的所有主机上查询任务, 节点上不存在的主机未执行或结果已取回, 不计入结果
+func resumeDetachedJob(taskModel models.Task, taskLog models.TaskLog) TaskResult {
+	startTime := time.Time(taskLog.StartTime)
+	results := make([]HostResult, len(taskModel.Hosts))
+	var wg sync.WaitGroup
+	for i, th := range taskModel.Hosts {
+		wg.Add(1)
+		go func(i int, th models.TaskHostDetail) {
+			defer wg.Done()
+			hostResult := newHostResult(th)
+			hostResult.StartTime = startTime
+			output, err := resumeDetachedFunc(th.Name, th.Port, taskLog.Id, startTime, taskModel.Timeout)
+			hostResult.EndTime = time.Now()
+			hostResult.Output = strings.TrimSpace(output)
+			hostResult.Err = err
+			results[i] = hostResult
+		}(i, th)
+	}
+	wg.Wait()
+
+	found := make([]HostResult, 0, len(results))
+	for _, r := range results {
+		if !errors.Is(r.Err, rpcClient.ErrDetachedNotFound) {
+			found = append(found, r)
+		}
+	}
+	if len(found) == 0 {
+		return TaskResult{Err: errDetachedJobNotFound, Result: errDetachedJobNotFound.Error()}
+	}
+	output, err := aggregateHostResults(found)
+
+	return TaskResult{Result: output, Err: err, Hosts: found}
+}
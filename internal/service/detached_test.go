@@ -0,0 +1,54 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tabortao/gocron/internal/models"
+	rpcClient "github.com/tabortao/gocron/internal/modules/rpc/client"
+)
+
+func TestResumeDetachedJob(t *testing.T) {
+	original := resumeDetachedFunc
+	defer func() { resumeDetachedFunc = original }()
+
+	startTime := time.Now().Add(-time.Minute)
+	var gotId int64
+	resumeDetachedFunc = func(ip string, port int, id int64, start time.Time, timeout int) (string, error) {
+		gotId = id
+		if !start.Equal(startTime) || timeout != 30 {
+			t.Errorf("unexpected start time %v or timeout %d", start, timeout)
+		}
+		if ip == "node-2" {
+			return "", rpcClient.ErrDetachedNotFound
+		}
+		return "done\n", nil
+	}
+
+	taskModel := models.Task{Timeout: 30, Detached: 1}
+	taskModel.Hosts = []models.TaskHostDetail{
+		{TaskHost: models.TaskHost{HostId: 1}, Name: "node-1", Port: 5921, Alias: "a"},
+		{TaskHost: models.TaskHost{HostId: 2}, Name: "node-2", Port: 5921, Alias: "b"},
+	}
+	taskLog := models.TaskLog{Id: 42, StartTime: models.LocalTime(startTime)}
+
+	result := resumeDetachedJob(taskModel, taskLog)
+	if result.Err != nil {
+		t.Fatalf("unexpected error: %v", result.Err)
+	}
+	if gotId != 42 {
+		t.Fatalf("expected job id 42, got %d", gotId)
+	}
+	if len(result.Hosts) != 1 || result.Hosts[0].Name != "node-1" || result.Hosts[0].Output != "done" {
+		t.Fatalf("unexpected host results %+v", result.Hosts)
+	}
+
+	resumeDetachedFunc = func(ip string, port int, id int64, start time.Time, timeout int) (string, error) {
+		return "", rpcClient.ErrDetachedNotFound
+	}
+	result = resumeDetachedJob(taskModel, taskLog)
+	if !errors.Is(result.Err, errDetachedJobNotFound) || len(result.Hosts) != 0 {
+		t.Fatalf("expected not found error, got %v", result.Err)
+	}
+}
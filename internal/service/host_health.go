@@ -0,0 +1,208 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/logger"
+	"github.com/tabortao/gocron/internal/modules/notify"
+	rpcClient "github.com/tabortao/gocron/internal/modules/rpc/client"
+	pb "github.com/tabortao/gocron/internal/modules/rpc/proto"
+)
+
+const (
+	// 单次健康检查超时时间
+	hostHealthTimeout = 5 * time.Second
+	// 连续失败多少次后标记为离线, 避免网络抖动误报
+	hostOfflineThreshold = 2
+)
+
+var (
+	ServiceHostHealth = HostHealth{failures: make(map[int]int)}
+
+	rpcHealthFunc = rpcClient.Health
+)
+
+// 节点健康检查
+type HostHealth struct {
+	mu       sync.Mutex
+	stop     chan struct{}
+	failures map[int]int // 各主机连续检查失败次数
+}
+
+// Start 按间隔定时检查所有节点, interval<=0 时不检查
+func (hh *HostHealth) Start(interval time.Duration) {
+	if interval <= 0 {
+		logger.Info("Host health check disabled")
+		return
+	}
+	hh.mu.Lock()
+	if hh.stop != nil {
+		hh.mu.Unlock()
+		return
+	}
+	hh.stop = make(chan struct{})
+	stop := hh.stop
+	hh.mu.Unlock()
+
+	logger.Infof("Host health check started#Interval-%s", interval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		hh.CheckAll()
+		for {
+			select {
+			case <-ticker.C:
+				hh.CheckAll()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+func (hh *HostHealth) Stop() {
+	hh.mu.Lock()
+	defer hh.mu.Unlock()
+	if hh.stop != nil {
+		close(hh.stop)
+		hh.stop = nil
+	}
+}
+
+// CheckAll 并发检查所有节点
+func (hh *HostHealth) CheckAll() {
+	hosts, err := new(models.Host).AllList()
+	if err != nil {
+		logger.Error("Host health check#Failed to get host list-", err)
+		return
+	}
+	var wg sync.WaitGroup
+	for _, host := range hosts {
+		wg.Add(1)
+		go func(host models.Host) {
+			defer wg.Done()
+			hh.Check(host)
+		}(host)
+	}
+	wg.Wait()
+}
+
+// Check 检查单个节点并保存状态, 在线状态变化时发送通知
+func (hh *HostHealth) Check(host models.Host) models.HostStatus {
+	resp, err := rpcHealthFunc(host.Name, host.Port, hostHealthTimeout)
+	// 旧版本节点不支持健康检查, 能连通即视为在线
+	if errors.Is(err, rpcClient.ErrUnimplemented) {
+		err = nil
+	}
+	status := hh.nextStatus(host, err)
+	if status == models.HostStatusOnline && resp != nil && resp.Draining {
+		status = models.HostStatusDraining
+	}
+	hostDrain.set(host.Id, status == models.HostStatusDraining)
+	data := hostHealthData(status, resp)
+	if _, updateErr := new(models.Host).Update(host.Id, data); updateErr != nil {
+		logger.Errorf("Host health check#Failed to update host status#Host-%s:%d#%s", host.Name, host.Port, updateErr.Error())
+	}
+	if host.Status != models.HostStatusUnknown && host.Status != status {
+		logger.Warnf("Host status changed#Host-%s:%d#%d->%d", host.Name, host.Port, host.Status, status)
+		// 只在离线和在线之间变化时通知, 排空视为在线
+		if (host.Status == models.HostStatusOffline) != (status == models.HostStatusOffline) {
+			go notifyHostStatus(host, status, err)
+		}
+	}
+
+	return status
+}
+
+// 计算检查后的状态, 连续失败达到阈值才标记为离线
+func (hh *HostHealth) nextStatus(host models.Host, err error) models.HostStatus {
+	hh.mu.Lock()
+	defer hh.mu.Unlock()
+	if err == nil {
+		delete(hh.failures, host.Id)
+		return models.HostStatusOnline
+	}
+	hh.failures[host.Id]++
+	if hh.failures[host.Id] >= hostOfflineThreshold || host.Status == models.HostStatusUnknown {
+		return models.HostStatusOffline
+	}
+
+	return host.Status
+}
+
+func hostHealthData(status models.HostStatus, resp *pb.HealthResponse) models.CommonMap {
+	data := models.CommonMap{"status": status}
+	if status != models.HostStatusOnline && status != models.HostStatusDraining {
+		return data
+	}
+	data["last_seen"] = time.Now()
+	if resp != nil {
+		data["version"] = resp.Version
+		data["platform"] = resp.Os + "/" + resp.Arch
+		data["load1"] = resp.Load1
+		data["mem_total"] = int64(resp.MemTotal)
+		data["mem_free"] = int64(resp.MemFree)
+		data["disk_total"] = int64(resp.DiskTotal)
+		data["disk_free"] = int64(resp.DiskFree)
+		data["running_jobs"] = int(resp.RunningJobs)
+		// 节点配置文件中设置了标签时以节点为准
+		if resp.Labels != "" {
+			if labels, err := models.NormalizeLabels(resp.Labels); err == nil {
+				data["labels"] = labels
+			} else {
+				logger.Warnf("Host health check#Invalid node labels-%s#%s", resp.Labels, err.Error())
+			}
+		}
+	}
+
+	return data
+}
+
+// 节点离线或恢复时, 通过在该节点上运行的任务的通知配置发送通知
+// 离线通知发给开启了失败或总是通知的任务, 恢复通知只发给总是通知的任务
+// 通知方式和接收人相同的任务只发送一次
+func notifyHostStatus(host models.Host, status models.HostStatus, err error) {
+	tasks, listErr := new(models.Task).NotifyListByHost(host)
+	if listErr != nil {
+		logger.Error("Host status notification#Failed to get tasks-", listErr)
+		return
+	}
+	statusName := "Success"
+	output := fmt.Sprintf("Host: [%s-%s:%d]\nnode is back online", host.Alias, host.Name, host.Port)
+	if status == models.HostStatusOffline {
+		statusName = "Failed"
+		output = fmt.Sprintf("Host: [%s-%s:%d]\nnode is offline", host.Alias, host.Name, host.Port)
+		if err != nil {
+			output += ": " + err.Error()
+		}
+	}
+	name := host.Alias
+	if name == "" {
+		name = host.Name
+	}
+	sent := make(map[string]bool)
+	for _, task := range tasks {
+		if task.NotifyStatus != 1 && task.NotifyStatus != 2 {
+			continue
+		}
+		if status != models.HostStatusOffline && task.NotifyStatus != 2 {
+			continue
+		}
+		key := fmt.Sprintf("%d#%s", task.NotifyType, task.NotifyReceiverId)
+		if sent[key] {
+			continue
+		}
+		sent[key] = true
+		pushNotification(task, notify.Message{
+			"name":    "Node " + name,
+			"output":  output,
+			"status":  statusName,
+			"task_id": task.Id,
+			"remark":  host.Remark,
+		})
+	}
+}
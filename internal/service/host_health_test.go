@@ -0,0 +1,97 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tabortao/gocron/internal/models"
+	pb "github.com/tabortao/gocron/internal/modules/rpc/proto"
+)
+
+func TestHostHealthNextStatus(t *testing.T) {
+	hh := HostHealth{failures: make(map[int]int)}
+	down := errors.New("connection refused")
+
+	online := models.Host{Id: 1, Status: models.HostStatusOnline}
+	if got := hh.nextStatus(online, down); got != models.HostStatusOnline {
+		t.Fatalf("first failure should keep online, got %d", got)
+	}
+	if got := hh.nextStatus(online, down); got != models.HostStatusOffline {
+		t.Fatalf("second failure should mark offline, got %d", got)
+	}
+	if got := hh.nextStatus(online, nil); got != models.HostStatusOnline {
+		t.Fatalf("success should mark online, got %d", got)
+	}
+	if hh.failures[1] != 0 {
+		t.Fatalf("failures should be reset, got %d", hh.failures[1])
+	}
+
+	unknown := models.Host{Id: 2}
+	if got := hh.nextStatus(unknown, down); got != models.HostStatusOffline {
+		t.Fatalf("unchecked host should be offline on first failure, got %d", got)
+	}
+}
+
+func TestHostHealthCheckDraining(t *testing.T) {
+	original := rpcHealthFunc
+	defer func() { rpcHealthFunc = original }()
+	defer hostDrain.set(3, false)
+	setupNodeTokenDB(t)
+
+	host := models.Host{Name: "node-3", Alias: "node-3", Port: 5921, Status: models.HostStatusOnline}
+	if _, err := host.Create(); err != nil {
+		t.Fatal(err)
+	}
+	draining := true
+	rpcHealthFunc = func(ip string, port int, timeout time.Duration) (*pb.HealthResponse, error) {
+		return &pb.HealthResponse{Version: "1.7.0", Draining: draining}, nil
+	}
+	hh := HostHealth{failures: make(map[int]int)}
+	if got := hh.Check(host); got != models.HostStatusDraining || !hostDrain.is(host.Id) {
+		t.Fatalf("expected draining status, got %d", got)
+	}
+	saved := models.Host{}
+	if err := saved.Find(host.Id); err != nil {
+		t.Fatal(err)
+	}
+	if saved.Status != models.HostStatusDraining || saved.Version != "1.7.0" {
+		t.Fatalf("draining status should be saved, got %+v", saved)
+	}
+
+	draining = false
+	if got := hh.Check(saved); got != models.HostStatusOnline || hostDrain.is(host.Id) {
+		t.Fatalf("expected online after drain, got %d", got)
+	}
+}
+
+func TestHostHealthData(t *testing.T) {
+	data := hostHealthData(models.HostStatusOffline, nil)
+	if len(data) != 1 || data["status"] != models.HostStatusOffline {
+		t.Fatalf("offline host should only update status, got %v", data)
+	}
+
+	data = hostHealthData(models.HostStatusOnline, &pb.HealthResponse{
+		Version: "1.7.0", Os: "linux", Arch: "amd64", Load1: 0.5,
+		MemTotal: 2048, MemFree: 1024, RunningJobs: 3,
+	})
+	if data["platform"] != "linux/amd64" || data["mem_free"] != int64(1024) || data["running_jobs"] != 3 {
+		t.Fatalf("unexpected data: %v", data)
+	}
+	if _, ok := data["last_seen"]; !ok {
+		t.Fatal("online host should update last_seen")
+	}
+	if _, ok := data["labels"]; ok {
+		t.Fatal("labels should not be updated when the node reports none")
+	}
+
+	// 节点配置的标签
+	data = hostHealthData(models.HostStatusOnline, &pb.HealthResponse{Labels: " role=web, env=prod "})
+	if data["labels"] != "env=prod,role=web" {
+		t.Fatalf("node labels should be normalized, got %v", data["labels"])
+	}
+	data = hostHealthData(models.HostStatusOnline, &pb.HealthResponse{Labels: "invalid"})
+	if _, ok := data["labels"]; ok {
+		t.Fatal("invalid node labels should be ignored")
+	}
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tabortao/gocron/internal/models"
+)
+
+var (
+	// 各主机上正在运行的任务数, 用于选择负载最低的主机
+	hostLoad = HostLoad{load: make(map[int]int)}
+
+	// 轮询选择主机时每个任务的下一个位置
+	roundRobin = RoundRobin{next: make(map[int]int)}
+
+	// 正在排空的主机, 由健康检查及执行结果更新
+	hostDrain = HostDrain{hosts: make(map[int]time.Time)}
+
+	shuffleFunc = rand.Shuffle
+)
+
+// 主机负载
+type HostLoad struct {
+	mu   sync.Mutex
+	load map[int]int
+}
+
+func (hl *HostLoad) add(hostId int) {
+	hl.mu.Lock()
+	hl.load[hostId]++
+	hl.mu.Unlock()
+}
+
+func (hl *HostLoad) done(hostId int) {
+	hl.mu.Lock()
+	defer hl.mu.Unlock()
+	hl.load[hostId]--
+	if hl.load[hostId] <= 0 {
+		delete(hl.load, hostId)
+	}
+}
+
+func (hl *HostLoad) get(hostId int) int {
+	hl.mu.Lock()
+	defer hl.mu.Unlock()
+	return hl.load[hostId]
+}
+
+// 轮询位置
+type RoundRobin struct {
+	mu   sync.Mutex
+	next map[int]int
+}
+
+func (rr *RoundRobin) take(taskId int, n int) int {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	i := rr.next[taskId] % n
+	rr.next[taskId] = i + 1
+	return i
+}
+
+// 排空标记的有效期, 未启用健康检查时节点重启后也能恢复使用
+const hostDrainTTL = 5 * time.Minute
+
+// 排空中的主机, value为标记过期时间
+type HostDrain struct {
+	mu    sync.Mutex
+	hosts map[int]time.Time
+}
+
+func (hd *HostDrain) set(hostId int, draining bool) {
+	hd.mu.Lock()
+	defer hd.mu.Unlock()
+	if draining {
+		hd.hosts[hostId] = time.Now().Add(hostDrainTTL)
+	} else {
+		delete(hd.hosts, hostId)
+	}
+}
+
+func (hd *HostDrain) is(hostId int) bool {
+	hd.mu.Lock()
+	defer hd.mu.Unlock()
+	expires, ok := hd.hosts[hostId]
+	if ok && time.Now().After(expires) {
+		delete(hd.hosts, hostId)
+		return false
+	}
+
+	return ok
+}
+
+// 按任务的主机选择方式返回候选主机, 第一台为首选, 其余依次用于故障转移
+// 排空中的主机不参与选择, 全部排空时仍返回所有主机, 由执行结果记录错误
+func selectHosts(taskModel models.Task) []models.TaskHostDetail {
+	hosts := make([]models.TaskHostDetail, 0, len(taskModel.Hosts))
+	for _, host := range taskModel.Hosts {
+		if !hostDrain.is(host.HostId) {
+			hosts = append(hosts, host)
+		}
+	}
+	if len(hosts) == 0 {
+		hosts = append(hosts, taskModel.Hosts...)
+	}
+	if len(hosts) <= 1 {
+		return hosts
+	}
+
+	switch taskModel.HostSelection {
+	case models.HostSelectionRoundRobin:
+		start := roundRobin.take(taskModel.Id, len(hosts))
+		hosts = append(hosts[start:], hosts[:start]...)
+	case models.HostSelectionRandom:
+		shuffleFunc(len(hosts), func(i, j int) {
+			hosts[i], hosts[j] = hosts[j], hosts[i]
+		})
+	case models.HostSelectionLeastLoaded:
+		// 稳定排序, 负载相同时保持原有顺序
+		loads := make(map[int]int, len(hosts))
+		for _, host := range hosts {
+			loads[host.HostId] = hostLoad.get(host.HostId)
+		}
+		sort.SliceStable(hosts, func(i, j int) bool {
+			return loads[hosts[i].HostId] < loads[hosts[j].HostId]
+		})
+	}
+
+	return hosts
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tabortao/gocron/internal/models"
+	rpcClient "github.com/tabortao/gocron/internal/modules/rpc/client"
+	pb "github.com/tabortao/gocron/internal/modules/rpc/proto"
+)
+
+func testHosts(names ...string) []models.TaskHostDetail {
+	hosts := make([]models.TaskHostDetail, len(names))
+	for i, name := range names {
+		hosts[i] = models.TaskHostDetail{TaskHost: models.TaskHost{HostId: i + 1}, Name: name, Port: 5921, Alias: name}
+	}
+	return hosts
+}
+
+func hostNames(hosts []models.TaskHostDetail) string {
+	names := make([]string, len(hosts))
+	for i, host := range hosts {
+		names[i] = host.Name
+	}
+	return strings.Join(names, ",")
+}
+
+func TestSelectHostsRoundRobin(t *testing.T) {
+	task := models.Task{Id: 1001, HostSelection: models.HostSelectionRoundRobin, Hosts: testHosts("a", "b", "c")}
+	expected := []string{"a,b,c", "b,c,a", "c,a,b", "a,b,c"}
+	for _, want := range expected {
+		if got := hostNames(selectHosts(task)); got != want {
+			t.Fatalf("expected %s, got %s", want, got)
+		}
+	}
+	if hostNames(task.Hosts) != "a,b,c" {
+		t.Fatal("task hosts must not be modified")
+	}
+}
+
+func TestSelectHostsLeastLoaded(t *testing.T) {
+	task := models.Task{Id: 1002, HostSelection: models.HostSelectionLeastLoaded, Hosts: testHosts("a", "b", "c")}
+	hostLoad.add(1)
+	hostLoad.add(1)
+	hostLoad.add(2)
+	defer func() {
+		hostLoad.done(1)
+		hostLoad.done(1)
+		hostLoad.done(2)
+	}()
+	if got := hostNames(selectHosts(task)); got != "c,b,a" {
+		t.Fatalf("expected c,b,a, got %s", got)
+	}
+}
+
+func TestRPCHandlerFailoverOnUnavailable(t *testing.T) {
+	original := rpcExecFunc
+	defer func() { rpcExecFunc = original }()
+
+	called := make([]string, 0)
+	rpcExecFunc = func(ip string, port int, taskReq *pb.TaskRequest) (string, error) {
+		called = append(called, ip)
+		if ip == "a" {
+			return "", fmt.Errorf("%w: connection refused", rpcClient.ErrUnavailable)
+		}
+		return "ok from " + ip, nil
+	}
+
+	task := models.Task{Id: 1003, HostSelection: models.HostSelectionFailover, Hosts: testHosts("a", "b", "c")}
+	output, err := new(RPCHandler).Run(task, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(called, ",") != "a,b" {
+		t.Fatalf("expected hosts a,b to be called, got %v", called)
+	}
+	if !strings.Contains(output, "ok from b") {
+		t.Fatalf("unexpected output: %s", output)
+	}
+}
+
+func TestRPCHandlerNoFailoverOnCommandError(t *testing.T) {
+	original := rpcExecFunc
+	defer func() { rpcExecFunc = original }()
+
+	calls := 0
+	rpcExecFunc = func(ip string, port int, taskReq *pb.TaskRequest) (string, error) {
+		calls++
+		return "", errors.New("exit status 1")
+	}
+
+	task := models.Task{Id: 1004, HostSelection: models.HostSelectionFailover, Hosts: testHosts("a", "b")}
+	if _, err := new(RPCHandler).Run(task, 1); err == nil {
+		t.Fatal("expected error")
+	}
+	if calls != 1 {
+		t.Fatalf("expected 1 call, got %d", calls)
+	}
+}
+
+func TestSelectHostsSkipsDraining(t *testing.T) {
+	original := rpcExecFunc
+	defer func() { rpcExecFunc = original }()
+	defer func() {
+		hostDrain.set(1, false)
+		hostDrain.set(2, false)
+	}()
+
+	// 执行时节点返回排空中, 转移到下一台并记录
+	called := make([]string, 0)
+	rpcExecFunc = func(ip string, port int, taskReq *pb.TaskRequest) (string, error) {
+		called = append(called, ip)
+		if ip == "a" {
+			return "", fmt.Errorf("%w: %w", rpcClient.ErrUnavailable, rpcClient.ErrDraining)
+		}
+		return "ok", nil
+	}
+	task := models.Task{Id: 1005, HostSelection: models.HostSelectionFailover, Hosts: testHosts("a", "b", "c")}
+	if _, err := new(RPCHandler).Run(task, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(called, ",") != "a,b" || !hostDrain.is(1) {
+		t.Fatalf("expected failover from draining host, called %v", called)
+	}
+
+	// 之后选择主机时跳过排空中的主机
+	if got := hostNames(selectHosts(task)); got != "b,c" {
+		t.Fatalf("expected b,c, got %s", got)
+	}
+	hostDrain.set(2, true)
+	single := models.Task{Id: 1006, Hosts: testHosts("a", "b")}
+	if got := hostNames(selectHosts(single)); got != "a,b" {
+		t.Fatalf("all hosts draining should keep all hosts, got %s", got)
+	}
+}
+
+func TestHostDrainClears(t *testing.T) {
+	original := rpcExecFunc
+	defer func() { rpcExecFunc = original }()
+	defer hostDrain.set(1, false)
+	rpcExecFunc = func(ip string, port int, taskReq *pb.TaskRequest) (string, error) {
+		return "ok", nil
+	}
+
+	// 执行成功后清除排空标记
+	hostDrain.set(1, true)
+	execOnHost(testHosts("a")[0], &pb.TaskRequest{})
+	if hostDrain.is(1) {
+		t.Fatal("successful exec should clear the draining flag")
+	}
+
+	// 标记过期后不再跳过
+	hostDrain.set(1, true)
+	hostDrain.mu.Lock()
+	hostDrain.hosts[1] = time.Now().Add(-time.Second)
+	hostDrain.mu.Unlock()
+	if hostDrain.is(1) {
+		t.Fatal("expired draining flag should be ignored")
+	}
+}
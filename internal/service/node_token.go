@@ -0,0 +1,145 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/app"
+	"github.com/tabortao/gocron/internal/modules/logger"
+	"github.com/tabortao/gocron/internal/modules/rpc/auth"
+	rpcClient "github.com/tabortao/gocron/internal/modules/rpc/client"
+	"github.com/tabortao/gocron/internal/modules/rpc/reverse"
+	"github.com/tabortao/gocron/internal/modules/utils"
+)
+
+const (
+	// 节点令牌缓存时间, 本服务生成或吊销令牌时立即清除
+	nodeTokenCacheTTL = time.Minute
+	// 下发令牌到节点的超时时间
+	nodeTokenPushTimeout = 5 * time.Second
+)
+
+// ErrNodeTokenNotPushed 令牌已在服务端吊销, 但未能通知节点
+var ErrNodeTokenNotPushed = errors.New("node token revoked on server, but the node was not notified")
+
+type cachedNodeToken struct {
+	token   string
+	expires time.Time
+}
+
+// 请求节点时附带的令牌, key为主机名
+var nodeTokenCache sync.Map
+
+func init() {
+	rpcClient.NodeToken = nodeToken
+}
+
+// IssueNodeToken 为主机生成新的节点令牌并返回明文, 明文只在生成时可见
+// 令牌由 auth_secret 派生, 修改 auth_secret 后需重新生成
+func IssueNodeToken(hostId int) (string, error) {
+	nonce, token := newNodeToken()
+	if err := saveNodeToken(hostId, nonce, token); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// RotateNodeToken 重新生成节点令牌, 先使用当前令牌将新令牌下发到节点, 节点更新成功后才保存
+// 节点无法访问时返回错误, 服务端和节点继续使用当前令牌
+func RotateNodeToken(host models.Host) (string, error) {
+	nonce, token := newNodeToken()
+	if err := rpcClient.SetToken(host.Name, host.Port, token, nodeTokenPushTimeout); err != nil {
+		return "", err
+	}
+	if err := saveNodeToken(host.Id, nonce, token); err != nil {
+		logger.Errorf("节点已更新令牌, 服务端保存失败, 需重新注册节点#主机-%s:%d#%v", host.Name, host.Port, err)
+		return "", err
+	}
+
+	return token, nil
+}
+
+// RevokeNodeToken 吊销节点令牌并通知节点, 节点在重新注册前拒绝所有请求
+// 节点无法访问时服务端仍吊销令牌, 返回 ErrNodeTokenNotPushed
+func RevokeNodeToken(host models.Host) error {
+	pushErr := rpcClient.SetToken(host.Name, host.Port, "", nodeTokenPushTimeout)
+	if err := new(models.Host).RevokeToken(host.Id); err != nil {
+		return err
+	}
+	nodeTokenCache.Clear()
+	// 已建立的反向连接使用被吊销的令牌认证, 断开后节点需使用新令牌重新连接
+	reverse.Disconnect(host.Name)
+	if pushErr != nil {
+		return fmt.Errorf("%w: %w", ErrNodeTokenNotPushed, pushErr)
+	}
+
+	return nil
+}
+
+func newNodeToken() (nonce string, token string) {
+	nonce = utils.RandAuthToken()
+	return nonce, auth.DeriveNodeToken(app.Setting.AuthSecret, nonce)
+}
+
+func saveNodeToken(hostId int, nonce string, token string) error {
+	if err := new(models.Host).SetToken(hostId, nonce, auth.HashToken(token)); err != nil {
+		return err
+	}
+	nodeTokenCache.Clear()
+
+	return nil
+}
+
+// 请求节点时附带的令牌, 未生成令牌的主机返回空, 结果缓存 nodeTokenCacheTTL
+func nodeToken(name string, port int) string {
+	if models.Db == nil || app.Setting == nil {
+		return ""
+	}
+	if v, ok := nodeTokenCache.Load(name); ok {
+		cached := v.(cachedNodeToken)
+		if time.Now().Before(cached.expires) {
+			return cached.token
+		}
+	}
+	token := loadNodeToken(name, port)
+	nodeTokenCache.Store(name, cachedNodeToken{token: token, expires: time.Now().Add(nodeTokenCacheTTL)})
+
+	return token
+}
+
+func loadNodeToken(name string, port int) string {
+	host := new(models.Host)
+	if err := host.FindByName(name); err != nil || host.TokenNonce == "" {
+		return ""
+	}
+	token := auth.DeriveNodeToken(app.Setting.AuthSecret, host.TokenNonce)
+	if !auth.VerifyToken(token, host.TokenHash) {
+		logger.Warnf("节点令牌与 auth_secret 不匹配, 请重新生成令牌#主机-%s:%d", name, port)
+		return ""
+	}
+
+	return token
+}
+
+// 反向连接的节点需以反向连接方式注册, 并提供正确的节点令牌或CN为主机名的客户端证书
+func authorizeReverseHost(hostname, token, certName string) error {
+	host := new(models.Host)
+	if err := host.FindByName(hostname); err != nil || !host.Reverse {
+		return errReverseHostNotRegistered
+	}
+	if token != "" {
+		if host.TokenHash == "" || !auth.VerifyToken(token, host.TokenHash) {
+			return errInvalidNodeToken
+		}
+		return nil
+	}
+	if certName == "" || certName != hostname {
+		return errNodeCredentialRequired
+	}
+
+	return nil
+}
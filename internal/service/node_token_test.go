@@ -0,0 +1,103 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/app"
+	"github.com/tabortao/gocron/internal/modules/setting"
+	"gorm.io/gorm"
+)
+
+func setupNodeTokenDB(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Host{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	oldDb, oldSetting := models.Db, app.Setting
+	models.Db, app.Setting = db, &setting.Setting{AuthSecret: "test-secret"}
+	nodeTokenCache.Clear()
+	t.Cleanup(func() {
+		models.Db, app.Setting = oldDb, oldSetting
+		nodeTokenCache.Clear()
+	})
+}
+
+func TestNodeTokenRotateAndRevoke(t *testing.T) {
+	setupNodeTokenDB(t)
+	host := &models.Host{Name: "node-1", Alias: "node-1", Port: 5921}
+	if _, err := host.Create(); err != nil {
+		t.Fatal(err)
+	}
+	if got := nodeToken("node-1", 5921); got != "" {
+		t.Fatalf("expected no token before issuing, got %q", got)
+	}
+
+	first, err := IssueNodeToken(host.Id)
+	if err != nil || first == "" {
+		t.Fatalf("IssueNodeToken = %q, %v", first, err)
+	}
+	if got := nodeToken("node-1", 5921); got != first {
+		t.Fatalf("nodeToken = %q, want %q", got, first)
+	}
+	second, _ := IssueNodeToken(host.Id)
+	if second == first || nodeToken("node-1", 5921) != second {
+		t.Fatal("rotated token should replace the old one")
+	}
+
+	// auth_secret 变化(重启)后不再发送无效令牌
+	app.Setting.AuthSecret = "changed"
+	nodeTokenCache.Clear()
+	if got := nodeToken("node-1", 5921); got != "" {
+		t.Fatalf("expected empty token after secret change, got %q", got)
+	}
+	app.Setting.AuthSecret = "test-secret"
+
+	if err := host.RevokeToken(host.Id); err != nil {
+		t.Fatal(err)
+	}
+	nodeTokenCache.Clear()
+	if got := nodeToken("node-1", 5921); got != "" {
+		t.Fatalf("expected empty token after revoke, got %q", got)
+	}
+}
+
+func TestAuthorizeReverseHost(t *testing.T) {
+	setupNodeTokenDB(t)
+	direct := &models.Host{Name: "direct", Alias: "direct", Port: 5921}
+	reverseHost := &models.Host{Name: "nat", Alias: "nat", Port: 5921, Reverse: true}
+	direct.Create()
+	reverseHost.Create()
+
+	if err := authorizeReverseHost("direct", "", "direct"); err != errReverseHostNotRegistered {
+		t.Fatalf("direct host: got %v", err)
+	}
+	if err := authorizeReverseHost("missing", "", "missing"); err != errReverseHostNotRegistered {
+		t.Fatalf("missing host: got %v", err)
+	}
+	// 没有令牌和证书时仅凭主机名不能连接
+	if err := authorizeReverseHost("nat", "", ""); err != errNodeCredentialRequired {
+		t.Fatalf("reverse host without credential: got %v", err)
+	}
+	if err := authorizeReverseHost("nat", "", "other"); err != errNodeCredentialRequired {
+		t.Fatalf("certificate of another host: got %v", err)
+	}
+	if err := authorizeReverseHost("nat", "", "nat"); err != nil {
+		t.Fatalf("valid certificate: got %v", err)
+	}
+	if err := authorizeReverseHost("nat", "any", ""); err != errInvalidNodeToken {
+		t.Fatalf("token before issued: got %v", err)
+	}
+
+	token, _ := IssueNodeToken(reverseHost.Id)
+	if err := authorizeReverseHost("nat", "wrong", ""); err != errInvalidNodeToken {
+		t.Fatalf("wrong token: got %v", err)
+	}
+	if err := authorizeReverseHost("nat", token, ""); err != nil {
+		t.Fatalf("valid token: got %v", err)
+	}
+}
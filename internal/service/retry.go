@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tabortao/gocron/internal/models"
+	rpcClient "github.com/tabortao/gocron/internal/modules/rpc/client"
+)
+
+// 未设置重试间隔时的基础间隔
+const defaultRetryInterval = time.Minute
+
+// 指数退避未设置最大间隔时的上限
+const maxRetryDelay = 24 * time.Hour
+
+var jitterFunc = rand.Float64
+
+// HTTPStatusError HTTP任务返回非200状态码
+type HTTPStatusError struct {
+	StatusCode int
+	Err        error // 请求未完成时的错误
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("HTTP status code is not 200-->%d", e.StatusCode)
+}
+
+func (e *HTTPStatusError) Unwrap() error {
+	return e.Err
+}
+
+// 计算第retry次重试前的等待时间, retry从1开始
+func retryDelay(taskModel models.Task, retry int8) time.Duration {
+	base := time.Duration(taskModel.RetryInterval) * time.Second
+	if base <= 0 {
+		base = defaultRetryInterval
+	}
+
+	var delay time.Duration
+	switch taskModel.RetryStrategy {
+	case models.RetryStrategyFixed:
+		delay = base
+	case models.RetryStrategyLinear:
+		delay = base * time.Duration(retry)
+	case models.RetryStrategyExponential:
+		delay = base
+		for i := int8(1); i < retry && delay < maxRetryDelay; i++ {
+			delay *= 2
+		}
+	default:
+		// 兼容旧版本: 设置了重试间隔时固定间隔, 否则每次递增1分钟
+		if taskModel.RetryInterval > 0 {
+			delay = base
+		} else {
+			delay = time.Duration(retry) * defaultRetryInterval
+		}
+	}
+
+	if taskModel.RetryJitter > 0 {
+		jitter := float64(taskModel.RetryJitter) / 100
+		// 在 [delay*(1-jitter), delay*(1+jitter)] 区间内随机
+		delay = time.Duration(float64(delay) * (1 + jitter*(2*jitterFunc()-1)))
+	}
+
+	maxDelay := maxRetryDelay
+	if taskModel.RetryMaxInterval > 0 {
+		maxDelay = time.Duration(taskModel.RetryMaxInterval) * time.Second
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	if delay < 0 {
+		delay = 0
+	}
+
+	return delay
+}
+
+// 根据任务配置的重试条件判断错误是否需要重试
+func shouldRetry(taskModel models.Task, err error) bool {
+	if err == nil || errors.Is(err, rpcClient.ErrManualStop) {
+		return false
+	}
+	retryOn := strings.TrimSpace(taskModel.RetryOn)
+	if retryOn == "" {
+		return true
+	}
+
+	for _, condition := range strings.Split(retryOn, ",") {
+		switch strings.TrimSpace(condition) {
+		case models.RetryOnTimeout:
+			if isTimeoutError(err) {
+				return true
+			}
+		case models.RetryOnUnavailable:
+			if errors.Is(err, rpcClient.ErrUnavailable) {
+				return true
+			}
+		case models.RetryOnExitCode:
+			var execErr *rpcClient.ExecError
+			if errors.As(err, &execErr) && execErr.ExitCode >= 0 &&
+				inExitCodes(taskModel.RetryExitCodes, execErr.ExitCode) {
+				return true
+			}
+		case models.RetryOnHTTP5xx:
+			var statusErr *HTTPStatusError
+			if errors.As(err, &statusErr) && statusErr.StatusCode >= 500 && statusErr.StatusCode <= 599 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func isTimeoutError(err error) bool {
+	if errors.Is(err, rpcClient.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// 退出码列表, 逗号分隔, 如 1,2,127
+func inExitCodes(exitCodes string, code int) bool {
+	for _, item := range strings.Split(exitCodes, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(item))
+		if err == nil && n == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseRetryOn 校验并规范化重试条件
+func ParseRetryOn(retryOn string) (string, error) {
+	conditions := make([]string, 0)
+	for _, condition := range strings.Split(retryOn, ",") {
+		condition = strings.TrimSpace(condition)
+		if condition == "" {
+			continue
+		}
+		switch condition {
+		case models.RetryOnTimeout, models.RetryOnUnavailable, models.RetryOnExitCode, models.RetryOnHTTP5xx:
+		default:
+			return "", fmt.Errorf("invalid retry condition: %s", condition)
+		}
+		conditions = append(conditions, condition)
+	}
+
+	return strings.Join(conditions, ","), nil
+}
+
+// ParseRetryExitCodes 校验并规范化退出码列表
+func ParseRetryExitCodes(exitCodes string) (string, error) {
+	codes := make([]string, 0)
+	for _, item := range strings.Split(exitCodes, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		n, err := strconv.Atoi(item)
+		if err != nil || n < 0 || n > 255 {
+			return "", fmt.Errorf("invalid exit code: %s", item)
+		}
+		codes = append(codes, strconv.Itoa(n))
+	}
+
+	return strings.Join(codes, ","), nil
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/tabortao/gocron/internal/models"
+	rpcClient "github.com/tabortao/gocron/internal/modules/rpc/client"
+)
+
+func TestRetryDelayStrategies(t *testing.T) {
+	cases := []struct {
+		name  string
+		task  models.Task
+		retry int8
+		want  time.Duration
+	}{
+		{"default without interval", models.Task{}, 3, 3 * time.Minute},
+		{"default with interval", models.Task{RetryInterval: 10}, 3, 10 * time.Second},
+		{"fixed", models.Task{RetryStrategy: models.RetryStrategyFixed, RetryInterval: 5}, 4, 5 * time.Second},
+		{"linear", models.Task{RetryStrategy: models.RetryStrategyLinear, RetryInterval: 5}, 4, 20 * time.Second},
+		{"exponential", models.Task{RetryStrategy: models.RetryStrategyExponential, RetryInterval: 5}, 4, 40 * time.Second},
+		{"exponential capped", models.Task{RetryStrategy: models.RetryStrategyExponential, RetryInterval: 5, RetryMaxInterval: 30}, 4, 30 * time.Second},
+	}
+	for _, tc := range cases {
+		if got := retryDelay(tc.task, tc.retry); got != tc.want {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestRetryDelayJitter(t *testing.T) {
+	original := jitterFunc
+	defer func() { jitterFunc = original }()
+
+	task := models.Task{RetryStrategy: models.RetryStrategyFixed, RetryInterval: 10, RetryJitter: 50}
+	jitterFunc = func() float64 { return 0 }
+	if got := retryDelay(task, 1); got != 5*time.Second {
+		t.Fatalf("expected 5s, got %s", got)
+	}
+	jitterFunc = func() float64 { return 1 }
+	if got := retryDelay(task, 1); got != 15*time.Second {
+		t.Fatalf("expected 15s, got %s", got)
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	cases := []struct {
+		name    string
+		retryOn string
+		err     error
+		want    bool
+	}{
+		{"any error", "", errors.New("boom"), true},
+		{"manual stop", "", rpcClient.ErrManualStop, false},
+		{"rpc timeout", models.RetryOnTimeout, rpcClient.ErrTimeout, true},
+		{"node timeout", models.RetryOnTimeout, &rpcClient.ExecError{Message: "timeout killed", ExitCode: -1}, true},
+		{"unavailable", models.RetryOnUnavailable, fmt.Errorf("%w: dial failed", rpcClient.ErrUnavailable), true},
+		{"exit code matched", models.RetryOnExitCode, &rpcClient.ExecError{Message: "exit status 2", ExitCode: 2}, true},
+		{"exit code not matched", models.RetryOnExitCode, &rpcClient.ExecError{Message: "exit status 3", ExitCode: 3}, false},
+		{"http 5xx", models.RetryOnHTTP5xx, &HTTPStatusError{StatusCode: 503}, true},
+		{"http 4xx", models.RetryOnHTTP5xx, &HTTPStatusError{StatusCode: 404}, false},
+		{"condition not matched", models.RetryOnTimeout, errors.New("boom"), false},
+	}
+	for _, tc := range cases {
+		task := models.Task{RetryOn: tc.retryOn, RetryExitCodes: "1,2"}
+		if got := shouldRetry(task, tc.err); got != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestExecJobStopsWhenRetryConditionNotMatched(t *testing.T) {
+	originalSleep := sleepFunc
+	defer func() { sleepFunc = originalSleep }()
+	sleepFunc = func(d time.Duration) {}
+
+	handler := &fakeHandler{
+		results: []handlerResponse{
+			{result: "first", err: &HTTPStatusError{StatusCode: 502}},
+			{result: "second", err: &HTTPStatusError{StatusCode: 404}},
+			{result: "third", err: nil},
+		},
+	}
+	task := models.Task{Id: 3, RetryTimes: 2, RetryOn: models.RetryOnHTTP5xx}
+	result := execJob(handler, task, 1)
+	if result.Err == nil || result.Result != "second" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if handler.callCount != 2 {
+		t.Fatalf("expected 2 handler calls, got %d", handler.callCount)
+	}
+	if len(result.Attempts) != 2 {
+		t.Fatalf("expected 2 attempts, got %d", len(result.Attempts))
+	}
+	if result.Attempts[0].Attempt != 1 || result.Attempts[0].Result != "first" || result.Attempts[0].Status != models.Failure {
+		t.Fatalf("unexpected first attempt: %+v", result.Attempts[0])
+	}
+}
+
+func TestParseRetryOn(t *testing.T) {
+	got, err := ParseRetryOn(" timeout, http_5xx ,")
+	if err != nil || got != "timeout,http_5xx" {
+		t.Fatalf("unexpected result %q, %v", got, err)
+	}
+	if _, err = ParseRetryOn("timeout,unknown"); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err = ParseRetryExitCodes("1,256"); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/tabortao/gocron/internal/modules/app"
+	"github.com/tabortao/gocron/internal/modules/logger"
+	"github.com/tabortao/gocron/internal/modules/rpc/auth"
+	"github.com/tabortao/gocron/internal/modules/rpc/reverse"
+)
+
+var (
+	errReverseHostNotRegistered = errors.New("host is not registered as reverse-connected")
+	errInvalidNodeToken         = errors.New("invalid node token")
+	errNodeCredentialRequired   = errors.New("node token or client certificate is required")
+)
+
+// StartAgentListener 启动节点反向连接监听, 未配置 agent.listen 时不启用
+func StartAgentListener() {
+	if app.Setting.AgentListen == "" {
+		return
+	}
+	certificate := auth.Certificate{
+		CAFile:   app.Setting.CAFile,
+		CertFile: app.Setting.CertFile,
+		KeyFile:  app.Setting.KeyFile,
+	}
+	enableTLS := app.Setting.EnableTLS
+	// 启用内置CA时使用其签发的服务端证书, 节点通过令牌认证, 客户端证书可选
+	if !enableTLS && BuiltinCA != nil {
+		enableTLS = true
+		certificate = builtinCertificate("")
+		certificate.ClientCertOptional = true
+	}
+	err := reverse.Start(app.Setting.AgentListen, enableTLS, certificate, authorizeReverseHost)
+	if err != nil {
+		logger.Error("启动节点反向连接监听失败", err)
+	}
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tabortao/gocron/internal/models"
+	"github.com/tabortao/gocron/internal/modules/logger"
+	rpcClient "github.com/tabortao/gocron/internal/modules/rpc/client"
+	pb "github.com/tabortao/gocron/internal/modules/rpc/proto"
+)
+
+// 单台主机的执行结果
+type HostResult struct {
+	HostId     int
+	Alias      string
+	Name       string
+	Port       int
+	Output     string
+	Err        error
+	Skipped    bool // 滚动执行中止或主机排空中, 未执行
+	Draining   bool // 主机排空中, 未执行
+	FailedOver bool // 节点不可用, 已转移到下一台主机执行
+	StartTime  time.Time
+	EndTime    time.Time
+}
+
+func (r HostResult) Status() models.Status {
+	switch {
+	case r.Skipped, errors.Is(r.Err, rpcClient.ErrManualStop):
+		return models.Cancel
+	case r.Err != nil:
+		return models.Failure
+	default:
+		return models.Finish
+	}
+}
+
+// 主机输出, 以 Host: [...] 开头
+func (r HostResult) String() string {
+	message := ""
+	if r.Skipped {
+		message = r.skipMessage() + "\n"
+	} else if r.Err != nil {
+		if errors.Is(r.Err, rpcClient.ErrManualStop) {
+			message = "Manually stopped\n"
+		} else {
+			message = strings.TrimSpace(r.Err.Error()) + "\n"
+		}
+	}
+
+	return fmt.Sprintf("Host: [%s-%s:%d]\n%s%s", r.Alias, r.Name, r.Port, message, strings.TrimSpace(r.Output))
+}
+
+func (r HostResult) skipMessage() string {
+	if r.Draining {
+		return "Skipped: host is draining"
+	}
+
+	return "Skipped: rolling execution aborted"
+}
+
+// 每批执行的主机数量
+func rollingBatchSize(taskModel models.Task, hostCount int) int {
+	size := taskModel.BatchSize
+	if taskModel.BatchUnit == models.BatchUnitPercent {
+		// 百分比向上取整, 至少一台
+		size = (hostCount*taskModel.BatchSize + 99) / 100
+	}
+	if size <= 0 || size > hostCount {
+		return hostCount
+	}
+
+	return size
+}
+
+// 按批次在所有主机上执行, 批次内并发, 批次间可暂停
+// 失败主机数达到 MaxFailures 后, 剩余主机不再执行
+// 排空中的主机不执行, 全部排空时仍在所有主机上执行, 由执行结果记录错误
+func runRolling(taskModel models.Task, taskRequest *pb.TaskRequest) []HostResult {
+	hosts := taskModel.Hosts
+	results := make([]HostResult, len(hosts))
+	// 参与执行的主机在 hosts 中的位置
+	targets := make([]int, 0, len(hosts))
+	for i, host := range hosts {
+		if !hostDrain.is(host.HostId) {
+			targets = append(targets, i)
+		}
+	}
+	if len(targets) == 0 {
+		for i := range hosts {
+			targets = append(targets, i)
+		}
+	} else if len(targets) < len(hosts) {
+		logger.Warnf("Rolling execution skips draining hosts#Task ID-%d#Draining hosts-%d", taskModel.Id, len(hosts)-len(targets))
+		for i := range hosts {
+			if hostDrain.is(hosts[i].HostId) {
+				results[i] = skippedHostResult(hosts[i])
+				results[i].Draining = true
+			}
+		}
+	}
+
+	size := rollingBatchSize(taskModel, len(targets))
+	failures := 0
+	for start := 0; start < len(targets); start += size {
+		end := start + size
+		if end > len(targets) {
+			end = len(targets)
+		}
+		if start > 0 {
+			if abortRolling(taskModel, failures, results, targets[:start]) {
+				logger.Warnf("Rolling execution aborted#Task ID-%d#Failed hosts-%d#Skipped hosts-%d",
+					taskModel.Id, failures, len(targets)-start)
+				for _, i := range targets[start:] {
+					results[i] = skippedHostResult(hosts[i])
+				}
+				break
+			}
+			if taskModel.BatchInterval > 0 {
+				logger.Infof("Rolling execution pause#Task ID-%d#Interval-%ds", taskModel.Id, taskModel.BatchInterval)
+				sleepFunc(time.Duration(taskModel.BatchInterval) * time.Second)
+			}
+		}
+
+		batch := targets[start:end]
+		done := make(chan struct{}, len(batch))
+		for _, i := range batch {
+			go func(i int) {
+				results[i] = execOnHost(hosts[i], taskRequest)
+				done <- struct{}{}
+			}(i)
+		}
+		for range batch {
+			<-done
+		}
+		for _, i := range batch {
+			if results[i].Err != nil {
+				failures++
+			}
+		}
+	}
+
+	return results
+}
+
+func skippedHostResult(th models.TaskHostDetail) HostResult {
+	now := time.Now()
+	result := newHostResult(th)
+	result.Skipped = true
+	result.StartTime, result.EndTime = now, now
+
+	return result
+}
+
+// 是否停止执行剩余批次, finished 为已执行主机在 results 中的位置
+func abortRolling(taskModel models.Task, failures int, results []HostResult, finished []int) bool {
+	for _, i := range finished {
+		if errors.Is(results[i].Err, rpcClient.ErrManualStop) {
+			return true
+		}
+	}
+
+	return taskModel.MaxFailures > 0 && failures >= taskModel.MaxFailures
+}
+
+// 汇总各主机结果, 错误取最后一台失败主机的错误, 已故障转移的主机不计入
+func aggregateHostResults(results []HostResult) (string, error) {
+	outputs := make([]string, 0, len(results))
+	var err error
+	for _, r := range results {
+		outputs = append(outputs, r.String())
+		if r.Err != nil && !r.FailedOver {
+			err = r.Err
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("%s: %w", hostSummary(results), err)
+	}
+
+	return strings.Join(outputs, "\n"), err
+}
+
+// 执行失败的主机数, 已故障转移到其他主机的不计入
+func failedHostCount(results []HostResult) int {
+	failed := 0
+	for _, r := range results {
+		if r.Status() == models.Failure && !r.FailedOver {
+			failed++
+		}
+	}
+
+	return failed
+}
+
+// 主机执行汇总, 如 3/5 hosts failed
+func hostSummary(results []HostResult) string {
+	summary := fmt.Sprintf("%d/%d hosts failed", failedHostCount(results), len(results))
+	skipped := 0
+	for _, r := range results {
+		if r.Skipped {
+			skipped++
+		}
+	}
+	if skipped > 0 {
+		summary += fmt.Sprintf(", %d hosts skipped", skipped)
+	}
+
+	return summary
+}
+
+// 转换为主机执行记录
+func newTaskLogHost(taskLogId int64, r HostResult) models.TaskLogHost {
+	record := models.TaskLogHost{
+		TaskLogId: taskLogId,
+		HostId:    r.HostId,
+		Alias:     r.Alias,
+		Name:      r.Name,
+		Port:      r.Port,
+		Status:    r.Status(),
+		Output:    r.Output,
+		StartTime: models.LocalTime(r.StartTime),
+		EndTime:   models.LocalTime(r.EndTime),
+	}
+	if r.Skipped {
+		record.ExitCode = -1
+		record.Error = r.skipMessage()
+	} else if r.Err != nil {
+		record.ExitCode = -1
+		var execErr *rpcClient.ExecError
+		if errors.As(r.Err, &execErr) {
+			record.ExitCode = execErr.ExitCode
+		}
+		message := []rune(r.Err.Error())
+		if len(message) > 512 {
+			message = message[:512]
+		}
+		record.Error = string(message)
+	}
+
+	return record
+}
+
+func newHostResult(th models.TaskHostDetail) HostResult {
+	return HostResult{HostId: th.HostId, Alias: th.Alias, Name: th.Name, Port: th.Port}
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tabortao/gocron/internal/models"
+	rpcClient "github.com/tabortao/gocron/internal/modules/rpc/client"
+	pb "github.com/tabortao/gocron/internal/modules/rpc/proto"
+)
+
+func TestRollingBatchSize(t *testing.T) {
+	tests := []struct {
+		size  int
+		unit  models.TaskBatchUnit
+		hosts int
+		want  int
+	}{
+		{0, models.BatchUnitHost, 5, 5},
+		{2, models.BatchUnitHost, 5, 2},
+		{10, models.BatchUnitHost, 5, 5},
+		{20, models.BatchUnitPercent, 5, 1},
+		{30, models.BatchUnitPercent, 5, 2},
+		{1, models.BatchUnitPercent, 5, 1},
+		{100, models.BatchUnitPercent, 5, 5},
+	}
+	for _, tt := range tests {
+		task := models.Task{BatchSize: tt.size, BatchUnit: tt.unit}
+		if got := rollingBatchSize(task, tt.hosts); got != tt.want {
+			t.Errorf("rollingBatchSize(%d, %d, %d) = %d, want %d", tt.size, tt.unit, tt.hosts, got, tt.want)
+		}
+	}
+}
+
+func stubRolling(t *testing.T, failed map[string]bool) (*[]string, *[]time.Duration) {
+	originalExec, originalSleep := rpcExecFunc, sleepFunc
+	t.Cleanup(func() { rpcExecFunc, sleepFunc = originalExec, originalSleep })
+
+	var mu sync.Mutex
+	called := make([]string, 0)
+	sleeps := make([]time.Duration, 0)
+	rpcExecFunc = func(ip string, port int, taskReq *pb.TaskRequest) (string, error) {
+		mu.Lock()
+		called = append(called, ip)
+		mu.Unlock()
+		if failed[ip] {
+			return "", errors.New("exit status 1")
+		}
+		return "ok from " + ip, nil
+	}
+	sleepFunc = func(d time.Duration) { sleeps = append(sleeps, d) }
+
+	return &called, &sleeps
+}
+
+func TestRPCHandlerRollingBatches(t *testing.T) {
+	called, sleeps := stubRolling(t, nil)
+
+	task := models.Task{Id: 2001, BatchSize: 2, BatchInterval: 5, Hosts: testHosts("a", "b", "c", "d", "e")}
+	results, err := new(RPCHandler).RunHosts(task, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*called) != 5 || len(results) != 5 {
+		t.Fatalf("expected 5 hosts executed, got %v", *called)
+	}
+	if len(*sleeps) != 2 || (*sleeps)[0] != 5*time.Second {
+		t.Fatalf("expected 2 pauses of 5s, got %v", *sleeps)
+	}
+	for i, r := range results {
+		if r.Name != task.Hosts[i].Name || r.Status() != models.Finish {
+			t.Errorf("unexpected result %d: %+v", i, r)
+		}
+	}
+}
+
+func TestRPCHandlerRollingAbortAfterFailures(t *testing.T) {
+	called, _ := stubRolling(t, map[string]bool{"a": true, "c": true})
+
+	task := models.Task{Id: 2002, BatchSize: 2, MaxFailures: 2, Hosts: testHosts("a", "b", "c", "d", "e", "f")}
+	output, err := new(RPCHandler).Run(task, 1)
+	if err == nil || !strings.Contains(err.Error(), "2/6 hosts failed, 2 hosts skipped") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*called) != 4 {
+		t.Fatalf("expected 4 hosts executed, got %v", *called)
+	}
+	if strings.Count(output, "Host: [") != 6 || strings.Count(output, "Skipped:") != 2 {
+		t.Fatalf("unexpected output: %s", output)
+	}
+
+	results, _ := new(RPCHandler).RunHosts(task, 1)
+	statuses := make([]models.Status, len(results))
+	for i, r := range results {
+		statuses[i] = r.Status()
+	}
+	want := []models.Status{models.Failure, models.Finish, models.Failure, models.Finish, models.Cancel, models.Cancel}
+	for i := range want {
+		if statuses[i] != want[i] {
+			t.Fatalf("statuses = %v, want %v", statuses, want)
+		}
+	}
+}
+
+func TestRPCHandlerRollingFailureWithoutLimit(t *testing.T) {
+	called, _ := stubRolling(t, map[string]bool{"a": true})
+
+	task := models.Task{Id: 2003, BatchSize: 1, Hosts: testHosts("a", "b", "c")}
+	_, err := new(RPCHandler).Run(task, 1)
+	if err == nil || err.Error() != "1/3 hosts failed: exit status 1" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*called) != 3 {
+		t.Fatalf("expected all hosts executed, got %v", *called)
+	}
+}
+
+func TestNewTaskLogHost(t *testing.T) {
+	start := time.Now()
+	tests := []struct {
+		result   HostResult
+		status   models.Status
+		exitCode int
+		error    string
+	}{
+		{HostResult{HostId: 1, Output: "ok"}, models.Finish, 0, ""},
+		{HostResult{HostId: 2, Err: &rpcClient.ExecError{Message: "exit status 3", ExitCode: 3}}, models.Failure, 3, "exit status 3"},
+		{HostResult{HostId: 3, Err: errors.New("connection refused")}, models.Failure, -1, "connection refused"},
+		{HostResult{HostId: 4, Skipped: true}, models.Cancel, -1, "Skipped: rolling execution aborted"},
+	}
+	for _, tt := range tests {
+		tt.result.StartTime, tt.result.EndTime = start, start
+		record := newTaskLogHost(10, tt.result)
+		if record.TaskLogId != 10 || record.HostId != tt.result.HostId {
+			t.Errorf("unexpected record ids: %+v", record)
+		}
+		if record.Status != tt.status || record.ExitCode != tt.exitCode || record.Error != tt.error {
+			t.Errorf("host %d: got status=%d exit=%d error=%q", tt.result.HostId, record.Status, record.ExitCode, record.Error)
+		}
+	}
+}
+
+func TestHostSummaryExcludesFailedOver(t *testing.T) {
+	results := []HostResult{
+		{Name: "a", Err: rpcClient.ErrUnavailable, FailedOver: true},
+		{Name: "b", Err: errors.New("exit status 2")},
+	}
+	_, err := aggregateHostResults(results)
+	if err == nil || err.Error() != "1/2 hosts failed: exit status 2" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := failedHostCount(results); got != 1 {
+		t.Fatalf("expected 1 failed host, got %d", got)
+	}
+}
+
+func TestRPCHandlerRollingSkipsDraining(t *testing.T) {
+	called, _ := stubRolling(t, nil)
+	hostDrain.set(2, true)
+	defer hostDrain.set(2, false)
+
+	task := models.Task{Id: 2004, BatchSize: 1, Hosts: testHosts("a", "b", "c")}
+	results, err := new(RPCHandler).RunHosts(task, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(*called, ",") != "a,c" {
+		t.Fatalf("draining host should be skipped, called %v", *called)
+	}
+	if !results[1].Skipped || !results[1].Draining || !strings.Contains(results[1].String(), "Skipped: host is draining") {
+		t.Fatalf("unexpected result for draining host %+v", results[1])
+	}
+}
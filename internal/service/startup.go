@@ -0,0 +1,25 @@
+package service
+
+import (
+	"time"
+
+	"github.com/tabortao/gocron/internal/modules/app"
+	"github.com/tabortao/gocron/internal/modules/rpc/grpcpool"
+)
+
+// StartBackground 启动定时任务及后台服务, 服务启动和安装完成时调用
+func StartBackground() {
+	// 初始化定时任务
+	ServiceTask.Initialize()
+	// 节点健康检查
+	ServiceHostHealth.Start(time.Duration(app.Setting.HostHealthInterval) * time.Second)
+	// 节点连接池维护
+	grpcpool.Pool.Start(grpcpool.Options{
+		CheckInterval: time.Duration(app.Setting.RPCPoolCheckInterval) * time.Second,
+		IdleTimeout:   time.Duration(app.Setting.RPCPoolIdleTimeout) * time.Second,
+		MaxAge:        time.Duration(app.Setting.RPCPoolMaxAge) * time.Second,
+	})
+	// 内置CA及节点反向连接
+	InitBuiltinCA()
+	StartAgentListener()
+}
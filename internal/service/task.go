@@ -30,6 +30,7 @@ var (
 	httpPostParamsFunc = httpclient.PostParams
 	notifyPushFunc     = notify.Push
 	sleepFunc          = time.Sleep
+	rpcExecFunc        = rpcClient.Exec
 
 	// 定时任务调度管理器
 	serviceCron *cron.Cron
@@ -115,15 +116,16 @@ type TaskResult struct {
 	Result     string
 	Err        error
 	RetryTimes int8
+	Attempts   []models.TaskLogAttempt // 配置了重试时每次执行的记录
+	Hosts      []HostResult            // 多主机任务最后一次执行时每台主机的结果
 }
 
 // 初始化任务, 从数据库取出所有任务, 添加到定时任务并运行
 func (task Task) Initialize() {
 	serviceCron = cron.New()
 	serviceCron.Start()
-	concurrencyQueue = ConcurrencyQueue{queue: make(chan struct{}, app.Setting.ConcurrencyQueue)}
-	taskCount = TaskCount{sync.WaitGroup{}, make(chan struct{})}
-	go taskCount.Wait()
+	task.InitRunner()
+	task.resumeDetachedLogs()
 
 	logger.Info("Starting to initialize scheduled tasks")
 	taskModel := new(models.Task)
@@ -152,6 +154,13 @@ func (task Task) Initialize() {
 	task.initLogCleanupTask()
 }
 
+// 初始化任务执行所需的并发队列和任务计数, 不加载定时任务
+func (task Task) InitRunner() {
+	concurrencyQueue = ConcurrencyQueue{queue: make(chan struct{}, app.Setting.ConcurrencyQueue)}
+	taskCount = TaskCount{sync.WaitGroup{}, make(chan struct{})}
+	go taskCount.Wait()
+}
+
 // 初始化日志清理任务
 func (task Task) initLogCleanupTask() {
 	settingModel := new(models.Setting)
@@ -177,6 +186,17 @@ func (task Task) initLogCleanupTask() {
 			// 清理日志文件
 			cleanupLogFiles()
 		}
+		// 审计日志单独配置保留天数
+		auditDays := settingModel.GetAuditLogRetentionDays()
+		if auditDays > 0 {
+			auditLogModel := new(models.AuditLog)
+			count, err := auditLogModel.RemoveByDays(auditDays)
+			if err != nil {
+				logger.Errorf("Failed to auto-cleanup audit logs: %s", err)
+			} else {
+				logger.Infof("Auto-cleanup audit logs older than %d days, deleted %d records", auditDays, count)
+			}
+		}
 	}, "log-cleanup")
 	logger.Infof("Log auto-cleanup task added, execution time: %s", cleanupTime)
 }
@@ -260,10 +280,45 @@ func (task Task) Run(taskModel models.Task) {
 	go createJob(taskModel)()
 }
 
+// Start 手动运行任务, 写入任务日志后在后台执行, 返回任务日志ID
+func (task Task) Start(taskModel models.Task) (int64, error) {
+	handler := createHandler(taskModel)
+	if handler == nil {
+		return 0, errors.New("unsupported task protocol")
+	}
+	taskLogId, run := prepareJob(handler, taskModel)
+	if run == nil {
+		return 0, errors.New("failed to create task log")
+	}
+	go run()
+
+	return taskLogId, nil
+}
+
 type Handler interface {
 	Run(taskModel models.Task, taskUniqueId int64) (string, error)
 }
 
+// 能返回每台主机执行结果的处理器
+type HostsHandler interface {
+	RunHosts(taskModel models.Task, taskUniqueId int64) ([]HostResult, error)
+}
+
+func runHandler(handler Handler, taskModel models.Task, taskUniqueId int64) (string, []HostResult, error) {
+	hostsHandler, ok := handler.(HostsHandler)
+	if !ok {
+		output, err := handler.Run(taskModel, taskUniqueId)
+		return output, nil, err
+	}
+	results, err := hostsHandler.RunHosts(taskModel, taskUniqueId)
+	if err != nil {
+		return "", nil, err
+	}
+	output, err := aggregateHostResults(results)
+
+	return output, results, err
+}
+
 // HTTP任务
 type HTTPHandler struct{}
 
@@ -288,7 +343,7 @@ func (h *HTTPHandler) Run(taskModel models.Task, taskUniqueId int64) (result str
 	}
 	// 返回状态码非200，均为失败
 	if resp.StatusCode != http.StatusOK {
-		return resp.Body, fmt.Errorf("HTTP status code is not 200-->%d", resp.StatusCode)
+		return resp.Body, &HTTPStatusError{StatusCode: resp.StatusCode, Err: resp.Err}
 	}
 
 	return resp.Body, err
@@ -298,57 +353,81 @@ func (h *HTTPHandler) Run(taskModel models.Task, taskUniqueId int64) (result str
 type RPCHandler struct{}
 
 func (h *RPCHandler) Run(taskModel models.Task, taskUniqueId int64) (result string, err error) {
+	results, err := h.RunHosts(taskModel, taskUniqueId)
+	if err != nil {
+		return "", err
+	}
+
+	return aggregateHostResults(results)
+}
+
+// RunHosts 执行任务并返回每台主机的结果
+func (h *RPCHandler) RunHosts(taskModel models.Task, taskUniqueId int64) ([]HostResult, error) {
 	logger.Infof("RPC task execution started#Task ID-%d#Host count-%d", taskModel.Id, len(taskModel.Hosts))
 	if len(taskModel.Hosts) == 0 {
-		return "", fmt.Errorf("task is not associated with any host")
+		return nil, fmt.Errorf("task is not associated with any host")
 	}
 	taskRequest := new(pb.TaskRequest)
 	taskRequest.Timeout = int32(taskModel.Timeout)
 	taskRequest.Command = taskModel.Command
+	taskRequest.Signature = taskModel.CommandSignature
+	taskRequest.TaskId = int64(taskModel.Id)
+	taskRequest.Detached = taskModel.Detached == 1
 	taskRequest.Id = taskUniqueId
-	resultChan := make(chan TaskResult, len(taskModel.Hosts))
-	for _, taskHost := range taskModel.Hosts {
-		logger.Infof("Preparing RPC call#Host-%s:%d#Command-%s", taskHost.Name, taskHost.Port, taskModel.Command)
-		go func(th models.TaskHostDetail) {
-			output, err := rpcClient.Exec(th.Name, th.Port, taskRequest)
-			errorMessage := ""
-			if err != nil {
-				// 如果是手动停止错误，保留原始错误以便后续判断，但显示翻译后的文本
-				if errors.Is(err, rpcClient.ErrManualStop) {
-					errorMessage = "Manually stopped"
-				} else {
-					errorMessage = err.Error()
-				}
-			}
-			output = strings.TrimSpace(output)
-			if errorMessage != "" {
-				errorMessage = strings.TrimSpace(errorMessage) + "\n"
-			}
-			outputMessage := fmt.Sprintf("Host: [%s-%s:%d]\n%s%s",
-				th.Alias, th.Name, th.Port, errorMessage, output,
-			)
-			logger.Infof("RPC call completed#Host-%s:%d#Output length-%d#Error-%v", th.Name, th.Port, len(output), err)
-			resultChan <- TaskResult{Err: err, Result: outputMessage}
-		}(taskHost)
+	if taskModel.HostSelection != models.HostSelectionAll {
+		return runOnOneHost(taskModel, taskRequest), nil
 	}
 
-	var aggregationErr error = nil
-	aggregationResult := ""
-	for i := 0; i < len(taskModel.Hosts); i++ {
-		taskResult := <-resultChan
-		aggregationResult += taskResult.Result
-		if taskResult.Err != nil {
-			aggregationErr = taskResult.Err
+	return runRolling(taskModel, taskRequest), nil
+}
+
+// 只在一台主机上执行, 节点不可用时依次尝试下一台
+func runOnOneHost(taskModel models.Task, taskRequest *pb.TaskRequest) []HostResult {
+	candidates := selectHosts(taskModel)
+	results := make([]HostResult, 0, 1)
+	for i, th := range candidates {
+		hostResult := execOnHost(th, taskRequest)
+		results = append(results, hostResult)
+		if !errors.Is(hostResult.Err, rpcClient.ErrUnavailable) {
+			break
+		}
+		if i < len(candidates)-1 {
+			logger.Warnf("Host unavailable, failover to next host#Task ID-%d#Host-%s:%d", taskModel.Id, th.Name, th.Port)
+			results[i].FailedOver = true
 		}
 	}
 
-	return aggregationResult, aggregationErr
+	return results
+}
+
+// 在单台主机上执行命令
+func execOnHost(th models.TaskHostDetail, taskRequest *pb.TaskRequest) HostResult {
+	logger.Infof("Preparing RPC call#Host-%s:%d#Command-%s", th.Name, th.Port, taskRequest.Command)
+	hostLoad.add(th.HostId)
+	defer hostLoad.done(th.HostId)
+
+	hostResult := newHostResult(th)
+	hostResult.StartTime = time.Now()
+	output, err := rpcExecFunc(th.Name, th.Port, taskRequest)
+	hostResult.EndTime = time.Now()
+	hostResult.Output = strings.TrimSpace(output)
+	hostResult.Err = err
+	// 节点拒绝新任务时标记排空, 执行成功说明节点已恢复
+	if errors.Is(err, rpcClient.ErrDraining) {
+		hostDrain.set(th.HostId, true)
+	} else if err == nil {
+		hostDrain.set(th.HostId, false)
+	}
+	logger.Infof("RPC call completed#Host-%s:%d#Output length-%d#Error-%v", th.Name, th.Port, len(hostResult.Output), err)
+
+	return hostResult
 }
 
 // 创建任务日志
 func createTaskLog(taskModel models.Task, status models.Status) (int64, error) {
 	taskLogModel := new(models.TaskLog)
 	taskLogModel.TaskId = taskModel.Id
+	taskLogModel.Revision = taskModel.Revision
 	taskLogModel.Name = taskModel.Name
 	taskLogModel.Spec = taskModel.Spec
 	taskLogModel.Protocol = taskModel.Protocol
@@ -401,14 +480,35 @@ func createJob(taskModel models.Task) cron.FuncJob {
 		return nil
 	}
 	taskFunc := func() {
-		logger.Infof("Task closure execution#ID-%d#Name-%s#Host count-%d", taskModel.Id, taskModel.Name, len(taskModel.Hosts))
-		taskCount.Add()
-		defer taskCount.Done()
+		if _, run := prepareJob(handler, taskModel); run != nil {
+			run()
+		}
+	}
 
-		taskLogId := beforeExecJob(taskModel)
-		if taskLogId <= 0 {
-			return
+	return taskFunc
+}
+
+// 写入任务日志, 返回日志ID及执行任务的函数, 无法执行时函数为nil
+func prepareJob(handler Handler, taskModel models.Task) (int64, func()) {
+	// 每次执行使用副本, 避免调度变量写回闭包
+	taskModel = bindScheduleVariables(taskModel)
+	// 通过主机分组或标签选择器指定目标的任务, 每次执行时重新解析主机
+	if taskModel.Protocol == models.TaskRPC && taskModel.HasHostTarget() {
+		if err := taskModel.RefreshHosts(); err != nil {
+			logger.Errorf("Failed to resolve task hosts#ID-%d#%s", taskModel.Id, err.Error())
 		}
+	}
+	logger.Infof("Task closure execution#ID-%d#Name-%s#Host count-%d", taskModel.Id, taskModel.Name, len(taskModel.Hosts))
+	taskCount.Add()
+
+	taskLogId := beforeExecJob(taskModel)
+	if taskLogId <= 0 {
+		taskCount.Done()
+		return 0, nil
+	}
+
+	return taskLogId, func() {
+		defer taskCount.Done()
 
 		// Multi=0 时，确保清理实例标记
 		// 注意：beforeExecJob 已经添加了实例标记，这里只需要清理
@@ -424,8 +524,6 @@ func createJob(taskModel models.Task) cron.FuncJob {
 		logger.Infof("Task completed#%s#Command-%s", taskModel.Name, taskModel.Command)
 		afterExecJob(taskModel, taskResult, taskLogId)
 	}
-
-	return taskFunc
 }
 
 func createHandler(taskModel models.Task) Handler {
@@ -472,6 +570,25 @@ func afterExecJob(taskModel models.Task, taskResult TaskResult, taskLogId int64)
 	if err != nil {
 		logger.Error("Task ended#Failed to update task log-", err)
 	}
+	if len(taskResult.Attempts) > 0 {
+		for i := range taskResult.Attempts {
+			taskResult.Attempts[i].TaskLogId = taskLogId
+		}
+		attemptModel := new(models.TaskLogAttempt)
+		if err = attemptModel.BatchCreate(taskResult.Attempts); err != nil {
+			logger.Error("Task ended#Failed to write task attempts-", err)
+		}
+	}
+	if len(taskResult.Hosts) > 0 {
+		logHosts := make([]models.TaskLogHost, len(taskResult.Hosts))
+		for i, hostResult := range taskResult.Hosts {
+			logHosts[i] = newTaskLogHost(taskLogId, hostResult)
+		}
+		logHostModel := new(models.TaskLogHost)
+		if err = logHostModel.BatchCreate(logHosts); err != nil {
+			logger.Error("Task ended#Failed to write task host results-", err)
+		}
+	}
 
 	// 发送邮件
 	go SendNotification(taskModel, taskResult)
@@ -511,8 +628,16 @@ func execDependencyTask(taskModel models.Task, taskResult TaskResult) {
 	}
 	logger.Infof("Starting dependency tasks execution#Parent task ID-%d#Dependency task count-%d", taskModel.Id, len(tasks))
 	for _, task := range tasks {
+		// 创建依赖后子任务可能被移动到其他项目, 未允许跨项目依赖时跳过
+		allowed, err := models.CrossDependencyAllowed(taskModel.ProjectId, task.ProjectId)
+		if err != nil || !allowed {
+			logger.Warnf("Cross-project dependency is not allowed, skipped#Parent task ID-%d#Dependency task ID-%d", taskModel.Id, task.Id)
+			continue
+		}
 		logger.Infof("Executing dependency task#Parent task ID-%d#Dependency task ID-%d#Dependency task name-%s", taskModel.Id, task.Id, task.Name)
 		task.Spec = fmt.Sprintf("Dependency task (Parent task ID-%d)", taskModel.Id)
+		// 子任务沿用父任务的调度时间
+		task.ScheduleTime = taskModel.ScheduleTime
 		ServiceTask.Run(task)
 	}
 }
@@ -539,6 +664,23 @@ func SendNotification(taskModel models.Task, taskResult TaskResult) {
 	} else {
 		statusName = "Success"
 	}
+	msg := notify.Message{
+		"name":    taskModel.Name,
+		"output":  taskResult.Result,
+		"status":  statusName,
+		"task_id": taskModel.Id,
+		"remark":  taskModel.Remark,
+	}
+	if len(taskResult.Hosts) > 0 {
+		msg["host_total"] = len(taskResult.Hosts)
+		msg["host_failed"] = failedHostCount(taskResult.Hosts)
+		msg["host_summary"] = hostSummary(taskResult.Hosts)
+	}
+	pushNotification(taskModel, msg)
+}
+
+// 按任务配置的通知方式推送消息
+func pushNotification(taskModel models.Task, msg notify.Message) {
 	notifyTypeMask, err := models.NormalizeNotifyTypeMask(taskModel.NotifyType)
 	if err != nil {
 		return
@@ -548,16 +690,15 @@ func SendNotification(taskModel models.Task, taskResult TaskResult) {
 		if taskType != 2 && taskType != 3 && taskType != 4 && notifyReceiverId == "" {
 			continue
 		}
-		msg := notify.Message{
+		item := notify.Message{
 			"task_type":        taskType,
 			"task_receiver_id": taskModel.NotifyReceiverId,
-			"name":             taskModel.Name,
-			"output":           taskResult.Result,
-			"status":           statusName,
-			"task_id":          taskModel.Id,
-			"remark":           taskModel.Remark,
+			"task_project_id":  taskModel.ProjectId,
+		}
+		for k, v := range msg {
+			item[k] = v
 		}
-		notifyPushFunc(msg)
+		notifyPushFunc(item)
 	}
 }
 
@@ -576,24 +717,54 @@ func execJob(handler Handler, taskModel models.Task, taskUniqueId int64) TaskRes
 	var i int8 = 0
 	var output string
 	var err error
+	var attempts []models.TaskLogAttempt
+	var hosts []HostResult
 	for i < execTimes {
-		output, err = handler.Run(taskModel, taskUniqueId)
+		startTime := time.Now()
+		output, hosts, err = runHandler(handler, taskModel, taskUniqueId)
+		if execTimes > 1 {
+			attempts = append(attempts, newTaskLogAttempt(i+1, startTime, output, err))
+		}
 		if err == nil {
-			return TaskResult{Result: output, Err: err, RetryTimes: i}
+			return TaskResult{Result: output, Err: err, RetryTimes: i, Attempts: attempts, Hosts: hosts}
+		}
+		if !shouldRetry(taskModel, err) {
+			logger.Infof("Task execution failed, retry condition not matched#Task ID-%d#Error-%s", taskModel.Id, err.Error())
+			return TaskResult{Result: output, Err: err, RetryTimes: i, Attempts: attempts, Hosts: hosts}
 		}
 		i++
 		if i < execTimes {
-			logger.Warnf("Task execution failed#Task ID-%d#Retry attempt %d#Output-%s#Error-%s", taskModel.Id, i, output, err.Error())
-			if taskModel.RetryInterval > 0 {
-				sleepFunc(time.Duration(taskModel.RetryInterval) * time.Second)
-			} else {
-				// 默认重试间隔时间，每次递增1分钟
-				sleepFunc(time.Duration(i) * time.Minute)
-			}
+			delay := retryDelay(taskModel, i)
+			logger.Warnf("Task execution failed#Task ID-%d#Retry attempt %d#Delay-%s#Output-%s#Error-%s", taskModel.Id, i, delay, output, err.Error())
+			sleepFunc(delay)
+		}
+	}
+
+	return TaskResult{Result: output, Err: err, RetryTimes: taskModel.RetryTimes, Attempts: attempts, Hosts: hosts}
+}
+
+// 单次执行记录
+func newTaskLogAttempt(attempt int8, startTime time.Time, output string, err error) models.TaskLogAttempt {
+	record := models.TaskLogAttempt{
+		Attempt:   attempt,
+		Status:    models.Finish,
+		Result:    output,
+		StartTime: models.LocalTime(startTime),
+		EndTime:   models.LocalTime(time.Now()),
+	}
+	if err != nil {
+		record.Status = models.Failure
+		if errors.Is(err, rpcClient.ErrManualStop) {
+			record.Status = models.Cancel
+		}
+		message := []rune(err.Error())
+		if len(message) > 512 {
+			message = message[:512]
 		}
+		record.Error = string(message)
 	}
 
-	return TaskResult{Result: output, Err: err, RetryTimes: taskModel.RetryTimes}
+	return record
 }
 
 // 清理日志文件
@@ -223,6 +223,21 @@ func TestSendNotificationBehavior(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "multiHostSummary",
+			task: models.Task{Name: "deploy", NotifyStatus: 1, NotifyType: 2},
+			result: TaskResult{Result: "bad", Err: errors.New("exit status 1"), Hosts: []HostResult{
+				{Name: "a", Err: errors.New("exit status 1")},
+				{Name: "b"},
+				{Name: "c", Err: errors.New("exit status 2")},
+			}},
+			count: 1,
+			check: func(t *testing.T, msg notify.Message) {
+				if msg["host_summary"] != "2/3 hosts failed" || msg["host_failed"] != 2 || msg["host_total"] != 3 {
+					t.Fatalf("unexpected host summary: %v/%v %v", msg["host_failed"], msg["host_total"], msg["host_summary"])
+				}
+			},
+		},
 		{
 			name:   "missingReceiverForMail",
 			task:   models.Task{NotifyStatus: 2, NotifyType: 1, NotifyReceiverId: ""},
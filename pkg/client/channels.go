@@ -0,0 +1,56 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// ChannelListOptions 通知渠道列表的筛选条件
+type ChannelListOptions struct {
+	ListOptions
+	// mail, slack, webhook, serverchan3, bark
+	Type      string
+	ProjectId int
+}
+
+func (opts ChannelListOptions) values() url.Values {
+	values := opts.ListOptions.values()
+	setString(values, "type", opts.Type)
+	setInt(values, "project_id", opts.ProjectId)
+
+	return values
+}
+
+// ListChannels 通知渠道列表
+func (c *Client) ListChannels(ctx context.Context, opts ChannelListOptions) (*Page[NotifyChannel], error) {
+	page := new(Page[NotifyChannel])
+	err := c.do(ctx, http.MethodGet, "/notification-channels", opts.values(), nil, page)
+
+	return page, err
+}
+
+func (c *Client) GetChannel(ctx context.Context, id int) (*NotifyChannel, error) {
+	channel := new(NotifyChannel)
+	err := c.do(ctx, http.MethodGet, idPath("/notification-channels", int64(id)), nil, nil, channel)
+
+	return channel, err
+}
+
+func (c *Client) CreateChannel(ctx context.Context, req NotifyChannelRequest) (*NotifyChannel, error) {
+	channel := new(NotifyChannel)
+	err := c.do(ctx, http.MethodPost, "/notification-channels", nil, req, channel)
+
+	return channel, err
+}
+
+func (c *Client) UpdateChannel(ctx context.Context, id int, req NotifyChannelRequest) (*NotifyChannel, error) {
+	channel := new(NotifyChannel)
+	err := c.do(ctx, http.MethodPut, idPath("/notification-channels", int64(id)), nil, req, channel)
+
+	return channel, err
+}
+
+func (c *Client) DeleteChannel(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, idPath("/notification-channels", int64(id)), nil, nil, nil)
+}
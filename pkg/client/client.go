@@ -0,0 +1,169 @@
+// Package client gocron v2接口的Go客户端, 使用个人API令牌认证
+//
+// 请求及响应结构与docs/openapi.json一致, 接口返回失败时错误类型为*Error, 可使用errors.Is判断错误类型:
+//
+//	c := client.New("http://127.0.0.1:5920", "gct_xxx")
+//	logId, err := c.RunTask(ctx, 1)
+//	if errors.Is(err, client.ErrNotFound) {
+//		// 任务不存在
+//	}
+//	taskLog, err := c.WaitForLog(ctx, logId)
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APIPrefix v2接口的路由前缀
+const APIPrefix = "/api/v2"
+
+// 等待任务执行完成时查询日志的默认间隔
+const defaultPollInterval = time.Second
+
+type Client struct {
+	// 服务端地址, 如http://127.0.0.1:5920
+	BaseURL string
+	// 个人API令牌, 以gct_开头
+	Token string
+	// 为nil时使用http.DefaultClient
+	HTTPClient *http.Client
+	// WaitForLog、TailLog查询日志的间隔, 为0时每秒查询一次
+	PollInterval time.Duration
+}
+
+// New 创建客户端
+func New(baseURL, token string) *Client {
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/"), Token: token}
+}
+
+// ListOptions 游标分页参数
+type ListOptions struct {
+	// 上一页返回的NextCursor, 为空时查询第一页
+	Cursor string
+	// 每页数量, 为0时使用服务端默认值20, 最大100
+	Limit int
+}
+
+func (opts ListOptions) values() url.Values {
+	values := url.Values{}
+	if opts.Cursor != "" {
+		values.Set("cursor", opts.Cursor)
+	}
+	setInt(values, "limit", opts.Limit)
+
+	return values
+}
+
+// Page 游标分页的列表, NextCursor为空时没有下一页
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor"`
+}
+
+// 统一的响应结构
+type response struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// 发送请求并解析响应中的data到result, result为nil时忽略响应内容
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	target := strings.TrimRight(c.BaseURL, "/") + APIPrefix + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var r response
+	if err = json.Unmarshal(data, &r); err != nil {
+		// 反向代理等返回的非JSON错误
+		if resp.StatusCode >= http.StatusBadRequest {
+			return &Error{StatusCode: resp.StatusCode, Code: codeForStatus(resp.StatusCode), Message: strings.TrimSpace(string(data))}
+		}
+		return fmt.Errorf("gocron: invalid response: %w", err)
+	}
+	if r.Code != CodeSuccess || resp.StatusCode >= http.StatusBadRequest {
+		code := r.Code
+		if code == CodeSuccess {
+			code = codeForStatus(resp.StatusCode)
+		}
+		return &Error{StatusCode: resp.StatusCode, Code: code, Message: r.Message}
+	}
+	if result == nil || len(r.Data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(r.Data, result)
+}
+
+func idPath(prefix string, id int64) string {
+	return prefix + "/" + strconv.FormatInt(id, 10)
+}
+
+func setInt(values url.Values, key string, value int) {
+	if value != 0 {
+		values.Set(key, strconv.Itoa(value))
+	}
+}
+
+func setString(values url.Values, key, value string) {
+	if value != "" {
+		values.Set(key, value)
+	}
+}
+
+func setStatus(values url.Values, status *Status) {
+	if status != nil {
+		values.Set("status", strconv.Itoa(int(*status)))
+	}
+}
+
+// Ptr 返回值的指针, 用于设置可选的筛选条件, 如Status: client.Ptr(client.TaskEnabled)
+func Ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,179 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 按路径返回固定响应的服务端
+func newServer(t *testing.T, handler func(w http.ResponseWriter, r *http.Request)) *Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gct_test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"code":401,"message":"认证失败","data":null}`))
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	c := New(server.URL+"/", "gct_test")
+	c.PollInterval = time.Millisecond
+
+	return c
+}
+
+func TestErrors(t *testing.T) {
+	c := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/tasks/1":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":404,"message":"任务不存在","data":null}`))
+		case "/api/v2/hosts":
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"code":1,"message":"主机名已存在","data":null}`))
+		case "/api/v2/users":
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("bad gateway"))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"code":403,"message":"令牌没有权限","data":null}`))
+		}
+	})
+	ctx := context.Background()
+
+	_, err := c.GetTask(ctx, 1)
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Message != "任务不存在" {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !errors.Is(err, ErrNotFound) || errors.Is(err, ErrForbidden) {
+		t.Errorf("errors.Is不正确 %v", err)
+	}
+	if _, err = c.CreateHost(ctx, HostRequest{Name: "a"}); !errors.Is(err, ErrConflict) || !errors.Is(err, ErrFailure) {
+		t.Errorf("expect conflict, got %v", err)
+	}
+	if _, err = c.ListUsers(ctx, UserListOptions{}); !errors.Is(err, ErrServer) {
+		t.Errorf("expect server error, got %v", err)
+	}
+	if _, err = c.ListChannels(ctx, ChannelListOptions{}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expect forbidden, got %v", err)
+	}
+
+	c.Token = "gct_invalid"
+	if _, err = c.GetTask(ctx, 1); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("expect unauthenticated, got %v", err)
+	}
+}
+
+func TestListTasks(t *testing.T) {
+	c := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/tasks" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.URL.RawQuery != "cursor=Mg&limit=2&name=backup&status=0" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"code":0,"message":"","data":{"items":[{"id":2,"name":"backup","status":0,"next_run_time":""},` +
+			`{"id":1,"name":"backup db","next_run_time":"2026-01-02 03:04:05"}],"next_cursor":"MQ"}}`))
+	})
+
+	page, err := c.ListTasks(context.Background(), TaskListOptions{
+		ListOptions: ListOptions{Cursor: "Mg", Limit: 2}, Name: "backup", Status: Ptr(TaskDisabled),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 2 || page.NextCursor != "MQ" || page.Items[1].Name != "backup db" {
+		t.Fatalf("unexpected page %+v", page)
+	}
+	if !page.Items[0].NextRunTime.IsZero() || page.Items[1].NextRunTime.Format(timeFormat) != "2026-01-02 03:04:05" {
+		t.Errorf("unexpected next run time %v", page.Items)
+	}
+}
+
+func TestRunTaskAndWait(t *testing.T) {
+	polls := 0
+	c := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /api/v2/tasks/3/run":
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"code":0,"message":"","data":{"log_id":7}}`))
+		case "GET /api/v2/logs/7":
+			polls++
+			status := "1"
+			if polls >= 3 {
+				status = "2"
+			}
+			w.Write([]byte(`{"code":0,"message":"","data":{"id":7,"task_id":3,"status":` + status + `,"result":"ok","start_time":"2026-01-02 03:04:05","end_time":""}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+	ctx := context.Background()
+
+	logId, err := c.RunTask(ctx, 3)
+	if err != nil || logId != 7 {
+		t.Fatalf("RunTask: %d %v", logId, err)
+	}
+	taskLog, err := c.WaitForLog(ctx, logId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if taskLog.Status != LogFinish || taskLog.Result != "ok" || polls != 3 {
+		t.Errorf("unexpected log %+v after %d polls", taskLog, polls)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err = c.WaitForLog(ctx, logId); !errors.Is(err, context.Canceled) {
+		t.Errorf("expect canceled, got %v", err)
+	}
+}
+
+func TestTailLog(t *testing.T) {
+	outputs := []string{
+		`{"output":"","status":1}`,
+		`{"output":"Host: [a]\nline1\n","status":1}`,
+		`{"output":"Host: [a]\nline1\nline2\n","status":1}`,
+		`{"output":"line1\nline2\ndone","status":2}`,
+	}
+	c := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/logs/5/output":
+			w.Write([]byte(`{"code":0,"message":"","data":` + outputs[0] + `}`))
+			if len(outputs) > 1 {
+				outputs = outputs[1:]
+			}
+		case "/api/v2/logs/5":
+			w.Write([]byte(`{"code":0,"message":"","data":{"id":5,"status":2}}`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	})
+
+	var b strings.Builder
+	taskLog, err := c.TailLog(context.Background(), 5, &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if taskLog.Status != LogFinish {
+		t.Errorf("unexpected status %d", taskLog.Status)
+	}
+	expected := "Host: [a]\nline1\nline2\n\nline1\nline2\ndone"
+	if b.String() != expected {
+		t.Errorf("unexpected output %q", b.String())
+	}
+}
+
+func TestTaskRequest(t *testing.T) {
+	task := Task{Name: "a", Level: LevelChild, Hosts: []TaskHost{{HostId: 2}, {HostId: 5}}}
+	req := task.Request().withDefaults()
+	if req.HostId != "2,5" || req.Level != LevelChild || req.DependencyStatus != 1 || req.HttpMethod != 1 {
+		t.Errorf("unexpected request %+v", req)
+	}
+}
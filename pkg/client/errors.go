@@ -0,0 +1,75 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// 响应码, 与服务端utils中的响应码一致
+const (
+	CodeSuccess      = 0
+	CodeFailure      = 1
+	CodeAuth         = 401
+	CodeUnauthorized = 403
+	CodeNotFound     = 404
+	CodeServer       = 500
+	CodeNotInstalled = 801
+)
+
+// Error 接口返回的错误
+type Error struct {
+	// HTTP状态码
+	StatusCode int
+	// 响应码
+	Code    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("gocron: %d %s (code %d)", e.StatusCode, e.Message, e.Code)
+}
+
+// Is 按响应码判断错误类型, 目标设置了HTTP状态码时按状态码判断
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	if t.StatusCode != 0 {
+		return t.StatusCode == e.StatusCode
+	}
+
+	return t.Code == e.Code
+}
+
+// 用于errors.Is判断的错误类型
+var (
+	// ErrFailure 请求参数错误或操作失败
+	ErrFailure = &Error{Code: CodeFailure}
+	// ErrUnauthenticated 令牌无效、已过期或用户已禁用
+	ErrUnauthenticated = &Error{Code: CodeAuth}
+	// ErrForbidden 令牌没有接口的权限范围或用户没有项目权限
+	ErrForbidden = &Error{Code: CodeUnauthorized}
+	ErrNotFound  = &Error{Code: CodeNotFound}
+	ErrServer    = &Error{Code: CodeServer}
+	// ErrNotInstalled 服务端未完成安装
+	ErrNotInstalled = &Error{Code: CodeNotInstalled}
+	// ErrConflict 名称已存在、资源被使用中等冲突
+	ErrConflict = &Error{StatusCode: http.StatusConflict}
+)
+
+// HTTP状态码对应的响应码, 用于解析反向代理等返回的非JSON错误
+func codeForStatus(status int) int {
+	switch {
+	case status == http.StatusUnauthorized:
+		return CodeAuth
+	case status == http.StatusForbidden:
+		return CodeUnauthorized
+	case status == http.StatusNotFound:
+		return CodeNotFound
+	case status >= http.StatusInternalServerError:
+		return CodeServer
+	default:
+		return CodeFailure
+	}
+}
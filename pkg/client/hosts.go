@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// HostListOptions 主机列表的筛选条件
+type HostListOptions struct {
+	ListOptions
+	Name      string
+	Group     string
+	ProjectId int
+}
+
+func (opts HostListOptions) values() url.Values {
+	values := opts.ListOptions.values()
+	setString(values, "name", opts.Name)
+	setString(values, "group", opts.Group)
+	setInt(values, "project_id", opts.ProjectId)
+
+	return values
+}
+
+// ListHosts 主机列表, 按id倒序
+func (c *Client) ListHosts(ctx context.Context, opts HostListOptions) (*Page[Host], error) {
+	page := new(Page[Host])
+	err := c.do(ctx, http.MethodGet, "/hosts", opts.values(), nil, page)
+
+	return page, err
+}
+
+func (c *Client) GetHost(ctx context.Context, id int) (*Host, error) {
+	host := new(Host)
+	err := c.do(ctx, http.MethodGet, idPath("/hosts", int64(id)), nil, nil, host)
+
+	return host, err
+}
+
+func (c *Client) CreateHost(ctx context.Context, req HostRequest) (*Host, error) {
+	host := new(Host)
+	err := c.do(ctx, http.MethodPost, "/hosts", nil, req, host)
+
+	return host, err
+}
+
+func (c *Client) UpdateHost(ctx context.Context, id int, req HostRequest) (*Host, error) {
+	host := new(Host)
+	err := c.do(ctx, http.MethodPut, idPath("/hosts", int64(id)), nil, req, host)
+
+	return host, err
+}
+
+// DeleteHost 删除主机, 仍被任务使用时返回ErrConflict
+func (c *Client) DeleteHost(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, idPath("/hosts", int64(id)), nil, nil, nil)
+}
+
+// PingHost 测试主机连接, 连接失败时返回状态码为502的错误
+func (c *Client) PingHost(ctx context.Context, id int) (string, error) {
+	result := new(PingResult)
+	err := c.do(ctx, http.MethodPost, idPath("/hosts", int64(id))+"/ping", nil, nil, result)
+
+	return result.Message, err
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// LogListOptions 任务日志列表的筛选条件
+type LogListOptions struct {
+	ListOptions
+	TaskId   int
+	Protocol int
+	// 为nil时不按状态筛选
+	Status *Status
+}
+
+func (opts LogListOptions) values() url.Values {
+	values := opts.ListOptions.values()
+	setInt(values, "task_id", opts.TaskId)
+	setInt(values, "protocol", opts.Protocol)
+	setStatus(values, opts.Status)
+
+	return values
+}
+
+// ListLogs 任务日志列表, 按id倒序
+func (c *Client) ListLogs(ctx context.Context, opts LogListOptions) (*Page[TaskLog], error) {
+	page := new(Page[TaskLog])
+	err := c.do(ctx, http.MethodGet, "/logs", opts.values(), nil, page)
+
+	return page, err
+}
+
+func (c *Client) GetLog(ctx context.Context, id int64) (*TaskLog, error) {
+	taskLog := new(TaskLog)
+	err := c.do(ctx, http.MethodGet, idPath("/logs", id), nil, nil, taskLog)
+
+	return taskLog, err
+}
+
+// LogOutput 任务日志的输出, 执行中的Shell任务返回各主机当前的全部输出
+func (c *Client) LogOutput(ctx context.Context, id int64) (*LogOutput, error) {
+	output := new(LogOutput)
+	err := c.do(ctx, http.MethodGet, idPath("/logs", id)+"/output", nil, nil, output)
+
+	return output, err
+}
+
+// StopLog 停止执行中的任务
+func (c *Client) StopLog(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodPost, idPath("/logs", id)+"/stop", nil, nil, nil)
+}
+
+func (c *Client) DeleteLog(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, idPath("/logs", id), nil, nil, nil)
+}
+
+// WaitForLog 等待任务执行结束, 返回最终的任务日志, 可通过ctx设置超时
+func (c *Client) WaitForLog(ctx context.Context, id int64) (*TaskLog, error) {
+	for {
+		taskLog, err := c.GetLog(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if taskLog.Status != LogRunning {
+			return taskLog, nil
+		}
+		if err = c.sleep(ctx); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// TailLog 持续输出任务日志到w直到任务执行结束, 返回最终的任务日志
+//
+// 服务端每次返回全部输出, 新输出以上次输出开头时只写入新增部分, 否则(如多台主机的输出交错、任务结束后返回保存的结果)写入完整输出
+func (c *Client) TailLog(ctx context.Context, id int64, w io.Writer) (*TaskLog, error) {
+	written := ""
+	for {
+		output, err := c.LogOutput(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if output.Output != written {
+			text := output.Output
+			if strings.HasPrefix(text, written) {
+				text = text[len(written):]
+			} else if written != "" {
+				text = "\n" + text
+			}
+			if _, err = io.WriteString(w, text); err != nil {
+				return nil, err
+			}
+			written = output.Output
+		}
+		if output.Status != LogRunning {
+			return c.GetLog(ctx, id)
+		}
+		if err = c.sleep(ctx); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// 等待一个查询间隔, ctx取消时返回错误
+func (c *Client) sleep(ctx context.Context) error {
+	interval := c.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,202 @@
+package client
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tabortao/gocron/internal/modules/utils"
+)
+
+const openAPIFile = "../../docs/openapi.json"
+
+type openAPISpec struct {
+	Paths map[string]map[string]struct {
+		OperationId string `json:"operationId"`
+		Parameters  []struct {
+			Name string `json:"name"`
+			In   string `json:"in"`
+		} `json:"parameters"`
+	} `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Properties map[string]struct {
+				Type   string `json:"type"`
+				Format string `json:"format"`
+			} `json:"properties"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+// OpenAPI文档中的结构对应的客户端类型
+var schemaTypes = map[string]interface{}{
+	"Host":              Host{},
+	"HostForm":          HostRequest{},
+	"LogOutput":         LogOutput{},
+	"NotifyChannel":     NotifyChannel{},
+	"NotifyChannelForm": NotifyChannelRequest{},
+	"PingResult":        PingResult{},
+	"RunResult":         RunResult{},
+	"Task":              Task{},
+	"TaskForm":          TaskRequest{},
+	"TaskHostDetail":    TaskHost{},
+	"TaskLog":           TaskLog{},
+	"User":              User{},
+	"UserForm":          UserRequest{},
+}
+
+// 列表接口对应的筛选条件, 所有条件均已设置
+var listOptions = map[string]interface{ values() url.Values }{
+	"listTasks": TaskListOptions{
+		ListOptions: ListOptions{Cursor: "a", Limit: 1}, Name: "a", Tag: "a", ProjectId: 1,
+		HostId: 1, Protocol: 1, Status: Ptr(TaskEnabled), CreatedBy: 1,
+	},
+	"listHosts":    HostListOptions{ListOptions: ListOptions{Cursor: "a", Limit: 1}, Name: "a", Group: "a", ProjectId: 1},
+	"listLogs":     LogListOptions{ListOptions: ListOptions{Cursor: "a", Limit: 1}, TaskId: 1, Protocol: 1, Status: Ptr(LogFinish)},
+	"listChannels": ChannelListOptions{ListOptions: ListOptions{Cursor: "a", Limit: 1}, Type: "mail", ProjectId: 1},
+	"listUsers":    UserListOptions{ListOptions: ListOptions{Cursor: "a", Limit: 1}, Name: "a", Status: Ptr(TaskEnabled)},
+}
+
+func loadSpec(t *testing.T) openAPISpec {
+	data, err := os.ReadFile(openAPIFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spec openAPISpec
+	if err = json.Unmarshal(data, &spec); err != nil {
+		t.Fatal(err)
+	}
+	if len(spec.Paths) == 0 || len(spec.Components.Schemas) == 0 {
+		t.Fatal("OpenAPI文档为空")
+	}
+
+	return spec
+}
+
+// 每个接口都有对应的客户端方法
+func TestOperations(t *testing.T) {
+	spec := loadSpec(t)
+	clientType := reflect.TypeOf(&Client{})
+	for path, operations := range spec.Paths {
+		for method, operation := range operations {
+			name := strings.ToUpper(operation.OperationId[:1]) + operation.OperationId[1:]
+			if _, ok := clientType.MethodByName(name); !ok {
+				t.Errorf("%s %s: Client缺少方法%s", method, path, name)
+			}
+			var query []string
+			for _, parameter := range operation.Parameters {
+				if parameter.In == "query" {
+					query = append(query, parameter.Name)
+				}
+			}
+			if len(query) == 0 {
+				continue
+			}
+			opts, ok := listOptions[operation.OperationId]
+			if !ok {
+				t.Errorf("%s: 缺少筛选条件", operation.OperationId)
+				continue
+			}
+			var keys []string
+			for key := range opts.values() {
+				keys = append(keys, key)
+			}
+			sort.Strings(query)
+			sort.Strings(keys)
+			if !reflect.DeepEqual(keys, query) {
+				t.Errorf("%s: 查询参数为%v, 文档中为%v", operation.OperationId, keys, query)
+			}
+		}
+	}
+}
+
+// 客户端类型的字段与OpenAPI文档中的结构一致
+func TestSchemas(t *testing.T) {
+	spec := loadSpec(t)
+	for name, schema := range spec.Components.Schemas {
+		if name == "Error" {
+			continue
+		}
+		value, ok := schemaTypes[name]
+		if !ok {
+			t.Errorf("结构%s没有对应的客户端类型", name)
+			continue
+		}
+		fields := jsonFields(reflect.TypeOf(value))
+		for property, p := range schema.Properties {
+			field, ok := fields[property]
+			if !ok {
+				t.Errorf("%s: 缺少字段%s", name, property)
+				continue
+			}
+			if got := schemaType(field); got != p.Type {
+				t.Errorf("%s.%s: 类型为%s, 文档中为%s", name, property, got, p.Type)
+			}
+			delete(fields, property)
+		}
+		for property := range fields {
+			t.Errorf("%s: 文档中没有字段%s", name, property)
+		}
+	}
+}
+
+// 响应码与服务端一致
+func TestCodes(t *testing.T) {
+	codes := map[int]int{
+		CodeSuccess:      utils.ResponseSuccess,
+		CodeFailure:      utils.ResponseFailure,
+		CodeAuth:         utils.AuthError,
+		CodeUnauthorized: utils.UnauthorizedError,
+		CodeNotFound:     utils.NotFound,
+		CodeServer:       utils.ServerError,
+		CodeNotInstalled: utils.AppNotInstall,
+	}
+	for code, expected := range codes {
+		if code != expected {
+			t.Errorf("响应码%d与服务端%d不一致", code, expected)
+		}
+	}
+}
+
+func jsonFields(typ reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = field.Type
+		}
+	}
+
+	return fields
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func schemaType(typ reflect.Type) string {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == timeType || typ == reflect.TypeOf(Time{}) {
+		return "string"
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice:
+		return "array"
+	default:
+		return "object"
+	}
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// TaskListOptions 任务列表的筛选条件
+type TaskListOptions struct {
+	ListOptions
+	// 任务名称, 模糊匹配
+	Name      string
+	Tag       string
+	ProjectId int
+	HostId    int
+	Protocol  int
+	// 为nil时不按状态筛选
+	Status    *Status
+	CreatedBy int
+}
+
+func (opts TaskListOptions) values() url.Values {
+	values := opts.ListOptions.values()
+	setString(values, "name", opts.Name)
+	setString(values, "tag", opts.Tag)
+	setInt(values, "project_id", opts.ProjectId)
+	setInt(values, "host_id", opts.HostId)
+	setInt(values, "protocol", opts.Protocol)
+	setInt(values, "created_by", opts.CreatedBy)
+	setStatus(values, opts.Status)
+
+	return values
+}
+
+// ListTasks 任务列表, 按id倒序
+func (c *Client) ListTasks(ctx context.Context, opts TaskListOptions) (*Page[Task], error) {
+	page := new(Page[Task])
+	err := c.do(ctx, http.MethodGet, "/tasks", opts.values(), nil, page)
+
+	return page, err
+}
+
+func (c *Client) GetTask(ctx context.Context, id int) (*Task, error) {
+	task := new(Task)
+	err := c.do(ctx, http.MethodGet, idPath("/tasks", int64(id)), nil, nil, task)
+
+	return task, err
+}
+
+func (c *Client) CreateTask(ctx context.Context, req TaskRequest) (*Task, error) {
+	task := new(Task)
+	err := c.do(ctx, http.MethodPost, "/tasks", nil, req.withDefaults(), task)
+
+	return task, err
+}
+
+// UpdateTask 修改任务, 请求中未设置的配置会被清空
+func (c *Client) UpdateTask(ctx context.Context, id int, req TaskRequest) (*Task, error) {
+	task := new(Task)
+	err := c.do(ctx, http.MethodPut, idPath("/tasks", int64(id)), nil, req.withDefaults(), task)
+
+	return task, err
+}
+
+func (c *Client) DeleteTask(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, idPath("/tasks", int64(id)), nil, nil, nil)
+}
+
+// RunTask 手动运行任务, 返回本次执行的任务日志ID, 任务在后台执行
+func (c *Client) RunTask(ctx context.Context, id int) (int64, error) {
+	result := new(RunResult)
+	err := c.do(ctx, http.MethodPost, idPath("/tasks", int64(id))+"/run", nil, nil, result)
+
+	return result.LogId, err
+}
+
+func (c *Client) EnableTask(ctx context.Context, id int) (*Task, error) {
+	task := new(Task)
+	err := c.do(ctx, http.MethodPost, idPath("/tasks", int64(id))+"/enable", nil, nil, task)
+
+	return task, err
+}
+
+func (c *Client) DisableTask(ctx context.Context, id int) (*Task, error) {
+	task := new(Task)
+	err := c.do(ctx, http.MethodPost, idPath("/tasks", int64(id))+"/disable", nil, nil, task)
+
+	return task, err
+}
@@ -0,0 +1,351 @@
+package client
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Status 任务、任务日志及用户的状态
+type Status int8
+
+// 任务及用户状态
+const (
+	TaskDisabled Status = 0
+	TaskEnabled  Status = 1
+)
+
+// 任务日志状态
+const (
+	LogFailure Status = 0
+	LogRunning Status = 1
+	LogFinish  Status = 2
+	LogCancel  Status = 3
+)
+
+// 任务执行方式
+const (
+	ProtocolHTTP  = 1
+	ProtocolShell = 2
+)
+
+// 任务级别
+const (
+	LevelParent = 1
+	LevelChild  = 2
+)
+
+// 服务端时间格式
+const timeFormat = "2006-01-02 15:04:05"
+
+// Time 服务端以本地时间格式返回的时间, 如任务日志的开始时间, 为空时是零值
+type Time struct {
+	time.Time
+}
+
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return json.Marshal("")
+	}
+	return json.Marshal(t.Format(timeFormat))
+}
+
+func (t *Time) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		t.Time = time.Time{}
+		return nil
+	}
+	parsed, err := time.ParseInLocation(timeFormat, s, time.Local)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+
+	return nil
+}
+
+// Task 定时任务
+type Task struct {
+	Id               int        `json:"id"`
+	Name             string     `json:"name"`
+	ProjectId        int        `json:"project_id"`
+	CreatedBy        int        `json:"created_by"`
+	Owner            string     `json:"owner"`
+	Level            int        `json:"level"`
+	DependencyTaskId string     `json:"dependency_task_id"`
+	DependencyStatus int        `json:"dependency_status"`
+	Spec             string     `json:"spec"`
+	Protocol         int        `json:"protocol"`
+	Command          string     `json:"command"`
+	CommandSignature string     `json:"command_signature"`
+	HttpMethod       int        `json:"http_method"`
+	Timeout          int        `json:"timeout"`
+	Multi            int        `json:"multi"`
+	Detached         int        `json:"detached"`
+	HostSelection    int        `json:"host_selection"`
+	HostGroup        string     `json:"host_group"`
+	HostSelector     string     `json:"host_selector"`
+	BatchSize        int        `json:"batch_size"`
+	BatchUnit        int        `json:"batch_unit"`
+	BatchInterval    int        `json:"batch_interval"`
+	MaxFailures      int        `json:"max_failures"`
+	RetryTimes       int        `json:"retry_times"`
+	RetryInterval    int        `json:"retry_interval"`
+	RetryStrategy    int        `json:"retry_strategy"`
+	RetryMaxInterval int        `json:"retry_max_interval"`
+	RetryJitter      int        `json:"retry_jitter"`
+	RetryOn          string     `json:"retry_on"`
+	RetryExitCodes   string     `json:"retry_exit_codes"`
+	NotifyStatus     int        `json:"notify_status"`
+	NotifyType       int        `json:"notify_type"`
+	NotifyReceiverId string     `json:"notify_receiver_id"`
+	NotifyKeyword    string     `json:"notify_keyword"`
+	Tag              string     `json:"tag"`
+	Remark           string     `json:"remark"`
+	Status           Status     `json:"status"`
+	Revision         int        `json:"revision"`
+	Hosts            []TaskHost `json:"hosts"`
+	NextRunTime      Time       `json:"next_run_time"`
+	CreatedAt        time.Time  `json:"created"`
+	DeletedAt        *time.Time `json:"deleted"`
+}
+
+// TaskHost 任务直接关联的主机
+type TaskHost struct {
+	Id     int    `json:"id"`
+	TaskId int    `json:"task_id"`
+	HostId int    `json:"host_id"`
+	Name   string `json:"name"`
+	Port   int    `json:"port"`
+	Alias  string `json:"alias"`
+}
+
+// TaskRequest 创建或修改任务的请求, 修改时为完整替换
+type TaskRequest struct {
+	Name      string `json:"name"`
+	ProjectId int    `json:"project_id"`
+	// 为0时使用父任务
+	Level            int    `json:"level"`
+	DependencyTaskId string `json:"dependency_task_id"`
+	// 为0时使用强依赖
+	DependencyStatus int    `json:"dependency_status"`
+	Spec             string `json:"spec"`
+	Protocol         int    `json:"protocol"`
+	Command          string `json:"command"`
+	CommandSignature string `json:"command_signature"`
+	// 为0时使用GET
+	HttpMethod       int    `json:"http_method"`
+	Timeout          int    `json:"timeout"`
+	Multi            int    `json:"multi"`
+	Detached         int    `json:"detached"`
+	HostSelection    int    `json:"host_selection"`
+	BatchSize        int    `json:"batch_size"`
+	BatchUnit        int    `json:"batch_unit"`
+	BatchInterval    int    `json:"batch_interval"`
+	MaxFailures      int    `json:"max_failures"`
+	RetryTimes       int    `json:"retry_times"`
+	RetryInterval    int    `json:"retry_interval"`
+	RetryStrategy    int    `json:"retry_strategy"`
+	RetryMaxInterval int    `json:"retry_max_interval"`
+	RetryJitter      int    `json:"retry_jitter"`
+	RetryOn          string `json:"retry_on"`
+	RetryExitCodes   string `json:"retry_exit_codes"`
+	// 主机ID, 多个以逗号分隔
+	HostId           string `json:"host_id"`
+	HostGroup        string `json:"host_group"`
+	HostSelector     string `json:"host_selector"`
+	Tag              string `json:"tag"`
+	Remark           string `json:"remark"`
+	NotifyStatus     int    `json:"notify_status"`
+	NotifyType       int    `json:"notify_type"`
+	NotifyReceiverId string `json:"notify_receiver_id"`
+	NotifyKeyword    string `json:"notify_keyword"`
+}
+
+// 未设置的取值使用与页面相同的默认值
+func (req TaskRequest) withDefaults() TaskRequest {
+	if req.Level == 0 {
+		req.Level = LevelParent
+	}
+	if req.DependencyStatus == 0 {
+		req.DependencyStatus = 1
+	}
+	if req.HttpMethod == 0 {
+		req.HttpMethod = 1
+	}
+
+	return req
+}
+
+// Request 由任务生成修改请求, 用于读取任务后修改部分配置
+func (t Task) Request() TaskRequest {
+	hostIds := make([]string, 0, len(t.Hosts))
+	for _, host := range t.Hosts {
+		hostIds = append(hostIds, strconv.Itoa(host.HostId))
+	}
+
+	return TaskRequest{
+		Name:             t.Name,
+		ProjectId:        t.ProjectId,
+		Level:            t.Level,
+		DependencyTaskId: t.DependencyTaskId,
+		DependencyStatus: t.DependencyStatus,
+		Spec:             t.Spec,
+		Protocol:         t.Protocol,
+		Command:          t.Command,
+		CommandSignature: t.CommandSignature,
+		HttpMethod:       t.HttpMethod,
+		Timeout:          t.Timeout,
+		Multi:            t.Multi,
+		Detached:         t.Detached,
+		HostSelection:    t.HostSelection,
+		BatchSize:        t.BatchSize,
+		BatchUnit:        t.BatchUnit,
+		BatchInterval:    t.BatchInterval,
+		MaxFailures:      t.MaxFailures,
+		RetryTimes:       t.RetryTimes,
+		RetryInterval:    t.RetryInterval,
+		RetryStrategy:    t.RetryStrategy,
+		RetryMaxInterval: t.RetryMaxInterval,
+		RetryJitter:      t.RetryJitter,
+		RetryOn:          t.RetryOn,
+		RetryExitCodes:   t.RetryExitCodes,
+		HostId:           strings.Join(hostIds, ","),
+		HostGroup:        t.HostGroup,
+		HostSelector:     t.HostSelector,
+		Tag:              t.Tag,
+		Remark:           t.Remark,
+		NotifyStatus:     t.NotifyStatus,
+		NotifyType:       t.NotifyType,
+		NotifyReceiverId: t.NotifyReceiverId,
+		NotifyKeyword:    t.NotifyKeyword,
+	}
+}
+
+// Host 主机
+type Host struct {
+	Id        int    `json:"id"`
+	Name      string `json:"name"`
+	ProjectId int    `json:"project_id"`
+	Alias     string `json:"alias"`
+	Port      int    `json:"port"`
+	Remark    string `json:"remark"`
+	Group     string `json:"group"`
+	// key=value, 多个以逗号分隔
+	Labels        string     `json:"labels"`
+	Reverse       bool       `json:"reverse"`
+	TLS           bool       `json:"tls"`
+	TokenIssuedAt *time.Time `json:"token_issued_at"`
+	// 健康检查上报的节点状态 0:未检查 1:在线 2:离线
+	Status      int        `json:"status"`
+	LastSeen    *time.Time `json:"last_seen"`
+	Version     string     `json:"version"`
+	Platform    string     `json:"platform"`
+	Load1       float64    `json:"load1"`
+	MemTotal    int64      `json:"mem_total"`
+	MemFree     int64      `json:"mem_free"`
+	DiskTotal   int64      `json:"disk_total"`
+	DiskFree    int64      `json:"disk_free"`
+	RunningJobs int        `json:"running_jobs"`
+}
+
+// HostRequest 创建或修改主机的请求
+type HostRequest struct {
+	Name      string `json:"name"`
+	ProjectId int    `json:"project_id"`
+	Alias     string `json:"alias"`
+	Port      int    `json:"port"`
+	Remark    string `json:"remark"`
+	Group     string `json:"group"`
+	Labels    string `json:"labels"`
+	// 节点主动连接服务端
+	Reverse bool `json:"reverse"`
+}
+
+// TaskLog 任务执行日志
+type TaskLog struct {
+	Id         int64  `json:"id"`
+	TaskId     int    `json:"task_id"`
+	Revision   int    `json:"revision"`
+	Name       string `json:"name"`
+	Spec       string `json:"spec"`
+	Protocol   int    `json:"protocol"`
+	Command    string `json:"command"`
+	Timeout    int    `json:"timeout"`
+	RetryTimes int    `json:"retry_times"`
+	Hostname   string `json:"hostname"`
+	StartTime  Time   `json:"start_time"`
+	EndTime    Time   `json:"end_time"`
+	Status     Status `json:"status"`
+	Result     string `json:"result"`
+	// 执行时长, 单位秒
+	TotalTime  int `json:"total_time"`
+	HostTotal  int `json:"host_total"`
+	HostFailed int `json:"host_failed"`
+}
+
+// LogOutput 任务日志的输出, 运行中的Shell任务为各主机的实时输出
+type LogOutput struct {
+	Output string `json:"output"`
+	Status Status `json:"status"`
+}
+
+// NotifyChannel 通知渠道
+type NotifyChannel struct {
+	Id int `json:"id"`
+	// mail, slack, webhook, serverchan3, bark
+	Type string `json:"type"`
+	// 邮件用户名、Slack频道名或地址名称
+	Name string `json:"name"`
+	// 邮箱或通知地址, Slack频道为空
+	Target string `json:"target"`
+	// 为0时所有项目共享
+	ProjectId int `json:"project_id"`
+}
+
+// NotifyChannelRequest 创建或修改通知渠道的请求, 修改时不能变更类型
+type NotifyChannelRequest struct {
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	Target    string `json:"target"`
+	ProjectId int    `json:"project_id"`
+}
+
+// User 用户
+type User struct {
+	Id          int       `json:"id"`
+	Name        string    `json:"name"`
+	Email       string    `json:"email"`
+	IsAdmin     int       `json:"is_admin"`
+	Status      Status    `json:"status"`
+	TwoFactorOn int       `json:"two_factor_on"`
+	Source      string    `json:"source"`
+	CreatedAt   time.Time `json:"created"`
+	UpdatedAt   time.Time `json:"updated"`
+}
+
+// UserRequest 创建或修改用户的请求, 修改时不变更密码
+type UserRequest struct {
+	Name            string `json:"name"`
+	Email           string `json:"email"`
+	Password        string `json:"password,omitempty"`
+	ConfirmPassword string `json:"confirm_password,omitempty"`
+	IsAdmin         int    `json:"is_admin"`
+	// 新建的用户均为启用状态, 修改时为0会禁用用户
+	Status Status `json:"status"`
+}
+
+// RunResult 手动运行任务的结果
+type RunResult struct {
+	LogId int64 `json:"log_id"`
+}
+
+// PingResult 测试主机连接的结果
+type PingResult struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// UserListOptions 用户列表的筛选条件
+type UserListOptions struct {
+	ListOptions
+	// 用户名, 模糊匹配
+	Name string
+	// 为nil时不按状态筛选
+	Status *Status
+}
+
+func (opts UserListOptions) values() url.Values {
+	values := opts.ListOptions.values()
+	setString(values, "name", opts.Name)
+	setStatus(values, opts.Status)
+
+	return values
+}
+
+// ListUsers 用户列表, 按id倒序, 需要管理员令牌
+func (c *Client) ListUsers(ctx context.Context, opts UserListOptions) (*Page[User], error) {
+	page := new(Page[User])
+	err := c.do(ctx, http.MethodGet, "/users", opts.values(), nil, page)
+
+	return page, err
+}
+
+func (c *Client) GetUser(ctx context.Context, id int) (*User, error) {
+	u := new(User)
+	err := c.do(ctx, http.MethodGet, idPath("/users", int64(id)), nil, nil, u)
+
+	return u, err
+}
+
+// CreateUser 创建用户, 必须设置密码
+func (c *Client) CreateUser(ctx context.Context, req UserRequest) (*User, error) {
+	u := new(User)
+	err := c.do(ctx, http.MethodPost, "/users", nil, req, u)
+
+	return u, err
+}
+
+func (c *Client) UpdateUser(ctx context.Context, id int, req UserRequest) (*User, error) {
+	u := new(User)
+	err := c.do(ctx, http.MethodPut, idPath("/users", int64(id)), nil, req, u)
+
+	return u, err
+}
+
+func (c *Client) DeleteUser(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, idPath("/users", int64(id)), nil, nil, nil)
+}
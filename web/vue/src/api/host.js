@@ -10,6 +10,14 @@ export default {
     httpClient.get('/host/all', {}, callback)
   },
 
+  groups (projectId, callback) {
+    httpClient.get('/host/groups', { project_id: projectId }, callback)
+  },
+
+  match (group, selector, projectId, callback) {
+    httpClient.get('/host/match', { group, selector, project_id: projectId }, callback)
+  },
+
   detail (id, callback) {
     httpClient.get(`/host/${id}`, {}, callback)
   },
@@ -22,6 +30,14 @@ export default {
     httpClient.post(`/host/remove/${id}`, {}, callback)
   },
 
+  rotateToken (id, callback) {
+    httpClient.post(`/host/token/${id}`, {}, callback)
+  },
+
+  revokeToken (id, callback) {
+    httpClient.post(`/host/token/revoke/${id}`, {}, callback)
+  },
+
   ping (id, callback) {
     httpClient.get(`/host/ping/${id}`, {}, callback)
   }
@@ -7,8 +7,8 @@ export default {
   updateSlack(data, callback) {
     httpClient.post('/system/slack/update', data, callback)
   },
-  createSlackChannel(channel, callback) {
-    httpClient.post('/system/slack/channel', { channel }, callback)
+  createSlackChannel(data, callback) {
+    httpClient.post('/system/slack/channel', data, callback)
   },
   removeSlackChannel(channelId, callback) {
     httpClient.post(`/system/slack/channel/remove/${channelId}`, {}, callback)
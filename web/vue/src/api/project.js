@@ -0,0 +1,24 @@
+import httpClient from '../utils/httpClient'
+
+export default {
+  // 当前用户可访问的项目及角色
+  list (callback) {
+    httpClient.get('/project', {}, callback)
+  },
+
+  update (data, callback) {
+    httpClient.post('/project/store', data, callback)
+  },
+
+  remove (id, callback) {
+    httpClient.post(`/project/remove/${id}`, {}, callback)
+  },
+
+  members (id, callback) {
+    httpClient.get(`/project/members/${id}`, {}, callback)
+  },
+
+  updateMembers (id, members, callback) {
+    httpClient.postJson(`/project/members/${id}`, { members }, callback)
+  }
+}
@@ -3,5 +3,14 @@ import httpClient from '../utils/httpClient'
 export default {
   loginLogList (query, callback) {
     httpClient.get('/system/login-log', query, callback)
+  },
+  auditLogList (query, callback) {
+    httpClient.get('/system/audit-log', query, callback)
+  },
+  loginSetting (callback) {
+    httpClient.get('/system/login-setting', {}, callback)
+  },
+  updateLoginSetting (data, callback) {
+    httpClient.postJson('/system/login-setting', data, callback)
   }
 }
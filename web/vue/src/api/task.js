@@ -25,6 +25,11 @@ export default {
     httpClient.post('/task/store', data, callback)
   },
 
+  // 项目可选的通知接收者, 包括共享的接收者
+  notifyReceivers (projectId, callback) {
+    httpClient.get('/task/notify-receivers', { project_id: projectId }, callback)
+  },
+
   remove (id, callback) {
     httpClient.post(`/task/remove/${id}`, {}, callback)
   },
@@ -51,5 +56,17 @@ export default {
 
   batchRemove (ids, callback) {
     httpClient.postJson('/task/batch-remove', { ids }, callback)
+  },
+
+  revisions (id, callback) {
+    httpClient.get(`/task/revisions/${id}`, {}, callback)
+  },
+
+  revisionDiff (id, from, to, callback) {
+    httpClient.get(`/task/revision-diff/${id}`, { from, to }, callback)
+  },
+
+  rollback (id, version, callback) {
+    httpClient.post(`/task/rollback/${id}`, { version }, callback)
   }
 }
@@ -9,6 +9,14 @@ export default {
     httpClient.get('/task/log/output', { id }, callback)
   },
 
+  attempts(id, callback) {
+    httpClient.get('/task/log/attempts', { id }, callback)
+  },
+
+  hosts(id, callback) {
+    httpClient.get('/task/log/hosts', { id }, callback)
+  },
+
   clear(callback) {
     httpClient.post('/task/log/clear', {}, callback)
   },
@@ -24,6 +24,10 @@ export default {
     httpClient.post('/user/login', data, callback, errorCallback)
   },
 
+  loginOptions(callback) {
+    httpClient.get('/user/login-options', {}, callback)
+  },
+
   enable(id, callback) {
     httpClient.post(`/user/enable/${id}`, {}, callback)
   },
@@ -65,5 +69,17 @@ export default {
 
   disable2FA(code, callback, errorCallback) {
     httpClient.post('/user/2fa/disable', { code }, callback, errorCallback)
+  },
+
+  apiTokens(callback) {
+    httpClient.get('/user/api-tokens', {}, callback)
+  },
+
+  createApiToken(data, callback) {
+    httpClient.postJson('/user/api-tokens/store', data, callback)
+  },
+
+  removeApiToken(id, callback) {
+    httpClient.post(`/user/api-tokens/remove/${id}`, {}, callback)
   }
 }
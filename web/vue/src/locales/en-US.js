@@ -30,7 +30,8 @@ export default {
     statistics: 'Statistics',
     logout: 'Logout',
     changePassword: 'Change Password',
-    twoFactor: 'Two-Factor Authentication'
+    twoFactor: 'Two-Factor Authentication',
+    apiTokens: 'API Tokens'
   },
   login: {
     title: 'User Login',
@@ -44,7 +45,11 @@ export default {
     verifyCodePlaceholder: 'Please enter 6-digit code',
     usernameRequired: 'Please enter username',
     passwordRequired: 'Please enter password',
-    verifyCodeRequired: 'Please enter 2FA code'
+    verifyCodeRequired: 'Please enter 2FA code',
+    sso: 'Sign in with SSO',
+    usePassword: 'Sign in with password',
+    passwordLoginDisabled: 'Password login is disabled, please use single sign-on',
+    ssoSigningIn: 'Signing in...'
   },
   task: {
     list: 'Task List',
@@ -52,6 +57,20 @@ export default {
     id: 'Task ID',
     name: 'Task Name',
     tag: 'Tag',
+    owner: 'Owner',
+    revisions: 'History',
+    revision: 'Revision',
+    revisionAuthor: 'Author',
+    revisionTime: 'Saved At',
+    revisionCurrent: 'Current',
+    revisionRollbackFrom: 'Rolled back from v{version}',
+    revisionFrom: 'From',
+    revisionTo: 'To',
+    revisionCompare: 'Compare',
+    revisionNoDiff: 'No differences',
+    rollback: 'Rollback',
+    confirmRollback: 'Restore the task to revision v{version}? A new revision will be saved.',
+    rollbackSuccess: 'Task rolled back',
     tagPlaceholder: 'Group tasks by tag',
     type: 'Task Type',
     mainTask: 'Main Task',
@@ -68,13 +87,57 @@ export default {
     httpMethod: 'HTTP Method',
     taskNode: 'Task Node',
     taskNodePlaceholder: 'Please select task node',
+    hostSelection: 'Host Selection',
+    hostGroup: 'Host Group',
+    hostGroupPlaceholder: 'Run on every host in this group',
+    hostSelector: 'Label Selector',
+    hostSelectorPlaceholder: 'e.g. env=prod,role!=db',
+    hostTargetMatched: 'Matched hosts',
+    hostSelectionAll: 'All hosts',
+    hostSelectionRoundRobin: 'Any one: round-robin',
+    hostSelectionRandom: 'Any one: random',
+    hostSelectionLeastLoaded: 'Any one: least loaded',
+    hostSelectionFailover: 'First healthy host',
+    batchSize: 'Hosts per Batch',
+    batchSizePlaceholder: '0 means all hosts at once',
+    batchUnit: 'Batch Unit',
+    batchUnitHost: 'Hosts',
+    batchUnitPercent: 'Percent (%)',
+    batchInterval: 'Pause Between Batches',
+    batchIntervalPlaceholder: 'Seconds, default 0',
+    maxFailures: 'Abort After Failures',
+    maxFailuresPlaceholder: 'Skip remaining hosts after N failed hosts, 0 never',
     command: 'Command',
+    commandSignature: 'Command Signature',
+    commandSignaturePlaceholder: 'Optional, required when the node only accepts signed commands',
+    commandSignatureTip:
+      'Generated with gocron-node -sign-key key.pem -sign-task-id <task ID> -sign "command" after the task is created. The signature is bound to the task and the command, and can not be used with schedule time variables.',
+    detached: 'Detached Execution',
+    detachedTip:
+      'The node starts the job and returns immediately; the job keeps running across node restarts. Requires gocron-node -state-dir.',
     timeout: 'Task Timeout',
     singleInstance: 'Single Instance',
     retryTimes: 'Retry Times on Failure',
     retryTimesPlaceholder: '0 - 10, default 0, no retry',
     retryInterval: 'Retry Interval on Failure',
     retryIntervalPlaceholder: '0 - 3600 (seconds), default 0, use system default',
+    retryStrategy: 'Retry Backoff',
+    retryStrategyDefault: 'Default',
+    retryStrategyFixed: 'Fixed',
+    retryStrategyLinear: 'Linear',
+    retryStrategyExponential: 'Exponential',
+    retryMaxInterval: 'Max Retry Interval',
+    retryMaxIntervalPlaceholder: '0 - 86400 (seconds), 0 means no limit',
+    retryJitter: 'Retry Jitter (%)',
+    retryJitterPlaceholder: '0 - 100, randomize the interval by up to this percentage',
+    retryOn: 'Retry On',
+    retryOnPlaceholder: 'Any error',
+    retryOnTimeout: 'Timeout',
+    retryOnUnavailable: 'Node unavailable',
+    retryOnExitCode: 'Exit code',
+    retryOnHttp5xx: 'HTTP 5xx',
+    retryExitCodes: 'Retry Exit Codes',
+    retryExitCodesPlaceholder: 'Comma separated, e.g. 1,2,127',
     notification: 'Task Notification',
     notifyType: 'Notification Type',
     notifyReceiver: 'Receiver',
@@ -123,6 +186,35 @@ export default {
     alias: 'Alias',
     port: 'Port',
     remark: 'Remark',
+    group: 'Group',
+    groupPlaceholder: 'Optional, e.g. web',
+    labels: 'Labels',
+    labelsPlaceholder: 'Optional, e.g. env=prod,role=worker',
+    status: 'Status',
+    online: 'Online',
+    offline: 'Offline',
+    draining: 'Draining',
+    unknown: 'Unknown',
+    lastSeen: 'Last seen',
+    version: 'Version',
+    load: 'Load (1m)',
+    memory: 'Free memory',
+    disk: 'Free disk',
+    runningJobs: 'Running jobs',
+    reverse: 'Reverse connect',
+    reverseTip:
+      'The node is behind NAT or a firewall and connects to the server itself; started with gocron-node -reverse-server',
+    nodeToken: 'Token',
+    nodeTokenIssuedAt: 'Token issued at',
+    nodeTokenNone: 'No token issued, the node accepts requests without a token',
+    nodeTokenRotate: 'Rotate',
+    nodeTokenRevoke: 'Revoke',
+    nodeTokenRotateConfirm:
+      'The new token is sent to the node, and the current token stops working once the node has saved it. Continue?',
+    nodeTokenRevokeConfirm:
+      'After revoking, the node rejects all requests from the server until it is re-registered. Continue?',
+    nodeTokenNewTip:
+      'The token is shown only once. The node has already saved it to its token file, copy it only if you configure nodes manually or reinstall the node with GOCRON_NODE_TOKEN.',
     createTime: 'Create Time',
     createNew: 'Add Node',
     namePlaceholder: 'Please enter host name',
@@ -153,6 +245,8 @@ export default {
       'To run docker / docker compose in tasks, grant the node user access to Docker (example): sudo usermod -aG docker tabor',
     installTipLine4:
       'After changing permissions, restart Docker and restart the node service: sudo service docker stop/start, sudo systemctl restart gocron-node; to run as root, enable -allow-root.',
+    installTipReverse:
+      'For nodes the server cannot reach, set GOCRON_NODE_REVERSE_SERVER=<server agent.listen address> before running the script to use reverse connect mode.',
     tokenExpires: 'Token Expires',
     tokenUsage: 'Usage',
     tokenReusable: 'This token can be reused within the validity period for batch installation',
@@ -191,7 +285,9 @@ export default {
     passwordRequired: 'Please enter password',
     confirmPasswordRequired: 'Please enter password again',
     oldPasswordRequired: 'Please enter old password',
-    newPasswordRequired: 'Please enter new password'
+    newPasswordRequired: 'Please enter new password',
+    source: 'Source',
+    localUser: 'Local'
   },
   system: {
     manage: 'System Management',
@@ -203,6 +299,38 @@ export default {
     slack: 'Slack Notification',
     webhook: 'WebHook Notification',
     loginTime: 'Login Time',
+    auditLog: 'Audit Log',
+    auditAction: 'Action',
+    auditResource: 'Resource',
+    auditResourceId: 'Resource ID',
+    auditResourceName: 'Name',
+    auditKeyword: 'Keyword',
+    auditDate: 'Date',
+    auditTime: 'Time',
+    auditField: 'Field',
+    auditBefore: 'Before',
+    auditAfter: 'After',
+    auditNoDiff: 'No field changes recorded',
+    auditActions: {
+      create: 'Create',
+      update: 'Update',
+      delete: 'Delete',
+      enable: 'Enable',
+      disable: 'Disable',
+      run: 'Run',
+      stop: 'Stop',
+      clear: 'Clear',
+      release: 'Release Connection',
+      rollback: 'Rollback'
+    },
+    auditResources: {
+      task: 'Task',
+      task_log: 'Task Log',
+      host: 'Host',
+      project: 'Project',
+      setting: 'Setting',
+      api_token: 'API Token'
+    },
     loginIp: 'Login IP',
     retentionDays: 'Retention Days',
     retentionDaysPlaceholder: 'Please enter retention days',
@@ -224,6 +352,15 @@ export default {
     logFileSizeLimit: 'Log File Size Limit',
     logFileSizeLimitTip:
       'Set to 0 to disable log file cleanup, greater than 0 will automatically clear when log file exceeds this size',
+    auditLogRetentionDays: 'Audit Log Retention Days',
+    auditLogRetentionTip: 'Set to 0 to keep audit logs forever, otherwise audit logs older than this are deleted at the cleanup time',
+    loginSettings: 'Login Settings',
+    ssoStatus: 'Single Sign-On',
+    ssoStatusTip: 'Configured with the oidc.* options in app.ini',
+    disablePasswordLogin: 'Disable Password Login',
+    disablePasswordLoginTip: 'When disabled, users must sign in with single sign-on, only the break-glass account can still use a password',
+    breakGlassUser: 'Break-glass Account',
+    breakGlassUserTip: 'Username of an enabled administrator that can still sign in with a password if single sign-on fails',
     logRetentionSaveSuccess: 'Saved successfully, cleanup task has been reloaded',
     emailServerConfig: 'Email Server Configuration',
     templateSupportsHtml: 'Notification template supports HTML',
@@ -247,6 +384,7 @@ export default {
     webhookUrls: 'Webhook URLs',
     webhookName: 'Webhook Name',
     logCleanup: 'Log Cleanup',
+    loginSetting: 'Login Settings',
     templateVariables: 'Template Variables',
     taskIdVar: 'Task ID',
     taskNameVar: 'Task Name',
@@ -268,10 +406,37 @@ export default {
     result: 'Result',
     host: 'Host',
     output: 'Output',
+    attempts: 'Attempts',
+    attemptNo: 'Attempt',
+    hostResults: 'Host Results',
+    hostFailedSummary: '{failed}/{total} hosts failed',
+    exitCode: 'exit code',
     success: 'Success',
     failed: 'Failed',
     viewOutput: 'View Output'
   },
+  apiToken: {
+    title: 'API Tokens',
+    description:
+      'Personal API tokens access the API as you with the selected scopes. Send them as "Authorization: Bearer <token>".',
+    create: 'Create Token',
+    name: 'Name',
+    nameRequired: 'Please enter token name',
+    token: 'Token',
+    scopes: 'Scopes',
+    scopesRequired: 'Please select at least one scope',
+    expiresAt: 'Expires At',
+    expiresDays: 'Expires In (days)',
+    expiresDaysTip: '0 means the token never expires',
+    never: 'Never',
+    lastUsed: 'Last Used',
+    neverUsed: 'Never used',
+    revoke: 'Revoke',
+    confirmRevoke: 'Revoke token "{name}"? Clients using it will no longer be able to access the API.',
+    copyNow: 'Copy the token now, it will not be shown again.',
+    copy: 'Copy',
+    copied: 'Copied to clipboard'
+  },
   twoFactor: {
     title: 'Two-Factor Authentication (2FA)',
     status: 'Status',
@@ -458,5 +623,31 @@ export default {
     executionCount: 'Execution Count',
     date: 'Date',
     detailedData: 'Detailed Data'
+  },
+  project: {
+    title: 'Projects',
+    name: 'Project',
+    projectName: 'Project Name',
+    remark: 'Remark',
+    create: 'New Project',
+    edit: 'Edit Project',
+    members: 'Members',
+    member: 'Member',
+    role: 'Role',
+    roleViewer: 'Viewer',
+    roleOperator: 'Operator',
+    roleEditor: 'Editor',
+    roleAdmin: 'Project Admin',
+    addMember: 'Add Member',
+    nameRequired: 'Please enter project name',
+    selectProject: 'Please select project',
+    deleteConfirm: 'Are you sure to delete this project?',
+    shared: 'Shared',
+    allowCrossDependency: 'Cross-project Dependency',
+    allowCrossDependencyTip: 'Tasks can depend on tasks of other projects only when both projects allow it',
+    receiverProject: 'Project',
+    receiverProjectTip: 'Shared receivers can be used by tasks of all projects',
+    roleTip:
+      'Viewer: view tasks, hosts and logs; Operator: also run, stop, enable and disable tasks; Editor: also create, edit and delete tasks and hosts; Project Admin: also manage members and node tokens. System admins have all permissions.'
   }
 }
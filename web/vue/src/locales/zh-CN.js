@@ -30,7 +30,8 @@ export default {
     statistics: '数据统计',
     logout: '退出',
     changePassword: '修改密码',
-    twoFactor: '双因素认证'
+    twoFactor: '双因素认证',
+    apiTokens: 'API令牌'
   },
   login: {
     title: '用户登录',
@@ -44,7 +45,11 @@ export default {
     verifyCodePlaceholder: '请输入6位验证码',
     usernameRequired: '请输入用户名',
     passwordRequired: '请输入密码',
-    verifyCodeRequired: '请输入验证码'
+    verifyCodeRequired: '请输入验证码',
+    sso: '单点登录',
+    usePassword: '使用密码登录',
+    passwordLoginDisabled: '已禁用密码登录, 请使用单点登录',
+    ssoSigningIn: '正在登录...'
   },
   task: {
     list: '定时任务',
@@ -52,6 +57,20 @@ export default {
     id: '任务ID',
     name: '任务名称',
     tag: '标签',
+    owner: '创建人',
+    revisions: '版本',
+    revision: '版本号',
+    revisionAuthor: '修改人',
+    revisionTime: '保存时间',
+    revisionCurrent: '当前',
+    revisionRollbackFrom: '由v{version}回滚',
+    revisionFrom: '从',
+    revisionTo: '到',
+    revisionCompare: '比较',
+    revisionNoDiff: '没有差异',
+    rollback: '回滚',
+    confirmRollback: '确定将任务恢复到版本v{version}吗？恢复后将保存为新版本。',
+    rollbackSuccess: '任务已回滚',
     tagPlaceholder: '通过标签将任务分组',
     type: '任务类型',
     mainTask: '主任务',
@@ -68,13 +87,55 @@ export default {
     httpMethod: '请求方法',
     taskNode: '任务节点',
     taskNodePlaceholder: '请选择任务节点',
+    hostSelection: '主机选择',
+    hostGroup: '主机分组',
+    hostGroupPlaceholder: '在该分组的所有主机上执行',
+    hostSelector: '标签选择器',
+    hostSelectorPlaceholder: '如 env=prod,role!=db',
+    hostTargetMatched: '匹配的主机',
+    hostSelectionAll: '所有主机',
+    hostSelectionRoundRobin: '任选一台: 轮询',
+    hostSelectionRandom: '任选一台: 随机',
+    hostSelectionLeastLoaded: '任选一台: 负载最低',
+    hostSelectionFailover: '第一台可用主机',
+    batchSize: '每批主机数',
+    batchSizePlaceholder: '0表示所有主机同时执行',
+    batchUnit: '批次单位',
+    batchUnitHost: '主机数',
+    batchUnitPercent: '百分比(%)',
+    batchInterval: '批次间隔',
+    batchIntervalPlaceholder: '单位秒, 默认0',
+    maxFailures: '失败中止阈值',
+    maxFailuresPlaceholder: '失败主机数达到N后跳过剩余主机, 0不中止',
     command: '命令',
+    commandSignature: '命令签名',
+    commandSignaturePlaceholder: '可选, 节点要求命令签名时填写',
+    commandSignatureTip: '任务创建后使用 gocron-node -sign-key key.pem -sign-task-id 任务ID -sign "命令" 生成, 签名只对该任务的该命令有效, 不能与调度时间变量同时使用',
+    detached: '分离执行',
+    detachedTip: '节点启动任务后立即返回, 节点重启后任务继续运行, 需节点配置 -state-dir',
     timeout: '任务超时时间',
     singleInstance: '单实例运行',
     retryTimes: '任务失败重试次数',
     retryTimesPlaceholder: '0 - 10, 默认0，不重试',
     retryInterval: '任务失败重试间隔时间',
     retryIntervalPlaceholder: '0 - 3600 (秒), 默认0，执行系统默认策略',
+    retryStrategy: '重试间隔策略',
+    retryStrategyDefault: '默认',
+    retryStrategyFixed: '固定间隔',
+    retryStrategyLinear: '线性递增',
+    retryStrategyExponential: '指数退避',
+    retryMaxInterval: '最大重试间隔',
+    retryMaxIntervalPlaceholder: '0 - 86400 (秒), 0表示不限制',
+    retryJitter: '重试间隔抖动 (%)',
+    retryJitterPlaceholder: '0 - 100, 在该百分比范围内随机调整间隔',
+    retryOn: '重试条件',
+    retryOnPlaceholder: '任何错误',
+    retryOnTimeout: '执行超时',
+    retryOnUnavailable: '节点不可用',
+    retryOnExitCode: '指定退出码',
+    retryOnHttp5xx: 'HTTP 5xx',
+    retryExitCodes: '重试退出码',
+    retryExitCodesPlaceholder: '多个用逗号分隔, 如 1,2,127',
     notification: '任务通知',
     notifyType: '通知类型',
     notifyReceiver: '接收用户',
@@ -122,6 +183,31 @@ export default {
     alias: '别名',
     port: '端口',
     remark: '备注',
+    group: '分组',
+    groupPlaceholder: '可选, 如 web',
+    labels: '标签',
+    labelsPlaceholder: '可选, 如 env=prod,role=worker',
+    status: '状态',
+    online: '在线',
+    offline: '离线',
+    draining: '排空中',
+    unknown: '未检查',
+    lastSeen: '最后在线',
+    version: '版本',
+    load: '负载(1分钟)',
+    memory: '可用内存',
+    disk: '可用磁盘',
+    runningJobs: '运行中任务',
+    reverse: '反向连接',
+    reverseTip: '节点位于NAT或防火墙后, 由节点主动连接服务端, 需使用 gocron-node -reverse-server 启动',
+    nodeToken: '令牌',
+    nodeTokenIssuedAt: '令牌生成时间',
+    nodeTokenNone: '未生成令牌, 节点不校验令牌',
+    nodeTokenRotate: '重新生成',
+    nodeTokenRevoke: '吊销',
+    nodeTokenRotateConfirm: '新令牌将下发到节点, 节点保存后当前令牌失效, 是否继续?',
+    nodeTokenRevokeConfirm: '吊销后, 节点在重新注册前将拒绝服务端的所有请求, 是否继续?',
+    nodeTokenNewTip: '令牌只显示一次, 节点已将其写入令牌文件, 仅在手动配置节点或通过 GOCRON_NODE_TOKEN 重新安装节点时需要复制。',
     createTime: '创建时间',
     createNew: '新增节点',
     namePlaceholder: '请输入主机名',
@@ -150,6 +236,8 @@ export default {
       '如需在任务中执行 docker / docker compose，请确保节点运行用户具备 docker 权限：sudo usermod -aG docker tabor（示例）',
     installTipLine4:
       '修改权限后可执行 sudo service docker stop/start，并重启 sudo systemctl restart gocron-node 使权限生效；如必须以 root 运行节点，需启用 -allow-root。',
+    installTipReverse:
+      '服务端无法访问的节点, 可在执行脚本前设置 GOCRON_NODE_REVERSE_SERVER=<服务端 agent.listen 地址> 使用反向连接模式。',
     tokenExpires: 'Token有效期',
     tokenUsage: '使用说明',
     tokenReusable: '此Token可在有效期内重复使用，适用于批量安装',
@@ -186,7 +274,9 @@ export default {
     passwordRequired: '请输入密码',
     confirmPasswordRequired: '请再次输入密码',
     oldPasswordRequired: '请输入旧密码',
-    newPasswordRequired: '请输入新密码'
+    newPasswordRequired: '请输入新密码',
+    source: '来源',
+    localUser: '本地'
   },
   system: {
     manage: '系统管理',
@@ -198,6 +288,38 @@ export default {
     slack: 'Slack通知',
     webhook: 'WebHook通知',
     loginTime: '登录时间',
+    auditLog: '审计日志',
+    auditAction: '操作',
+    auditResource: '资源',
+    auditResourceId: '资源ID',
+    auditResourceName: '名称',
+    auditKeyword: '关键字',
+    auditDate: '日期',
+    auditTime: '操作时间',
+    auditField: '字段',
+    auditBefore: '修改前',
+    auditAfter: '修改后',
+    auditNoDiff: '无字段变更',
+    auditActions: {
+      create: '新增',
+      update: '修改',
+      delete: '删除',
+      enable: '启用',
+      disable: '禁用',
+      run: '运行',
+      stop: '停止',
+      clear: '清空',
+      release: '释放连接',
+      rollback: '回滚'
+    },
+    auditResources: {
+      task: '任务',
+      task_log: '任务日志',
+      host: '主机',
+      project: '项目',
+      setting: '系统配置',
+      api_token: 'API令牌'
+    },
     loginIp: '登录IP',
     retentionDays: '保留天数',
     retentionDaysPlaceholder: '请输入保留天数',
@@ -217,6 +339,15 @@ export default {
     selectTime: '选择时间',
     logFileSizeLimit: '日志文件大小限制',
     logFileSizeLimitTip: '设置为0表示不清理日志文件，大于0则当日志文件超过此大小时自动清空',
+    auditLogRetentionDays: '审计日志保留天数',
+    auditLogRetentionTip: '设置为0表示永久保留审计日志，大于0则在清理时间删除超过此天数的审计日志',
+    loginSettings: '登录设置',
+    ssoStatus: '单点登录',
+    ssoStatusTip: '在配置文件app.ini的oidc.*配置项中设置',
+    disablePasswordLogin: '禁用密码登录',
+    disablePasswordLoginTip: '禁用后用户需使用单点登录, 只有应急账户可使用密码登录',
+    breakGlassUser: '应急账户',
+    breakGlassUserTip: '单点登录故障时仍可使用密码登录的管理员用户名',
     logRetentionSaveSuccess: '保存成功，清理任务已重新加载',
     emailServerConfig: '邮件服务器配置',
     templateSupportsHtml: '通知模板支持html',
@@ -240,6 +371,7 @@ export default {
     webhookUrls: 'Webhook地址列表',
     webhookName: 'Webhook名称',
     logCleanup: '日志清理',
+    loginSetting: '登录设置',
     templateVariables: '通知模板支持的变量',
     taskIdVar: '任务ID',
     taskNameVar: '任务名称',
@@ -263,10 +395,36 @@ export default {
     result: '执行结果',
     host: '主机',
     output: '执行输出',
+    attempts: '执行记录',
+    attemptNo: '第几次',
+    hostResults: '主机执行结果',
+    hostFailedSummary: '{failed}/{total} 台主机失败',
+    exitCode: '退出码',
     success: '成功',
     failed: '失败',
     viewOutput: '查看输出'
   },
+  apiToken: {
+    title: 'API令牌',
+    description: '个人API令牌以你的身份及所选权限范围访问接口, 请求时设置请求头 "Authorization: Bearer <令牌>"',
+    create: '创建令牌',
+    name: '名称',
+    nameRequired: '请输入令牌名称',
+    token: '令牌',
+    scopes: '权限范围',
+    scopesRequired: '请至少选择一个权限范围',
+    expiresAt: '过期时间',
+    expiresDays: '有效天数',
+    expiresDaysTip: '0表示永不过期',
+    never: '永不过期',
+    lastUsed: '最近使用',
+    neverUsed: '未使用',
+    revoke: '吊销',
+    confirmRevoke: '确定吊销令牌"{name}"吗? 使用该令牌的客户端将无法访问接口',
+    copyNow: '请立即复制令牌, 关闭后将无法再次查看',
+    copy: '复制',
+    copied: '已复制到剪贴板'
+  },
   twoFactor: {
     title: '双因素认证 (2FA)',
     status: '状态',
@@ -450,5 +608,30 @@ export default {
     executionCount: '执行次数',
     date: '日期',
     detailedData: '详细数据'
+  },
+  project: {
+    title: '项目管理',
+    name: '项目',
+    projectName: '项目名称',
+    remark: '备注',
+    create: '新增项目',
+    edit: '编辑项目',
+    members: '成员',
+    member: '成员',
+    role: '角色',
+    roleViewer: '查看者',
+    roleOperator: '操作员',
+    roleEditor: '编辑者',
+    roleAdmin: '项目管理员',
+    addMember: '添加成员',
+    nameRequired: '请输入项目名称',
+    selectProject: '请选择项目',
+    deleteConfirm: '确定删除该项目吗?',
+    shared: '共享',
+    allowCrossDependency: '跨项目依赖',
+    allowCrossDependencyTip: '父子任务所在项目都允许时, 任务才能依赖其他项目的任务',
+    receiverProject: '所属项目',
+    receiverProjectTip: '共享的接收者可被所有项目的任务使用',
+    roleTip: '查看者：查看任务、主机及日志；操作员：另可执行、停止、启用、禁用任务；编辑者：另可新增、修改、删除任务和主机；项目管理员：另可管理成员及节点令牌。系统管理员拥有全部权限。'
   }
 }
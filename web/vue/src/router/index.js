@@ -26,12 +26,14 @@ const routes = [
   {
     path: '/task/create',
     name: 'task-create',
-    component: () => import('../pages/task/edit.vue')
+    component: () => import('../pages/task/edit.vue'),
+    meta: { noNeedAdmin: true }
   },
   {
     path: '/task/edit/:id',
     name: 'task-edit',
-    component: () => import('../pages/task/edit.vue')
+    component: () => import('../pages/task/edit.vue'),
+    meta: { noNeedAdmin: true }
   },
   {
     path: '/task/log',
@@ -48,12 +50,20 @@ const routes = [
   {
     path: '/host/create',
     name: 'host-create',
-    component: () => import('../pages/host/edit.vue')
+    component: () => import('../pages/host/edit.vue'),
+    meta: { noNeedAdmin: true }
   },
   {
     path: '/host/edit/:id',
     name: 'host-edit',
-    component: () => import('../pages/host/edit.vue')
+    component: () => import('../pages/host/edit.vue'),
+    meta: { noNeedAdmin: true }
+  },
+  {
+    path: '/project',
+    name: 'project-list',
+    component: () => import('../pages/project/list.vue'),
+    meta: { noNeedAdmin: true }
   },
   {
     path: '/user',
@@ -76,6 +86,12 @@ const routes = [
     component: () => import('../pages/user/login.vue'),
     meta: { noLogin: true }
   },
+  {
+    path: '/user/sso',
+    name: 'user-sso',
+    component: () => import('../pages/user/sso.vue'),
+    meta: { noLogin: true }
+  },
   {
     path: '/user/edit-password/:id',
     name: 'user-edit-password',
@@ -93,6 +109,12 @@ const routes = [
     component: () => import('../pages/user/twoFactor.vue'),
     meta: { noNeedAdmin: true }
   },
+  {
+    path: '/user/api-tokens',
+    name: 'user-api-tokens',
+    component: () => import('../pages/user/apiTokens.vue'),
+    meta: { noNeedAdmin: true }
+  },
   {
     path: '/system',
     redirect: '/system/notification/email'
@@ -127,11 +149,21 @@ const routes = [
     name: 'login-log',
     component: () => import('../pages/system/loginLog.vue')
   },
+  {
+    path: '/system/audit-log',
+    name: 'audit-log',
+    component: () => import('../pages/system/auditLog.vue')
+  },
   {
     path: '/system/log-retention',
     name: 'log-retention',
     component: () => import('../pages/system/logRetention.vue')
   },
+  {
+    path: '/system/login-setting',
+    name: 'login-setting',
+    component: () => import('../pages/system/loginSetting.vue')
+  },
   {
     path: '/system/help',
     name: 'system-help',
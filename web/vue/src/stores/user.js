@@ -1,15 +1,40 @@
 import { defineStore } from 'pinia'
+import projectService from '../api/project'
+
+// 项目中的操作, 需要的最低角色与其级别相同: 1查看者 2操作员 3编辑者 4项目管理员
+export const Action = {
+  view: 1,
+  run: 2,
+  edit: 3,
+  manage: 4
+}
 
 export const useUserStore = defineStore('user', {
   state: () => ({
     token: '',
     uid: '',
     username: '',
-    isAdmin: false
+    isAdmin: false,
+    projects: []
   }),
   
   getters: {
-    isLogin: (state) => state.token !== ''
+    isLogin: (state) => state.token !== '',
+    // 在项目中是否拥有操作权限, 系统管理员拥有全部权限
+    can: (state) => (projectId, action) => {
+      if (state.isAdmin) return true
+      const project = state.projects.find(item => item.id === projectId)
+      return !!project && project.role >= action
+    },
+    // 是否在任一项目中拥有操作权限
+    canAny: (state) => (action) => {
+      return state.isAdmin || state.projects.some(item => item.role >= action)
+    },
+    // 项目名称, 0为共享
+    projectName: (state) => (projectId) => {
+      const project = state.projects.find(item => item.id === projectId)
+      return project ? project.name : ''
+    }
   },
   
   actions: {
@@ -19,18 +44,26 @@ export const useUserStore = defineStore('user', {
       this.username = user.username || ''
       this.isAdmin = user.isAdmin || false
     },
+
+    loadProjects(callback) {
+      projectService.list((data) => {
+        this.projects = data || []
+        if (callback) callback(this.projects)
+      })
+    },
     
     logout() {
       this.token = ''
       this.uid = ''
       this.username = ''
       this.isAdmin = false
+      this.projects = []
     }
   },
   
   persist: {
     key: 'gocron-user',
     storage: localStorage,
-    paths: ['token', 'uid', 'username', 'isAdmin']
+    paths: ['token', 'uid', 'username', 'isAdmin', 'projects']
   }
 })